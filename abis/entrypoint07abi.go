@@ -0,0 +1,452 @@
+package abis
+
+// EntryPoint07Abi is the ABI of the ERC-4337 EntryPoint 0.7, 0.8 keeps the same functions for packed UserOperations
+const EntryPoint07Abi = `[
+    {
+        "type": "function",
+        "name": "addStake",
+        "inputs": [
+            { "name": "unstakeDelaySec", "type": "uint32" }
+        ],
+        "outputs": [],
+        "stateMutability": "payable"
+    },
+    {
+        "type": "function",
+        "name": "balanceOf",
+        "inputs": [
+            { "name": "account", "type": "address" }
+        ],
+        "outputs": [
+            { "name": "", "type": "uint256" }
+        ],
+        "stateMutability": "view"
+    },
+    {
+        "type": "function",
+        "name": "delegateAndRevert",
+        "inputs": [
+            { "name": "target", "type": "address" },
+            { "name": "data", "type": "bytes" }
+        ],
+        "outputs": [],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "function",
+        "name": "depositTo",
+        "inputs": [
+            { "name": "account", "type": "address" }
+        ],
+        "outputs": [],
+        "stateMutability": "payable"
+    },
+    {
+        "type": "function",
+        "name": "deposits",
+        "inputs": [
+            { "name": "", "type": "address" }
+        ],
+        "outputs": [
+            { "name": "deposit", "type": "uint256" },
+            { "name": "staked", "type": "bool" },
+            { "name": "stake", "type": "uint112" },
+            { "name": "unstakeDelaySec", "type": "uint32" },
+            { "name": "withdrawTime", "type": "uint48" }
+        ],
+        "stateMutability": "view"
+    },
+    {
+        "type": "function",
+        "name": "getDepositInfo",
+        "inputs": [
+            { "name": "account", "type": "address" }
+        ],
+        "outputs": [
+            {
+                "name": "info",
+                "type": "tuple",
+                "components": [
+                    { "name": "deposit", "type": "uint256" },
+                    { "name": "staked", "type": "bool" },
+                    { "name": "stake", "type": "uint112" },
+                    { "name": "unstakeDelaySec", "type": "uint32" },
+                    { "name": "withdrawTime", "type": "uint48" }
+                ]
+            }
+        ],
+        "stateMutability": "view"
+    },
+    {
+        "type": "function",
+        "name": "getNonce",
+        "inputs": [
+            { "name": "sender", "type": "address" },
+            { "name": "key", "type": "uint192" }
+        ],
+        "outputs": [
+            { "name": "nonce", "type": "uint256" }
+        ],
+        "stateMutability": "view"
+    },
+    {
+        "type": "function",
+        "name": "getSenderAddress",
+        "inputs": [
+            { "name": "initCode", "type": "bytes" }
+        ],
+        "outputs": [],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "function",
+        "name": "getUserOpHash",
+        "inputs": [
+            {
+                "name": "userOp",
+                "type": "tuple",
+                "components": [
+                    { "name": "sender", "type": "address" },
+                    { "name": "nonce", "type": "uint256" },
+                    { "name": "initCode", "type": "bytes" },
+                    { "name": "callData", "type": "bytes" },
+                    { "name": "accountGasLimits", "type": "bytes32" },
+                    { "name": "preVerificationGas", "type": "uint256" },
+                    { "name": "gasFees", "type": "bytes32" },
+                    { "name": "paymasterAndData", "type": "bytes" },
+                    { "name": "signature", "type": "bytes" }
+                ]
+            }
+        ],
+        "outputs": [
+            { "name": "", "type": "bytes32" }
+        ],
+        "stateMutability": "view"
+    },
+    {
+        "type": "function",
+        "name": "handleAggregatedOps",
+        "inputs": [
+            {
+                "name": "opsPerAggregator",
+                "type": "tuple[]",
+                "components": [
+                    {
+                        "name": "userOps",
+                        "type": "tuple[]",
+                        "components": [
+                            { "name": "sender", "type": "address" },
+                            { "name": "nonce", "type": "uint256" },
+                            { "name": "initCode", "type": "bytes" },
+                            { "name": "callData", "type": "bytes" },
+                            { "name": "accountGasLimits", "type": "bytes32" },
+                            { "name": "preVerificationGas", "type": "uint256" },
+                            { "name": "gasFees", "type": "bytes32" },
+                            { "name": "paymasterAndData", "type": "bytes" },
+                            { "name": "signature", "type": "bytes" }
+                        ]
+                    },
+                    { "name": "aggregator", "type": "address" },
+                    { "name": "signature", "type": "bytes" }
+                ]
+            },
+            { "name": "beneficiary", "type": "address" }
+        ],
+        "outputs": [],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "function",
+        "name": "handleOps",
+        "inputs": [
+            {
+                "name": "ops",
+                "type": "tuple[]",
+                "components": [
+                    { "name": "sender", "type": "address" },
+                    { "name": "nonce", "type": "uint256" },
+                    { "name": "initCode", "type": "bytes" },
+                    { "name": "callData", "type": "bytes" },
+                    { "name": "accountGasLimits", "type": "bytes32" },
+                    { "name": "preVerificationGas", "type": "uint256" },
+                    { "name": "gasFees", "type": "bytes32" },
+                    { "name": "paymasterAndData", "type": "bytes" },
+                    { "name": "signature", "type": "bytes" }
+                ]
+            },
+            { "name": "beneficiary", "type": "address" }
+        ],
+        "outputs": [],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "function",
+        "name": "incrementNonce",
+        "inputs": [
+            { "name": "key", "type": "uint192" }
+        ],
+        "outputs": [],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "function",
+        "name": "innerHandleOp",
+        "inputs": [
+            { "name": "callData", "type": "bytes" },
+            {
+                "name": "opInfo",
+                "type": "tuple",
+                "components": [
+                    {
+                        "name": "mUserOp",
+                        "type": "tuple",
+                        "components": [
+                            { "name": "sender", "type": "address" },
+                            { "name": "nonce", "type": "uint256" },
+                            { "name": "verificationGasLimit", "type": "uint256" },
+                            { "name": "callGasLimit", "type": "uint256" },
+                            { "name": "paymasterVerificationGasLimit", "type": "uint256" },
+                            { "name": "paymasterPostOpGasLimit", "type": "uint256" },
+                            { "name": "preVerificationGas", "type": "uint256" },
+                            { "name": "paymaster", "type": "address" },
+                            { "name": "maxFeePerGas", "type": "uint256" },
+                            { "name": "maxPriorityFeePerGas", "type": "uint256" }
+                        ]
+                    },
+                    { "name": "userOpHash", "type": "bytes32" },
+                    { "name": "prefund", "type": "uint256" },
+                    { "name": "contextOffset", "type": "uint256" },
+                    { "name": "preOpGas", "type": "uint256" }
+                ]
+            },
+            { "name": "context", "type": "bytes" }
+        ],
+        "outputs": [
+            { "name": "actualGasCost", "type": "uint256" }
+        ],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "function",
+        "name": "nonceSequenceNumber",
+        "inputs": [
+            { "name": "", "type": "address" },
+            { "name": "", "type": "uint192" }
+        ],
+        "outputs": [
+            { "name": "", "type": "uint256" }
+        ],
+        "stateMutability": "view"
+    },
+    {
+        "type": "function",
+        "name": "supportsInterface",
+        "inputs": [
+            { "name": "interfaceId", "type": "bytes4" }
+        ],
+        "outputs": [
+            { "name": "", "type": "bool" }
+        ],
+        "stateMutability": "view"
+    },
+    {
+        "type": "function",
+        "name": "unlockStake",
+        "inputs": [],
+        "outputs": [],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "function",
+        "name": "withdrawStake",
+        "inputs": [
+            { "name": "withdrawAddress", "type": "address" }
+        ],
+        "outputs": [],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "function",
+        "name": "withdrawTo",
+        "inputs": [
+            { "name": "withdrawAddress", "type": "address" },
+            { "name": "withdrawAmount", "type": "uint256" }
+        ],
+        "outputs": [],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "event",
+        "name": "AccountDeployed",
+        "inputs": [
+            { "name": "userOpHash", "type": "bytes32", "indexed": true },
+            { "name": "sender", "type": "address", "indexed": true },
+            { "name": "factory", "type": "address", "indexed": false },
+            { "name": "paymaster", "type": "address", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "BeforeExecution",
+        "inputs": [],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "Deposited",
+        "inputs": [
+            { "name": "account", "type": "address", "indexed": true },
+            { "name": "totalDeposit", "type": "uint256", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "PostOpRevertReason",
+        "inputs": [
+            { "name": "userOpHash", "type": "bytes32", "indexed": true },
+            { "name": "sender", "type": "address", "indexed": true },
+            { "name": "nonce", "type": "uint256", "indexed": false },
+            { "name": "revertReason", "type": "bytes", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "SignatureAggregatorChanged",
+        "inputs": [
+            { "name": "aggregator", "type": "address", "indexed": true }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "StakeLocked",
+        "inputs": [
+            { "name": "account", "type": "address", "indexed": true },
+            { "name": "totalStaked", "type": "uint256", "indexed": false },
+            { "name": "unstakeDelaySec", "type": "uint256", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "StakeUnlocked",
+        "inputs": [
+            { "name": "account", "type": "address", "indexed": true },
+            { "name": "withdrawTime", "type": "uint256", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "StakeWithdrawn",
+        "inputs": [
+            { "name": "account", "type": "address", "indexed": true },
+            { "name": "withdrawAddress", "type": "address", "indexed": false },
+            { "name": "amount", "type": "uint256", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "UserOperationEvent",
+        "inputs": [
+            { "name": "userOpHash", "type": "bytes32", "indexed": true },
+            { "name": "sender", "type": "address", "indexed": true },
+            { "name": "paymaster", "type": "address", "indexed": true },
+            { "name": "nonce", "type": "uint256", "indexed": false },
+            { "name": "success", "type": "bool", "indexed": false },
+            { "name": "actualGasCost", "type": "uint256", "indexed": false },
+            { "name": "actualGasUsed", "type": "uint256", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "UserOperationPrefundTooLow",
+        "inputs": [
+            { "name": "userOpHash", "type": "bytes32", "indexed": true },
+            { "name": "sender", "type": "address", "indexed": true },
+            { "name": "nonce", "type": "uint256", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "UserOperationRevertReason",
+        "inputs": [
+            { "name": "userOpHash", "type": "bytes32", "indexed": true },
+            { "name": "sender", "type": "address", "indexed": true },
+            { "name": "nonce", "type": "uint256", "indexed": false },
+            { "name": "revertReason", "type": "bytes", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "event",
+        "name": "Withdrawn",
+        "inputs": [
+            { "name": "account", "type": "address", "indexed": true },
+            { "name": "withdrawAddress", "type": "address", "indexed": false },
+            { "name": "amount", "type": "uint256", "indexed": false }
+        ],
+        "anonymous": false
+    },
+    {
+        "type": "error",
+        "name": "DelegateAndRevert",
+        "inputs": [
+            { "name": "success", "type": "bool" },
+            { "name": "ret", "type": "bytes" }
+        ]
+    },
+    {
+        "type": "error",
+        "name": "FailedOp",
+        "inputs": [
+            { "name": "opIndex", "type": "uint256" },
+            { "name": "reason", "type": "string" }
+        ]
+    },
+    {
+        "type": "error",
+        "name": "FailedOpWithRevert",
+        "inputs": [
+            { "name": "opIndex", "type": "uint256" },
+            { "name": "reason", "type": "string" },
+            { "name": "inner", "type": "bytes" }
+        ]
+    },
+    {
+        "type": "error",
+        "name": "PostOpReverted",
+        "inputs": [
+            { "name": "returnData", "type": "bytes" }
+        ]
+    },
+    {
+        "type": "error",
+        "name": "ReentrancyGuardReentrantCall",
+        "inputs": []
+    },
+    {
+        "type": "error",
+        "name": "SenderAddressResult",
+        "inputs": [
+            { "name": "sender", "type": "address" }
+        ]
+    },
+    {
+        "type": "error",
+        "name": "SignatureValidationFailed",
+        "inputs": [
+            { "name": "aggregator", "type": "address" }
+        ]
+    },
+    {
+        "type": "receive",
+        "stateMutability": "payable"
+    }
+]`
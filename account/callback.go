@@ -0,0 +1,126 @@
+package account
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// SignFunc signs the 32-byte hash with the owner key of the account and returns the 65-byte [R || S || V] secp256k1 signature,
+// V being 0/1 or 27/28
+type SignFunc func(hash common.Hash) ([]byte, error)
+
+// CallbackSigner signs for a Kernel account owned by an ECDSA key behind Sign, e.g. an HSM or a remote signing service.
+// SignHash needs Client to read the account metadata, unless AccountMetadata is set.
+type CallbackSigner struct {
+	Client          types.RPCClient
+	Address         common.Address
+	Sign            SignFunc
+	Validator       Validator
+	AccountMetadata *AccountMetadata
+}
+
+// NewCallbackSigner creates a signer of the account at address signing with sign.
+func NewCallbackSigner(address common.Address, sign SignFunc) (*CallbackSigner, error) {
+	if sign == nil {
+		return nil, errors.New("sign is required")
+	}
+
+	return &CallbackSigner{
+		Address:   address,
+		Sign:      sign,
+		Validator: NewEcdsaValidator(),
+	}, nil
+}
+
+func (s *CallbackSigner) GetAddress() common.Address {
+	return s.Address
+}
+
+// GetValidator returns the Validator the signatures are validated by
+func (s *CallbackSigner) GetValidator() Validator {
+	return s.Validator
+}
+
+func (s *CallbackSigner) SignMessage(message []byte) ([]byte, error) {
+	return s.SignHash(common.BytesToHash(accounts.TextHash(message)))
+}
+
+func (s *CallbackSigner) SignTypedData(typedData *signer.TypedData) ([]byte, error) {
+	hash, _, err := signer.TypedDataAndHash(*typedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SignHash(common.BytesToHash(hash))
+}
+
+func (s *CallbackSigner) SignHash(hash common.Hash) ([]byte, error) {
+	return s.SignHashContext(context.Background(), hash)
+}
+
+// SignHashContext signs the hash for ERC-1271 validation by the account, the account metadata is read within ctx
+func (s *CallbackSigner) SignHashContext(ctx context.Context, hash common.Hash) ([]byte, error) {
+	accountMetadata, err := cachedAccountMetadata(ctx, &s.AccountMetadata, s.Client, s.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	finalHash, err := kernelMessageHash(accountMetadata, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := s.signHashBase(finalHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(s.Validator.GetIdentifier(), signature...), nil
+}
+
+func (s *CallbackSigner) SignUserOperationHash(hash common.Hash) ([]byte, error) {
+	return s.signHashBase(hash)
+}
+
+// signHashBase signs the hash with the callback and normalizes the signature to the low-S form with V 27/28 expected by ecrecover
+func (s *CallbackSigner) signHashBase(hash common.Hash) ([]byte, error) {
+	signature, err := s.Sign(hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign with callback")
+	}
+
+	return normalizeSignature(signature)
+}
+
+// normalizeSignature converts a 65-byte [R || S || V] signature to low-S with V 27/28, flipping V when S is flipped
+func normalizeSignature(signature []byte) ([]byte, error) {
+	if len(signature) != crypto.SignatureLength {
+		return nil, errors.Errorf("signature must be %d bytes, got %d", crypto.SignatureLength, len(signature))
+	}
+
+	normalized := make([]byte, crypto.SignatureLength)
+	copy(normalized, signature)
+
+	v := normalized[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return nil, errors.Errorf("invalid signature recovery id %d", signature[64])
+	}
+
+	sValue := new(big.Int).SetBytes(normalized[32:64])
+	if sValue.Cmp(secp256k1HalfN) > 0 {
+		new(big.Int).Sub(secp256k1N, sValue).FillBytes(normalized[32:64])
+		v ^= 1
+	}
+	normalized[64] = v + 27
+
+	return normalized, nil
+}
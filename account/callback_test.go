@@ -0,0 +1,128 @@
+package account
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackSigner_SignUserOperationHash(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+	owner := crypto.PubkeyToAddress(privateKey.PublicKey)
+	address := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	hash := crypto.Keccak256Hash([]byte("user operation"))
+
+	tests := []struct {
+		name          string
+		sign          SignFunc
+		expectedError string
+	}{
+		{
+			name: "recovery_id",
+			sign: func(hash common.Hash) ([]byte, error) {
+				return crypto.Sign(hash.Bytes(), privateKey)
+			},
+		},
+		{
+			name: "v_27",
+			sign: func(hash common.Hash) ([]byte, error) {
+				signature, err := crypto.Sign(hash.Bytes(), privateKey)
+				signature[64] += 27
+				return signature, err
+			},
+		},
+		{
+			name: "high_s",
+			sign: func(hash common.Hash) ([]byte, error) {
+				signature, err := crypto.Sign(hash.Bytes(), privateKey)
+				s := new(big.Int).SetBytes(signature[32:64])
+				new(big.Int).Sub(secp256k1N, s).FillBytes(signature[32:64])
+				signature[64] ^= 1
+				return signature, err
+			},
+		},
+		{
+			name: "short",
+			sign: func(hash common.Hash) ([]byte, error) {
+				return make([]byte, 64), nil
+			},
+			expectedError: "signature must be 65 bytes, got 64",
+		},
+		{
+			name: "invalid_v",
+			sign: func(hash common.Hash) ([]byte, error) {
+				signature, err := crypto.Sign(hash.Bytes(), privateKey)
+				signature[64] = 5
+				return signature, err
+			},
+			expectedError: "invalid signature recovery id 5",
+		},
+		{
+			name: "callback_error",
+			sign: func(hash common.Hash) ([]byte, error) {
+				return nil, errors.New("hsm unavailable")
+			},
+			expectedError: "failed to sign with callback: hsm unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callbackSigner, err := NewCallbackSigner(address, tt.sign)
+			require.NoError(t, err)
+			assert.Equal(t, address, callbackSigner.GetAddress())
+
+			signature, err := callbackSigner.SignUserOperationHash(hash)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			privateKeySigner, err := NewSmartAccountPrivateKeySigner(nil, address, privateKey)
+			require.NoError(t, err)
+			expected, err := privateKeySigner.SignUserOperationHash(hash)
+			require.NoError(t, err)
+			assert.Equal(t, expected, signature)
+
+			recoverable := append([]byte{}, signature...)
+			recoverable[64] -= 27
+			publicKey, err := crypto.SigToPub(hash.Bytes(), recoverable)
+			require.NoError(t, err)
+			assert.Equal(t, owner, crypto.PubkeyToAddress(*publicKey))
+		})
+	}
+}
+
+func TestCallbackSigner_SignHash(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+	address := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	metadata := &AccountMetadata{Name: "Kernel", Version: "0.3.1", ChainId: big.NewInt(137), VerifyingContract: address}
+
+	callbackSigner, err := NewCallbackSigner(address, func(hash common.Hash) ([]byte, error) {
+		return crypto.Sign(hash.Bytes(), privateKey)
+	})
+	require.NoError(t, err)
+	callbackSigner.AccountMetadata = metadata
+
+	privateKeySigner, err := NewSmartAccountPrivateKeySigner(nil, address, privateKey)
+	require.NoError(t, err)
+	privateKeySigner.AccountMetadata = metadata
+
+	hash := crypto.Keccak256Hash([]byte("message"))
+	signature, err := callbackSigner.SignHash(hash)
+	require.NoError(t, err)
+	expected, err := privateKeySigner.SignHash(hash)
+	require.NoError(t, err)
+	assert.Equal(t, expected, signature)
+
+	_, err = NewCallbackSigner(address, nil)
+	assert.EqualError(t, err, "sign is required")
+}
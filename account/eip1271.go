@@ -0,0 +1,46 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"github.com/DIMO-Network/go-zerodev/abis"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
+	"strings"
+)
+
+// eip1271MagicValue is returned by isValidSignature for valid signatures
+var eip1271MagicValue = []byte{0x16, 0x26, 0xba, 0x7e}
+
+// IsValidSignature calls the ERC-1271 isValidSignature of the account, signature being a signature of hash returned by SignHash.
+// The account has to be deployed.
+func IsValidSignature(ctx context.Context, client types.RPCClient, address common.Address, hash common.Hash, signature []byte) (bool, error) {
+	parsedAbi, err := abi.JSON(strings.NewReader(abis.Eip1271Abi))
+	if err != nil {
+		return false, err
+	}
+
+	callData, err := parsedAbi.Pack("isValidSignature", hash, signature)
+	if err != nil {
+		return false, err
+	}
+
+	msg := struct {
+		To   common.Address `json:"to"`
+		Data hexutil.Bytes  `json:"data"`
+	}{
+		To:   address,
+		Data: callData,
+	}
+
+	var hex hexutil.Bytes
+	if err := client.CallContext(ctx, &hex, "eth_call", msg, "latest"); err != nil {
+		return false, errors.Wrap(err, "failed to call isValidSignature")
+	}
+
+	// bytes4 is returned left aligned in a 32-byte word
+	return len(hex) >= len(eip1271MagicValue) && bytes.Equal(hex[:len(eip1271MagicValue)], eip1271MagicValue), nil
+}
@@ -0,0 +1,74 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidSignature(t *testing.T) {
+	address := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	hash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+	signature := common.FromHex("0x01abcd")
+
+	tests := []struct {
+		name          string
+		response      string
+		callErr       error
+		expectedValid bool
+		expectedError bool
+	}{
+		{
+			name:          "valid",
+			response:      "0x1626ba7e00000000000000000000000000000000000000000000000000000000",
+			expectedValid: true,
+		},
+		{
+			name:     "invalid",
+			response: "0xffffffff00000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			name:          "call_error",
+			callErr:       errors.New("execution reverted"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockRPCClient{
+				callContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+					assert.Equal(t, "eth_call", method)
+
+					msg := args[0].(struct {
+						To   common.Address `json:"to"`
+						Data hexutil.Bytes  `json:"data"`
+					})
+					assert.Equal(t, address, msg.To)
+					// isValidSignature(bytes32,bytes) selector
+					assert.Equal(t, common.FromHex("0x1626ba7e"), []byte(msg.Data[:4]))
+					assert.Equal(t, hash.Bytes(), []byte(msg.Data[4:36]))
+
+					if tt.callErr != nil {
+						return tt.callErr
+					}
+					*result.(*hexutil.Bytes) = hexutil.MustDecode(tt.response)
+					return nil
+				},
+			}
+
+			valid, err := IsValidSignature(context.Background(), client, address, hash, signature)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedValid, valid)
+		})
+	}
+}
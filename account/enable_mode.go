@@ -0,0 +1,122 @@
+package account
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+)
+
+// enableTypeHash is Kernel's ENABLE_TYPE_HASH, the type of the typed data the root validator signs to enable a validator
+var enableTypeHash = crypto.Keccak256Hash([]byte("Enable(bytes21 validationId,uint32 nonce,address hook,bytes validatorData,bytes hookData,bytes selectorData)"))
+
+var (
+	bytesType, _   = abi.NewType("bytes", "", nil)
+	bytes21, _     = abi.NewType("bytes21", "", nil)
+	uint32Type, _  = abi.NewType("uint32", "", nil)
+	addressType, _ = abi.NewType("address", "", nil)
+)
+
+// enableModeArguments are the abi encoded fields following the hook in an enable mode signature
+var enableModeArguments = abi.Arguments{
+	{Name: "validatorData", Type: bytesType},
+	{Name: "hookData", Type: bytesType},
+	{Name: "selectorData", Type: bytesType},
+	{Name: "enableSig", Type: bytesType},
+	{Name: "userOpSig", Type: bytesType},
+}
+
+// EnableModeSignature is the signature of a UserOperation in Kernel's enable mode (ValidationModeEnable in the nonce key),
+// the first UserOperation of a validator installing it and being validated by it at once. Kernel decodes it as
+//
+//	hook (20 bytes) | abi.encode(bytes validatorData, bytes hookData, bytes selectorData, bytes enableSig, bytes userOpSig)
+//
+// Hook is the hook of the validator, zero for none. ValidatorData is its enable data, e.g. from BuildPermissionEnableData.
+// SelectorData starts with the selector the validator is granted access to, e.g. the account's execute.
+// EnableSignature is the root validator's signature of Hash, UserOpSignature the validator's signature of the UserOperation.
+type EnableModeSignature struct {
+	Hook            common.Address
+	ValidatorData   []byte
+	HookData        []byte
+	SelectorData    []byte
+	EnableSignature []byte
+	UserOpSignature []byte
+}
+
+// Encode encodes the signature the way Kernel decodes it in enable mode.
+func (e *EnableModeSignature) Encode() ([]byte, error) {
+	encoded, err := enableModeArguments.Pack(
+		nonNilBytes(e.ValidatorData),
+		nonNilBytes(e.HookData),
+		nonNilBytes(e.SelectorData),
+		nonNilBytes(e.EnableSignature),
+		nonNilBytes(e.UserOpSignature),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode enable mode signature")
+	}
+
+	return append(e.Hook.Bytes(), encoded...), nil
+}
+
+// DecodeEnableModeSignature decodes a UserOperation signature in Kernel's enable mode.
+func DecodeEnableModeSignature(signature []byte) (*EnableModeSignature, error) {
+	if len(signature) < common.AddressLength {
+		return nil, errors.Errorf("enable mode signature of %d bytes is too short", len(signature))
+	}
+
+	unpacked, err := enableModeArguments.Unpack(signature[common.AddressLength:])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode enable mode signature")
+	}
+
+	return &EnableModeSignature{
+		Hook:            common.BytesToAddress(signature[:common.AddressLength]),
+		ValidatorData:   unpacked[0].([]byte),
+		HookData:        unpacked[1].([]byte),
+		SelectorData:    unpacked[2].([]byte),
+		EnableSignature: unpacked[3].([]byte),
+		UserOpSignature: unpacked[4].([]byte),
+	}, nil
+}
+
+// Hash computes the hash of the Enable typed data in the account's EIP-712 domain the root validator signs to enable validator,
+// nonce has to be the account's currentNonce().
+func (e *EnableModeSignature) Hash(accountMetadata *AccountMetadata, validator Validator, nonce uint32) (common.Hash, error) {
+	accountTypedData := getAccountTypedData(accountMetadata)
+	domainSeparator, err := accountTypedData.HashStruct("EIP712Domain", accountTypedData.Domain.Map())
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to hash account domain")
+	}
+
+	args := abi.Arguments{
+		{Type: bytes32},
+		{Type: bytes21},
+		{Type: uint32Type},
+		{Type: addressType},
+		{Type: bytes32},
+		{Type: bytes32},
+		{Type: bytes32},
+	}
+	packed, err := args.Pack(
+		enableTypeHash,
+		GetValidationID(validator),
+		nonce,
+		e.Hook,
+		crypto.Keccak256Hash(e.ValidatorData),
+		crypto.Keccak256Hash(e.HookData),
+		crypto.Keccak256Hash(e.SelectorData),
+	)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to encode enable typed data")
+	}
+
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator, crypto.Keccak256(packed)), nil
+}
+
+func nonNilBytes(b []byte) []byte {
+	if b == nil {
+		return []byte{}
+	}
+	return b
+}
@@ -0,0 +1,168 @@
+package account
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// kernelParseEnableModeData decodes an enable mode signature like Kernel's _parseEnableModeData,
+// reading the offset and length words of each bytes field following the 20 bytes hook.
+func kernelParseEnableModeData(t *testing.T, packedData []byte) (common.Address, [][]byte) {
+	t.Helper()
+
+	word := func(at int) int {
+		require.LessOrEqual(t, at+32, len(packedData))
+		return int(new(big.Int).SetBytes(packedData[at : at+32]).Int64())
+	}
+
+	hook := common.BytesToAddress(packedData[:20])
+	offset := 20
+	fields := make([][]byte, 5)
+	for i := range fields {
+		dataOffset := offset + 32 + word(offset+i*32)
+		length := word(dataOffset - 32)
+		require.LessOrEqual(t, dataOffset+length, len(packedData))
+		fields[i] = packedData[dataOffset : dataOffset+length]
+	}
+
+	return hook, fields
+}
+
+func TestEnableModeSignature_Encode(t *testing.T) {
+	enableMode := &EnableModeSignature{
+		Hook:            common.HexToAddress("0x3333333333333333333333333333333333333333"),
+		ValidatorData:   common.FromHex(testPermissionEnableData),
+		SelectorData:    common.FromHex("0xe9ae5c53"),
+		EnableSignature: common.FromHex("0x" + common.Bytes2Hex(make([]byte, 64)) + "1b"),
+		UserOpSignature: common.FromHex("0xff0102"),
+	}
+
+	encoded, err := enableMode.Encode()
+	require.NoError(t, err)
+
+	hook, fields := kernelParseEnableModeData(t, encoded)
+	assert.Equal(t, enableMode.Hook, hook)
+	assert.Equal(t, enableMode.ValidatorData, fields[0])
+	assert.Empty(t, fields[1])
+	assert.Equal(t, enableMode.SelectorData, fields[2])
+	assert.Equal(t, enableMode.EnableSignature, fields[3])
+	assert.Equal(t, enableMode.UserOpSignature, fields[4])
+
+	decoded, err := DecodeEnableModeSignature(encoded)
+	require.NoError(t, err)
+	enableMode.HookData = []byte{}
+	assert.Equal(t, enableMode, decoded)
+
+	_, err = DecodeEnableModeSignature(encoded[:19])
+	assert.Error(t, err)
+	_, err = DecodeEnableModeSignature(encoded[:100])
+	assert.Error(t, err)
+}
+
+func TestEnableModeSignature_Hash(t *testing.T) {
+	assert.Equal(t, "0xb17ab1224aca0d4255ef8161acaf2ac121b8faa32a4b2258c912cc5f8308c505", enableTypeHash.Hex())
+
+	accountMetadata := &AccountMetadata{
+		Name:              "Kernel",
+		Version:           "0.3.1",
+		ChainId:           big.NewInt(137),
+		VerifyingContract: common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"),
+	}
+	validator := NewPermissionValidator(GetPermissionID(common.FromHex(testPermissionEnableData)))
+	enableMode := &EnableModeSignature{
+		ValidatorData: common.FromHex(testPermissionEnableData),
+		SelectorData:  common.FromHex("0xe9ae5c53"),
+	}
+
+	hash, err := enableMode.Hash(accountMetadata, validator, 3)
+	require.NoError(t, err)
+
+	validationID := GetValidationID(validator)
+	typedData := signer.TypedData{
+		Types: signer.Types{
+			"EIP712Domain": getAccountTypedData(accountMetadata).Types["EIP712Domain"],
+			"Enable": []signer.Type{
+				{Name: "validationId", Type: "bytes21"},
+				{Name: "nonce", Type: "uint32"},
+				{Name: "hook", Type: "address"},
+				{Name: "validatorData", Type: "bytes"},
+				{Name: "hookData", Type: "bytes"},
+				{Name: "selectorData", Type: "bytes"},
+			},
+		},
+		PrimaryType: "Enable",
+		Domain:      getAccountTypedData(accountMetadata).Domain,
+		Message: signer.TypedDataMessage{
+			"validationId":  hexutil.Encode(validationID[:]),
+			"nonce":         math.NewHexOrDecimal256(3),
+			"hook":          common.Address{}.Hex(),
+			"validatorData": hexutil.Encode(enableMode.ValidatorData),
+			"hookData":      "0x",
+			"selectorData":  hexutil.Encode(enableMode.SelectorData),
+		},
+	}
+	expected, _, err := signer.TypedDataAndHash(typedData)
+	require.NoError(t, err)
+	assert.Equal(t, common.BytesToHash(expected), hash)
+
+	other, err := enableMode.Hash(accountMetadata, validator, 4)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestSmartAccountPrivateKeySigner_EnableMode(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+	owner := crypto.PubkeyToAddress(privateKey.PublicKey)
+	address := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+
+	recoverSigner := func(hash common.Hash, signature []byte) common.Address {
+		require.Len(t, signature, 65)
+		ecdsaSignature := append([]byte{}, signature...)
+		ecdsaSignature[64] -= 27
+		publicKey, err := crypto.SigToPub(hash.Bytes(), ecdsaSignature)
+		require.NoError(t, err)
+		return crypto.PubkeyToAddress(*publicKey)
+	}
+
+	root, err := NewSmartAccountPrivateKeySigner(nil, address, privateKey)
+	require.NoError(t, err)
+	root.AccountMetadata = &AccountMetadata{Name: "Kernel", Version: "0.3.1", ChainId: big.NewInt(137), VerifyingContract: address}
+
+	validator := NewWebAuthnValidator(common.HexToAddress("0x7ab16Ff354AcB328452F1D445b3Ddee9a91e9e69"))
+	enableMode := &EnableModeSignature{
+		ValidatorData: common.FromHex("0x01"),
+		SelectorData:  common.FromHex("0xe9ae5c53"),
+	}
+	enableMode.EnableSignature, err = root.SignEnable(context.Background(), enableMode, validator, 1)
+	require.NoError(t, err)
+	enableHash, err := enableMode.Hash(root.AccountMetadata, validator, 1)
+	require.NoError(t, err)
+	assert.Equal(t, owner, recoverSigner(enableHash, enableMode.EnableSignature))
+
+	s, err := NewSmartAccountPrivateKeySignerWithValidator(nil, address, privateKey, validator)
+	require.NoError(t, err)
+	s.Mode = SignerModeEnable
+	s.EnableMode = enableMode
+
+	hash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+	signature, err := s.SignUserOperationHash(hash)
+	require.NoError(t, err)
+
+	decoded, err := DecodeEnableModeSignature(signature)
+	require.NoError(t, err)
+	assert.Equal(t, enableMode.ValidatorData, decoded.ValidatorData)
+	assert.Equal(t, enableMode.SelectorData, decoded.SelectorData)
+	assert.Equal(t, enableMode.EnableSignature, decoded.EnableSignature)
+	assert.Equal(t, owner, recoverSigner(hash, decoded.UserOpSignature))
+	assert.Nil(t, enableMode.UserOpSignature)
+}
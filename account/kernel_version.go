@@ -0,0 +1,19 @@
+package account
+
+// KernelVersion selects how the signer wraps signatures for the Kernel implementation of the account
+type KernelVersion string
+
+const (
+	// KernelVersionV3 is Kernel v3.0 and v3.1, the default: UserOperation signatures are the raw validator signatures, the validator
+	// is selected by the nonce key, and ERC-1271 signatures are prefixed with the validator identifier
+	KernelVersionV3 KernelVersion = "v3"
+	// KernelVersionV2 is Kernel v2.3 and v2.4: UserOperation signatures are prefixed with the 4 bytes sudo or plugin mode, and
+	// ERC-1271 signatures are validated by the default validator as they are. The enable mode is not supported
+	KernelVersionV2 KernelVersion = "v2"
+)
+
+// kernelV2SudoMode prefixes the UserOperation signatures of Kernel v2 validated by the default validator
+var kernelV2SudoMode = []byte{0x00, 0x00, 0x00, 0x00}
+
+// kernelV2PluginMode prefixes the UserOperation signatures of Kernel v2 validated by the validator of the called selector
+var kernelV2PluginMode = []byte{0x00, 0x00, 0x00, 0x01}
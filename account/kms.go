@@ -0,0 +1,180 @@
+package account
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// KMSClient is the subset of a KMS API used by KMSSigner, e.g. a thin wrapper of the AWS KMS client
+// signing with ECDSA_SHA_256 over a DIGEST message type, using an ECC_SECG_P256K1 key.
+type KMSClient interface {
+	// Sign signs the 32-byte digest with the key and returns the DER encoded ECDSA signature
+	Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error)
+	// GetPublicKey returns the DER encoded SubjectPublicKeyInfo of the key
+	GetPublicKey(ctx context.Context, keyID string) ([]byte, error)
+}
+
+// KMSSigner signs for a Kernel account owned by an ECDSA key held in a KMS, the private key never leaves the KMS.
+type KMSSigner struct {
+	Client          types.RPCClient
+	Address         common.Address
+	KMS             KMSClient
+	KeyID           string
+	Owner           common.Address
+	Validator       Validator
+	AccountMetadata *AccountMetadata
+}
+
+// NewKMSSigner creates a signer of the account at address owned by the KMS key keyID, the owner address is derived from the key's public key.
+func NewKMSSigner(ctx context.Context, client types.RPCClient, address common.Address, kms KMSClient, keyID string) (*KMSSigner, error) {
+	publicKey, err := kms.GetPublicKey(ctx, keyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get kms public key")
+	}
+
+	owner, err := kmsPublicKeyAddress(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KMSSigner{
+		Client:    client,
+		Address:   address,
+		KMS:       kms,
+		KeyID:     keyID,
+		Owner:     owner,
+		Validator: NewEcdsaValidator(),
+	}, nil
+}
+
+func (s *KMSSigner) GetAddress() common.Address {
+	return s.Address
+}
+
+// OwnerAddress returns the address of the KMS key, the owner registered in its Validator
+func (s *KMSSigner) OwnerAddress() common.Address {
+	return s.Owner
+}
+
+// GetValidator returns the Validator the signatures are validated by
+func (s *KMSSigner) GetValidator() Validator {
+	return s.Validator
+}
+
+func (s *KMSSigner) SignMessage(message []byte) ([]byte, error) {
+	return s.SignHash(common.BytesToHash(accounts.TextHash(message)))
+}
+
+func (s *KMSSigner) SignTypedData(typedData *signer.TypedData) ([]byte, error) {
+	hash, _, err := signer.TypedDataAndHash(*typedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SignHash(common.BytesToHash(hash))
+}
+
+func (s *KMSSigner) SignHash(hash common.Hash) ([]byte, error) {
+	return s.SignHashContext(context.Background(), hash)
+}
+
+// SignHashContext signs the hash for ERC-1271 validation by the account, see SignHash
+func (s *KMSSigner) SignHashContext(ctx context.Context, hash common.Hash) ([]byte, error) {
+	accountMetadata, err := cachedAccountMetadata(ctx, &s.AccountMetadata, s.Client, s.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	finalHash, err := kernelMessageHash(accountMetadata, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := s.signHashBase(ctx, finalHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(s.Validator.GetIdentifier(), signature...), nil
+}
+
+func (s *KMSSigner) SignUserOperationHash(hash common.Hash) ([]byte, error) {
+	return s.SignUserOperationHashContext(context.Background(), hash)
+}
+
+// SignUserOperationHashContext signs the UserOperation hash with the KMS key, the KMS call is bound to ctx
+func (s *KMSSigner) SignUserOperationHashContext(ctx context.Context, hash common.Hash) ([]byte, error) {
+	return s.signHashBase(ctx, hash)
+}
+
+// signHashBase signs the hash with KMS and converts the DER signature to the 65-byte [R || S || V] form
+func (s *KMSSigner) signHashBase(ctx context.Context, hash common.Hash) ([]byte, error) {
+	derSignature, err := s.KMS.Sign(ctx, s.KeyID, hash.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign with kms")
+	}
+
+	return kmsSignatureToEthereum(derSignature, hash, s.Owner)
+}
+
+// kmsSignatureToEthereum normalizes the DER signature to low-S, as required by ecrecover, and finds the recovery id matching owner
+func kmsSignatureToEthereum(derSignature []byte, hash common.Hash, owner common.Address) ([]byte, error) {
+	var parsed struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(derSignature, &parsed); err != nil {
+		return nil, errors.Wrap(err, "failed to decode kms signature")
+	}
+
+	s := parsed.S
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s = new(big.Int).Sub(secp256k1N, s)
+	}
+
+	signature := make([]byte, crypto.SignatureLength)
+	parsed.R.FillBytes(signature[:32])
+	s.FillBytes(signature[32:64])
+
+	for recoveryID := byte(0); recoveryID < 2; recoveryID++ {
+		signature[64] = recoveryID
+
+		publicKey, err := crypto.SigToPub(hash.Bytes(), signature)
+		if err == nil && crypto.PubkeyToAddress(*publicKey) == owner {
+			signature[64] += 27
+			return signature, nil
+		}
+	}
+
+	return nil, errors.New("kms signature does not match the key owner")
+}
+
+// kmsPublicKeyAddress derives the address of a secp256k1 public key encoded as DER SubjectPublicKeyInfo
+func kmsPublicKeyAddress(der []byte) (common.Address, error) {
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &publicKeyInfo); err != nil {
+		return common.Address{}, errors.Wrap(err, "failed to decode kms public key")
+	}
+
+	publicKey, err := crypto.UnmarshalPubkey(publicKeyInfo.PublicKey.Bytes)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "kms key is not a secp256k1 public key")
+	}
+
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
@@ -0,0 +1,119 @@
+package account
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockKMSClient signs with a local key, returning DER signatures like a KMS
+type mockKMSClient struct {
+	privateKey *ecdsa.PrivateKey
+	highS      bool
+	latency    time.Duration
+}
+
+func (m *mockKMSClient) Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(m.latency):
+	}
+
+	signature, err := crypto.Sign(digest, m.privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:64])
+	if m.highS {
+		// KMS doesn't normalize signatures, both S and N-S are valid ECDSA signatures
+		s = new(big.Int).Sub(secp256k1N, s)
+	}
+
+	return asn1.Marshal(struct{ R, S *big.Int }{R: r, S: s})
+}
+
+func (m *mockKMSClient) GetPublicKey(ctx context.Context, keyID string) ([]byte, error) {
+	return asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1},
+			Parameters: asn1.RawValue{FullBytes: []byte{0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a}},
+		},
+		PublicKey: asn1.BitString{Bytes: crypto.FromECDSAPub(&m.privateKey.PublicKey), BitLength: 65 * 8},
+	})
+}
+
+func TestKMSSigner_SignUserOperationHash(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	hash := common.HexToHash("0xe361e4b3ddb22445e07c6d63862332f3313663b87dec5297c0a0ee33eac68876")
+
+	expectedSignature, err := crypto.Sign(hash.Bytes(), privateKey)
+	require.NoError(t, err)
+	expectedSignature[64] += 27
+
+	tests := []struct {
+		name  string
+		highS bool
+	}{
+		{name: "low_s"},
+		{name: "high_s_normalized", highS: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kms := &mockKMSClient{privateKey: privateKey, highS: tt.highS}
+
+			s, err := NewKMSSigner(context.Background(), nil, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), kms, "key-id")
+			require.NoError(t, err)
+			assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), s.Owner)
+
+			signature, err := s.SignUserOperationHash(hash)
+			require.NoError(t, err)
+			assert.Equal(t, expectedSignature, signature)
+		})
+	}
+}
+
+func TestKMSSigner_SignUserOperationHashContext_Timeout(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	kms := &mockKMSClient{privateKey: privateKey}
+	s, err := NewKMSSigner(context.Background(), nil, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), kms, "key-id")
+	require.NoError(t, err)
+
+	kms.latency = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = s.SignUserOperationHashContext(ctx, common.HexToHash("0x01"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestKMSSigner_WrongKey(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	s, err := NewKMSSigner(context.Background(), nil, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &mockKMSClient{privateKey: privateKey}, "key-id")
+	require.NoError(t, err)
+
+	s.Owner = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	_, err = s.SignUserOperationHash(common.HexToHash("0x01"))
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
 	"math/big"
 	"strings"
 )
@@ -21,7 +22,26 @@ type AccountMetadata struct {
 	Extensions        []*big.Int     `json:"extensions"`
 }
 
-func GetAccountMetadata(client types.RPCClient, address common.Address) (*AccountMetadata, error) {
+// cachedAccountMetadata returns *cached, read first from the account at address with client if it's nil
+func cachedAccountMetadata(ctx context.Context, cached **AccountMetadata, client types.RPCClient, address common.Address) (*AccountMetadata, error) {
+	if *cached != nil {
+		return *cached, nil
+	}
+	if client == nil {
+		return nil, errors.New("client is required to read the account metadata")
+	}
+
+	accountMetadata, err := GetAccountMetadata(ctx, client, address)
+	if err != nil {
+		return nil, err
+	}
+
+	*cached = accountMetadata
+	return accountMetadata, nil
+}
+
+// GetAccountMetadata reads the EIP-712 domain of the account at address, the eth_call is bound to ctx
+func GetAccountMetadata(ctx context.Context, client types.RPCClient, address common.Address) (*AccountMetadata, error) {
 	parsedAbi, err := abi.JSON(strings.NewReader(abis.Eip1271Abi))
 	if err != nil {
 		return nil, err
@@ -41,7 +61,7 @@ func GetAccountMetadata(client types.RPCClient, address common.Address) (*Accoun
 	}
 
 	var hex hexutil.Bytes
-	if err := client.CallContext(context.Background(), &hex, "eth_call", msg, "latest"); err != nil {
+	if err := client.CallContext(ctx, &hex, "eth_call", msg, "latest"); err != nil {
 		return nil, err
 	}
 
@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/DIMO-Network/go-zerodev/types"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/stretchr/testify/assert"
@@ -75,7 +76,7 @@ func TestGetAccountMetadata(t *testing.T) {
 				callContextFunc: tt.mockResponse,
 			}
 
-			result, err := GetAccountMetadata(mockClient, expectedAddress)
+			result, err := GetAccountMetadata(context.Background(), mockClient, expectedAddress)
 
 			if tt.expectedError != nil {
 				assert.ErrorContains(t, err, tt.expectedError.Error())
@@ -87,3 +88,63 @@ func TestGetAccountMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestCachedAccountMetadata(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	address := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+
+	calls := 0
+	client := &mockRPCClient{callContextFunc: func(callCtx context.Context, result interface{}, method string, args ...interface{}) error {
+		calls++
+		assert.Equal(t, ctx, callCtx)
+		return errors.New("rpc call failed")
+	}}
+
+	var cached *AccountMetadata
+	_, err := cachedAccountMetadata(ctx, &cached, client, address)
+	assert.EqualError(t, err, "rpc call failed")
+	assert.Nil(t, cached)
+
+	// a set metadata is returned without reading it
+	cached = &AccountMetadata{Name: "Kernel"}
+	accountMetadata, err := cachedAccountMetadata(ctx, &cached, client, address)
+	assert.NoError(t, err)
+	assert.Same(t, cached, accountMetadata)
+	assert.Equal(t, 1, calls)
+
+	cached = nil
+	_, err = cachedAccountMetadata(ctx, &cached, nil, address)
+	assert.EqualError(t, err, "client is required to read the account metadata")
+}
+
+func TestSigners_SignHashContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	address := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+
+	client := &mockRPCClient{callContextFunc: func(callCtx context.Context, result interface{}, method string, args ...interface{}) error {
+		assert.Equal(t, ctx, callCtx)
+		return errors.New("rpc call failed")
+	}}
+
+	tests := []struct {
+		name   string
+		signer types.ContextHashSigner
+	}{
+		{name: "private_key", signer: &SmartAccountPrivateKeySigner{Client: client, Address: address}},
+		{name: "multisig", signer: &MultiSigSigner{Client: client, Address: address}},
+		{name: "session_key", signer: &SessionKeySigner{Client: client, Address: address}},
+		{name: "webauthn", signer: &WebAuthnSigner{Client: client, Address: address}},
+		{name: "callback", signer: &CallbackSigner{Client: client, Address: address}},
+		{name: "kms", signer: &KMSSigner{Client: client, Address: address}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the account metadata is read within ctx
+			_, err := tt.signer.SignHashContext(ctx, common.HexToHash("0x01"))
+			assert.EqualError(t, err, "rpc call failed")
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"sort"
+)
+
+// EcdsaSigner signs raw hashes with an ECDSA key, returning 65-byte [R || S || V] signatures, e.g. zerodev.PrivateKeySigner
+type EcdsaSigner interface {
+	GetAddress() common.Address
+	SignHash(hash common.Hash) ([]byte, error)
+}
+
+// MultiSigOwner is an owner of a multisig account with its weight in the validator
+type MultiSigOwner struct {
+	Signer EcdsaSigner
+	Weight uint64
+}
+
+// MultiSigSigner signs for a Kernel account guarded by a weighted ECDSA validator, e.g. 2-of-3 owners.
+// Signatures of the owners are concatenated ordered by ascending owner address, the order the validator checks them in.
+// UserOperations signed by it have to use the nonce key returned by NonceKey.
+type MultiSigSigner struct {
+	Client          types.RPCClient
+	Address         common.Address
+	Owners          []MultiSigOwner
+	Threshold       uint64
+	Validator       *WeightedEcdsaValidator
+	AccountMetadata *AccountMetadata
+}
+
+// NewMultiSigSigner creates a signer of the account at address guarded by the weighted ECDSA validator deployed at validatorAddress.
+// The owners have to weigh at least threshold in total.
+func NewMultiSigSigner(client types.RPCClient, address common.Address, validatorAddress common.Address, owners []MultiSigOwner, threshold uint64) (*MultiSigSigner, error) {
+	if threshold == 0 {
+		return nil, errors.New("threshold must be positive")
+	}
+
+	var totalWeight uint64
+	for _, owner := range owners {
+		totalWeight += owner.Weight
+	}
+	if totalWeight < threshold {
+		return nil, errors.Errorf("owners weigh %d, below the threshold of %d", totalWeight, threshold)
+	}
+
+	return &MultiSigSigner{
+		Client:    client,
+		Address:   address,
+		Owners:    owners,
+		Threshold: threshold,
+		Validator: NewWeightedEcdsaValidator(validatorAddress),
+	}, nil
+}
+
+func (s *MultiSigSigner) GetAddress() common.Address {
+	return s.Address
+}
+
+// NonceKey returns the nonce key routing UserOperations to the weighted ECDSA validator
+func (s *MultiSigSigner) NonceKey(key uint16) *big.Int {
+	return GetNonceKey(s.Validator, ValidationModeDefault, key)
+}
+
+func (s *MultiSigSigner) SignMessage(message []byte) ([]byte, error) {
+	return s.SignHash(common.BytesToHash(accounts.TextHash(message)))
+}
+
+func (s *MultiSigSigner) SignTypedData(typedData *signer.TypedData) ([]byte, error) {
+	hash, _, err := signer.TypedDataAndHash(*typedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SignHash(common.BytesToHash(hash))
+}
+
+// SignHash signs the hash for ERC-1271 validation with all owners, prefixed with the validator identifier
+func (s *MultiSigSigner) SignHash(hash common.Hash) ([]byte, error) {
+	return s.signHashWith(context.Background(), hash, s.ownerAddresses())
+}
+
+// SignHashContext works like SignHash, the account metadata is read within ctx
+func (s *MultiSigSigner) SignHashContext(ctx context.Context, hash common.Hash) ([]byte, error) {
+	return s.signHashWith(ctx, hash, s.ownerAddresses())
+}
+
+// SignHashWith works like SignHash, signing only with the given owners, which have to meet the threshold
+func (s *MultiSigSigner) SignHashWith(hash common.Hash, owners []common.Address) ([]byte, error) {
+	return s.signHashWith(context.Background(), hash, owners)
+}
+
+func (s *MultiSigSigner) signHashWith(ctx context.Context, hash common.Hash, owners []common.Address) ([]byte, error) {
+	accountMetadata, err := cachedAccountMetadata(ctx, &s.AccountMetadata, s.Client, s.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	finalHash, err := kernelMessageHash(accountMetadata, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := s.signHashBase(finalHash, owners)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(s.Validator.GetIdentifier(), signature...), nil
+}
+
+// SignUserOperationHash signs the hash with all owners. Kernel routes the UserOperation to the validator through
+// the nonce key, see NonceKey, so unlike SignHash the signature isn't prefixed with the validator identifier.
+func (s *MultiSigSigner) SignUserOperationHash(hash common.Hash) ([]byte, error) {
+	return s.SignUserOperationHashWith(hash, s.ownerAddresses())
+}
+
+// SignUserOperationHashWith signs the hash only with the given owners, which have to meet the threshold.
+// Allows signing when some of the owners are not available.
+func (s *MultiSigSigner) SignUserOperationHashWith(hash common.Hash, owners []common.Address) ([]byte, error) {
+	return s.signHashBase(hash, owners)
+}
+
+// signHashBase signs the hash with the owners and concatenates the signatures ordered by owner address
+func (s *MultiSigSigner) signHashBase(hash common.Hash, owners []common.Address) ([]byte, error) {
+	selected := make([]MultiSigOwner, 0, len(owners))
+	var weight uint64
+
+	for _, address := range owners {
+		owner, err := s.findOwner(address)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range selected {
+			if other.Signer.GetAddress() == address {
+				return nil, errors.Errorf("owner %s selected more than once", address.Hex())
+			}
+		}
+
+		selected = append(selected, owner)
+		weight += owner.Weight
+	}
+
+	if weight < s.Threshold {
+		return nil, errors.Errorf("signers weigh %d, below the threshold of %d", weight, s.Threshold)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return bytes.Compare(selected[i].Signer.GetAddress().Bytes(), selected[j].Signer.GetAddress().Bytes()) < 0
+	})
+
+	var signatures bytes.Buffer
+	for _, owner := range selected {
+		signature, err := owner.Signer.SignHash(hash)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to sign with owner %s", owner.Signer.GetAddress().Hex())
+		}
+		signatures.Write(signature)
+	}
+
+	return signatures.Bytes(), nil
+}
+
+func (s *MultiSigSigner) findOwner(address common.Address) (MultiSigOwner, error) {
+	for _, owner := range s.Owners {
+		if owner.Signer.GetAddress() == address {
+			return owner, nil
+		}
+	}
+	return MultiSigOwner{}, errors.Errorf("%s is not an owner", address.Hex())
+}
+
+func (s *MultiSigSigner) ownerAddresses() []common.Address {
+	addresses := make([]common.Address, len(s.Owners))
+	for i, owner := range s.Owners {
+		addresses[i] = owner.Signer.GetAddress()
+	}
+	return addresses
+}
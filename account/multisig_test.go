@@ -0,0 +1,133 @@
+package account
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEcdsaSigner struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+func (s *testEcdsaSigner) GetAddress() common.Address {
+	return crypto.PubkeyToAddress(s.privateKey.PublicKey)
+}
+
+func (s *testEcdsaSigner) SignHash(hash common.Hash) ([]byte, error) {
+	signature, err := crypto.Sign(hash.Bytes(), s.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27
+	return signature, nil
+}
+
+func newTestMultiSigOwners(t *testing.T) []MultiSigOwner {
+	var owners []MultiSigOwner
+	for _, key := range []string{
+		"4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36",
+		"ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
+		"59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+	} {
+		privateKey, err := crypto.HexToECDSA(key)
+		require.NoError(t, err)
+		owners = append(owners, MultiSigOwner{Signer: &testEcdsaSigner{privateKey: privateKey}, Weight: 1})
+	}
+	return owners
+}
+
+func TestMultiSigSigner_SignUserOperationHash(t *testing.T) {
+	owners := newTestMultiSigOwners(t)
+	hash := common.HexToHash("0xe361e4b3ddb22445e07c6d63862332f3313663b87dec5297c0a0ee33eac68876")
+
+	tests := []struct {
+		name          string
+		signers       []MultiSigOwner
+		expectedError bool
+	}{
+		{
+			name:    "all_owners",
+			signers: owners,
+		},
+		{
+			name:    "threshold_subset",
+			signers: []MultiSigOwner{owners[2], owners[0]},
+		},
+		{
+			name:          "below_threshold",
+			signers:       []MultiSigOwner{owners[1]},
+			expectedError: true,
+		},
+		{
+			name:          "duplicate_owner",
+			signers:       []MultiSigOwner{owners[1], owners[1]},
+			expectedError: true,
+		},
+		{
+			name:          "not_an_owner",
+			signers:       []MultiSigOwner{owners[0], {Signer: &testEcdsaSigner{privateKey: mustGenerateKey(t)}, Weight: 1}},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewMultiSigSigner(nil, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), common.HexToAddress("0xeD89244160CfE273800B58b1B534031699dFeEEE"), owners, 2)
+			require.NoError(t, err)
+
+			addresses := make([]common.Address, len(tt.signers))
+			for i, signer := range tt.signers {
+				addresses[i] = signer.Signer.GetAddress()
+			}
+
+			signature, err := s.SignUserOperationHashWith(hash, addresses)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, signature, 65*len(tt.signers))
+
+			// signatures are ordered by ascending signer address
+			var previous common.Address
+			for i := 0; i < len(tt.signers); i++ {
+				ownerSignature := common.CopyBytes(signature[i*65 : (i+1)*65])
+				ownerSignature[64] -= 27
+
+				publicKey, err := crypto.SigToPub(hash.Bytes(), ownerSignature)
+				require.NoError(t, err)
+
+				signer := crypto.PubkeyToAddress(*publicKey)
+				assert.Contains(t, addresses, signer)
+				assert.Equal(t, -1, bytes.Compare(previous.Bytes(), signer.Bytes()))
+				previous = signer
+			}
+		})
+	}
+}
+
+func TestNewMultiSigSigner_Threshold(t *testing.T) {
+	owners := newTestMultiSigOwners(t)
+
+	_, err := NewMultiSigSigner(nil, common.Address{}, common.Address{}, owners, 4)
+	assert.Error(t, err)
+
+	_, err = NewMultiSigSigner(nil, common.Address{}, common.Address{}, owners, 0)
+	assert.Error(t, err)
+
+	s, err := NewMultiSigSigner(nil, common.Address{}, common.HexToAddress("0xeD89244160CfE273800B58b1B534031699dFeEEE"), owners, 3)
+	require.NoError(t, err)
+	assert.Equal(t, common.FromHex("0x01ed89244160cfe273800b58b1b534031699dfeeee"), s.Validator.GetIdentifier())
+}
+
+func mustGenerateKey(t *testing.T) *ecdsa.PrivateKey {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return privateKey
+}
@@ -0,0 +1,42 @@
+package account
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+)
+
+// ecdsaValidatorStorageSelector selects ecdsaValidatorStorage(address) of the ECDSA validator, returning the owner of an account
+var ecdsaValidatorStorageSelector = crypto.Keccak256([]byte("ecdsaValidatorStorage(address)"))[:4]
+
+// OwnerSigner is a signer signing for an account with the key of an owner registered in the account's validator
+type OwnerSigner interface {
+	types.AccountSigner
+	OwnerAddress() common.Address
+	GetValidator() Validator
+}
+
+// GetEcdsaValidatorOwner returns the owner of account registered in the ECDSA validator at validator.
+// It's the zero address if the validator isn't installed on the account, e.g. because the account isn't deployed yet.
+func GetEcdsaValidatorOwner(ctx context.Context, client types.RPCClient, validator common.Address, account common.Address) (common.Address, error) {
+	msg := struct {
+		To   common.Address `json:"to"`
+		Data hexutil.Bytes  `json:"data"`
+	}{
+		To:   validator,
+		Data: append(append([]byte{}, ecdsaValidatorStorageSelector...), common.LeftPadBytes(account.Bytes(), 32)...),
+	}
+
+	var hex hexutil.Bytes
+	if err := client.CallContext(ctx, &hex, "eth_call", msg, "latest"); err != nil {
+		return common.Address{}, errors.Wrap(err, "failed to call ecdsaValidatorStorage")
+	}
+	if len(hex) < 32 {
+		return common.Address{}, errors.Errorf("ecdsaValidatorStorage returned %d bytes, no ECDSA validator deployed at %s", len(hex), validator.Hex())
+	}
+
+	return common.BytesToAddress(hex[12:32]), nil
+}
@@ -0,0 +1,255 @@
+package account
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	// EcdsaSignerAddress is the Kernel permission signer module validating ECDSA signatures of a session key
+	EcdsaSignerAddress = "0x6A6F069E2a08c2468e7724Ab3250CdBFBA14D4FF"
+)
+
+// Kernel validation modes, first byte of the nonce key
+const (
+	ValidationModeDefault = byte(0x00)
+	ValidationModeEnable  = byte(0x01)
+	ValidationModeInstall = byte(0x02)
+)
+
+// policyFlagForAllValidation applies the policy (or signer) to both UserOperations and ERC-1271 signatures
+var policyFlagForAllValidation = []byte{0x00, 0x00}
+
+// permissionSignerPrefix precedes the signer's signature in UserOperation signatures of a permission validator
+const permissionSignerPrefix = byte(0xff)
+
+const kernelValidationABI = `[{
+        "type": "function",
+        "name": "installValidations",
+        "inputs": [
+            { "name": "vIds", "type": "bytes21[]", "internalType": "ValidationId[]" },
+            { "name": "configs", "type": "tuple[]", "internalType": "struct ValidationConfig[]", "components": [
+                { "name": "nonce", "type": "uint32", "internalType": "uint32" },
+                { "name": "hook", "type": "address", "internalType": "contract IHook" }
+            ]},
+            { "name": "validationData", "type": "bytes[]", "internalType": "bytes[]" },
+            { "name": "hookData", "type": "bytes[]", "internalType": "bytes[]" }
+        ],
+        "outputs": [],
+        "stateMutability": "payable"
+    },
+    {
+        "type": "function",
+        "name": "grantAccess",
+        "inputs": [
+            { "name": "vId", "type": "bytes21", "internalType": "ValidationId" },
+            { "name": "selector", "type": "bytes4", "internalType": "bytes4" },
+            { "name": "allow", "type": "bool", "internalType": "bool" }
+        ],
+        "outputs": [],
+        "stateMutability": "payable"
+    }]`
+
+var (
+	bytesArray, _      = abi.NewType("bytes[]", "", nil)
+	callPermissions, _ = abi.NewType("tuple[]", "", []abi.ArgumentMarshaling{
+		{Name: "callType", Type: "bytes1"},
+		{Name: "target", Type: "address"},
+		{Name: "selector", Type: "bytes4"},
+		{Name: "valueLimit", Type: "uint256"},
+		{Name: "rules", Type: "tuple[]", Components: []abi.ArgumentMarshaling{
+			{Name: "condition", Type: "uint8"},
+			{Name: "offset", Type: "uint64"},
+			{Name: "params", Type: "bytes32[]"},
+		}},
+	})
+)
+
+// Policy restricts what a permission (session key) is allowed to do
+type Policy interface {
+	GetAddress() common.Address
+	GetPolicyData() ([]byte, error)
+}
+
+// CallPermission allows calls of Selector on Target, sending at most ValueLimit wei per call
+type CallPermission struct {
+	Target     common.Address
+	Selector   [4]byte
+	ValueLimit *big.Int
+}
+
+// CallPolicy is the Kernel call policy module, calls not matching any of its Permissions are rejected
+type CallPolicy struct {
+	Address     common.Address
+	Permissions []CallPermission
+}
+
+func (p *CallPolicy) GetAddress() common.Address {
+	return p.Address
+}
+
+func (p *CallPolicy) GetPolicyData() ([]byte, error) {
+	type paramRule struct {
+		Condition uint8
+		Offset    uint64
+		Params    [][32]byte
+	}
+	type permission struct {
+		CallType   [1]byte
+		Target     common.Address
+		Selector   [4]byte
+		ValueLimit *big.Int
+		Rules      []paramRule
+	}
+
+	permissions := make([]permission, len(p.Permissions))
+	for i, callPermission := range p.Permissions {
+		valueLimit := callPermission.ValueLimit
+		if valueLimit == nil {
+			valueLimit = big.NewInt(0)
+		}
+
+		permissions[i] = permission{
+			Target:     callPermission.Target,
+			Selector:   callPermission.Selector,
+			ValueLimit: valueLimit,
+			Rules:      []paramRule{},
+		}
+	}
+
+	return abi.Arguments{{Type: callPermissions}}.Pack(permissions)
+}
+
+// BuildPermissionEnableData encodes the permission made of the policies and the ECDSA signer of sessionKey,
+// the result is the validator data used when installing or enabling the permission.
+func BuildPermissionEnableData(sessionKey common.Address, policies []Policy) ([]byte, error) {
+	policyAndSignerData := make([][]byte, 0, len(policies)+1)
+	for _, policy := range policies {
+		policyData, err := policy.GetPolicyData()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode policy data")
+		}
+
+		policyAndSignerData = append(policyAndSignerData, concatBytes(policyFlagForAllValidation, policy.GetAddress().Bytes(), policyData))
+	}
+
+	// the signer is always the last element
+	policyAndSignerData = append(policyAndSignerData, concatBytes(policyFlagForAllValidation, common.HexToAddress(EcdsaSignerAddress).Bytes(), sessionKey.Bytes()))
+
+	enableData, err := abi.Arguments{{Type: bytesArray}}.Pack(policyAndSignerData)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode permission enable data")
+	}
+
+	return enableData, nil
+}
+
+// GetPermissionID derives the permission id from the enable data, the first 4 bytes of its hash.
+func GetPermissionID(enableData []byte) [4]byte {
+	var permissionID [4]byte
+	copy(permissionID[:], crypto.Keccak256(enableData))
+	return permissionID
+}
+
+// PermissionValidator identifies a permission installed on a Kernel account
+type PermissionValidator struct {
+	Type         []byte
+	PermissionID [4]byte
+}
+
+func NewPermissionValidator(permissionID [4]byte) *PermissionValidator {
+	return &PermissionValidator{
+		Type:         common.FromHex(ValidatorTypePermission),
+		PermissionID: permissionID,
+	}
+}
+
+func (p *PermissionValidator) GetType() []byte {
+	return p.Type
+}
+
+// GetAddress returns the permission id left aligned in an address, the way Kernel stores it in the validation id.
+func (p *PermissionValidator) GetAddress() common.Address {
+	var address common.Address
+	copy(address[:], p.PermissionID[:])
+	return address
+}
+
+func (p *PermissionValidator) GetIdentifier() []byte {
+	return append(p.Type, p.PermissionID[:]...)
+}
+
+// GetValidationID returns the 21 bytes Kernel validation id of the validator
+func GetValidationID(validator Validator) [21]byte {
+	var validationID [21]byte
+	copy(validationID[:], validator.GetType())
+	copy(validationID[1:], validator.GetAddress().Bytes())
+	return validationID
+}
+
+// GetNonceKey builds the 192-bit nonce key routing a UserOperation to the validator:
+// mode (1 byte) | validation id (21 bytes) | key (2 bytes)
+func GetNonceKey(validator Validator, mode byte, key uint16) *big.Int {
+	validationID := GetValidationID(validator)
+
+	nonceKey := make([]byte, 0, 24)
+	nonceKey = append(nonceKey, mode)
+	nonceKey = append(nonceKey, validationID[:]...)
+	nonceKey = append(nonceKey, byte(key>>8), byte(key))
+
+	return new(big.Int).SetBytes(nonceKey)
+}
+
+// EncodeInstallValidationCall encodes the call data of a UserOperation, signed by the root validator, installing validator with validatorData.
+// nonce has to be the account's currentNonce(). The installed validator also needs access to the selectors it calls, see EncodeGrantAccessCall.
+func EncodeInstallValidationCall(validator Validator, validatorData []byte, nonce uint32) ([]byte, error) {
+	parsedAbi, err := abi.JSON(strings.NewReader(kernelValidationABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse kernel validation abi")
+	}
+
+	type validationConfig struct {
+		Nonce uint32
+		Hook  common.Address
+	}
+
+	callData, err := parsedAbi.Pack(
+		"installValidations",
+		[][21]byte{GetValidationID(validator)},
+		[]validationConfig{{Nonce: nonce}},
+		[][]byte{validatorData},
+		[][]byte{{}},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode installValidations call data")
+	}
+
+	return callData, nil
+}
+
+// EncodeGrantAccessCall encodes the call data of a UserOperation, signed by the root validator, allowing validator to validate calls of selector.
+func EncodeGrantAccessCall(validator Validator, selector [4]byte, allow bool) ([]byte, error) {
+	parsedAbi, err := abi.JSON(strings.NewReader(kernelValidationABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse kernel validation abi")
+	}
+
+	callData, err := parsedAbi.Pack("grantAccess", GetValidationID(validator), selector, allow)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode grantAccess call data")
+	}
+
+	return callData, nil
+}
+
+func concatBytes(parts ...[]byte) []byte {
+	var result []byte
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+package account
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"math/big"
+)
+
+// SessionKeySigner signs with a session key for a permission of a Kernel account.
+// UserOperations signed by it have to use the nonce key returned by NonceKey.
+type SessionKeySigner struct {
+	Client          types.RPCClient
+	Address         common.Address
+	PrivateKey      *ecdsa.PrivateKey
+	Validator       *PermissionValidator
+	AccountMetadata *AccountMetadata
+	// EnableMode installs the permission with the first UserOperation, its EnableSignature signed by the root signer with SignEnable.
+	// Nil once the permission is installed
+	EnableMode *EnableModeSignature
+}
+
+// NewSessionKeySigner creates a signer of the account at address for the permission identified by permissionID, see GetPermissionID.
+func NewSessionKeySigner(client types.RPCClient, address common.Address, privateKey *ecdsa.PrivateKey, permissionID [4]byte) (*SessionKeySigner, error) {
+	return &SessionKeySigner{
+		Client:     client,
+		Address:    address,
+		PrivateKey: privateKey,
+		Validator:  NewPermissionValidator(permissionID),
+	}, nil
+}
+
+func (s *SessionKeySigner) GetAddress() common.Address {
+	return s.Address
+}
+
+// NonceKey returns the nonce key routing UserOperations to the permission validator, in the enable mode if EnableMode is set
+func (s *SessionKeySigner) NonceKey(key uint16) *big.Int {
+	if s.EnableMode != nil {
+		return GetNonceKey(s.Validator, ValidationModeEnable, key)
+	}
+	return GetNonceKey(s.Validator, ValidationModeDefault, key)
+}
+
+func (s *SessionKeySigner) SignMessage(message []byte) ([]byte, error) {
+	return s.SignHash(common.BytesToHash(accounts.TextHash(message)))
+}
+
+func (s *SessionKeySigner) SignTypedData(typedData *signer.TypedData) ([]byte, error) {
+	hash, _, err := signer.TypedDataAndHash(*typedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SignHash(common.BytesToHash(hash))
+}
+
+func (s *SessionKeySigner) SignHash(hash common.Hash) ([]byte, error) {
+	return s.SignHashContext(context.Background(), hash)
+}
+
+// SignHashContext signs the hash for ERC-1271 validation by the account, the account metadata is read within ctx
+func (s *SessionKeySigner) SignHashContext(ctx context.Context, hash common.Hash) ([]byte, error) {
+	accountMetadata, err := cachedAccountMetadata(ctx, &s.AccountMetadata, s.Client, s.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	finalHash, err := kernelMessageHash(accountMetadata, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := s.signHashBase(finalHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(s.Validator.GetIdentifier(), signature...), nil
+}
+
+// SignUserOperationHash signs the hash with the session key, prefixed as expected by the permission validator.
+// With EnableMode the signature is the EnableMode signature installing the permission
+func (s *SessionKeySigner) SignUserOperationHash(hash common.Hash) ([]byte, error) {
+	signature, err := s.signHashBase(hash)
+	if err != nil {
+		return nil, err
+	}
+	signature = append([]byte{permissionSignerPrefix}, signature...)
+
+	if s.EnableMode == nil {
+		return signature, nil
+	}
+
+	enableMode := *s.EnableMode
+	enableMode.UserOpSignature = signature
+	return enableMode.Encode()
+}
+
+func (s *SessionKeySigner) signHashBase(hash common.Hash) ([]byte, error) {
+	signature, err := crypto.Sign(hash.Bytes(), s.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27
+
+	return signature, nil
+}
@@ -0,0 +1,91 @@
+package account
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPermissionEnableData = "0x00000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000001a000000000000000000000000000000000000000000000000000000000000001360000222222222222222222222222222222222222222200000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001111111111111111111111111111111111111111a9059cbb000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000de0b6b3a764000000000000000000000000000000000000000000000000000000000000000000a0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000002a00006a6f069e2a08c2468e7724ab3250cdbfba14d4ff9858effd232b4033e47d90003d41ec34ecaeda9400000000000000000000000000000000000000000000"
+
+func TestBuildPermissionEnableData(t *testing.T) {
+	policy := &CallPolicy{
+		Address: common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		Permissions: []CallPermission{
+			{
+				Target:     common.HexToAddress("0x1111111111111111111111111111111111111111"),
+				Selector:   [4]byte{0xa9, 0x05, 0x9c, 0xbb},
+				ValueLimit: big.NewInt(1_000_000_000_000_000_000),
+			},
+		},
+	}
+
+	enableData, err := BuildPermissionEnableData(common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), []Policy{policy})
+	require.NoError(t, err)
+
+	assert.Equal(t, testPermissionEnableData, hexutil.Encode(enableData))
+	assert.Equal(t, [4]byte{0x5e, 0xfb, 0x38, 0x4f}, GetPermissionID(enableData))
+}
+
+func TestSessionKeySigner(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	s, err := NewSessionKeySigner(nil, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), privateKey, GetPermissionID(common.FromHex(testPermissionEnableData)))
+	require.NoError(t, err)
+
+	assert.Equal(t, "0x25efb384f000000000000000000000000000000000001", hexutil.EncodeBig(s.NonceKey(1)))
+	assert.Equal(t, common.FromHex("0x025efb384f"), s.Validator.GetIdentifier())
+
+	hash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+	signature, err := s.SignUserOperationHash(hash)
+	require.NoError(t, err)
+
+	// 0xff signer prefix followed by the session key's r || s || v
+	require.Len(t, signature, 66)
+	assert.Equal(t, byte(0xff), signature[0])
+
+	ecdsaSignature := append([]byte{}, signature[1:]...)
+	ecdsaSignature[64] -= 27
+	publicKey, err := crypto.SigToPub(hash.Bytes(), ecdsaSignature)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), crypto.PubkeyToAddress(*publicKey))
+}
+
+func TestSessionKeySigner_EnableMode(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	enableData := common.FromHex(testPermissionEnableData)
+	s, err := NewSessionKeySigner(nil, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), privateKey, GetPermissionID(enableData))
+	require.NoError(t, err)
+	enableMode := &EnableModeSignature{
+		ValidatorData:   enableData,
+		SelectorData:    common.FromHex("0xe9ae5c53"),
+		EnableSignature: common.FromHex("0xabab"),
+	}
+	s.EnableMode = enableMode
+
+	assert.Equal(t, "0x1025efb384f000000000000000000000000000000000001", hexutil.EncodeBig(s.NonceKey(1)))
+
+	hash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+	signature, err := s.SignUserOperationHash(hash)
+	require.NoError(t, err)
+
+	decoded, err := DecodeEnableModeSignature(signature)
+	require.NoError(t, err)
+	assert.Equal(t, enableData, decoded.ValidatorData)
+	assert.Equal(t, enableMode.EnableSignature, decoded.EnableSignature)
+	assert.Nil(t, enableMode.UserOpSignature)
+
+	// the wrapped signature is the one of the installed permission
+	s.EnableMode = nil
+	installed, err := s.SignUserOperationHash(hash)
+	require.NoError(t, err)
+	assert.Equal(t, installed, decoded.UserOpSignature)
+}
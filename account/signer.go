@@ -1,34 +1,74 @@
 package account
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"fmt"
 	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
 	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/friendsofgo/errors"
+	"math/big"
 )
 
 var (
 	bytes32, _ = abi.NewType("bytes32", "", nil)
 )
 
+// Kernel v3 modes of the UserOperations of a SmartAccountPrivateKeySigner
+const (
+	// SignerModeSudo validates the UserOperations by the root validator of the account, with the nonce key 0
+	SignerModeSudo = byte(0x00)
+	// SignerModeEnable installs the validator of ValidatorID with the first UserOperation, see EnableModeSignature
+	SignerModeEnable = byte(0x01)
+	// SignerModeUse validates the UserOperations by the installed validator of ValidatorID
+	SignerModeUse = byte(0x02)
+)
+
 type SmartAccountPrivateKeySigner struct {
-	Client          types.RPCClient
-	Address         common.Address
-	PrivateKey      *ecdsa.PrivateKey
-	Validator       Validator
+	Client     types.RPCClient
+	Address    common.Address
+	PrivateKey *ecdsa.PrivateKey
+	// Validator is the Kernel validator module the signatures are validated by, its identifier prefixes ERC-1271 signatures
+	Validator Validator
+	// ValidatorID is the 21 bytes Kernel validation id UserOperations are routed to by NonceKey in SignerModeEnable and SignerModeUse
+	ValidatorID [21]byte
+	// Mode is SignerModeSudo, SignerModeEnable with the EnableMode data signed by the root validator, or SignerModeUse
+	Mode            byte
 	AccountMetadata *AccountMetadata
+	EnableMode      *EnableModeSignature
+	// KernelVersion is the Kernel implementation of the account, KernelVersionV3 if empty
+	KernelVersion KernelVersion
 }
 
 func NewSmartAccountPrivateKeySigner(client types.RPCClient, address common.Address, privateKey *ecdsa.PrivateKey) (*SmartAccountPrivateKeySigner, error) {
+	s, err := NewSmartAccountPrivateKeySignerWithValidator(client, address, privateKey, NewEcdsaValidator())
+	if err != nil {
+		return nil, err
+	}
+
+	s.Mode = SignerModeSudo
+	return s, nil
+}
+
+// NewSmartAccountPrivateKeySignerWithValidator creates a signer for a non-root validator installed on the account, e.g. a secondary ECDSA validator.
+// UserOperations signed by it, in SignerModeUse, have to use the nonce key returned by NonceKey.
+func NewSmartAccountPrivateKeySignerWithValidator(client types.RPCClient, address common.Address, privateKey *ecdsa.PrivateKey, validator Validator) (*SmartAccountPrivateKeySigner, error) {
+	if validator == nil {
+		return nil, errors.New("validator is required")
+	}
+
 	return &SmartAccountPrivateKeySigner{
-		Client:     client,
-		Address:    address,
-		PrivateKey: privateKey,
-		Validator:  NewEcdsaValidator(),
+		Client:      client,
+		Address:     address,
+		PrivateKey:  privateKey,
+		Validator:   validator,
+		ValidatorID: GetValidationID(validator),
+		Mode:        SignerModeUse,
 	}, nil
 }
 
@@ -36,9 +76,37 @@ func (s *SmartAccountPrivateKeySigner) GetAddress() common.Address {
 	return s.Address
 }
 
+// OwnerAddress returns the address of the signer's private key, the owner registered in its Validator
+func (s *SmartAccountPrivateKeySigner) OwnerAddress() common.Address {
+	return crypto.PubkeyToAddress(s.PrivateKey.PublicKey)
+}
+
+// GetValidator returns the Validator the signatures are validated by
+func (s *SmartAccountPrivateKeySigner) GetValidator() Validator {
+	return s.Validator
+}
+
+// NonceKey returns the nonce key routing UserOperations to the validator of the signer's Mode,
+// key alone in SignerModeSudo (the root validator) and the ValidatorID in the other modes.
+func (s *SmartAccountPrivateKeySigner) NonceKey(key uint16) *big.Int {
+	mode := ValidationModeDefault
+	switch s.Mode {
+	case SignerModeSudo:
+		return new(big.Int).SetUint64(uint64(key))
+	case SignerModeEnable:
+		mode = ValidationModeEnable
+	}
+
+	nonceKey := make([]byte, 0, 24)
+	nonceKey = append(nonceKey, mode)
+	nonceKey = append(nonceKey, s.ValidatorID[:]...)
+	nonceKey = append(nonceKey, byte(key>>8), byte(key))
+
+	return new(big.Int).SetBytes(nonceKey)
+}
+
 func (s *SmartAccountPrivateKeySigner) SignMessage(message []byte) ([]byte, error) {
-	hash := crypto.Keccak256Hash(message)
-	return s.SignHash(hash)
+	return s.SignHash(common.BytesToHash(accounts.TextHash(message)))
 }
 
 func (s *SmartAccountPrivateKeySigner) SignTypedData(typedData *signer.TypedData) ([]byte, error) {
@@ -51,33 +119,104 @@ func (s *SmartAccountPrivateKeySigner) SignTypedData(typedData *signer.TypedData
 }
 
 func (s *SmartAccountPrivateKeySigner) SignHash(hash common.Hash) ([]byte, error) {
-	accountTypedData, err := s.getAccountTypedData()
+	return s.SignHashContext(context.Background(), hash)
+}
+
+// SignHashContext signs the hash for ERC-1271 validation by the account, the account metadata is read within ctx
+func (s *SmartAccountPrivateKeySigner) SignHashContext(ctx context.Context, hash common.Hash) ([]byte, error) {
+	accountMetadata, err := s.getAccountMetadata(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	domainSeparator, err := accountTypedData.HashStruct("EIP712Domain", accountTypedData.Domain.Map())
+	finalHash, err := kernelMessageHash(accountMetadata, hash)
 	if err != nil {
 		return nil, err
 	}
 
-	wrappedHash, err := s.kernelHashWrap(hash)
+	signature, err := s.signHashBase(finalHash)
 	if err != nil {
 		return nil, err
 	}
 
-	rawData := fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(wrappedHash))
-	finalHash := crypto.Keccak256Hash([]byte(rawData))
+	switch s.KernelVersion {
+	case "", KernelVersionV3:
+		return append(s.Validator.GetIdentifier(), signature...), nil
+	case KernelVersionV2:
+		return signature, nil
+	default:
+		return nil, errors.Errorf("kernel version %q is not supported", s.KernelVersion)
+	}
+}
 
-	signature, err := s.signHashBase(finalHash)
+// SignUserOperationHash signs the hash for the validator of the signer's Mode, the UserOperation is routed to it by its nonce key.
+// In SignerModeEnable the signature is the EnableMode signature installing the validator.
+// Kernel v2 signatures are prefixed with the mode instead, its enable mode is not supported.
+func (s *SmartAccountPrivateKeySigner) SignUserOperationHash(hash common.Hash) ([]byte, error) {
+	switch s.KernelVersion {
+	case "", KernelVersionV3:
+	case KernelVersionV2:
+		return s.signUserOperationHashV2(hash)
+	default:
+		return nil, errors.Errorf("kernel version %q is not supported", s.KernelVersion)
+	}
+
+	switch s.Mode {
+	case SignerModeSudo, SignerModeUse:
+		return s.signHashBase(hash)
+	case SignerModeEnable:
+		if s.EnableMode == nil {
+			return nil, errors.New("enable mode is required to sign in the enable signer mode")
+		}
+
+		signature, err := s.signHashBase(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		enableMode := *s.EnableMode
+		enableMode.UserOpSignature = signature
+		return enableMode.Encode()
+	default:
+		return nil, errors.Errorf("signer mode 0x%02x is not supported", s.Mode)
+	}
+}
+
+// signUserOperationHashV2 signs hash in the Kernel v2 sudo mode, validated by the default validator of the account,
+// or in its plugin mode, validated by the validator set for the called selector
+func (s *SmartAccountPrivateKeySigner) signUserOperationHashV2(hash common.Hash) ([]byte, error) {
+	var mode []byte
+	switch s.Mode {
+	case SignerModeSudo:
+		mode = kernelV2SudoMode
+	case SignerModeUse:
+		mode = kernelV2PluginMode
+	default:
+		return nil, errors.Errorf("signer mode 0x%02x is not supported by kernel %s", s.Mode, KernelVersionV2)
+	}
+
+	signature, err := s.signHashBase(hash)
 	if err != nil {
 		return nil, err
 	}
 
-	return append(s.Validator.GetIdentifier(), signature...), nil
+	return append(append([]byte{}, mode...), signature...), nil
 }
 
-func (s *SmartAccountPrivateKeySigner) SignUserOperationHash(hash common.Hash) ([]byte, error) {
+// SignEnable signs, as the root validator of the account, the enabling of validator with the data of enableMode.
+// nonce has to be the account's currentNonce(), the signature is the EnableSignature of enableMode.
+// The account metadata is read within ctx.
+func (s *SmartAccountPrivateKeySigner) SignEnable(ctx context.Context, enableMode *EnableModeSignature, validator Validator, nonce uint32) ([]byte, error) {
+	accountMetadata, err := s.getAccountMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := enableMode.Hash(accountMetadata, validator, nonce)
+	if err != nil {
+		return nil, err
+	}
+
 	return s.signHashBase(hash)
 }
 
@@ -91,7 +230,30 @@ func (s *SmartAccountPrivateKeySigner) signHashBase(hash common.Hash) ([]byte, e
 	return signature, nil
 }
 
-func (s *SmartAccountPrivateKeySigner) kernelHashWrap(hash common.Hash) ([]byte, error) {
+func (s *SmartAccountPrivateKeySigner) getAccountMetadata(ctx context.Context) (*AccountMetadata, error) {
+	return cachedAccountMetadata(ctx, &s.AccountMetadata, s.Client, s.Address)
+}
+
+// kernelMessageHash computes the hash the Kernel account validates for ERC-1271 signatures of hash,
+// hash wrapped in Kernel(bytes32 hash) typed data of the account's EIP-712 domain.
+func kernelMessageHash(accountMetadata *AccountMetadata, hash common.Hash) (common.Hash, error) {
+	accountTypedData := getAccountTypedData(accountMetadata)
+
+	domainSeparator, err := accountTypedData.HashStruct("EIP712Domain", accountTypedData.Domain.Map())
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	wrappedHash, err := kernelHashWrap(hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	rawData := fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(wrappedHash))
+	return crypto.Keccak256Hash([]byte(rawData)), nil
+}
+
+func kernelHashWrap(hash common.Hash) ([]byte, error) {
 	args := abi.Arguments{
 		{Type: bytes32},
 		{Type: bytes32},
@@ -105,16 +267,7 @@ func (s *SmartAccountPrivateKeySigner) kernelHashWrap(hash common.Hash) ([]byte,
 	return crypto.Keccak256(packed), nil
 }
 
-func (s *SmartAccountPrivateKeySigner) getAccountTypedData() (*signer.TypedData, error) {
-	if s.AccountMetadata == nil {
-		accountMetadata, err := GetAccountMetadata(s.Client, s.Address)
-		if err != nil {
-			return nil, err
-		}
-
-		s.AccountMetadata = accountMetadata
-	}
-
+func getAccountTypedData(accountMetadata *AccountMetadata) *signer.TypedData {
 	return &signer.TypedData{
 		Types: signer.Types{
 			"EIP712Domain": []signer.Type{
@@ -125,10 +278,10 @@ func (s *SmartAccountPrivateKeySigner) getAccountTypedData() (*signer.TypedData,
 			},
 		},
 		Domain: signer.TypedDataDomain{
-			Name:              s.AccountMetadata.Name,
-			Version:           s.AccountMetadata.Version,
-			ChainId:           math.NewHexOrDecimal256(s.AccountMetadata.ChainId.Int64()),
-			VerifyingContract: s.AccountMetadata.VerifyingContract.String(),
+			Name:              accountMetadata.Name,
+			Version:           accountMetadata.Version,
+			ChainId:           math.NewHexOrDecimal256(accountMetadata.ChainId.Int64()),
+			VerifyingContract: accountMetadata.VerifyingContract.String(),
 		},
-	}, nil
+	}
 }
@@ -0,0 +1,188 @@
+package account
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmartAccountPrivateKeySigner_Validator(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+	address := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	hash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+
+	tests := []struct {
+		name               string
+		validator          Validator
+		mode               byte
+		expectedID         string
+		expectedNonceKey   string
+		expectedSignErrMsg string
+	}{
+		{
+			name:             "ecdsa",
+			validator:        NewEcdsaValidator(),
+			mode:             SignerModeUse,
+			expectedID:       "0x01845adb2c711129d4f3966735ed98a9f09fc4ce57",
+			expectedNonceKey: "0x1845adb2c711129d4f3966735ed98a9f09fc4ce570001",
+		},
+		{
+			name:             "webauthn",
+			validator:        NewWebAuthnValidator(common.HexToAddress("0x7ab16Ff354AcB328452F1D445b3Ddee9a91e9e69")),
+			mode:             SignerModeUse,
+			expectedID:       "0x017ab16ff354acb328452f1d445b3ddee9a91e9e69",
+			expectedNonceKey: "0x17ab16ff354acb328452f1d445b3ddee9a91e9e690001",
+		},
+		{
+			name:               "enable_mode",
+			validator:          NewEcdsaValidator(),
+			mode:               SignerModeEnable,
+			expectedID:         "0x01845adb2c711129d4f3966735ed98a9f09fc4ce57",
+			expectedNonceKey:   "0x101845adb2c711129d4f3966735ed98a9f09fc4ce570001",
+			expectedSignErrMsg: "enable mode is required",
+		},
+		{
+			name:             "sudo_mode",
+			validator:        NewEcdsaValidator(),
+			mode:             SignerModeSudo,
+			expectedID:       "0x01845adb2c711129d4f3966735ed98a9f09fc4ce57",
+			expectedNonceKey: "0x1",
+		},
+		{
+			name:               "unknown_mode",
+			validator:          NewEcdsaValidator(),
+			mode:               0x03,
+			expectedID:         "0x01845adb2c711129d4f3966735ed98a9f09fc4ce57",
+			expectedNonceKey:   "0x1845adb2c711129d4f3966735ed98a9f09fc4ce570001",
+			expectedSignErrMsg: "signer mode 0x03 is not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSmartAccountPrivateKeySignerWithValidator(nil, address, privateKey, tt.validator)
+			require.NoError(t, err)
+			s.Mode = tt.mode
+
+			assert.Equal(t, tt.expectedID, hexutil.Encode(s.ValidatorID[:]))
+			assert.Equal(t, tt.expectedNonceKey, hexutil.EncodeBig(s.NonceKey(1)))
+
+			signature, err := s.SignUserOperationHash(hash)
+			if tt.expectedSignErrMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedSignErrMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, signature, 65)
+
+			ecdsaSignature := append([]byte{}, signature...)
+			ecdsaSignature[64] -= 27
+			publicKey, err := crypto.SigToPub(hash.Bytes(), ecdsaSignature)
+			require.NoError(t, err)
+			assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), crypto.PubkeyToAddress(*publicKey))
+		})
+	}
+}
+
+func TestNewSmartAccountPrivateKeySignerWithValidator_NilValidator(t *testing.T) {
+	_, err := NewSmartAccountPrivateKeySignerWithValidator(nil, common.Address{}, nil, nil)
+	assert.EqualError(t, err, "validator is required")
+}
+
+func TestSmartAccountPrivateKeySigner_KernelVersion(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+	owner := crypto.PubkeyToAddress(privateKey.PublicKey)
+	address := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	hash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+	accountMetadata := &AccountMetadata{Name: "Kernel", Version: "0.3.1", ChainId: big.NewInt(137), VerifyingContract: address}
+
+	recoverSigner := func(hash common.Hash, signature []byte) common.Address {
+		require.Len(t, signature, 65)
+		ecdsaSignature := append([]byte{}, signature...)
+		ecdsaSignature[64] -= 27
+		publicKey, err := crypto.SigToPub(hash.Bytes(), ecdsaSignature)
+		require.NoError(t, err)
+		return crypto.PubkeyToAddress(*publicKey)
+	}
+
+	tests := []struct {
+		name                string
+		kernelVersion       KernelVersion
+		mode                byte
+		userOpPrefix        []byte
+		messagePrefix       []byte
+		expectedErrContains string
+	}{
+		{
+			name:          "default",
+			messagePrefix: common.FromHex("0x01845adb2c711129d4f3966735ed98a9f09fc4ce57"),
+		},
+		{
+			name:          "v3",
+			kernelVersion: KernelVersionV3,
+			messagePrefix: common.FromHex("0x01845adb2c711129d4f3966735ed98a9f09fc4ce57"),
+		},
+		{
+			name:          "v2",
+			kernelVersion: KernelVersionV2,
+			userOpPrefix:  common.FromHex("0x00000000"),
+		},
+		{
+			name:          "v2_plugin",
+			kernelVersion: KernelVersionV2,
+			mode:          SignerModeUse,
+			userOpPrefix:  common.FromHex("0x00000001"),
+		},
+		{
+			name:                "unsupported",
+			kernelVersion:       "v1",
+			expectedErrContains: `kernel version "v1" is not supported`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSmartAccountPrivateKeySigner(nil, address, privateKey)
+			require.NoError(t, err)
+			s.AccountMetadata = accountMetadata
+			s.KernelVersion = tt.kernelVersion
+			s.Mode = tt.mode
+
+			userOpSignature, err := s.SignUserOperationHash(hash)
+			if tt.expectedErrContains != "" {
+				assert.ErrorContains(t, err, tt.expectedErrContains)
+				_, err = s.SignHash(hash)
+				assert.ErrorContains(t, err, tt.expectedErrContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, userOpSignature, len(tt.userOpPrefix)+65)
+			assert.Equal(t, hexutil.Encode(tt.userOpPrefix), hexutil.Encode(userOpSignature[:len(tt.userOpPrefix)]))
+			assert.Equal(t, owner, recoverSigner(hash, userOpSignature[len(tt.userOpPrefix):]))
+
+			// both versions validate ERC-1271 signatures of the Kernel(bytes32 hash) typed data
+			messageSignature, err := s.SignHash(hash)
+			require.NoError(t, err)
+			require.Len(t, messageSignature, len(tt.messagePrefix)+65)
+			assert.Equal(t, hexutil.Encode(tt.messagePrefix), hexutil.Encode(messageSignature[:len(tt.messagePrefix)]))
+			messageHash, err := kernelMessageHash(accountMetadata, hash)
+			require.NoError(t, err)
+			assert.Equal(t, owner, recoverSigner(messageHash, messageSignature[len(tt.messagePrefix):]))
+		})
+	}
+
+	s, err := NewSmartAccountPrivateKeySigner(nil, address, privateKey)
+	require.NoError(t, err)
+	s.KernelVersion = KernelVersionV2
+	s.Mode = SignerModeEnable
+	_, err = s.SignUserOperationHash(hash)
+	assert.EqualError(t, err, "signer mode 0x01 is not supported by kernel v2")
+}
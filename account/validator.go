@@ -41,3 +41,53 @@ func (e *EcdsaValidator) GetAddress() common.Address {
 func (e *EcdsaValidator) GetIdentifier() []byte {
 	return append(e.Type, e.Address.Bytes()...)
 }
+
+type WebAuthnValidator struct {
+	Type    []byte
+	Address common.Address
+}
+
+// NewWebAuthnValidator creates the validator for the Kernel WebAuthn validator module deployed at address.
+func NewWebAuthnValidator(address common.Address) *WebAuthnValidator {
+	return &WebAuthnValidator{
+		Type:    common.FromHex(ValidatorTypeSecondary),
+		Address: address,
+	}
+}
+
+func (w *WebAuthnValidator) GetType() []byte {
+	return w.Type
+}
+
+func (w *WebAuthnValidator) GetAddress() common.Address {
+	return w.Address
+}
+
+func (w *WebAuthnValidator) GetIdentifier() []byte {
+	return append(w.Type, w.Address.Bytes()...)
+}
+
+type WeightedEcdsaValidator struct {
+	Type    []byte
+	Address common.Address
+}
+
+// NewWeightedEcdsaValidator creates the validator for the Kernel weighted ECDSA (multisig) validator module deployed at address.
+func NewWeightedEcdsaValidator(address common.Address) *WeightedEcdsaValidator {
+	return &WeightedEcdsaValidator{
+		Type:    common.FromHex(ValidatorTypeSecondary),
+		Address: address,
+	}
+}
+
+func (w *WeightedEcdsaValidator) GetType() []byte {
+	return w.Type
+}
+
+func (w *WeightedEcdsaValidator) GetAddress() common.Address {
+	return w.Address
+}
+
+func (w *WeightedEcdsaValidator) GetIdentifier() []byte {
+	return append(w.Type, w.Address.Bytes()...)
+}
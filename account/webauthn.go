@@ -0,0 +1,158 @@
+package account
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"strings"
+)
+
+var (
+	webAuthnBytes, _   = abi.NewType("bytes", "", nil)
+	webAuthnString, _  = abi.NewType("string", "", nil)
+	webAuthnUint256, _ = abi.NewType("uint256", "", nil)
+	webAuthnBool, _    = abi.NewType("bool", "", nil)
+)
+
+// p256N is the order of the P-256 curve, the WebAuthn validator only accepts low-S signatures
+var p256N, _ = new(big.Int).SetString("FFFFFFFF00000000FFFFFFFFFFFFFFFFBCE6FAADA7179E84F3B9CAC2FC632551", 16)
+
+const webAuthnResponseType = `"type":"webauthn.get"`
+
+// WebAuthnAssertion is the authenticator response of a WebAuthn assertion (navigator.credentials.get)
+type WebAuthnAssertion struct {
+	AuthenticatorData []byte
+	ClientDataJSON    string
+	R                 *big.Int
+	S                 *big.Int
+}
+
+// WebAuthnAuthenticator obtains an assertion of the passkey over challenge,
+// clientDataJSON of the assertion has to contain challenge encoded as base64url.
+type WebAuthnAuthenticator func(challenge []byte) (*WebAuthnAssertion, error)
+
+// WebAuthnSigner signs for Kernel accounts validated by the WebAuthn (passkey) validator
+type WebAuthnSigner struct {
+	Client          types.RPCClient
+	Address         common.Address
+	Authenticator   WebAuthnAuthenticator
+	Validator       Validator
+	UsePrecompiled  bool
+	AccountMetadata *AccountMetadata
+}
+
+// NewWebAuthnSigner creates a signer of the account at address, validatorAddress is the address of the WebAuthn validator module the account uses.
+// usePrecompiled makes the validator verify signatures with the RIP-7212 P-256 precompile, available only on some chains.
+func NewWebAuthnSigner(client types.RPCClient, address common.Address, validatorAddress common.Address, authenticator WebAuthnAuthenticator, usePrecompiled bool) (*WebAuthnSigner, error) {
+	if authenticator == nil {
+		return nil, errors.New("authenticator is required")
+	}
+
+	return &WebAuthnSigner{
+		Client:         client,
+		Address:        address,
+		Authenticator:  authenticator,
+		Validator:      NewWebAuthnValidator(validatorAddress),
+		UsePrecompiled: usePrecompiled,
+	}, nil
+}
+
+func (s *WebAuthnSigner) GetAddress() common.Address {
+	return s.Address
+}
+
+func (s *WebAuthnSigner) SignMessage(message []byte) ([]byte, error) {
+	return s.SignHash(common.BytesToHash(accounts.TextHash(message)))
+}
+
+func (s *WebAuthnSigner) SignTypedData(typedData *signer.TypedData) ([]byte, error) {
+	hash, _, err := signer.TypedDataAndHash(*typedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SignHash(common.BytesToHash(hash))
+}
+
+func (s *WebAuthnSigner) SignHash(hash common.Hash) ([]byte, error) {
+	return s.SignHashContext(context.Background(), hash)
+}
+
+// SignHashContext signs the hash for ERC-1271 validation by the account, the account metadata is read within ctx
+func (s *WebAuthnSigner) SignHashContext(ctx context.Context, hash common.Hash) ([]byte, error) {
+	accountMetadata, err := cachedAccountMetadata(ctx, &s.AccountMetadata, s.Client, s.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	finalHash, err := kernelMessageHash(accountMetadata, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := s.signChallenge(finalHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(s.Validator.GetIdentifier(), signature...), nil
+}
+
+func (s *WebAuthnSigner) SignUserOperationHash(hash common.Hash) ([]byte, error) {
+	return s.signChallenge(hash)
+}
+
+func (s *WebAuthnSigner) signChallenge(hash common.Hash) ([]byte, error) {
+	assertion, err := s.Authenticator(hash.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get webauthn assertion")
+	}
+
+	return EncodeWebAuthnSignature(assertion, s.UsePrecompiled)
+}
+
+// EncodeWebAuthnSignature encodes the assertion in the format expected by the Kernel WebAuthn validator:
+// abi.encode(authenticatorData, clientDataJSON, responseTypeLocation, r, s, usePrecompiled)
+func EncodeWebAuthnSignature(assertion *WebAuthnAssertion, usePrecompiled bool) ([]byte, error) {
+	if assertion == nil || assertion.R == nil || assertion.S == nil {
+		return nil, errors.New("assertion with r and s is required")
+	}
+
+	responseTypeLocation := strings.LastIndex(assertion.ClientDataJSON, webAuthnResponseType)
+	if responseTypeLocation < 0 {
+		return nil, errors.New("clientDataJSON does not contain " + webAuthnResponseType)
+	}
+
+	s := assertion.S
+	if s.Cmp(new(big.Int).Rsh(p256N, 1)) > 0 {
+		s = new(big.Int).Sub(p256N, s)
+	}
+
+	args := abi.Arguments{
+		{Name: "authenticatorData", Type: webAuthnBytes},
+		{Name: "clientDataJSON", Type: webAuthnString},
+		{Name: "responseTypeLocation", Type: webAuthnUint256},
+		{Name: "r", Type: webAuthnUint256},
+		{Name: "s", Type: webAuthnUint256},
+		{Name: "usePrecompiled", Type: webAuthnBool},
+	}
+
+	signature, err := args.Pack(
+		assertion.AuthenticatorData,
+		assertion.ClientDataJSON,
+		big.NewInt(int64(responseTypeLocation)),
+		assertion.R,
+		s,
+		usePrecompiled,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode webauthn signature")
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,46 @@
+package account
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebAuthnSigner_SignUserOperationHash(t *testing.T) {
+	userOpHash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+	highS, _ := new(big.Int).SetString("ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc63254c", 16)
+
+	var challenge []byte
+	authenticator := func(c []byte) (*WebAuthnAssertion, error) {
+		challenge = c
+		return &WebAuthnAssertion{
+			AuthenticatorData: common.FromHex("0x49960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d97630500000000"),
+			ClientDataJSON:    `{"type":"webauthn.get","challenge":"3q2-7w","origin":"http://localhost:3000","crossOrigin":false}`,
+			R:                 common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111").Big(),
+			S:                 highS,
+		}, nil
+	}
+
+	s, err := NewWebAuthnSigner(nil, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), common.HexToAddress("0x1111111111111111111111111111111111111111"), authenticator, true)
+	require.NoError(t, err)
+
+	signature, err := s.SignUserOperationHash(userOpHash)
+	require.NoError(t, err)
+
+	assert.Equal(t, userOpHash.Bytes(), challenge)
+	assert.Equal(t, "0x00000000000000000000000000000000000000000000000000000000000000c000000000000000000000000000000000000000000000000000000000000001200000000000000000000000000000000000000000000000000000000000000001111111111111111111111111111111111111111111111111111111111111111100000000000000000000000000000000000000000000000000000000000000050000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000002549960de5880e8c687434170f6476605b8fe4aeb9a28632c7995cf3ba831d9763050000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000617b2274797065223a22776562617574686e2e676574222c226368616c6c656e6765223a223371322d3777222c226f726967696e223a22687474703a2f2f6c6f63616c686f73743a33303030222c2263726f73734f726967696e223a66616c73657d00000000000000000000000000000000000000000000000000000000000000", hexutil.Encode(signature))
+}
+
+func TestEncodeWebAuthnSignature_InvalidClientData(t *testing.T) {
+	_, err := EncodeWebAuthnSignature(&WebAuthnAssertion{
+		ClientDataJSON: `{"type":"webauthn.create"}`,
+		R:              big.NewInt(1),
+		S:              big.NewInt(1),
+	}, false)
+
+	assert.Error(t, err)
+}
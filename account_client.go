@@ -0,0 +1,80 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/account"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+)
+
+// rootValidatorSelector selects rootValidator() of Kernel v3 accounts, returning the bytes21 validation id of the root validator
+var rootValidatorSelector = crypto.Keccak256([]byte("rootValidator()"))[:4]
+
+// AccountClient queries the on-chain state of smart accounts
+type AccountClient struct {
+	Client types.RPCClient
+}
+
+// NewAccountClient creates a new AccountClient instance.
+func NewAccountClient(rpcClient types.RPCClient) (*AccountClient, error) {
+	if rpcClient == nil {
+		return nil, errors.New("rpcClient is required")
+	}
+
+	return &AccountClient{
+		Client: rpcClient,
+	}, nil
+}
+
+// IsDeployed checks whether there is code deployed at the account address.
+// Undeployed (counterfactual) accounts are deployed by the factory with their first UserOperation.
+func (a *AccountClient) IsDeployed(ctx context.Context, account common.Address) (bool, error) {
+	var code hexutil.Bytes
+	if err := a.Client.CallContext(ctx, &code, "eth_getCode", account, "latest"); err != nil {
+		return false, errors.Wrap(err, "failed to call eth_getCode")
+	}
+
+	return len(code) > 0, nil
+}
+
+// GetRootValidator returns the address of the root validator module installed on the Kernel v3 account.
+// Fails if the account isn't deployed or its root validation is a permission rather than a validator.
+func (a *AccountClient) GetRootValidator(ctx context.Context, kernelAccount common.Address) (common.Address, error) {
+	msg := struct {
+		To   common.Address `json:"to"`
+		Data hexutil.Bytes  `json:"data"`
+	}{
+		To:   kernelAccount,
+		Data: rootValidatorSelector,
+	}
+
+	var hex hexutil.Bytes
+	if err := a.Client.CallContext(ctx, &hex, "eth_call", msg, "latest"); err != nil {
+		return common.Address{}, errors.Wrap(err, "failed to call rootValidator")
+	}
+	if len(hex) < 32 {
+		return common.Address{}, errors.Errorf("rootValidator returned %d bytes, %s is not a deployed Kernel v3 account", len(hex), kernelAccount.Hex())
+	}
+
+	// the validation id is the validation type followed by the validator address, left aligned in the word
+	validationType := hex[0]
+	if validationType != common.FromHex(account.ValidatorTypeSecondary)[0] {
+		return common.Address{}, errors.Errorf("root validation type of %s is 0x%02x, not a validator", kernelAccount.Hex(), validationType)
+	}
+
+	return common.BytesToAddress(hex[1:21]), nil
+}
+
+// GetAccountOwner returns the owner registered for the Kernel v3 account in its root validator, e.g. the ECDSA validator.
+// Compare it to the owner of the configured signer to check it can sign for the account.
+func (a *AccountClient) GetAccountOwner(ctx context.Context, kernelAccount common.Address) (common.Address, error) {
+	validator, err := a.GetRootValidator(ctx, kernelAccount)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return account.GetEcdsaValidatorOwner(ctx, a.Client, validator, kernelAccount)
+}
@@ -0,0 +1,112 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/account"
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountClient_IsDeployed(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     hexutil.Bytes
+		expected bool
+	}{
+		{
+			name:     "not_deployed",
+			code:     hexutil.Bytes{},
+			expected: false,
+		},
+		{
+			name:     "deployed",
+			code:     common.FromHex("0xef0100"),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rpcClient := &mockRPCClient{
+				callContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+					assert.Equal(t, "eth_getCode", method)
+					*result.(*hexutil.Bytes) = tt.code
+					return nil
+				},
+			}
+
+			client, err := NewAccountClient(rpcClient)
+			require.NoError(t, err)
+
+			deployed, err := client.IsDeployed(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, deployed)
+		})
+	}
+}
+
+func TestAccountClient_GetAccountOwner(t *testing.T) {
+	kernelAccount := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	owner := common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+
+	tests := []struct {
+		name              string
+		rootValidator     string
+		expectedValidator common.Address
+		expectedError     string
+	}{
+		{
+			name:              "ecdsa_validator",
+			rootValidator:     "0x01845adb2c711129d4f3966735ed98a9f09fc4ce570000000000000000000000",
+			expectedValidator: common.HexToAddress(account.EcdsaValidatorAddress),
+		},
+		{
+			name:          "permission",
+			rootValidator: "0x02deadbeef000000000000000000000000000000000000000000000000000000",
+			expectedError: "is 0x02, not a validator",
+		},
+		{
+			name:          "not_deployed",
+			rootValidator: "0x",
+			expectedError: "rootValidator returned 0 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network := zerodevtest.NewMockRPCClient().
+				On("eth_call", tt.rootValidator, nil).
+				On("eth_call", tt.rootValidator, nil).
+				On("eth_call", hexutil.Encode(common.LeftPadBytes(owner.Bytes(), 32)), nil)
+			accountClient, err := NewAccountClient(network)
+			require.NoError(t, err)
+
+			validator, err := accountClient.GetRootValidator(context.Background(), kernelAccount)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedValidator, validator)
+
+			accountOwner, err := accountClient.GetAccountOwner(context.Background(), kernelAccount)
+			require.NoError(t, err)
+			assert.Equal(t, owner, accountOwner)
+
+			// the owner is read from the root validator
+			calls := network.Calls()
+			require.Len(t, calls, 3)
+			msg, err := json.Marshal(calls[2].Args[0])
+			require.NoError(t, err)
+			assert.Contains(t, strings.ToLower(string(msg)), strings.ToLower(account.EcdsaValidatorAddress))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package zerodev
+
+import (
+	"context"
+	"fmt"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/friendsofgo/errors"
+)
+
+// UserOperationBatchError is returned by SendUserOperationBatch when the bundler rejected some operations of the batch.
+// Errors holds the error of every operation in the order of the batch, nil for the accepted ones.
+type UserOperationBatchError struct {
+	Errors []error
+}
+
+func (e *UserOperationBatchError) Error() string {
+	rejected := 0
+	var first error
+	for _, err := range e.Errors {
+		if err == nil {
+			continue
+		}
+		if first == nil {
+			first = err
+		}
+		rejected++
+	}
+	return fmt.Sprintf("%d of %d user operations were rejected, first: %v", rejected, len(e.Errors), first)
+}
+
+// Unwrap returns the errors of the rejected operations, so errors.Is and errors.As match any of them
+func (e *UserOperationBatchError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// SendUserOperationBatch sends independent signed operations, e.g. of different senders, in one JSON-RPC batch request.
+// Returns the hash of every operation in the order of ops, nil for the operations the bundler rejected. A rejection doesn't
+// fail the other operations, the rejections are returned as a *UserOperationBatchError along with the hashes of the accepted ones.
+// The calls are made one by one if the bundler client doesn't support batches.
+func (b *BundlerClient) SendUserOperationBatch(ctx context.Context, ops []*UserOperation) ([][]byte, error) {
+	if len(ops) == 0 {
+		return nil, nil
+	}
+
+	hexes := make([]hexutil.Bytes, len(ops))
+	batch := make([]rpc.BatchElem, len(ops))
+	for i, op := range ops {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_sendUserOperation",
+			Args:   []interface{}{toRPCUserOperation(op, b.EntryPoint.GetVersion()), b.EntryPoint.GetAddress()},
+			Result: &hexes[i],
+		}
+	}
+
+	if err := batchCallContext(ctx, b.Client, batch); err != nil {
+		return nil, errors.Wrap(err, "failed to send eth_sendUserOperation batch")
+	}
+
+	hashes := make([][]byte, len(ops))
+	errs := make([]error, len(ops))
+	rejected := false
+	for i, elem := range batch {
+		if elem.Error == nil {
+			hashes[i] = hexes[i]
+			continue
+		}
+
+		err := newBundlerError(elem.Error)
+		if hash := b.findSubmittedUserOperation(ctx, ops[i], err); hash != nil {
+			hashes[i] = hash
+			continue
+		}
+		errs[i] = errors.Wrap(err, "failed to call eth_sendUserOperation")
+		rejected = true
+	}
+
+	if rejected {
+		return hashes, &UserOperationBatchError{Errors: errs}
+	}
+	return hashes, nil
+}
+
+// batchCallContext sends batch in one request if client supports batches, the calls are made one by one otherwise
+func batchCallContext(ctx context.Context, client types.RPCClient, batch []rpc.BatchElem) error {
+	if batchClient, ok := client.(types.BatchRPCClient); ok {
+		return batchClient.BatchCallContext(ctx, batch)
+	}
+	return callSequentially(ctx, client, batch)
+}
+
+// callSequentially makes the calls of batch one by one with client, setting the error of each call on its element
+func callSequentially(ctx context.Context, client types.RPCClient, batch []rpc.BatchElem) error {
+	for i := range batch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch[i].Error = client.CallContext(ctx, batch[i].Result, batch[i].Method, batch[i].Args...)
+	}
+	return nil
+}
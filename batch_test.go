@@ -0,0 +1,96 @@
+package zerodev
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBatch() []*UserOperation {
+	senders := []string{
+		"0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A",
+		"0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		"0x5FbDB2315678afecb367f032d93F642f64180aa3",
+	}
+
+	ops := make([]*UserOperation, len(senders))
+	for i, sender := range senders {
+		ops[i] = newTestUserOperation()
+		ops[i].Sender = common.HexToAddress(sender)
+		ops[i].Signature = common.FromHex("0xdeadbeef")
+	}
+	return ops
+}
+
+func TestBundlerClient_SendUserOperationBatch(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	rejection := errors.New("AA21 didn't pay prefund")
+	mock := zerodevtest.NewMockRPCClient().
+		On("eth_sendUserOperation", "0x01", nil).
+		On("eth_sendUserOperation", nil, rejection).
+		On("eth_sendUserOperation", "0x03", nil)
+
+	bundler, err := NewBundlerClient(NewRetryingRPCClient(mock, nil), entrypoint, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	bundler.EnableCapture(10)
+
+	ops := newTestBatch()
+	hashes, err := bundler.SendUserOperationBatch(context.Background(), ops)
+
+	var batchErr *UserOperationBatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.ErrorIs(t, err, rejection)
+	assert.EqualError(t, err, "1 of 3 user operations were rejected, first: failed to call eth_sendUserOperation: AA21 didn't pay prefund")
+	require.Len(t, batchErr.Errors, 3)
+	assert.NoError(t, batchErr.Errors[0])
+	assert.Error(t, batchErr.Errors[1])
+	assert.NoError(t, batchErr.Errors[2])
+
+	assert.Equal(t, [][]byte{{0x01}, nil, {0x03}}, hashes)
+
+	// the operations are sent in one batch, in order
+	assert.Equal(t, 1, mock.Batches())
+	calls := mock.Calls()
+	require.Len(t, calls, 3)
+	for i, call := range calls {
+		assert.Equal(t, ops[i], call.Args[0])
+	}
+
+	requests := bundler.LastRequests()
+	require.Len(t, requests, 3)
+	assert.Equal(t, "eth_sendUserOperation", requests[1].Method)
+	assert.Error(t, requests[1].Error)
+	assert.JSONEq(t, `"0x03"`, string(requests[2].Result))
+}
+
+func TestBundlerClient_SendUserOperationBatch_Sequential(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	var senders []interface{}
+	bundler := &BundlerClient{
+		Client: &mockRPCClient{
+			callContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				senders = append(senders, args[0].(*UserOperation).Sender)
+				*result.(*hexutil.Bytes) = []byte{byte(len(senders))}
+				return nil
+			},
+		},
+		EntryPoint: entrypoint,
+	}
+
+	ops := newTestBatch()
+	hashes, err := bundler.SendUserOperationBatch(context.Background(), ops)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{{0x01}, {0x02}, {0x03}}, hashes)
+	assert.Equal(t, []interface{}{ops[0].Sender, ops[1].Sender, ops[2].Sender}, senders)
+}
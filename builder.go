@@ -0,0 +1,74 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// UserOperationBuilder composes the UserOperationOptions of a UserOperation step by step, Build performs the RPC calls.
+// The sender defaults to the client's account. A builder is not safe for concurrent use, it can be reused to build similar operations.
+type UserOperationBuilder struct {
+	client   *Client
+	sender   *common.Address
+	callData []byte
+	options  UserOperationOptions
+}
+
+// NewUserOperationBuilder creates a UserOperationBuilder of a UserOperation of the client
+func (c *Client) NewUserOperationBuilder() *UserOperationBuilder {
+	return &UserOperationBuilder{client: c}
+}
+
+// WithSender sets the sender of the UserOperation, only the client's own account is deployed by its first operation
+func (b *UserOperationBuilder) WithSender(sender common.Address) *UserOperationBuilder {
+	b.sender = &sender
+	return b
+}
+
+// WithCallData sets the call data executed by the sender, e.g. from EncodeExecute or EncodeExecuteBatchCall
+func (b *UserOperationBuilder) WithCallData(callData []byte) *UserOperationBuilder {
+	b.callData = callData
+	return b
+}
+
+// WithNonceKey selects the nonce key, see UserOperationOptions.NonceKey
+func (b *UserOperationBuilder) WithNonceKey(nonceKey *big.Int) *UserOperationBuilder {
+	b.options.NonceKey = nonceKey
+	return b
+}
+
+// WithNonce sets the full nonce instead of fetching it, see UserOperationOptions.Nonce
+func (b *UserOperationBuilder) WithNonce(nonce *big.Int) *UserOperationBuilder {
+	b.options.Nonce = nonce
+	return b
+}
+
+// WithGasOverrides customizes the fees, see GasOverrides
+func (b *UserOperationBuilder) WithGasOverrides(gasOverrides *GasOverrides) *UserOperationBuilder {
+	b.options.GasOverrides = gasOverrides
+	return b
+}
+
+// WithPaymaster selects whether the client's paymaster sponsors the UserOperation, the default, or the account pays for its own gas
+func (b *UserOperationBuilder) WithPaymaster(sponsored bool) *UserOperationBuilder {
+	b.options.SelfFunded = !sponsored
+	return b
+}
+
+// Build builds the UserOperation with GetUserOperationAndHashToSignWithOptions, returning it ready to sign with its hash
+func (b *UserOperationBuilder) Build(ctx context.Context) (*UserOperation, *common.Hash, error) {
+	if b.callData == nil {
+		return nil, nil, errors.New("callData is required")
+	}
+
+	sender := b.client.Signer.GetAddress()
+	if b.sender != nil {
+		sender = *b.sender
+	}
+
+	callData := b.callData
+	options := b.options
+	return b.client.GetUserOperationAndHashToSignWithOptions(ctx, sender, &callData, &options)
+}
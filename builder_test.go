@@ -0,0 +1,51 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserOperationBuilder(t *testing.T) {
+	client, network, paymaster := newTestClient(t, 0)
+	sender := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+
+	// the builder matches GetUserOperationAndHashToSign
+	op, hash, err := client.NewUserOperationBuilder().
+		WithSender(sender).
+		WithCallData(common.FromHex("0xdeadbeef")).
+		Build(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, sender, op.Sender)
+	assert.Equal(t, big.NewInt(5), op.Nonce)
+	assert.Equal(t, "0xe361e4b3ddb22445e07c6d63862332f3313663b87dec5297c0a0ee33eac68876", hash.Hex())
+
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x186a0"}`), nil)
+	sponsored := paymaster.CallCount("zd_sponsorUserOperation")
+	nonceCalls := network.CallCount("eth_call")
+
+	op, _, err = client.NewUserOperationBuilder().
+		WithCallData(common.FromHex("0xdeadbeef")).
+		WithNonce(big.NewInt(9)).
+		WithGasOverrides(&GasOverrides{MaxFeePerGas: big.NewInt(40_000_000_000), MaxPriorityFeePerGas: big.NewInt(1_000_000_000)}).
+		WithPaymaster(false).
+		Build(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, client.Signer.GetAddress(), op.Sender)
+	assert.Equal(t, big.NewInt(9), op.Nonce)
+	assert.Equal(t, big.NewInt(40_000_000_000), op.MaxFeePerGas)
+	assert.Empty(t, op.Paymaster)
+	assert.Equal(t, sponsored, paymaster.CallCount("zd_sponsorUserOperation"))
+	assert.Equal(t, 1, bundler.CallCount("eth_estimateUserOperationGas"))
+	assert.Equal(t, nonceCalls, network.CallCount("eth_call"))
+
+	_, _, err = client.NewUserOperationBuilder().WithSender(sender).Build(context.Background())
+	assert.EqualError(t, err, "callData is required")
+}
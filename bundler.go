@@ -8,7 +8,10 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/friendsofgo/errors"
+	"math"
 	"math/big"
+	"math/rand"
+	"sync/atomic"
 	"time"
 )
 
@@ -43,6 +46,76 @@ type GetUserOperationGasPriceResponse struct {
 	Fast     *GasPriceSpecification `json:"fast"`
 }
 
+// GasEstimate holds gas limits estimated by the bundler for a UserOperation
+type GasEstimate struct {
+	PreVerificationGas            *big.Int `json:"preVerificationGas"`
+	VerificationGasLimit          *big.Int `json:"verificationGasLimit"`
+	CallGasLimit                  *big.Int `json:"callGasLimit"`
+	PaymasterVerificationGasLimit *big.Int `json:"paymasterVerificationGasLimit"`
+	PaymasterPostOpGasLimit       *big.Int `json:"paymasterPostOpGasLimit"`
+}
+
+type GasEstimateHex struct {
+	PreVerificationGas            string `json:"preVerificationGas"`
+	VerificationGasLimit          string `json:"verificationGasLimit"`
+	CallGasLimit                  string `json:"callGasLimit"`
+	PaymasterVerificationGasLimit string `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       string `json:"paymasterPostOpGasLimit,omitempty"`
+}
+
+func (g *GasEstimate) UnmarshalJSON(b []byte) error {
+	var unmarshal GasEstimateHex
+	err := json.Unmarshal(b, &unmarshal)
+	if err != nil {
+		return err
+	}
+
+	*g = GasEstimate{
+		PreVerificationGas:            big.NewInt(0).SetBytes(common.FromHex(unmarshal.PreVerificationGas)),
+		VerificationGasLimit:          big.NewInt(0).SetBytes(common.FromHex(unmarshal.VerificationGasLimit)),
+		CallGasLimit:                  big.NewInt(0).SetBytes(common.FromHex(unmarshal.CallGasLimit)),
+		PaymasterVerificationGasLimit: big.NewInt(0).SetBytes(common.FromHex(unmarshal.PaymasterVerificationGasLimit)),
+		PaymasterPostOpGasLimit:       big.NewInt(0).SetBytes(common.FromHex(unmarshal.PaymasterPostOpGasLimit)),
+	}
+
+	return nil
+}
+
+// defaultReceiptPollingMaxDelay bounds the receipt polling backoff when ReceiptPollingBackoff.MaxDelay is not set
+const defaultReceiptPollingMaxDelay = 10 * time.Minute
+
+// ReceiptPollingBackoff configures exponential backoff between receipt polling retries.
+// The delay starts at BaseDelay and is multiplied by Multiplier (2 if unset) after every retry, up to MaxDelay (10 minutes if unset).
+// NewClient requires a positive BaseDelay and a Multiplier of at least 1.
+type ReceiptPollingBackoff struct {
+	BaseDelay  time.Duration
+	Multiplier float64
+	MaxDelay   time.Duration
+}
+
+// Delay returns the delay after the given zero-based attempt
+func (r *ReceiptPollingBackoff) Delay(attempt int) time.Duration {
+	multiplier := r.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	maxDelay := r.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultReceiptPollingMaxDelay
+	}
+	if r.BaseDelay <= 0 {
+		return 0
+	}
+
+	// compared as floats, large attempts overflow time.Duration
+	delay := float64(r.BaseDelay) * math.Pow(multiplier, float64(attempt))
+	if delay > float64(maxDelay) {
+		return maxDelay
+	}
+	return time.Duration(delay)
+}
+
 type SendUserOperationRequest struct {
 	ChainID           *uint64         `json:"chainId"`
 	Operation         *UserOperation  `json:"userOp"`
@@ -57,7 +130,8 @@ type SendUserOperationResponse struct {
 }
 
 type UserOperationReceipt struct {
-	TransactionHash   *hexutil.Bytes  `json:"transactionHash"`
+	// TransactionHash is the hash of the bundle transaction that included the UserOperation, e.g. for block explorer links
+	TransactionHash   common.Hash     `json:"transactionHash"`
 	TransactionIndex  *hexutil.Big    `json:"transactionIndex"`
 	BlockHash         *hexutil.Bytes  `json:"blockHash"`
 	BlockNumber       *hexutil.Big    `json:"blockNumber"`
@@ -70,7 +144,44 @@ type UserOperationReceipt struct {
 	LogsBloom         *hexutil.Bytes  `json:"logsBloom"`
 	Status            *hexutil.Uint   `json:"status"`
 	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice"`
+	// UserOperationHash, Success and the revert fields describe the UserOperation within the transaction
+	UserOperationHash *hexutil.Bytes `json:"userOpHash,omitempty"`
+	Success           bool           `json:"success"`
+	RevertData        hexutil.Bytes  `json:"revertData,omitempty"`
+	RevertReason      string         `json:"revertReason,omitempty"`
+	// ActualGasUsed and ActualGasCost are the gas used and paid in wei by the UserOperation, not the whole transaction
+	ActualGasUsed *big.Int `json:"actualGasUsed,omitempty"`
+	ActualGasCost *big.Int `json:"actualGasCost,omitempty"`
+	// Paymaster, GasToken and TokenCharged are set if the UserOperation was sponsored, the latter two if the paymaster charged an ERC-20 token
+	Paymaster    *common.Address `json:"paymaster,omitempty"`
+	GasToken     *common.Address `json:"gasToken,omitempty"`
+	TokenCharged *big.Int        `json:"tokenCharged,omitempty"`
+	// Event is the UserOperationEvent the entrypoint emitted for the UserOperation, nil if it's missing from the logs
+	Event *UserOperationEvent `json:"userOperationEvent,omitempty"`
+}
+
+// UserOperationStatus is a UserOperation known by the bundler, BlockNumber, BlockHash and TransactionHash are nil while it's pending in the mempool
+type UserOperationStatus struct {
+	UserOperation   *UserOperation
+	EntryPoint      common.Address
+	BlockNumber     *big.Int
+	BlockHash       *common.Hash
+	TransactionHash *common.Hash
+}
+
+// IsPending reports whether the UserOperation is in the bundler mempool and not yet included in a block
+func (s *UserOperationStatus) IsPending() bool {
+	return s.BlockNumber == nil
+}
+
+type GetUserOperationByHashResponse struct {
+	UserOperation   json.RawMessage `json:"userOperation"`
+	EntryPoint      common.Address  `json:"entryPoint"`
+	BlockNumber     *hexutil.Big    `json:"blockNumber"`
+	BlockHash       *common.Hash    `json:"blockHash"`
+	TransactionHash *common.Hash    `json:"transactionHash"`
 }
+
 type GetUserOperationReceiptResponse struct {
 	UserOpHash    *hexutil.Bytes       `json:"userOpHash"`
 	Entrypoint    common.Address       `json:"entrypoint"`
@@ -88,6 +199,19 @@ type BundlerClient struct {
 	Client     types.RPCClient
 	EntryPoint Entrypoint
 	ChainID    *big.Int
+	Capture    *RPCCapture
+	// NetworkClient is the network RPC, optional. Gas prices are derived from it if the bundler doesn't implement zd_getUserOperationGasPrice
+	NetworkClient types.RPCClient
+	// Subscriber is a WebSocket client, optional. Receipts are awaited with a subscription to the UserOperationEvent of the entrypoint
+	// instead of polling, polling is used if the subscription fails
+	Subscriber types.SubscriptionRPCClient
+	// Clock times receipt polling, SystemClock if not set
+	Clock Clock
+	// Backups are bundlers asked for receipts and operations the bundler doesn't know, e.g. after it restarted or a failover,
+	// by GetUserOperationByHash and the receipt getters. Optional
+	Backups []types.RPCClient
+
+	gasPriceUnsupported atomic.Bool
 }
 
 func NewBundlerClient(rpcClient types.RPCClient, entrypoint Entrypoint, chainID *big.Int) (*BundlerClient, error) {
@@ -110,23 +234,52 @@ func (b *BundlerClient) GetChainID() *big.Int {
 	return b.ChainID
 }
 
-func (b *BundlerClient) GetUserOperationGasPrice() (*GetUserOperationGasPriceResponse, error) {
+// GetUserOperationGasPrice returns the gas prices suggested by the bundler. If the bundler doesn't implement zd_getUserOperationGasPrice,
+// e.g. on some testnets, they're derived from the base fee and priority fee of NetworkClient, the same for all speeds.
+func (b *BundlerClient) GetUserOperationGasPrice(ctx context.Context) (*GetUserOperationGasPriceResponse, error) {
 	var err error
 	var response GetUserOperationGasPriceResponse
 
-	err = b.Client.CallContext(context.Background(), &response, "zd_getUserOperationGasPrice")
+	if b.NetworkClient != nil && b.gasPriceUnsupported.Load() {
+		return getNetworkGasPrice(ctx, b.NetworkClient)
+	}
+
+	err = b.Client.CallContext(ctx, &response, "zd_getUserOperationGasPrice")
 	if err != nil {
+		if b.NetworkClient != nil && isMethodNotFound(err) {
+			b.gasPriceUnsupported.Store(true)
+			return getNetworkGasPrice(ctx, b.NetworkClient)
+		}
 		return nil, errors.Wrap(err, "failed to call zd_getUserOperationGasPrice")
 	}
 
 	return &response, nil
 }
 
-func (b *BundlerClient) SendUserOperation(op *UserOperation) ([]byte, error) {
+// EstimateUserOperationGas estimates gas limits of the UserOperation with the bundler, without involving a paymaster.
+// The op's signature is replaced with a dummy one, as it is not known at this point.
+func (b *BundlerClient) EstimateUserOperationGas(ctx context.Context, op *UserOperation) (*GasEstimate, error) {
+	op.Signature = common.FromHex(SignatureDummy)
+
+	var response GasEstimate
+
+	err := b.Client.CallContext(ctx, &response, "eth_estimateUserOperationGas", toRPCUserOperation(op, b.EntryPoint.GetVersion()), b.EntryPoint.GetAddress())
+	if err != nil {
+		return nil, errors.Wrap(newBundlerError(err), "failed to call eth_estimateUserOperationGas")
+	}
+
+	return &response, nil
+}
+
+func (b *BundlerClient) SendUserOperation(ctx context.Context, op *UserOperation) ([]byte, error) {
 	var hex hexutil.Bytes
 
-	err := b.Client.CallContext(context.Background(), &hex, "eth_sendUserOperation", op, b.EntryPoint.GetAddress())
+	err := b.Client.CallContext(ctx, &hex, "eth_sendUserOperation", toRPCUserOperation(op, b.EntryPoint.GetVersion()), b.EntryPoint.GetAddress())
 	if err != nil {
+		err = newBundlerError(err)
+		if hash := b.findSubmittedUserOperation(ctx, op, err); hash != nil {
+			return hash, nil
+		}
 		return nil, errors.Wrap(err, "failed to call eth_sendUserOperation")
 	}
 
@@ -134,25 +287,220 @@ func (b *BundlerClient) SendUserOperation(op *UserOperation) ([]byte, error) {
 	return response, nil
 }
 
-func (b *BundlerClient) GetUserOperationReceipt(hash []byte, pollingDelaySeconds int, pollingRetries int) (*UserOperationReceipt, error) {
+// findSubmittedUserOperation returns the hash of op if the bundler rejected it because it was already submitted,
+// e.g. by a retried eth_sendUserOperation whose first response was lost. Returns nil otherwise.
+// An "already known" operation is in the mempool, an invalid nonce is only accepted if the bundler knows the operation.
+func (b *BundlerClient) findSubmittedUserOperation(ctx context.Context, op *UserOperation, sendErr error) []byte {
+	alreadyKnown := errors.Is(sendErr, ErrAlreadyKnown)
+	if !alreadyKnown && !errors.Is(sendErr, ErrInvalidNonce) {
+		return nil
+	}
+
+	hash, err := b.EntryPoint.GetUserOperationHash(op)
+	if err != nil {
+		return nil
+	}
+	if alreadyKnown {
+		return hash.Bytes()
+	}
+
+	status, err := b.GetUserOperationByHash(ctx, hash.Bytes())
+	if err != nil || status == nil {
+		return nil
+	}
+
+	return hash.Bytes()
+}
+
+// SupportedEntryPoints returns the addresses of the entrypoints supported by the bundler
+func (b *BundlerClient) SupportedEntryPoints(ctx context.Context) ([]common.Address, error) {
+	var entrypoints []common.Address
+
+	err := b.Client.CallContext(ctx, &entrypoints, "eth_supportedEntryPoints")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call eth_supportedEntryPoints")
+	}
+
+	return entrypoints, nil
+}
+
+// GetUserOperationByHash returns the UserOperation with its inclusion status, pending or included in a block.
+// Returns nil without error if the bundler doesn't know the hash, e.g. it never accepted the operation or dropped it.
+func (b *BundlerClient) GetUserOperationByHash(ctx context.Context, hash []byte) (*UserOperationStatus, error) {
+	var response *GetUserOperationByHashResponse
+
+	err := b.callBundlers(ctx, &response, func() bool { return response != nil }, "eth_getUserOperationByHash", hexutil.Encode(hash))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call eth_getUserOperationByHash")
+	}
+	if response == nil {
+		return nil, nil
+	}
+
+	op, err := fromRPCUserOperation(response.UserOperation, b.EntryPoint.GetVersion())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode user operation")
+	}
+
+	status := &UserOperationStatus{
+		UserOperation:   op,
+		EntryPoint:      response.EntryPoint,
+		BlockHash:       response.BlockHash,
+		TransactionHash: response.TransactionHash,
+	}
+	if response.BlockNumber != nil {
+		status.BlockNumber = response.BlockNumber.ToInt()
+	}
+
+	return status, nil
+}
+
+func (b *BundlerClient) GetUserOperationReceipt(ctx context.Context, hash []byte, pollingDelaySeconds int, pollingRetries int) (*UserOperationReceipt, error) {
+	return b.waitForUserOperationReceipt(ctx, hash, pollingRetries, fixedPollingDelay(time.Duration(pollingDelaySeconds)*time.Second), b.Clock)
+}
+
+// GetUserOperationReceiptWithBackoff polls for the receipt like GetUserOperationReceipt, increasing the delay between retries exponentially.
+func (b *BundlerClient) GetUserOperationReceiptWithBackoff(ctx context.Context, hash []byte, backoff *ReceiptPollingBackoff, pollingRetries int) (*UserOperationReceipt, error) {
+	return b.waitForUserOperationReceipt(ctx, hash, pollingRetries, backoff.Delay, b.Clock)
+}
+
+// WaitForUserOperationReceipt polls for the receipt every pollingDelay until it's available or ctx is done.
+// Use a context with deadline to limit the wait, the returned error matches context.DeadlineExceeded when it expires.
+func (b *BundlerClient) WaitForUserOperationReceipt(ctx context.Context, hash []byte, pollingDelay time.Duration) (*UserOperationReceipt, error) {
+	return b.waitForUserOperationReceipt(ctx, hash, 0, fixedPollingDelay(pollingDelay), b.Clock)
+}
+
+// waitForUserOperationReceipt waits for the receipt until it's available, ctx is done or the wait of maxAttempts polls passed, 0 means no limit.
+// It waits on a subscription if Subscriber is set, polling otherwise.
+func (b *BundlerClient) waitForUserOperationReceipt(ctx context.Context, hash []byte, maxAttempts int, delay func(attempt int) time.Duration, clock Clock) (*UserOperationReceipt, error) {
+	clock = clockOrSystem(clock)
+	if b.Subscriber != nil {
+		receipt, subscribed, err := b.subscribeUserOperationReceipt(ctx, hash, maxAttempts, delay, clock)
+		if subscribed {
+			return receipt, err
+		}
+	}
+
+	return b.pollUserOperationReceipt(ctx, hash, maxAttempts, delay, clock)
+}
+
+// pollUserOperationReceipt polls for the receipt until it's available, ctx is done or maxAttempts is reached, 0 means no limit.
+func (b *BundlerClient) pollUserOperationReceipt(ctx context.Context, hash []byte, maxAttempts int, delay func(attempt int) time.Duration, clock Clock) (*UserOperationReceipt, error) {
 	var response GetUserOperationReceiptResponse
-	ctx := context.Background()
 
-	for i := 0; i < pollingRetries; i++ {
-		err := b.Client.CallContext(ctx, &response, "eth_getUserOperationReceipt", hexutil.Encode(hash))
+	for attempt := 0; maxAttempts <= 0 || attempt < maxAttempts; attempt++ {
+		err := b.callBundlers(ctx, &response, func() bool { return response.UserOpHash != nil }, "eth_getUserOperationReceipt", hexutil.Encode(hash))
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to call eth_getUserOperationReceipt")
 		}
-		if response.UserOpHash == nil {
-			time.Sleep(time.Duration(pollingDelaySeconds) * time.Second)
-			continue
+		if response.UserOpHash != nil || attempt == maxAttempts-1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "timed out waiting for receipt of user operation "+hexutil.Encode(hash))
+		case <-clock.After(delay(attempt)):
 		}
-		break
 	}
 
 	if response.UserOpHash == nil {
 		return nil, errors.New("failed to get receipt for user operation: " + hexutil.Encode(hash))
 	}
 
-	return &response.Receipt, nil
+	return newUserOperationReceipt(b.EntryPoint.GetAddress(), &response)
+}
+
+// callBundlers calls method on the bundler, then on each of Backups until found reports a result.
+// A failing bundler doesn't stop the others from being asked, the first error is returned only if all of them failed.
+func (b *BundlerClient) callBundlers(ctx context.Context, result interface{}, found func() bool, method string, args ...interface{}) error {
+	clients := append([]types.RPCClient{b.Client}, b.Backups...)
+
+	var firstErr error
+	failed := 0
+	for _, client := range clients {
+		if err := client.CallContext(ctx, result, method, args...); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+			continue
+		}
+		if found() {
+			return nil
+		}
+	}
+
+	if failed == len(clients) {
+		return firstErr
+	}
+	return nil
+}
+
+// newUserOperationReceipt combines the transaction receipt with the result of the UserOperation, decoding its revert reason if it failed
+func newUserOperationReceipt(entrypoint common.Address, response *GetUserOperationReceiptResponse) (*UserOperationReceipt, error) {
+	receipt := response.Receipt
+	receipt.UserOperationHash = response.UserOpHash
+	receipt.Success = response.Success
+	setUserOperationCost(&receipt, response)
+
+	event, err := FindUserOperationEvent(entrypoint, *response.UserOpHash, response.Logs, response.Receipt.Logs)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Event = event
+
+	if response.Success {
+		return &receipt, nil
+	}
+
+	revertData, err := findRevertData(entrypoint, *response.UserOpHash, response.Logs, response.Receipt.Logs)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt.RevertData = revertData
+	if len(revertData) > 0 {
+		reason, err := DecodeRevertReason(revertData)
+		if err == nil {
+			receipt.RevertReason = reason.Message
+		}
+	}
+
+	return &receipt, nil
+}
+
+func fixedPollingDelay(delay time.Duration) func(int) time.Duration {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// jitteredPollingDelay randomizes each delay of delay by up to ± percent, so clients started together don't poll in sync
+func jitteredPollingDelay(delay func(int) time.Duration, percent int) func(int) time.Duration {
+	return func(attempt int) time.Duration {
+		base := delay(attempt)
+		spread := float64(base) * float64(percent) / 100
+		return base + time.Duration(spread*(2*rand.Float64()-1))
+	}
+}
+
+// EnableCapture records the params and raw results of the last limit JSON-RPC calls, see LastRequests.
+// Enabling it again only changes the limit
+func (b *BundlerClient) EnableCapture(limit int) {
+	if b.Capture != nil {
+		b.Capture.setLimit(limit)
+		return
+	}
+
+	b.Capture = NewRPCCapture(limit)
+	b.Client = &capturingRPCClient{Client: b.Client, Capture: b.Capture}
+}
+
+// LastRequests returns the captured JSON-RPC calls, oldest first, or nil if capturing isn't enabled
+func (b *BundlerClient) LastRequests() []CapturedCall {
+	if b.Capture == nil {
+		return nil
+	}
+	return b.Capture.LastRequests()
 }
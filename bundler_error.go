@@ -0,0 +1,124 @@
+package zerodev
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/friendsofgo/errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrSenderAlreadyConstructed     = errors.New("AA10 sender already constructed")
+	ErrInitCodeFailed               = errors.New("AA13 initCode failed or OOG")
+	ErrInitCodeWrongSender          = errors.New("AA14 initCode must return sender")
+	ErrInitCodeNotDeployed          = errors.New("AA15 initCode must create sender")
+	ErrAccountNotDeployed           = errors.New("AA20 account not deployed")
+	ErrPrefundNotPaid               = errors.New("AA21 didn't pay prefund")
+	ErrExpiredOrNotDue              = errors.New("AA22 expired or not due")
+	ErrAccountValidationReverted    = errors.New("AA23 reverted (or OOG)")
+	ErrInvalidSignature             = errors.New("AA24 signature error")
+	ErrInvalidNonce                 = errors.New("AA25 invalid account nonce")
+	ErrVerificationGasLimitExceeded = errors.New("AA26 over verificationGasLimit")
+	ErrPaymasterNotDeployed         = errors.New("AA30 paymaster not deployed")
+	ErrPaymasterDepositTooLow       = errors.New("AA31 paymaster deposit too low")
+	ErrPaymasterExpiredOrNotDue     = errors.New("AA32 paymaster expired or not due")
+	ErrPaymasterValidationReverted  = errors.New("AA33 paymaster reverted (or OOG)")
+	ErrPaymasterInvalidSignature    = errors.New("AA34 paymaster signature error")
+	ErrPaymasterVerificationGas     = errors.New("AA40 over paymaster verificationGasLimit")
+	ErrVerificationGasTooLow        = errors.New("AA41 too little verificationGas")
+	ErrPostOpReverted               = errors.New("AA50 postOp reverted")
+	ErrPrefundBelowActualGasCost    = errors.New("AA51 prefund below actualGasCost")
+)
+
+// bundlerErrorReasons maps AAxx reason codes returned by the bundler to sentinel errors.
+var bundlerErrorReasons = map[string]error{
+	"AA10": ErrSenderAlreadyConstructed,
+	"AA13": ErrInitCodeFailed,
+	"AA14": ErrInitCodeWrongSender,
+	"AA15": ErrInitCodeNotDeployed,
+	"AA20": ErrAccountNotDeployed,
+	"AA21": ErrPrefundNotPaid,
+	"AA22": ErrExpiredOrNotDue,
+	"AA23": ErrAccountValidationReverted,
+	"AA24": ErrInvalidSignature,
+	"AA25": ErrInvalidNonce,
+	"AA26": ErrVerificationGasLimitExceeded,
+	"AA30": ErrPaymasterNotDeployed,
+	"AA31": ErrPaymasterDepositTooLow,
+	"AA32": ErrPaymasterExpiredOrNotDue,
+	"AA33": ErrPaymasterValidationReverted,
+	"AA34": ErrPaymasterInvalidSignature,
+	"AA40": ErrPaymasterVerificationGas,
+	"AA41": ErrVerificationGasTooLow,
+	"AA50": ErrPostOpReverted,
+	"AA51": ErrPrefundBelowActualGasCost,
+}
+
+var (
+	// ErrAlreadyKnown is returned for an operation already in the bundler mempool, SendUserOperation returns its hash instead
+	ErrAlreadyKnown = errors.New("user operation already known")
+	// ErrReplacementUnderpriced is returned for an operation replacing a pending one of the same nonce without higher fees
+	ErrReplacementUnderpriced = errors.New("replacement underpriced, bump maxFeePerGas and maxPriorityFeePerGas by at least 10%, e.g. with Client.ReplaceUserOperation")
+)
+
+// bundlerErrorMessages maps messages of bundler errors without an AAxx reason code to sentinel errors, matched case-insensitively
+var bundlerErrorMessages = []struct {
+	message string
+	err     error
+}{
+	{message: "already known", err: ErrAlreadyKnown},
+	{message: "replacement underpriced", err: ErrReplacementUnderpriced},
+}
+
+var bundlerErrorReasonRegexp = regexp.MustCompile(`AA\d\d`)
+
+// BundlerError is a JSON-RPC error returned by the bundler.
+// Reason holds the AAxx code found in the message, if any.
+type BundlerError struct {
+	Code    int
+	Message string
+	Reason  string
+	Data    interface{}
+}
+
+func (e *BundlerError) Error() string {
+	return fmt.Sprintf("bundler error %d: %s", e.Code, e.Message)
+}
+
+// Unwrap returns the sentinel error matching Reason or the message, so errors.Is can be used against the ErrXxx values.
+func (e *BundlerError) Unwrap() error {
+	if err, ok := bundlerErrorReasons[e.Reason]; ok {
+		return err
+	}
+
+	message := strings.ToLower(e.Message)
+	for _, known := range bundlerErrorMessages {
+		if strings.Contains(message, known.message) {
+			return known.err
+		}
+	}
+
+	return nil
+}
+
+// newBundlerError converts a JSON-RPC error into a BundlerError, other errors are returned as is.
+func newBundlerError(err error) error {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return err
+	}
+
+	bundlerErr := &BundlerError{
+		Code:    rpcErr.ErrorCode(),
+		Message: rpcErr.Error(),
+		Reason:  bundlerErrorReasonRegexp.FindString(rpcErr.Error()),
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		bundlerErr.Data = dataErr.ErrorData()
+	}
+
+	return bundlerErr
+}
@@ -0,0 +1,595 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRPCClient struct {
+	callContextFunc func(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+func (m *mockRPCClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if m.callContextFunc != nil {
+		return m.callContextFunc(ctx, result, method, args...)
+	}
+	return nil
+}
+
+func (m *mockRPCClient) Close() {}
+
+type mockJSONRPCError struct {
+	code    int
+	message string
+}
+
+func (e *mockJSONRPCError) Error() string {
+	return e.message
+}
+
+func (e *mockJSONRPCError) ErrorCode() int {
+	return e.code
+}
+
+func TestBundlerClient_GetUserOperationReceipt_ContextCanceled(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	calls := 0
+	bundler := &BundlerClient{
+		Client: &mockRPCClient{
+			callContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				calls++
+				return nil
+			},
+		},
+		EntryPoint: entrypoint,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	receipt, err := bundler.GetUserOperationReceipt(ctx, []byte{0x01}, 10, 5)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, receipt)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestBundlerClient_SendUserOperation_BundlerError(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		rpcError       error
+		expectedCode   int
+		expectedReason string
+		expectedError  error
+	}{
+		{
+			name:           "invalid_signature",
+			rpcError:       &mockJSONRPCError{code: -32507, message: "UserOperation reverted during simulation with reason: AA24 signature error"},
+			expectedCode:   -32507,
+			expectedReason: "AA24",
+			expectedError:  ErrInvalidSignature,
+		},
+		{
+			name:           "prefund_not_paid",
+			rpcError:       &mockJSONRPCError{code: -32500, message: "AA21 didn't pay prefund"},
+			expectedCode:   -32500,
+			expectedReason: "AA21",
+			expectedError:  ErrPrefundNotPaid,
+		},
+		{
+			name:          "replacement_underpriced",
+			rpcError:      &mockJSONRPCError{code: -32602, message: "Replacement underpriced"},
+			expectedCode:  -32602,
+			expectedError: ErrReplacementUnderpriced,
+		},
+		{
+			name:         "no_reason",
+			rpcError:     &mockJSONRPCError{code: -32602, message: "invalid params"},
+			expectedCode: -32602,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundler := &BundlerClient{
+				Client: &mockRPCClient{
+					callContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+						return tt.rpcError
+					},
+				},
+				EntryPoint: entrypoint,
+			}
+
+			_, err := bundler.SendUserOperation(context.Background(), newTestUserOperation())
+			require.Error(t, err)
+
+			var bundlerErr *BundlerError
+			require.True(t, errors.As(err, &bundlerErr))
+			assert.Equal(t, tt.expectedCode, bundlerErr.Code)
+			assert.Equal(t, tt.expectedReason, bundlerErr.Reason)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestBundlerClient_SendUserOperation_AlreadySubmitted(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	op := newTestUserOperation()
+	expectedHash, err := entrypoint.GetUserOperationHash(op)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		submitted     json.RawMessage
+		expectedError bool
+	}{
+		{
+			name:      "known_to_bundler",
+			submitted: json.RawMessage(`{"userOperation":{},"entryPoint":"0x0000000071727De22E5E9d8BAf0edAc6f37da032"}`),
+		},
+		{
+			name:          "unknown_to_bundler",
+			submitted:     json.RawMessage(`null`),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient().
+				On("eth_sendUserOperation", nil, &mockJSONRPCError{code: -32507, message: "AA25 invalid account nonce"}).
+				On("eth_getUserOperationByHash", tt.submitted, nil)
+
+			bundler := &BundlerClient{
+				Client:     mock,
+				EntryPoint: entrypoint,
+			}
+
+			hash, err := bundler.SendUserOperation(context.Background(), op)
+			if tt.expectedError {
+				assert.ErrorIs(t, err, ErrInvalidNonce)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, expectedHash.Bytes(), hash)
+		})
+	}
+}
+
+func TestBundlerClient_SendUserOperation_AlreadyKnown(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	op := newTestUserOperation()
+	expectedHash, err := entrypoint.GetUserOperationHash(op)
+	require.NoError(t, err)
+
+	mock := zerodevtest.NewMockRPCClient().
+		On("eth_sendUserOperation", nil, &mockJSONRPCError{code: -32602, message: "Already known"})
+	bundler := &BundlerClient{Client: mock, EntryPoint: entrypoint}
+
+	// the operation is in the mempool, its hash is returned without asking the bundler
+	hash, err := bundler.SendUserOperation(context.Background(), op)
+	require.NoError(t, err)
+	assert.Equal(t, expectedHash.Bytes(), hash)
+	assert.Equal(t, 0, mock.CallCount("eth_getUserOperationByHash"))
+}
+
+func TestBundlerClient_GetUserOperationByHash(t *testing.T) {
+	entrypoint07, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	entrypoint06, err := NewEntrypoint06(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		entrypoint        Entrypoint
+		response          json.RawMessage
+		expectedUnknown   bool
+		expectedPending   bool
+		expectedFactory   common.Address
+		expectedPaymaster []byte
+	}{
+		{
+			name:            "unknown",
+			entrypoint:      entrypoint07,
+			response:        json.RawMessage(`null`),
+			expectedUnknown: true,
+		},
+		{
+			name:       "pending",
+			entrypoint: entrypoint07,
+			response: json.RawMessage(`{
+				"userOperation": {"sender": "0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A", "nonce": "0x5", "callData": "0xdeadbeef", "maxFeePerGas": "0x6fc23ac00", "maxPriorityFeePerGas": "0x59682f00"},
+				"entryPoint": "0x0000000071727De22E5E9d8BAf0edAc6f37da032",
+				"blockNumber": null,
+				"blockHash": null,
+				"transactionHash": null
+			}`),
+			expectedPending: true,
+		},
+		{
+			name:       "included",
+			entrypoint: entrypoint07,
+			response: json.RawMessage(`{
+				"userOperation": {"sender": "0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A", "nonce": "0x5", "callData": "0xdeadbeef", "maxFeePerGas": "0x6fc23ac00", "maxPriorityFeePerGas": "0x59682f00", "paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633"},
+				"entryPoint": "0x0000000071727De22E5E9d8BAf0edAc6f37da032",
+				"blockNumber": "0x3d0900",
+				"blockHash": "0x2d4c1f6d5ed4ad3d4d1ea0ee3f2b5a1fa86a6e3a7b5f5c4c2e4a52a1d6c1e2f3",
+				"transactionHash": "0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"
+			}`),
+			expectedPaymaster: common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633"),
+		},
+		{
+			name:       "entrypoint_06",
+			entrypoint: entrypoint06,
+			response: json.RawMessage(`{
+				"userOperation": {"sender": "0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A", "nonce": "0x5", "initCode": "0x5de4839a76cf55d0c90e2061ef4386d962e15ae3abcd", "callData": "0xdeadbeef", "maxFeePerGas": "0x6fc23ac00", "maxPriorityFeePerGas": "0x59682f00", "paymasterAndData": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633ef", "signature": "0x"},
+				"entryPoint": "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789",
+				"blockNumber": null
+			}`),
+			expectedPending:   true,
+			expectedFactory:   common.HexToAddress("0x5de4839a76cf55d0c90e2061ef4386d962e15ae3"),
+			expectedPaymaster: common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient().On("eth_getUserOperationByHash", tt.response, nil)
+			bundler := &BundlerClient{
+				Client:     mock,
+				EntryPoint: tt.entrypoint,
+			}
+
+			status, err := bundler.GetUserOperationByHash(context.Background(), common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"))
+			require.NoError(t, err)
+			assert.Equal(t, []interface{}{"0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"}, mock.Calls()[0].Args)
+
+			if tt.expectedUnknown {
+				assert.Nil(t, status)
+				return
+			}
+			require.NotNil(t, status)
+
+			assert.Equal(t, tt.expectedPending, status.IsPending())
+			assert.Equal(t, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), status.UserOperation.Sender)
+			assert.Equal(t, big.NewInt(5), status.UserOperation.Nonce)
+			assert.Equal(t, common.FromHex("0xdeadbeef"), status.UserOperation.CallData)
+			assert.Equal(t, tt.expectedFactory, status.UserOperation.Factory)
+			assert.Equal(t, tt.expectedPaymaster, status.UserOperation.Paymaster)
+
+			if tt.expectedPending {
+				assert.Nil(t, status.TransactionHash)
+				return
+			}
+			assert.Equal(t, big.NewInt(4_000_000), status.BlockNumber)
+			assert.Equal(t, common.HexToHash("0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"), *status.TransactionHash)
+		})
+	}
+}
+
+func TestBundlerClient_EstimateUserOperationGas(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	bundler := &BundlerClient{
+		Client: &mockRPCClient{
+			callContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				assert.Equal(t, "eth_estimateUserOperationGas", method)
+				assert.Equal(t, entrypoint.GetAddress(), args[1])
+				return json.Unmarshal([]byte(`{"preVerificationGas":"0xc350","verificationGasLimit":"0x30d40","callGasLimit":"0x186a0"}`), result)
+			},
+		},
+		EntryPoint: entrypoint,
+	}
+
+	estimate, err := bundler.EstimateUserOperationGas(context.Background(), newTestUserOperation())
+	require.NoError(t, err)
+
+	assert.Equal(t, big.NewInt(50_000), estimate.PreVerificationGas)
+	assert.Equal(t, big.NewInt(200_000), estimate.VerificationGasLimit)
+	assert.Equal(t, big.NewInt(100_000), estimate.CallGasLimit)
+	assert.Equal(t, 0, estimate.PaymasterVerificationGasLimit.Sign())
+	assert.Equal(t, 0, estimate.PaymasterPostOpGasLimit.Sign())
+}
+
+func TestBundlerClient_GetUserOperationReceipt(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	receipt := json.RawMessage(`{"userOpHash":"0x01","success":true,"receipt":{"transactionHash":"0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"}}`)
+
+	tests := []struct {
+		name          string
+		responses     []interface{}
+		retries       int
+		expectedCalls int
+		expectedError bool
+	}{
+		{
+			name:          "immediate",
+			responses:     []interface{}{receipt},
+			retries:       3,
+			expectedCalls: 1,
+		},
+		{
+			name:          "after_polling",
+			responses:     []interface{}{nil, nil, receipt},
+			retries:       3,
+			expectedCalls: 3,
+		},
+		{
+			name:          "retries_exhausted",
+			responses:     []interface{}{nil},
+			retries:       3,
+			expectedCalls: 3,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient()
+			for _, response := range tt.responses {
+				mock.On("eth_getUserOperationReceipt", response, nil)
+			}
+
+			bundler := &BundlerClient{Client: mock, EntryPoint: entrypoint}
+
+			result, err := bundler.GetUserOperationReceipt(context.Background(), []byte{0x01}, 0, tt.retries)
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, common.HexToHash("0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"), result.TransactionHash)
+			}
+			assert.Equal(t, tt.expectedCalls, mock.CallCount("eth_getUserOperationReceipt"))
+		})
+	}
+}
+
+func TestReceiptPollingBackoff_Delay(t *testing.T) {
+	backoff := &ReceiptPollingBackoff{
+		BaseDelay: time.Second,
+		MaxDelay:  10 * time.Second,
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for attempt, delay := range expected {
+		assert.Equal(t, delay, backoff.Delay(attempt))
+	}
+
+	backoff.Multiplier = 1.5
+	assert.Equal(t, 2250*time.Millisecond, backoff.Delay(2))
+
+	// without MaxDelay large attempts are bounded instead of overflowing
+	backoff = &ReceiptPollingBackoff{BaseDelay: time.Second}
+	for _, attempt := range []int{20, 64, 1_000, math.MaxInt32} {
+		assert.Equal(t, defaultReceiptPollingMaxDelay, backoff.Delay(attempt))
+	}
+}
+
+func TestNewClient_ReceiptPollingBackoff(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		backoff       *ReceiptPollingBackoff
+		expectedError string
+	}{
+		{
+			name:          "unset_base_delay",
+			backoff:       &ReceiptPollingBackoff{},
+			expectedError: "receiptPollingBackoff.BaseDelay must be positive",
+		},
+		{
+			name:          "negative_base_delay",
+			backoff:       &ReceiptPollingBackoff{BaseDelay: -time.Second},
+			expectedError: "receiptPollingBackoff.BaseDelay must be positive",
+		},
+		{
+			name:          "shrinking_multiplier",
+			backoff:       &ReceiptPollingBackoff{BaseDelay: time.Second, Multiplier: 0.5},
+			expectedError: "receiptPollingBackoff.Multiplier must be at least 1",
+		},
+		{
+			name:          "negative_multiplier",
+			backoff:       &ReceiptPollingBackoff{BaseDelay: time.Second, Multiplier: -2},
+			expectedError: "receiptPollingBackoff.Multiplier must be at least 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(&ClientConfig{
+				AccountPK:             privateKey,
+				EntryPointVersion:     EntryPointVersion07,
+				BundlerURL:            &url.URL{Scheme: "http", Host: "bundler"},
+				ChainID:               big.NewInt(ChainPolygon),
+				ReceiptPollingBackoff: tt.backoff,
+			})
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestJitteredPollingDelay(t *testing.T) {
+	delay := jitteredPollingDelay(fixedPollingDelay(10*time.Second), 20)
+
+	varied := false
+	for attempt := 0; attempt < 100; attempt++ {
+		jittered := delay(attempt)
+		assert.GreaterOrEqual(t, jittered, 8*time.Second)
+		assert.LessOrEqual(t, jittered, 12*time.Second)
+		varied = varied || jittered != 10*time.Second
+	}
+	assert.True(t, varied)
+}
+
+func TestClient_ReceiptPollingDelay(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.ReceiptPollingDelay = 10
+	assert.Equal(t, 10*time.Second, client.receiptPollingDelay()(0))
+
+	client.ReceiptPollingBackoff = &ReceiptPollingBackoff{BaseDelay: time.Second}
+	assert.Equal(t, 4*time.Second, client.receiptPollingDelay()(2))
+
+	client.ReceiptPollingJitterPercent = 50
+	for attempt := 0; attempt < 10; attempt++ {
+		assert.InDelta(t, float64(time.Second<<attempt), float64(client.receiptPollingDelay()(attempt)), float64(time.Second<<attempt)/2)
+	}
+}
+
+func TestClient_WaitForUserOperationReceipt_Clock(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	clock := zerodevtest.NewFakeClock(time.Unix(1_700_000_000, 0))
+	client.Clock = clock
+	client.ReceiptPollingBackoff = &ReceiptPollingBackoff{BaseDelay: time.Hour, MaxDelay: 24 * time.Hour}
+	client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_getUserOperationReceipt", nil, nil).
+		On("eth_getUserOperationReceipt", nil, nil).
+		On("eth_getUserOperationReceipt", json.RawMessage(`{
+			"userOpHash": "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77",
+			"success": true,
+			"receipt": {"transactionHash": "0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"}
+		}`), nil)
+
+	start := time.Now()
+	receipt, err := client.WaitForUserOperationReceipt(context.Background(), common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"))
+	require.NoError(t, err)
+
+	assert.True(t, receipt.Success)
+	assert.Equal(t, []time.Duration{time.Hour, 2 * time.Hour}, clock.Waits())
+	assert.Equal(t, time.Unix(1_700_000_000, 0).Add(3*time.Hour), clock.Now())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestBundlerClient_GetUserOperationReceipt_NoFinalSleep(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	mock := zerodevtest.NewMockRPCClient().On("eth_getUserOperationReceipt", nil, nil)
+	bundler := &BundlerClient{Client: mock, EntryPoint: entrypoint}
+
+	start := time.Now()
+	_, err = bundler.GetUserOperationReceiptWithBackoff(context.Background(), []byte{0x01}, &ReceiptPollingBackoff{BaseDelay: time.Hour}, 1)
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, mock.CallCount("eth_getUserOperationReceipt"))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestBundlerClient_WaitForUserOperationReceipt(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	t.Run("receipt", func(t *testing.T) {
+		mock := zerodevtest.NewMockRPCClient().
+			On("eth_getUserOperationReceipt", nil, nil).
+			On("eth_getUserOperationReceipt", nil, nil).
+			On("eth_getUserOperationReceipt", json.RawMessage(`{"userOpHash":"0x01","success":true,"receipt":{"transactionHash":"0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"}}`), nil)
+		bundler := &BundlerClient{Client: mock, EntryPoint: entrypoint}
+
+		receipt, err := bundler.WaitForUserOperationReceipt(context.Background(), []byte{0x01}, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, common.HexToHash("0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"), receipt.TransactionHash)
+		assert.Equal(t, 3, mock.CallCount("eth_getUserOperationReceipt"))
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		mock := zerodevtest.NewMockRPCClient().On("eth_getUserOperationReceipt", nil, nil)
+		bundler := &BundlerClient{Client: mock, EntryPoint: entrypoint}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		receipt, err := bundler.WaitForUserOperationReceipt(ctx, []byte{0x01}, 10*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "timed out waiting for receipt")
+		assert.Nil(t, receipt)
+	})
+}
+
+func TestBundlerClient_Backups(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	hash := common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+
+	t.Run("receipt", func(t *testing.T) {
+		primary := zerodevtest.NewMockRPCClient().On("eth_getUserOperationReceipt", nil, errors.New("connection refused"))
+		unknown := zerodevtest.NewMockRPCClient().On("eth_getUserOperationReceipt", nil, nil)
+		known := zerodevtest.NewMockRPCClient().On("eth_getUserOperationReceipt", json.RawMessage(`{
+			"userOpHash": "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77",
+			"success": true,
+			"receipt": {"transactionHash": "0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"}
+		}`), nil)
+		notAsked := zerodevtest.NewMockRPCClient()
+		bundler := &BundlerClient{Client: primary, EntryPoint: entrypoint, Backups: []types.RPCClient{unknown, known, notAsked}}
+
+		receipt, err := bundler.GetUserOperationReceipt(context.Background(), hash, 0, 1)
+		require.NoError(t, err)
+		assert.True(t, receipt.Success)
+		assert.Equal(t, 1, primary.CallCount("eth_getUserOperationReceipt"))
+		assert.Equal(t, 1, unknown.CallCount("eth_getUserOperationReceipt"))
+		assert.Equal(t, 1, known.CallCount("eth_getUserOperationReceipt"))
+		assert.Empty(t, notAsked.Calls())
+	})
+
+	t.Run("operation", func(t *testing.T) {
+		primary := zerodevtest.NewMockRPCClient().On("eth_getUserOperationByHash", json.RawMessage(`null`), nil)
+		backup := zerodevtest.NewMockRPCClient().On("eth_getUserOperationByHash", json.RawMessage(`{
+			"userOperation": {"sender": "0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A", "nonce": "0x5", "callData": "0xdeadbeef", "maxFeePerGas": "0x6fc23ac00", "maxPriorityFeePerGas": "0x59682f00"},
+			"entryPoint": "0x0000000071727De22E5E9d8BAf0edAc6f37da032",
+			"blockNumber": null
+		}`), nil)
+		bundler := &BundlerClient{Client: primary, EntryPoint: entrypoint, Backups: []types.RPCClient{backup}}
+
+		status, err := bundler.GetUserOperationByHash(context.Background(), hash)
+		require.NoError(t, err)
+		require.NotNil(t, status)
+		assert.True(t, status.IsPending())
+	})
+
+	t.Run("all_failed", func(t *testing.T) {
+		primaryErr := errors.New("connection refused")
+		primary := zerodevtest.NewMockRPCClient().On("eth_getUserOperationByHash", nil, primaryErr)
+		backup := zerodevtest.NewMockRPCClient().On("eth_getUserOperationByHash", nil, errors.New("timeout"))
+		bundler := &BundlerClient{Client: primary, EntryPoint: entrypoint, Backups: []types.RPCClient{backup}}
+
+		_, err := bundler.GetUserOperationByHash(context.Background(), hash)
+		assert.ErrorIs(t, err, primaryErr)
+
+		// a backup not knowing the operation is not a failure
+		bundler.Backups = []types.RPCClient{zerodevtest.NewMockRPCClient().On("eth_getUserOperationByHash", json.RawMessage(`null`), nil)}
+		status, err := bundler.GetUserOperationByHash(context.Background(), hash)
+		require.NoError(t, err)
+		assert.Nil(t, status)
+	})
+}
@@ -0,0 +1,64 @@
+package zerodev
+
+import (
+	"context"
+	"fmt"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// DefaultMaxCallDataSize bounds the call data of UserOperations when ClientConfig.MaxCallDataSize is not set,
+// bundle transactions above the 128KB transaction pool limit of geth are not relayed
+const DefaultMaxCallDataSize = 128 * 1024
+
+const (
+	// the call gas limit is implausible above callGasPerCallDataByte per byte of call data, and at least minImplausibleCallGasLimit
+	callGasPerCallDataByte     = 10_000
+	minImplausibleCallGasLimit = 10_000_000
+)
+
+// checkCallData returns a descriptive error for call data that would be rejected by the bundler or is most likely a mistake
+func (c *Client) checkCallData(callData *[]byte) error {
+	if callData == nil {
+		return errors.New("callData is required, encode the call with EncodeExecute or EncodeExecuteBatch")
+	}
+
+	maxSize := c.MaxCallDataSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxCallDataSize
+	}
+	if maxSize > 0 && len(*callData) > maxSize {
+		return errors.Errorf("callData of %d bytes exceeds the maximum of %d bytes, split the calls into several UserOperations or raise ClientConfig.MaxCallDataSize", len(*callData), maxSize)
+	}
+
+	// the hex string of call data passed as bytes instead of being decoded
+	if len(*callData) >= 2 && (*callData)[0] == '0' && ((*callData)[1] == 'x' || (*callData)[1] == 'X') {
+		return errors.New("callData starts with the text \"0x\", it's likely hex-encoded twice, decode it with common.FromHex or hexutil.Decode")
+	}
+
+	return nil
+}
+
+// warnCallGasLimit logs a warning if the call gas limit of op is implausibly large for its call data, see callGasLimitWarning
+func (c *Client) warnCallGasLimit(ctx context.Context, op *UserOperation) {
+	if warning := callGasLimitWarning(op); warning != "" {
+		c.logger().WarnContext(ctx, warning, "sender", op.Sender, "nonce", op.Nonce)
+	}
+}
+
+// callGasLimitWarning describes a call gas limit implausibly large for the call data size of op, empty if it's plausible
+func callGasLimitWarning(op *UserOperation) string {
+	if op.CallGasLimit == nil {
+		return ""
+	}
+
+	limit := new(big.Int).Mul(big.NewInt(callGasPerCallDataByte), big.NewInt(int64(len(op.CallData))))
+	if limit.Cmp(big.NewInt(minImplausibleCallGasLimit)) < 0 {
+		limit.SetInt64(minImplausibleCallGasLimit)
+	}
+	if op.CallGasLimit.Cmp(limit) <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("callGasLimit %s is implausibly large for %d bytes of callData, check the call doesn't loop or revert during estimation", op.CallGasLimit, len(op.CallData))
+}
@@ -0,0 +1,129 @@
+package zerodev
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CheckCallData(t *testing.T) {
+	tests := []struct {
+		name            string
+		callData        *[]byte
+		maxCallDataSize int
+		expectedError   string
+	}{
+		{
+			name:     "valid",
+			callData: &[]byte{0xde, 0xad, 0xbe, 0xef},
+		},
+		{
+			name:          "nil",
+			expectedError: "callData is required",
+		},
+		{
+			name:          "default_max",
+			callData:      bytesOf(DefaultMaxCallDataSize + 1),
+			expectedError: "callData of 131073 bytes exceeds the maximum of 131072 bytes",
+		},
+		{
+			name:            "configured_max",
+			callData:        bytesOf(101),
+			maxCallDataSize: 100,
+			expectedError:   "callData of 101 bytes exceeds the maximum of 100 bytes",
+		},
+		{
+			name:            "max_disabled",
+			callData:        bytesOf(DefaultMaxCallDataSize + 1),
+			maxCallDataSize: -1,
+		},
+		{
+			name:          "hex_encoded_twice",
+			callData:      &[]byte{'0', 'x', 'd', 'e', 'a', 'd'},
+			expectedError: "it's likely hex-encoded twice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{MaxCallDataSize: tt.maxCallDataSize}
+
+			err := client.checkCallData(tt.callData)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestClient_GetUserOperationAndHashToSign_CallDataTooLarge(t *testing.T) {
+	client, network, paymaster := newTestClient(t, 0)
+	client.MaxCallDataSize = 4
+
+	_, _, err := client.GetUserOperationAndHashToSign(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), bytesOf(5))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the maximum of 4 bytes")
+
+	assert.Empty(t, network.Calls())
+	assert.Empty(t, paymaster.Calls())
+}
+
+func TestCallGasLimitWarning(t *testing.T) {
+	tests := []struct {
+		name         string
+		callGasLimit *big.Int
+		callDataSize int
+		expectWarn   bool
+	}{
+		{name: "unset", callDataSize: 4},
+		{name: "plausible", callGasLimit: big.NewInt(100_000), callDataSize: 4},
+		{name: "minimum", callGasLimit: big.NewInt(minImplausibleCallGasLimit), callDataSize: 4},
+		{name: "implausible", callGasLimit: big.NewInt(minImplausibleCallGasLimit + 1), callDataSize: 4, expectWarn: true},
+		{name: "large_call_data", callGasLimit: big.NewInt(15_000_000), callDataSize: 2_000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &UserOperation{CallGasLimit: tt.callGasLimit, CallData: *bytesOf(tt.callDataSize)}
+			assert.Equal(t, tt.expectWarn, callGasLimitWarning(op) != "")
+		})
+	}
+}
+
+func TestClient_CallGasLimitWarningAttribute(t *testing.T) {
+	var logs bytes.Buffer
+	client, _, _ := newTestClient(t, 0)
+	client.PaymasterClient = nil
+	tracer := &recordingTracer{}
+	client.Tracer = tracer
+	client.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x1c9c381"}`), nil)
+
+	callData := []byte{0xde, 0xad, 0xbe, 0xef}
+	_, _, err := client.GetUserOperationAndHashToSign(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData)
+	require.NoError(t, err)
+
+	estimate := tracer.span(SpanEstimateUserOperationGas)
+	require.NotNil(t, estimate)
+	assert.Contains(t, estimate.attributes[AttributeCallGasLimitWarning], "callGasLimit 30000001 is implausibly large for 4 bytes of callData")
+	assert.NoError(t, estimate.err)
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), "callGasLimit 30000001 is implausibly large for 4 bytes of callData")
+}
+
+func bytesOf(size int) *[]byte {
+	data := make([]byte, size)
+	return &data
+}
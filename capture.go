@@ -0,0 +1,145 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"sync"
+	"time"
+)
+
+// CapturedCall is a JSON-RPC call recorded by RPCCapture, with the params and result as sent and received
+type CapturedCall struct {
+	Method   string
+	Params   json.RawMessage
+	Result   json.RawMessage
+	Error    error
+	Time     time.Time
+	Duration time.Duration
+}
+
+// RPCCapture records the last Limit JSON-RPC calls of the clients it wraps, to reproduce issues with bundlers and paymasters.
+// A Limit of 0 or less keeps every call
+type RPCCapture struct {
+	Limit int
+
+	mu    sync.Mutex
+	calls []CapturedCall
+}
+
+// NewRPCCapture creates a new RPCCapture instance keeping the last limit calls.
+func NewRPCCapture(limit int) *RPCCapture {
+	return &RPCCapture{
+		Limit: limit,
+	}
+}
+
+// LastRequests returns the captured calls, oldest first
+func (c *RPCCapture) LastRequests() []CapturedCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]CapturedCall(nil), c.calls...)
+}
+
+func (c *RPCCapture) record(call CapturedCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = append(c.calls, call)
+	if c.Limit > 0 && len(c.calls) > c.Limit {
+		c.calls = c.calls[len(c.calls)-c.Limit:]
+	}
+}
+
+// setLimit changes Limit, the calls beyond it are dropped with the next recorded call
+func (c *RPCCapture) setLimit(limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Limit = limit
+}
+
+// capturingRPCClient records the calls made through it to Capture
+type capturingRPCClient struct {
+	Client  types.RPCClient
+	Capture *RPCCapture
+}
+
+func (c *capturingRPCClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	call := CapturedCall{
+		Method: method,
+		Time:   time.Now(),
+	}
+
+	// no args are sent as an empty array, like rpc.Client does
+	params, err := json.Marshal(append([]interface{}{}, args...))
+	if err == nil {
+		call.Params = params
+	}
+
+	// the raw result is decoded into result afterward, so it's captured exactly as received
+	var raw json.RawMessage
+	var target interface{}
+	if result != nil {
+		target = &raw
+	}
+
+	err = c.Client.CallContext(ctx, target, method, args...)
+	if err == nil && result != nil && len(raw) > 0 {
+		err = json.Unmarshal(raw, result)
+	}
+
+	call.Result = raw
+	call.Error = err
+	call.Duration = time.Since(call.Time)
+	c.Capture.record(call)
+
+	return err
+}
+
+// BatchCallContext records every call of batch, with the time and duration of the whole batch
+func (c *capturingRPCClient) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	start := time.Now()
+
+	// the raw results are decoded into the results of batch afterward, like CallContext does
+	raws := make([]json.RawMessage, len(batch))
+	captured := make([]rpc.BatchElem, len(batch))
+	for i, elem := range batch {
+		captured[i] = rpc.BatchElem{Method: elem.Method, Args: elem.Args}
+		if elem.Result != nil {
+			captured[i].Result = &raws[i]
+		}
+	}
+
+	if err := batchCallContext(ctx, c.Client, captured); err != nil {
+		return err
+	}
+
+	duration := time.Since(start)
+	for i := range batch {
+		batch[i].Error = captured[i].Error
+		if batch[i].Error == nil && batch[i].Result != nil && len(raws[i]) > 0 {
+			batch[i].Error = json.Unmarshal(raws[i], batch[i].Result)
+		}
+
+		call := CapturedCall{
+			Method:   batch[i].Method,
+			Time:     start,
+			Result:   raws[i],
+			Error:    batch[i].Error,
+			Duration: duration,
+		}
+		if params, err := json.Marshal(append([]interface{}{}, batch[i].Args...)); err == nil {
+			call.Params = params
+		}
+		c.Capture.record(call)
+	}
+
+	return nil
+}
+
+func (c *capturingRPCClient) Close() {
+	c.Client.Close()
+}
@@ -0,0 +1,94 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundlerClient_LastRequests(t *testing.T) {
+	ctx := context.Background()
+	mock := zerodevtest.NewMockRPCClient().
+		On("eth_chainId", "0x89", nil).
+		On("eth_supportedEntryPoints", nil, errors.New("method not found"))
+
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	bundler, err := NewBundlerClient(mock, entrypoint, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	assert.Nil(t, bundler.LastRequests())
+
+	bundler.EnableCapture(2)
+
+	var chainID string
+	require.NoError(t, bundler.Client.CallContext(ctx, &chainID, "eth_chainId"))
+	assert.Equal(t, "0x89", chainID)
+
+	require.NoError(t, bundler.Client.CallContext(ctx, &chainID, "eth_chainId", "0x1"))
+
+	var entrypoints []string
+	err = bundler.Client.CallContext(ctx, &entrypoints, "eth_supportedEntryPoints")
+	require.Error(t, err)
+
+	calls := bundler.LastRequests()
+	require.Len(t, calls, 2)
+
+	assert.Equal(t, "eth_chainId", calls[0].Method)
+	assert.JSONEq(t, `["0x1"]`, string(calls[0].Params))
+	assert.Equal(t, json.RawMessage(`"0x89"`), calls[0].Result)
+	assert.NoError(t, calls[0].Error)
+
+	assert.Equal(t, "eth_supportedEntryPoints", calls[1].Method)
+	assert.JSONEq(t, `[]`, string(calls[1].Params))
+	assert.Empty(t, calls[1].Result)
+	assert.EqualError(t, calls[1].Error, "method not found")
+}
+
+func TestPaymasterClient_LastRequests(t *testing.T) {
+	mock := zerodevtest.NewMockRPCClient().
+		On("zd_sponsorUserOperation", json.RawMessage(`{"paymaster":"0x01"}`), nil)
+
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	paymaster, err := NewPaymasterClient(mock, entrypoint, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	paymaster.EnableCapture(5)
+
+	var result map[string]string
+	require.NoError(t, paymaster.Client.CallContext(context.Background(), &result, "zd_sponsorUserOperation", map[string]string{"chainId": "137"}))
+	assert.Equal(t, map[string]string{"paymaster": "0x01"}, result)
+
+	calls := paymaster.LastRequests()
+	require.Len(t, calls, 1)
+	assert.JSONEq(t, `[{"chainId":"137"}]`, string(calls[0].Params))
+	assert.JSONEq(t, `{"paymaster":"0x01"}`, string(calls[0].Result))
+
+	// enabling it again keeps the wrapped client and the calls, only the limit changes
+	client := paymaster.Client
+	paymaster.EnableCapture(1)
+	assert.Same(t, client, paymaster.Client)
+	require.NoError(t, paymaster.Client.CallContext(context.Background(), &result, "zd_sponsorUserOperation"))
+	calls = paymaster.LastRequests()
+	require.Len(t, calls, 1)
+	assert.JSONEq(t, `[]`, string(calls[0].Params))
+	assert.Equal(t, 2, mock.CallCount("zd_sponsorUserOperation"))
+}
+
+func TestRPCCapture_Unbounded(t *testing.T) {
+	for _, limit := range []int{0, -1} {
+		capture := NewRPCCapture(limit)
+		for i := 0; i < 3; i++ {
+			capture.record(CapturedCall{Method: "eth_chainId"})
+		}
+		assert.Len(t, capture.LastRequests(), 3)
+	}
+}
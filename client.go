@@ -1,38 +1,246 @@
 package zerodev
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"github.com/DIMO-Network/go-zerodev/account"
 	"github.com/DIMO-Network/go-zerodev/types"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/friendsofgo/errors"
+	"log/slog"
 	"math/big"
+	"net/http"
 	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultHTTPTimeout bounds each RPC request when ClientConfig.HTTPClient is not set
+	defaultHTTPTimeout = 30 * time.Second
+	// defaultDialTimeout bounds connecting to websocket endpoints, HTTP endpoints connect lazily
+	defaultDialTimeout = 10 * time.Second
 )
 
 type ClientConfig struct {
-	AccountAddress             common.Address
-	AccountPK                  *ecdsa.PrivateKey
-	EntryPointVersion          string
-	RpcURL                     *url.URL
-	PaymasterURL               *url.URL
-	BundlerURL                 *url.URL
+	// AccountAddress is the smart account the client sends user operations of, not the owner address of AccountPK
+	AccountAddress    common.Address
+	AccountPK         *ecdsa.PrivateKey
+	EntryPointVersion string
+	// EntryPointAddress overrides the canonical entrypoint address of EntryPointVersion, e.g. on local networks
+	EntryPointAddress common.Address
+	// UserOperationHasher replaces the hashing of Entrypoint 0.7 on chains computing the UserOperation hash differently, optional
+	UserOperationHasher UserOperationHasher
+	RpcURL              *url.URL
+	// PaymasterURL is optional, without it the account pays for its own gas and op.Paymaster stays the zero address
+	PaymasterURL *url.URL
+	// Paymaster selects sponsored or ERC-20 paymaster mode, defaults to sponsored
+	Paymaster *PaymasterConfig
+	// BundlerURL of a ws or wss endpoint makes receipts awaited with an eth_subscribe subscription instead of polling, see BundlerClient.Subscriber
+	BundlerURL *url.URL
+	// BackupBundlerURLs are bundlers asked for receipts and operations BundlerURL doesn't know, e.g. in a failover setup,
+	// see BundlerClient.Backups. Operations are only sent to BundlerURL. They use BundlerHeaders
+	BackupBundlerURLs []*url.URL
+	// RpcHeaders, PaymasterHeaders and BundlerHeaders are HTTP headers sent to each endpoint, e.g. Authorization of a gateway
+	RpcHeaders       http.Header
+	PaymasterHeaders http.Header
+	BundlerHeaders   http.Header
+	// HTTPClient is used for the HTTP endpoints, to set timeouts, proxy or connection pooling.
+	// Defaults to a client with a 30s timeout per request
+	HTTPClient                 *http.Client
 	ChainID                    *big.Int
 	ReceiptPollingDelaySeconds int
 	ReceiptPollingRetries      int
+	// ReceiptPollingBackoff enables exponential backoff between receipt polling retries instead of the fixed ReceiptPollingDelaySeconds
+	ReceiptPollingBackoff *ReceiptPollingBackoff
+	// ReceiptPollingJitterPercent randomizes each receipt polling delay by up to ± this percentage, e.g. 20,
+	// so many clients sharing a bundler don't poll it in sync. Defaults to 0, no jitter
+	ReceiptPollingJitterPercent int
+	// Clock times receipt polling and confirmations, defaults to SystemClock. Tests can set a fake clock to skip the delays
+	Clock Clock
+	// NonceKey is the default 192-bit nonce key used for user operations, defaults to 0
+	NonceKey *big.Int
+	// AccountIndex is the index used to derive AccountAddress from the owner when deploying it, defaults to 0.
+	// When AccountAddress is not set and AccountIndex is, AccountAddress is computed by the factory, e.g. to use account #1 of AccountPK
+	AccountIndex *big.Int
+	// AccountFactoryAddress is the Kernel factory deploying the account and computing its address, defaults to KernelFactoryAddress
+	AccountFactoryAddress common.Address
+	// RetryPolicy retries RPC calls failing with transient errors, defaults to DefaultRetryPolicy retrying reads only
+	RetryPolicy *RetryPolicy
+	// Tracer traces the lifecycle of user operations, e.g. otelzerodev.NewTracer wrapping an OpenTelemetry TracerProvider.
+	// Optional, operations are not traced by default
+	Tracer Tracer
+	// Metrics records outcomes of user operations, optional
+	Metrics Metrics
+	// SkipChainIDVerification skips checking ChainID against eth_chainId of the network RPC and the bundler, see Client.VerifyChainID
+	SkipChainIDVerification bool
+	// SkipEntryPointVerification skips checking that the bundler supports the entrypoint, see Client.VerifyEntryPoint
+	SkipEntryPointVerification bool
+	// ManageNonces hands out nonces with a NonceManager, so concurrent user operations of the same account don't reuse a nonce
+	ManageNonces bool
+	// NonceStallTimeout makes the NonceManager hand out a nonce again once it was handed out this long ago without being included,
+	// e.g. DefaultNonceStallTimeout, see NonceManager.StallTimeout. 0 disables the recovery
+	NonceStallTimeout time.Duration
+	// GasLimitMultiplier raises the call and verification gas limits estimated by the bundler, e.g. 1.2, clamped to [1, 3].
+	// zd_sponsorUserOperation limits are signed by the paymaster and kept as returned
+	GasLimitMultiplier float64
+	// Logger logs gas limit adjustments and warnings about user operations, defaults to slog.Default()
+	Logger *slog.Logger
+	// IdempotencyCache makes resending an identical signed user operation return the stored result instead of submitting it again,
+	// e.g. NewMemoryIdempotencyCache. Optional
+	IdempotencyCache IdempotencyCache
+	// VerifySigner checks the signer's owner is registered in the ECDSA validator of the sender before the client signs for it,
+	// once per sender. Costs an eth_call, off by default
+	VerifySigner bool
+	// MaxCallDataSize bounds the call data of user operations in bytes, larger call data fails before anything is requested.
+	// Defaults to DefaultMaxCallDataSize, negative disables the check
+	MaxCallDataSize int
+	// EIP7702Delegate makes the EOA of AccountPK the account, delegated to this Kernel implementation with EIP-7702.
+	// The first user operation carries the authorization signed with AccountPK. Entrypoint 0.8 only, AccountAddress is the EOA
+	EIP7702Delegate common.Address
+	// MinPaymasterVerificationGasLimit and MinPaymasterPostOpGasLimit raise the paymaster gas limits estimated below them,
+	// for paymasters needing more gas than estimated. EIP-7677 only, zd_sponsorUserOperation limits are signed by the paymaster
+	MinPaymasterVerificationGasLimit *big.Int
+	MinPaymasterPostOpGasLimit       *big.Int
+	// MaxFeePerGasBaseFeeMultiplier caps the maxFeePerGas suggested by the bundler at baseFee * multiplier + maxPriorityFeePerGas,
+	// with the base fee of the latest block, e.g. 2 to stay includable through a few full blocks. 0 disables the cap, it must be at least 1 otherwise.
+	// Fees set with GasOverrides.MaxFeePerGas are not capped
+	MaxFeePerGasBaseFeeMultiplier float64
+	// AllowedPaymasters rejects sponsorships by any other paymaster, e.g. injected by a compromised paymaster endpoint,
+	// before the UserOperation is hashed and signed. Empty allows any paymaster
+	AllowedPaymasters []common.Address
+	// GasEstimateCache reuses recent gas estimates of similar self-funded or EIP-7677 sponsored user operations instead of estimating again,
+	// e.g. NewMemoryGasEstimateCache with a short TTL. zd_sponsorUserOperation estimates within the sponsorship, it's not cached. Optional
+	GasEstimateCache GasEstimateCache
+	// RequestDecorator rewrites the method and params of every JSON-RPC request to the network RPC, the paymaster and the bundler,
+	// e.g. to namespace them for a multi-tenant endpoint. eth_subscribe subscriptions are not decorated. Optional
+	RequestDecorator RequestDecorator
+
+	// dial connects to the endpoints, dialRPCClient if nil, replaced in tests
+	dial func(rpcURL *url.URL, headers http.Header, httpClient *http.Client) (types.RPCClient, error)
+}
+
+// UserOperationOptions customizes how a UserOperation is built, unset fields fall back to the client defaults.
+type UserOperationOptions struct {
+	// NonceKey selects an independent nonce channel, operations using different keys can be submitted concurrently
+	NonceKey *big.Int
+	// Nonce sets the full nonce, key and sequence, instead of fetching it from the entrypoint or the NonceManager,
+	// e.g. to rebuild a known UserOperation offline. NonceKey is ignored when set
+	Nonce *big.Int
+	// SelfFunded estimates gas with the bundler and skips the paymaster, the account pays for its own gas.
+	// Always the case when the client has no PaymasterURL configured
+	SelfFunded bool
+	// GasOverrides replaces the standard gas price suggested by the bundler
+	GasOverrides *GasOverrides
+	// ValidAfter and ValidUntil bound when the UserOperation can be included, zero for no bound.
+	// They're sent in the paymaster context and signed by the paymaster into the paymaster data, only sponsored operations support them
+	ValidAfter time.Time
+	ValidUntil time.Time
+}
+
+// GasSpeed selects one of the gas prices suggested by the bundler
+type GasSpeed string
+
+const (
+	GasSpeedSlow     GasSpeed = "slow"
+	GasSpeedStandard GasSpeed = "standard"
+	GasSpeedFast     GasSpeed = "fast"
+)
+
+// GasOverrides customizes fees of a UserOperation. Speed picks the bundler suggestion (standard if empty),
+// MaxFeePerGas and MaxPriorityFeePerGas replace the suggested values when set.
+// Fees are set before sponsorship or gas estimation, gas limits are still estimated by the paymaster or bundler,
+// which might reject fees below their minimum.
+type GasOverrides struct {
+	Speed                GasSpeed
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// needsGasPrice reports whether the bundler gas price is needed, it's not when both fees are overridden
+func (g *GasOverrides) needsGasPrice() bool {
+	return g == nil || g.MaxFeePerGas == nil || g.MaxPriorityFeePerGas == nil
+}
+
+// speed returns the bundler suggestion the fees are picked from, standard if not set
+func (g *GasOverrides) speed() GasSpeed {
+	if g == nil || g.Speed == "" {
+		return GasSpeedStandard
+	}
+	return g.Speed
+}
+
+// apply resolves the fees from the bundler gasPrice and the overrides
+func (g *GasOverrides) apply(op *UserOperation, gasPrice *GetUserOperationGasPriceResponse) error {
+	if gasPrice != nil {
+		speed := g.speed()
+
+		var specification *GasPriceSpecification
+		switch speed {
+		case GasSpeedSlow:
+			specification = gasPrice.Slow
+		case GasSpeedStandard:
+			specification = gasPrice.Standard
+		case GasSpeedFast:
+			specification = gasPrice.Fast
+		default:
+			return errors.New("unsupported gas speed: " + string(speed))
+		}
+		if specification == nil {
+			return errors.New("bundler did not return " + string(speed) + " gas price")
+		}
+
+		op.MaxFeePerGas = specification.MaxFeePerGas
+		op.MaxPriorityFeePerGas = specification.MaxPriorityFeePerGas
+	}
+
+	if g != nil && g.MaxFeePerGas != nil {
+		op.MaxFeePerGas = g.MaxFeePerGas
+	}
+	if g != nil && g.MaxPriorityFeePerGas != nil {
+		op.MaxPriorityFeePerGas = g.MaxPriorityFeePerGas
+	}
+
+	if op.MaxPriorityFeePerGas.Cmp(op.MaxFeePerGas) > 0 {
+		return errors.New("maxPriorityFeePerGas must not exceed maxFeePerGas")
+	}
+
+	return nil
 }
 
 type UserOperationResult struct {
 	UserOperationHash []byte                `json:"userOperationHash"`
 	Receipt           *UserOperationReceipt `json:"receipt,omitempty"`
+	// EntryPoint, EntryPointVersion and ChainID the operation was sent to, the hash can be verified with them offline
+	EntryPoint        common.Address `json:"entryPoint"`
+	EntryPointVersion string         `json:"entryPointVersion"`
+	ChainID           *big.Int       `json:"chainId"`
+	// TransactionHash and BlockNumber of the bundle transaction that included the operation, set once the receipt is known
+	TransactionHash common.Hash `json:"transactionHash"`
+	BlockNumber     *big.Int    `json:"blockNumber,omitempty"`
+	// UserOperation is the signed operation as submitted, with its gas limits and paymaster data, e.g. to persist, replay or replace it
+	UserOperation *UserOperation `json:"userOperation,omitempty"`
+}
+
+// setReceipt sets the receipt of the operation and the transaction that included it
+func (r *UserOperationResult) setReceipt(receipt *UserOperationReceipt) {
+	r.Receipt = receipt
+	r.TransactionHash = receipt.TransactionHash
+	r.BlockNumber = nil
+	if receipt.BlockNumber != nil {
+		r.BlockNumber = new(big.Int).Set(receipt.BlockNumber.ToInt())
+	}
 }
 
 type Client struct {
 	Signer          types.AccountSigner
 	EntryPoint      Entrypoint
 	PaymasterClient *PaymasterClient
+	PaymasterConfig *PaymasterConfig
 	BundlerClient   *BundlerClient
+	AccountClient   *AccountClient
 	ChainID         *big.Int
 	RpcClients      struct {
 		Network   *rpc.Client
@@ -41,60 +249,241 @@ type Client struct {
 	}
 	ReceiptPollingDelay   int
 	ReceiptPollingRetries int
+	ReceiptPollingBackoff *ReceiptPollingBackoff
+	// ReceiptPollingJitterPercent randomizes each receipt polling delay by up to ± this percentage
+	ReceiptPollingJitterPercent int
+	Clock                       Clock
+	NonceKey                    *big.Int
+	AccountFactory              *KernelFactory
+	AccountAddress              common.Address
+	AccountOwner                common.Address
+	AccountIndex                *big.Int
+	Tracer                      Tracer
+	Metrics                     Metrics
+	NonceManager                *NonceManager
+	GasLimitMultiplier          float64
+	Logger                      *slog.Logger
+	// IdempotencyCache stores the results of sent user operations, resending an identical operation returns its stored result
+	IdempotencyCache IdempotencyCache
+	// VerifySigner checks the Signer is authorized for the sender before it signs, see VerifySignerAuthorized
+	VerifySigner bool
+	// MaxCallDataSize bounds the call data of user operations in bytes, 0 uses DefaultMaxCallDataSize and negative disables the check
+	MaxCallDataSize int
+	// EIP7702 makes the client's signer EOA the account through EIP-7702 delegation, optional
+	EIP7702 *EIP7702Account
+	// MinPaymasterVerificationGasLimit and MinPaymasterPostOpGasLimit are floors of the EIP-7677 paymaster gas limits, optional
+	MinPaymasterVerificationGasLimit *big.Int
+	MinPaymasterPostOpGasLimit       *big.Int
+	// MaxFeePerGasBaseFeeMultiplier caps the bundler's maxFeePerGas at baseFee * multiplier + maxPriorityFeePerGas, optional
+	MaxFeePerGasBaseFeeMultiplier float64
+	// AllowedPaymasters are the only paymasters sponsorships are accepted from, any if empty
+	AllowedPaymasters []common.Address
+	// GasEstimateCache stores recent gas estimates, similar self-funded and EIP-7677 sponsored user operations reuse them
+	GasEstimateCache GasEstimateCache
+
+	// dialedRPCClients are the endpoints dialed by NewClient, closed by Close
+	dialedRPCClients []types.RPCClient
+	closeOnce        sync.Once
+	verifiedSenders  sync.Map
 }
 
-func NewClient(config *ClientConfig) (*Client, error) {
-	if config.AccountPK == nil || config.PaymasterURL == nil || config.BundlerURL == nil || config.EntryPointVersion != EntryPointVersion07 || config.ChainID == nil {
-		return nil, errors.New("accountPK, paymasterURL, bundlerURL, entryPointVersion and chainID are required")
+func NewClient(config *ClientConfig) (_ *Client, err error) {
+	if config.AccountPK == nil || config.BundlerURL == nil || config.ChainID == nil {
+		return nil, errors.New("accountPK, bundlerURL and chainID are required")
+	}
+
+	switch config.EntryPointVersion {
+	case EntryPointVersion06, EntryPointVersion07, EntryPointVersion08:
+	default:
+		return nil, unsupportedEntryPointVersionError(config.EntryPointVersion)
+	}
+
+	if config.UserOperationHasher != nil && config.EntryPointVersion != EntryPointVersion07 {
+		return nil, errors.New("userOperationHasher is only supported with entryPointVersion " + EntryPointVersion07)
 	}
 
-	networkRpc, err := rpc.Dial(config.RpcURL.String())
+	if config.MaxFeePerGasBaseFeeMultiplier != 0 && !(config.MaxFeePerGasBaseFeeMultiplier >= 1) {
+		return nil, errors.New("maxFeePerGasBaseFeeMultiplier must be at least 1")
+	}
+
+	// a zero delay would poll the bundler in a hot loop
+	if backoff := config.ReceiptPollingBackoff; backoff != nil {
+		if backoff.BaseDelay <= 0 {
+			return nil, errors.New("receiptPollingBackoff.BaseDelay must be positive")
+		}
+		if backoff.Multiplier != 0 && !(backoff.Multiplier >= 1) {
+			return nil, errors.New("receiptPollingBackoff.Multiplier must be at least 1")
+		}
+	}
+
+	if config.ReceiptPollingJitterPercent < 0 || config.ReceiptPollingJitterPercent > 100 {
+		return nil, errors.New("receiptPollingJitterPercent must be between 0 and 100")
+	}
+
+	if config.NonceKey != nil && (config.NonceKey.Sign() < 0 || config.NonceKey.BitLen() > nonceKeyBits) {
+		return nil, errors.New("nonceKey must be a non-negative 192-bit integer")
+	}
+
+	paymasterConfig := &PaymasterConfig{Mode: PaymasterModeSponsored}
+	if config.Paymaster != nil {
+		paymasterConfig = config.Paymaster
+	}
+
+	// zd_sponsorUserOperation returns gas limits already signed by the paymaster, the floors can't raise them
+	if (config.MinPaymasterVerificationGasLimit != nil || config.MinPaymasterPostOpGasLimit != nil) && !paymasterConfig.EIP7677 {
+		return nil, errors.New("minPaymasterVerificationGasLimit and minPaymasterPostOpGasLimit require an EIP-7677 paymaster")
+	}
+
+	switch paymasterConfig.Mode {
+	case PaymasterModeSponsored:
+	case PaymasterModeERC20:
+		if paymasterConfig.Token == (common.Address{}) {
+			return nil, errors.New("token is required in erc20 paymaster mode")
+		}
+		// without a paymaster the account would silently pay for its own gas in ETH
+		if config.PaymasterURL == nil {
+			return nil, errors.New("paymasterURL is required in erc20 paymaster mode")
+		}
+	default:
+		return nil, errors.New("unsupported paymaster mode: " + paymasterConfig.Mode)
+	}
+
+	// every client dialed so far is closed if NewClient fails
+	var networkRpc, paymasterRpc, bundleRpc types.RPCClient
+	var backupBundlerRpcs []types.RPCClient
+	defer func() {
+		if err != nil {
+			closeRPCClients(networkRpc, paymasterRpc, bundleRpc)
+			closeRPCClients(backupBundlerRpcs...)
+		}
+	}()
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	dial := dialRPCClient
+	if config.dial != nil {
+		dial = config.dial
+	}
+
+	networkRpc, err = dial(config.RpcURL, config.RpcHeaders, httpClient)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to connect to RPC")
 	}
 
-	paymasterRpc, err := rpc.Dial(config.PaymasterURL.String())
-	if err != nil {
-		networkRpc.Close()
-		return nil, errors.Wrap(err, "failed to connect to Paymaster")
+	if config.PaymasterURL != nil {
+		paymasterRpc, err = dial(config.PaymasterURL, config.PaymasterHeaders, httpClient)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to connect to Paymaster")
+		}
 	}
 
-	bundleRpc, err := rpc.Dial(config.BundlerURL.String())
+	bundleRpc, err = dial(config.BundlerURL, config.BundlerHeaders, httpClient)
 	if err != nil {
-		paymasterRpc.Close()
-		networkRpc.Close()
 		return nil, errors.Wrap(err, "failed to connect to Bundler")
 	}
 
-	entrypoint, err := NewEntrypoint07(networkRpc, config.ChainID)
+	for _, backupURL := range config.BackupBundlerURLs {
+		var backupRpc types.RPCClient
+		backupRpc, err = dial(backupURL, config.BundlerHeaders, httpClient)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to connect to backup Bundler")
+		}
+		backupBundlerRpcs = append(backupBundlerRpcs, backupRpc)
+	}
+
+	retryPolicy := DefaultRetryPolicy()
+	if config.RetryPolicy != nil {
+		retryPolicy = config.RetryPolicy
+	}
+	// endpoints are called with the RequestDecorator and retried under the RetryPolicy, timed by the Clock
+	wrapRPCClient := func(rpcClient types.RPCClient) *RetryingRPCClient {
+		retrying := NewRetryingRPCClient(newDecoratingRPCClient(rpcClient, config.RequestDecorator), retryPolicy)
+		retrying.Clock = config.Clock
+		return retrying
+	}
+	networkClient := wrapRPCClient(networkRpc)
+
+	entrypoint, err := newEntrypoint(config.EntryPointVersion, networkClient, config.ChainID, config.EntryPointAddress)
 	if err != nil {
-		networkRpc.Close()
-		paymasterRpc.Close()
-		networkRpc.Close()
 		return nil, errors.Wrap(err, "failed to initialize entrypoint")
 	}
+	if entrypoint07, ok := entrypoint.(*EntrypointClient07); ok {
+		entrypoint07.Hasher = config.UserOperationHasher
+	}
 
-	paymasterClient, err := NewPaymasterClient(paymasterRpc, entrypoint, config.ChainID)
-	if err != nil {
-		networkRpc.Close()
-		paymasterRpc.Close()
-		networkRpc.Close()
-		return nil, errors.Wrap(err, "failed to initialize paymasterClient")
+	var paymasterClient *PaymasterClient
+	if paymasterRpc != nil {
+		paymasterClient, err = NewPaymasterClient(wrapRPCClient(paymasterRpc), entrypoint, config.ChainID)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialize paymasterClient")
+		}
 	}
 
-	bundlerClient, err := NewBundlerClient(bundleRpc, entrypoint, config.ChainID)
+	bundlerClient, err := NewBundlerClient(wrapRPCClient(bundleRpc), entrypoint, config.ChainID)
 	if err != nil {
-		networkRpc.Close()
-		paymasterRpc.Close()
-		networkRpc.Close()
 		return nil, errors.Wrap(err, "failed to initialize bundlerClient")
 	}
+	bundlerClient.NetworkClient = networkClient
+	for _, backupRpc := range backupBundlerRpcs {
+		bundlerClient.Backups = append(bundlerClient.Backups, wrapRPCClient(backupRpc))
+	}
+	bundlerClient.Clock = config.Clock
+	if subscriber, ok := bundleRpc.(types.SubscriptionRPCClient); ok {
+		bundlerClient.Subscriber = subscriber
+	}
 
-	signer, err := account.NewSmartAccountPrivateKeySigner(networkRpc, config.AccountAddress, config.AccountPK)
+	accountClient, err := NewAccountClient(networkClient)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize accountClient")
+	}
+
+	var accountFactory *KernelFactory
+	if config.EntryPointVersion == EntryPointVersion07 {
+		accountFactory, err = NewKernelFactory()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialize accountFactory")
+		}
+		if config.AccountFactoryAddress != (common.Address{}) {
+			accountFactory.Address = config.AccountFactoryAddress
+		}
+	}
+
+	accountAddress := config.AccountAddress
+	var eip7702Account *EIP7702Account
+	if config.EIP7702Delegate != (common.Address{}) {
+		if config.EntryPointVersion != EntryPointVersion08 {
+			return nil, errors.New("eip7702Delegate is only supported with entryPointVersion " + EntryPointVersion08)
+		}
+		eoa := crypto.PubkeyToAddress(config.AccountPK.PublicKey)
+		if accountAddress != (common.Address{}) && accountAddress != eoa {
+			return nil, errors.New("accountAddress of an EIP-7702 account must be the address of accountPK")
+		}
+		accountAddress = eoa
+
+		eip7702Account, err = NewEIP7702Account(config.AccountPK, config.EIP7702Delegate)
+		if err != nil {
+			return nil, err
+		}
+	} else if accountAddress == (common.Address{}) && config.AccountIndex != nil {
+		if accountFactory == nil {
+			return nil, errors.New("deriving accountAddress from accountIndex is only supported with entryPointVersion " + EntryPointVersion07)
+		}
+		accountAddress, err = accountFactory.GetAccountAddress(context.Background(), networkClient, crypto.PubkeyToAddress(config.AccountPK.PublicKey), config.AccountIndex)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to derive accountAddress")
+		}
+	}
+
+	// the sender of the client's operations is the smart account, only an EIP-7702 account is the EOA itself
+	if eip7702Account == nil && accountAddress != (common.Address{}) && accountAddress == crypto.PubkeyToAddress(config.AccountPK.PublicKey) {
+		return nil, errors.New("accountAddress is the owner address of accountPK, it must be the smart account address")
+	}
+
+	signer, err := account.NewSmartAccountPrivateKeySigner(networkClient, accountAddress, config.AccountPK)
 	if err != nil {
-		networkRpc.Close()
-		paymasterRpc.Close()
-		networkRpc.Close()
 		return nil, errors.Wrap(err, "failed to initialize signer")
 	}
 
@@ -108,10 +497,12 @@ func NewClient(config *ClientConfig) (*Client, error) {
 		pollingRetries = config.ReceiptPollingRetries
 	}
 
-	return &Client{
+	client := &Client{
 		Signer:          signer,
 		PaymasterClient: paymasterClient,
+		PaymasterConfig: paymasterConfig,
 		BundlerClient:   bundlerClient,
+		AccountClient:   accountClient,
 		EntryPoint:      entrypoint,
 		ChainID:         config.ChainID,
 		RpcClients: struct {
@@ -119,29 +510,258 @@ func NewClient(config *ClientConfig) (*Client, error) {
 			Paymaster *rpc.Client
 			Bundler   *rpc.Client
 		}{
-			Network:   networkRpc,
-			Paymaster: paymasterRpc,
-			Bundler:   bundleRpc,
+			Network:   rpcClientOf(networkRpc),
+			Paymaster: rpcClientOf(paymasterRpc),
+			Bundler:   rpcClientOf(bundleRpc),
 		},
-		ReceiptPollingDelay:   pollingDelaySeconds,
-		ReceiptPollingRetries: pollingRetries,
-	}, nil
+		ReceiptPollingDelay:              pollingDelaySeconds,
+		ReceiptPollingRetries:            pollingRetries,
+		ReceiptPollingBackoff:            config.ReceiptPollingBackoff,
+		ReceiptPollingJitterPercent:      config.ReceiptPollingJitterPercent,
+		Clock:                            config.Clock,
+		NonceKey:                         config.NonceKey,
+		AccountFactory:                   accountFactory,
+		AccountAddress:                   accountAddress,
+		AccountOwner:                     crypto.PubkeyToAddress(config.AccountPK.PublicKey),
+		AccountIndex:                     config.AccountIndex,
+		Tracer:                           config.Tracer,
+		Metrics:                          config.Metrics,
+		GasLimitMultiplier:               clampGasLimitMultiplier(config.GasLimitMultiplier),
+		Logger:                           config.Logger,
+		IdempotencyCache:                 config.IdempotencyCache,
+		VerifySigner:                     config.VerifySigner,
+		MaxCallDataSize:                  config.MaxCallDataSize,
+		EIP7702:                          eip7702Account,
+		MinPaymasterVerificationGasLimit: config.MinPaymasterVerificationGasLimit,
+		MinPaymasterPostOpGasLimit:       config.MinPaymasterPostOpGasLimit,
+		MaxFeePerGasBaseFeeMultiplier:    config.MaxFeePerGasBaseFeeMultiplier,
+		AllowedPaymasters:                config.AllowedPaymasters,
+		GasEstimateCache:                 config.GasEstimateCache,
+		dialedRPCClients:                 append([]types.RPCClient{networkRpc, paymasterRpc, bundleRpc}, backupBundlerRpcs...),
+	}
+
+	if config.ManageNonces {
+		client.NonceManager = NewNonceManager(entrypoint)
+		client.NonceManager.StallTimeout = config.NonceStallTimeout
+	}
+
+	if !config.SkipChainIDVerification {
+		if err := client.VerifyChainID(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	if !config.SkipEntryPointVerification {
+		if err := client.VerifyEntryPoint(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+// dialRPCClient dials the endpoints of NewClient, WebSocket endpoints also serve subscriptions
+func dialRPCClient(rpcURL *url.URL, headers http.Header, httpClient *http.Client) (types.RPCClient, error) {
+	rpcClient, err := dialRPC(rpcURL, headers, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	if isWebSocketURL(rpcURL) {
+		return &subscriptionRPCClient{Client: rpcClient}, nil
+	}
+	return rpcClient, nil
+}
+
+// rpcClientOf returns the *rpc.Client of an endpoint dialed by dialRPCClient, nil for other clients
+func rpcClientOf(rpcClient types.RPCClient) *rpc.Client {
+	switch dialed := rpcClient.(type) {
+	case *rpc.Client:
+		return dialed
+	case *subscriptionRPCClient:
+		return dialed.Client
+	default:
+		return nil
+	}
+}
+
+// dialRPC connects to the endpoint at rpcURL with httpClient, sending headers with every request.
+// ws and wss endpoints are dialed as WebSocket connections, httpClient only serves HTTP endpoints
+func dialRPC(rpcURL *url.URL, headers http.Header, httpClient *http.Client) (*rpc.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDialTimeout)
+	defer cancel()
+
+	var options []rpc.ClientOption
+	if !isWebSocketURL(rpcURL) {
+		options = append(options, rpc.WithHTTPClient(httpClient))
+	}
+	if len(headers) > 0 {
+		options = append(options, rpc.WithHeaders(headers))
+	}
+
+	return rpc.DialOptions(ctx, rpcURL.String(), options...)
+}
+
+// newEntrypoint creates the entrypoint client matching the configured version, at address if set or at the canonical address otherwise
+func newEntrypoint(version string, rpcClient types.RPCClient, chainID *big.Int, address common.Address) (Entrypoint, error) {
+	switch version {
+	case EntryPointVersion06:
+		if address == (common.Address{}) {
+			address = common.HexToAddress(entryPointAddress06)
+		}
+		return NewEntrypoint06WithAddress(rpcClient, chainID, address)
+	case EntryPointVersion07:
+		if address == (common.Address{}) {
+			address = common.HexToAddress(entryPointAddress07)
+		}
+		return NewEntrypoint07WithAddress(rpcClient, chainID, address)
+	case EntryPointVersion08:
+		if address == (common.Address{}) {
+			address = common.HexToAddress(entryPointAddress08)
+		}
+		return NewEntrypoint08WithAddress(rpcClient, chainID, address)
+	default:
+		return nil, unsupportedEntryPointVersionError(version)
+	}
+}
+
+func unsupportedEntryPointVersionError(version string) error {
+	return errors.Errorf("unsupported entryPointVersion %q, supported versions are %s, %s and %s",
+		version, EntryPointVersion06, EntryPointVersion07, EntryPointVersion08)
 }
 
-func (c *Client) Close() {
-	c.RpcClients.Network.Close()
-	c.RpcClients.Paymaster.Close()
-	c.RpcClients.Bundler.Close()
+// Close closes the RPC clients, it's safe to call more than once and with endpoints not connected
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		if c.dialedRPCClients != nil {
+			closeRPCClients(c.dialedRPCClients...)
+			return
+		}
+		// a Client not created by NewClient
+		for _, rpcClient := range []*rpc.Client{c.RpcClients.Network, c.RpcClients.Paymaster, c.RpcClients.Bundler} {
+			if rpcClient != nil {
+				rpcClient.Close()
+			}
+		}
+		if c.BundlerClient != nil {
+			closeRPCClients(c.BundlerClient.Backups...)
+		}
+	})
+	return nil
+}
+
+// closeRPCClients closes the clients that are set
+func closeRPCClients(rpcClients ...types.RPCClient) {
+	for _, rpcClient := range rpcClients {
+		if rpcClient != nil {
+			rpcClient.Close()
+		}
+	}
 }
 
 // GetUserOperationAndHashToSign creates a UserOperation based on the sender and callData, computes its hash and returns both.
 // Allows to create UserOperation with custom sender and then customize the signing process.
 // After adding signature to the returned UserOperation, it can be sent by SendSignedUserOperation
-func (c *Client) GetUserOperationAndHashToSign(sender common.Address, callData *[]byte) (*UserOperation, *common.Hash, error) {
+func (c *Client) GetUserOperationAndHashToSign(ctx context.Context, sender common.Address, callData *[]byte) (*UserOperation, *common.Hash, error) {
+	return c.GetUserOperationAndHashToSignWithOptions(ctx, sender, callData, nil)
+}
+
+// GetUserOperationAndHashToSignWithOptions works like GetUserOperationAndHashToSign and allows to customize the UserOperation
+// e.g. pick a nonce key different from the client's default.
+func (c *Client) GetUserOperationAndHashToSignWithOptions(ctx context.Context, sender common.Address, callData *[]byte, opts *UserOperationOptions) (*UserOperation, *common.Hash, error) {
+	if opts == nil {
+		opts = &UserOperationOptions{}
+	}
+
+	if err := c.checkCallData(callData); err != nil {
+		return nil, nil, err
+	}
+	if err := c.validateValidity(opts, clockOrSystem(c.Clock).Now()); err != nil {
+		return nil, nil, err
+	}
+
+	if opts.Nonce != nil && (opts.Nonce.Sign() < 0 || opts.Nonce.BitLen() > 256) {
+		return nil, nil, errors.New("nonce must be a non-negative 256-bit integer")
+	}
+
+	nonceKey := c.NonceKey
+	if opts.NonceKey != nil {
+		nonceKey = opts.NonceKey
+	}
+
+	op, opHash, err := c.getUserOperationAndHashToSign(ctx, sender, callData, nonceKey, opts)
+	if err != nil {
+		// an explicit nonce wasn't handed out by the NonceManager
+		if opts.Nonce == nil {
+			c.ResetNonce(sender, nonceKey)
+		}
+		return nil, nil, err
+	}
+
+	return op, opHash, nil
+}
+
+// getUserOperationAndHashToSign builds the UserOperation of GetUserOperationAndHashToSignWithOptions
+func (c *Client) getUserOperationAndHashToSign(ctx context.Context, sender common.Address, callData *[]byte, nonceKey *big.Int, opts *UserOperationOptions) (*UserOperation, *common.Hash, error) {
 	var err error
 	var op UserOperation
 
-	nonce, err := c.EntryPoint.GetNonce(sender)
+	// nonce, gas price and deployment state are independent, fetch them concurrently
+	var nonce *big.Int
+	var gasPrice *GetUserOperationGasPriceResponse
+	var eip7702Auth *EIP7702Authorization
+	var baseFee *big.Int
+	deployed := true
+
+	err = runConcurrently(ctx,
+		func(ctx context.Context) error {
+			if opts.Nonce != nil {
+				nonce = new(big.Int).Set(opts.Nonce)
+				return nil
+			}
+
+			var err error
+			nonce, err = c.getNonce(ctx, sender, nonceKey)
+			return err
+		},
+		func(ctx context.Context) error {
+			if !opts.GasOverrides.needsGasPrice() {
+				return nil
+			}
+
+			var err error
+			gasPrice, err = c.BundlerClient.GetUserOperationGasPrice(ctx)
+			return err
+		},
+		func(ctx context.Context) error {
+			if !c.capsMaxFeePerGas(opts.GasOverrides) {
+				return nil
+			}
+
+			var err error
+			baseFee, err = getBaseFee(ctx, c.AccountClient.Client)
+			return err
+		},
+		func(ctx context.Context) error {
+			// the client's own account is deployed with its first UserOperation
+			if sender != c.Signer.GetAddress() || c.AccountFactory == nil {
+				return nil
+			}
+
+			var err error
+			deployed, err = c.AccountClient.IsDeployed(ctx, sender)
+			return err
+		},
+		func(ctx context.Context) error {
+			// the client's EOA is delegated with its first UserOperation
+			if sender != c.Signer.GetAddress() || c.EIP7702 == nil {
+				return nil
+			}
+
+			var err error
+			eip7702Auth, err = c.eip7702Authorization(ctx, sender)
+			return err
+		},
+	)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -150,77 +770,452 @@ func (c *Client) GetUserOperationAndHashToSign(sender common.Address, callData *
 	op.Nonce = nonce
 	op.CallData = *callData
 
-	gasPrice, err := c.BundlerClient.GetUserOperationGasPrice()
+	if !deployed {
+		err = c.AccountFactory.SetFactory(&op, c.AccountOwner, c.AccountIndex)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if eip7702Auth != nil {
+		op.SetEIP7702Authorization(eip7702Auth)
+	}
+
+	err = opts.GasOverrides.apply(&op, gasPrice)
 	if err != nil {
 		return nil, nil, err
 	}
+	if baseFee != nil {
+		c.capMaxFeePerGas(ctx, &op, baseFee)
+	}
 
-	op.MaxFeePerGas = gasPrice.Standard.MaxFeePerGas
-	op.MaxPriorityFeePerGas = gasPrice.Standard.MaxPriorityFeePerGas
+	if opts.SelfFunded || c.PaymasterClient == nil {
+		spanCtx, span := c.startSpan(ctx, SpanEstimateUserOperationGas)
+		gasEstimate, cached, err := c.estimateUserOperationGas(spanCtx, &op, opts.GasOverrides.speed())
+		if err != nil {
+			endSpan(span, err)
+			return nil, nil, err
+		}
+		if cached {
+			span.SetAttributes(Attribute{Key: AttributeGasEstimateCached, Value: "true"})
+		}
 
-	sponsorResponse, err := c.PaymasterClient.SponsorUserOperation(&op)
+		op.PreVerificationGas = gasEstimate.PreVerificationGas
+		op.VerificationGasLimit = gasEstimate.VerificationGasLimit
+		op.CallGasLimit = gasEstimate.CallGasLimit
+		if c.applyGasLimitMultiplier(ctx, &op) {
+			span.SetAttributes(gasLimitMultiplierAttribute(c.GasLimitMultiplier))
+		}
+		span.SetAttributes(gasLimitAttributes(&op)...)
+		endSpan(span, nil)
+	} else {
+		err = c.sponsor(ctx, &op, c.paymasterContext(opts), opts.GasOverrides.speed())
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	c.warnCallGasLimit(ctx, &op)
+
+	opHash, err := c.EntryPoint.GetUserOperationHash(&op)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	op.Paymaster = make([]byte, 0)
-	op.PaymasterData = make([]byte, 0)
+	return &op, opHash, nil
+}
+
+// BuildSignedUserOperation creates a UserOperation based on the sender and callData and signs it with the client's Signer,
+// the returned UserOperation is ready for SendSignedUserOperation. Use GetUserOperationAndHashToSign and SignUserOperation
+// when the sender has a different signer.
+func (c *Client) BuildSignedUserOperation(ctx context.Context, sender common.Address, callData *[]byte) (*UserOperation, error) {
+	if err := c.verifySigner(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	op, opHash, err := c.GetUserOperationAndHashToSign(ctx, sender, callData)
+	if err != nil {
+		return nil, err
+	}
+
+	err = SignUserOperationContext(ctx, op, *opHash, c.Signer)
+	if err != nil {
+		c.ResetNonce(op.Sender, nonceKeyOf(op.Nonce))
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// logger returns the client's Logger, slog.Default() if not set
+func (c *Client) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.Default()
+	}
+	return c.Logger
+}
+
+// getNonce returns the next nonce from the NonceManager if the client manages nonces, the on-chain nonce otherwise
+func (c *Client) getNonce(ctx context.Context, sender common.Address, nonceKey *big.Int) (*big.Int, error) {
+	if c.NonceManager != nil {
+		return c.NonceManager.Next(ctx, sender, nonceKey)
+	}
+	return c.EntryPoint.GetNonceWithKey(ctx, sender, nonceKey)
+}
+
+// ResetNonce forgets the nonces handed out for sender and nonceKey, the next user operation uses the on-chain nonce.
+// Called after a failure, or to recover manually from an operation that will never be included. No-op unless the client manages nonces
+func (c *Client) ResetNonce(sender common.Address, nonceKey *big.Int) {
+	if c.NonceManager != nil {
+		c.NonceManager.Reset(sender, nonceKey)
+	}
+}
+
+// sponsor sets the paymaster data and gas limits of op returned by the paymaster for paymasterContext,
+// speed is the gas speed the fees of op come from
+func (c *Client) sponsor(ctx context.Context, op *UserOperation, paymasterContext map[string]interface{}, speed GasSpeed) error {
+	spanCtx, span := c.startSpan(ctx, SpanSponsorUserOperation)
+	if c.PaymasterConfig != nil && c.PaymasterConfig.EIP7677 {
+		cached, err := c.sponsorEIP7677(spanCtx, op, paymasterContext, speed)
+		if err == nil {
+			err = c.checkPaymasterAllowed(op)
+		}
+		if err == nil {
+			if cached {
+				span.SetAttributes(Attribute{Key: AttributeGasEstimateCached, Value: "true"})
+			}
+			if c.GasLimitMultiplier > minGasLimitMultiplier {
+				span.SetAttributes(gasLimitMultiplierAttribute(c.GasLimitMultiplier))
+			}
+			span.SetAttributes(gasLimitAttributes(op)...)
+		}
+		endSpan(span, err)
+		return err
+	}
+
+	sponsorResponse, err := c.sponsorUserOperation(spanCtx, op, paymasterContext)
+	if err != nil {
+		endSpan(span, err)
+		return err
+	}
+
+	op.Paymaster = sponsorResponse.Paymaster
+	op.PaymasterData = sponsorResponse.PaymasterData
+	if len(sponsorResponse.PaymasterAndData) >= common.AddressLength {
+		// entrypoint 0.6 paymasters return the concatenated form
+		op.Paymaster = sponsorResponse.PaymasterAndData[:common.AddressLength]
+		op.PaymasterData = sponsorResponse.PaymasterAndData[common.AddressLength:]
+	}
 	op.PreVerificationGas = sponsorResponse.PreVerificationGas
 	op.VerificationGasLimit = sponsorResponse.VerificationGasLimit
-	op.PaymasterVerificationGasLimit = big.NewInt(0)
-	op.PaymasterPostOpGasLimit = big.NewInt(0)
+	op.PaymasterVerificationGasLimit = sponsorResponse.PaymasterVerificationGasLimit
+	op.PaymasterPostOpGasLimit = sponsorResponse.PaymasterPostOpGasLimit
 	op.CallGasLimit = sponsorResponse.CallGasLimit
+	if err := c.checkPaymasterAllowed(op); err != nil {
+		endSpan(span, err)
+		return err
+	}
+	span.SetAttributes(gasLimitAttributes(op)...)
+	endSpan(span, nil)
 
-	opHash, err := c.EntryPoint.GetUserOperationHash(&op)
-	if err != nil {
-		return nil, nil, err
+	return nil
+}
+
+// sponsorUserOperation requests paymaster data in the configured paymaster mode
+func (c *Client) sponsorUserOperation(ctx context.Context, op *UserOperation, paymasterContext map[string]interface{}) (*SponsorUserOperationResponse, error) {
+	var gasTokenData *GasTokenData
+	if c.PaymasterConfig != nil && c.PaymasterConfig.Mode == PaymasterModeERC20 {
+		gasTokenData = &GasTokenData{TokenAddress: c.PaymasterConfig.Token}
 	}
 
-	return &op, opHash, nil
+	return c.PaymasterClient.sponsorUserOperation(ctx, op, gasTokenData, paymasterContext)
 }
 
 // SendSignedUserOperation sends a pre-signed user operation to the bundler.
 // Allows to create UserOperation with different sender and this sender's signature
-func (c *Client) SendSignedUserOperation(signedOp *UserOperation, waitForReceipt bool) (*UserOperationResult, error) {
-	response, err := c.BundlerClient.SendUserOperation(signedOp)
+// The operation is checked with UserOperation.Validate first, invalid operations are not sent.
+// When waiting for the receipt fails, e.g. ctx is done, the result of the sent operation is returned along with the error.
+func (c *Client) SendSignedUserOperation(ctx context.Context, signedOp *UserOperation, waitForReceipt bool) (*UserOperationResult, error) {
+	ctx, span := c.startSpan(ctx, SpanSendUserOperation, c.userOperationAttributes(signedOp.Sender)...)
+	result, err := c.sendSignedUserOperation(ctx, span, signedOp, waitForReceipt)
+	endSpan(span, err)
+	return result, err
+}
+
+// sendSignedUserOperation submits signedOp and waits for its receipt if requested, tracing both as children of parent
+func (c *Client) sendSignedUserOperation(ctx context.Context, parent Span, signedOp *UserOperation, waitForReceipt bool) (*UserOperationResult, error) {
+	if err := signedOp.Validate(); err != nil {
+		c.ResetNonce(signedOp.Sender, nonceKeyOf(signedOp.Nonce))
+		return nil, errors.Wrap(err, "invalid user operation")
+	}
+
+	var hash common.Hash
+	if c.IdempotencyCache != nil {
+		opHash, err := c.EntryPoint.GetUserOperationHash(signedOp)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get user operation hash")
+		}
+		hash = *opHash
+
+		cached, err := c.reserveUserOperation(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if cached != nil {
+			parent.SetAttributes(userOperationHashAttribute(cached.UserOperationHash))
+			return c.completeUserOperationResult(ctx, parent, hash, cached, waitForReceipt)
+		}
+	}
+
+	spanCtx, span := c.startSpan(ctx, SpanSubmitUserOperation)
+	response, err := c.BundlerClient.SendUserOperation(spanCtx, signedOp)
 	if err != nil {
+		c.recordSendError(err)
+		c.ResetNonce(signedOp.Sender, nonceKeyOf(signedOp.Nonce))
+		c.releaseUserOperation(ctx, parent, hash)
+		endSpan(span, err)
 		return nil, err
 	}
+	c.metrics().IncUserOperationsSubmitted()
+	span.SetAttributes(userOperationHashAttribute(response))
+	parent.SetAttributes(userOperationHashAttribute(response))
+	endSpan(span, nil)
+
+	sentOp := *signedOp
+	result := &UserOperationResult{
+		UserOperationHash: response,
+		EntryPoint:        c.EntryPoint.GetAddress(),
+		EntryPointVersion: c.EntryPoint.GetVersion(),
+		ChainID:           new(big.Int).Set(c.ChainID),
+		UserOperation:     &sentOp,
+	}
+	c.storeUserOperationResult(ctx, parent, hash, result)
 
-	var receipt *UserOperationReceipt
+	return c.completeUserOperationResult(ctx, parent, hash, result, waitForReceipt)
+}
 
-	if waitForReceipt {
-		receipt, _ = c.BundlerClient.GetUserOperationReceipt(response, c.ReceiptPollingDelay, c.ReceiptPollingRetries)
+// completeUserOperationResult waits for the receipt of the sent operation of result if requested and it's not known yet.
+// The operation was sent, failing to get its receipt returns result without it along with the error
+func (c *Client) completeUserOperationResult(ctx context.Context, parent Span, hash common.Hash, result *UserOperationResult, waitForReceipt bool) (*UserOperationResult, error) {
+	if !waitForReceipt || result.Receipt != nil {
+		return result, nil
 	}
 
-	return &UserOperationResult{
-		UserOperationHash: response,
-		Receipt:           receipt,
-	}, nil
+	spanCtx, span := c.startSpan(ctx, SpanWaitForUserOperationReceipt, userOperationHashAttribute(result.UserOperationHash))
+	receipt, err := c.getUserOperationReceipt(spanCtx, result.UserOperationHash)
+	endSpan(span, err)
+	if err != nil {
+		return result, errors.Wrap(err, "user operation sent, failed to get its receipt")
+	}
+
+	withReceipt := *result
+	withReceipt.setReceipt(receipt)
+	result = &withReceipt
+	c.storeUserOperationResult(ctx, parent, hash, result)
+	return result, nil
+}
+
+// reserveUserOperation returns the result stored in the IdempotencyCache for hash, or reserves hash for sending if there is none.
+// ErrUserOperationInProgress is returned if hash is reserved by a concurrent sender
+func (c *Client) reserveUserOperation(ctx context.Context, hash common.Hash) (*UserOperationResult, error) {
+	cached, err := c.IdempotencyCache.Get(ctx, hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read idempotency cache")
+	}
+	if cached != nil {
+		return cached, nil
+	}
+
+	reserved, err := c.IdempotencyCache.Reserve(ctx, hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reserve user operation in idempotency cache")
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	// a concurrent sender reserved it first, it may have stored its result since
+	cached, err = c.IdempotencyCache.Get(ctx, hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read idempotency cache")
+	}
+	if cached == nil {
+		return nil, ErrUserOperationInProgress
+	}
+	return cached, nil
+}
+
+// releaseUserOperation releases the reservation of hash after sending failed, so the operation can be sent again
+func (c *Client) releaseUserOperation(ctx context.Context, parent Span, hash common.Hash) {
+	if c.IdempotencyCache == nil {
+		return
+	}
+	if err := c.IdempotencyCache.Release(ctx, hash); err != nil {
+		parent.RecordError(errors.Wrap(err, "failed to release user operation in idempotency cache"))
+	}
+}
+
+// storeUserOperationResult stores result in the IdempotencyCache if set, the operation was sent so failing to store it doesn't fail it
+func (c *Client) storeUserOperationResult(ctx context.Context, parent Span, hash common.Hash, result *UserOperationResult) {
+	if c.IdempotencyCache == nil {
+		return
+	}
+	if err := c.IdempotencyCache.Set(ctx, hash, result); err != nil {
+		parent.RecordError(errors.Wrap(err, "failed to store user operation result in idempotency cache"))
+	}
 }
 
 // SendUserOperation creates and sends a signed user operation using the provided call data.
 // Sender of the user operation is the client's Sender and the signer is SenderSigner
-func (c *Client) SendUserOperation(callData *[]byte, waitForReceipt bool) (*UserOperationResult, error) {
-	op, opHash, err := c.GetUserOperationAndHashToSign(c.Signer.GetAddress(), callData)
+func (c *Client) SendUserOperation(ctx context.Context, callData *[]byte, waitForReceipt bool) (*UserOperationResult, error) {
+	return c.SendUserOperationWithOptions(ctx, callData, waitForReceipt, nil)
+}
+
+// SendUserOperationWithOptions works like SendUserOperation and allows to customize the UserOperation.
+func (c *Client) SendUserOperationWithOptions(ctx context.Context, callData *[]byte, waitForReceipt bool, opts *UserOperationOptions) (*UserOperationResult, error) {
+	ctx, span := c.startSpan(ctx, SpanSendUserOperation, c.userOperationAttributes(c.Signer.GetAddress())...)
+	result, err := c.sendUserOperation(ctx, span, callData, waitForReceipt, opts)
+	endSpan(span, err)
+	return result, err
+}
+
+// sendUserOperation builds, signs and sends the UserOperation of the client's sender, tracing it as children of parent
+func (c *Client) sendUserOperation(ctx context.Context, parent Span, callData *[]byte, waitForReceipt bool, opts *UserOperationOptions) (*UserOperationResult, error) {
+	if err := c.checkSignerAccount(); err != nil {
+		return nil, err
+	}
+	if err := c.verifySigner(ctx, c.Signer.GetAddress()); err != nil {
+		return nil, err
+	}
+
+	op, opHash, err := c.GetUserOperationAndHashToSignWithOptions(ctx, c.Signer.GetAddress(), callData, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = SignUserOperationContext(ctx, op, *opHash, c.Signer)
+	if err != nil {
+		c.ResetNonce(op.Sender, nonceKeyOf(op.Nonce))
+		return nil, err
+	}
+
+	return c.sendSignedUserOperation(ctx, parent, op, waitForReceipt)
+}
+
+// userOperationAttributes tags the SendUserOperation span
+func (c *Client) userOperationAttributes(sender common.Address) []Attribute {
+	return []Attribute{
+		{Key: AttributeSender, Value: sender.Hex()},
+		bigIntAttribute(AttributeChainID, c.ChainID),
+	}
+}
+
+// SendBatchUserOperation creates and sends a signed user operation executing all calls atomically.
+// Gas limits are estimated for the whole batch, so a batch costs about the sum of its calls but pays the UserOperation overhead only once.
+func (c *Client) SendBatchUserOperation(ctx context.Context, calls []BatchCall, waitForReceipt bool) (*UserOperationResult, error) {
+	callData, err := EncodeExecuteBatchCall(calls)
 	if err != nil {
 		return nil, err
 	}
 
-	signature, err := c.Signer.SignUserOperationHash(*opHash)
+	return c.SendUserOperation(ctx, callData, waitForReceipt)
+}
+
+// SendTransaction sends value wei along with data to to from the client's account, the way an EOA sends a transaction.
+// The call is wrapped in a UserOperation executing it through the account, see SendUserOperation.
+func (c *Client) SendTransaction(ctx context.Context, to common.Address, value *big.Int, data []byte, waitForReceipt bool) (*UserOperationResult, error) {
+	callData, err := EncodeExecute(to, value, data)
 	if err != nil {
 		return nil, err
 	}
 
-	op.Signature = signature
+	return c.SendUserOperation(ctx, &callData, waitForReceipt)
+}
+
+func (c *Client) GetUserOperationReceipt(ctx context.Context, result *UserOperationResult) (*UserOperationReceipt, error) {
+	return c.getUserOperationReceipt(ctx, result.UserOperationHash)
+}
+
+// WaitForUserOperationReceipt polls for the receipt until it's available or ctx is done, regardless of ReceiptPollingRetries.
+// Polling uses the configured backoff or the fixed ReceiptPollingDelay.
+func (c *Client) WaitForUserOperationReceipt(ctx context.Context, hash []byte) (*UserOperationReceipt, error) {
+	return c.waitForUserOperationReceipt(ctx, hash, 0)
+}
+
+// getUserOperationReceipt polls for the receipt at most ReceiptPollingRetries times
+func (c *Client) getUserOperationReceipt(ctx context.Context, hash []byte) (*UserOperationReceipt, error) {
+	return c.waitForUserOperationReceipt(ctx, hash, c.ReceiptPollingRetries)
+}
+
+// receiptPollingDelay returns the delay between polls, with backoff if configured, the fixed ReceiptPollingDelay otherwise,
+// randomized by ReceiptPollingJitterPercent
+func (c *Client) receiptPollingDelay() func(attempt int) time.Duration {
+	delay := fixedPollingDelay(time.Duration(c.ReceiptPollingDelay) * time.Second)
+	if c.ReceiptPollingBackoff != nil {
+		delay = c.ReceiptPollingBackoff.Delay
+	}
+
+	if c.ReceiptPollingJitterPercent > 0 {
+		return jitteredPollingDelay(delay, c.ReceiptPollingJitterPercent)
+	}
+	return delay
+}
+
+// waitForUserOperationReceipt polls for the receipt with backoff if configured, with the fixed delay otherwise
+func (c *Client) waitForUserOperationReceipt(ctx context.Context, hash []byte, maxAttempts int) (*UserOperationReceipt, error) {
+	clock := clockOrSystem(c.Clock)
+	start := clock.Now()
+	receipt, err := c.BundlerClient.waitForUserOperationReceipt(ctx, hash, maxAttempts, c.receiptPollingDelay(), clock)
+	if err != nil {
+		return nil, err
+	}
+
+	c.recordReceipt(receipt, clock.Now().Sub(start))
+	return receipt, nil
+}
+
+// runConcurrently runs fns concurrently and waits for all of them.
+// The first error cancels the context of the others and is returned.
+func runConcurrently(ctx context.Context, fns ...func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for _, fn := range fns {
+		wg.Add(1)
+		go func(fn func(ctx context.Context) error) {
+			defer wg.Done()
+			if err := fn(ctx); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(fn)
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+// ComputeAccountAddress computes the counterfactual address of the account of owner deployed with salt, it can receive funds before deployment.
+// The client's own account is derived with AccountOwner and AccountIndex.
+func (c *Client) ComputeAccountAddress(ctx context.Context, owner common.Address, salt *big.Int) (common.Address, error) {
+	if c.AccountFactory == nil {
+		return common.Address{}, errors.New("account factory is only supported with entryPointVersion " + EntryPointVersion07)
+	}
 
-	return c.SendSignedUserOperation(op, waitForReceipt)
+	return c.AccountFactory.GetAccountAddress(ctx, c.AccountClient.Client, owner, salt)
 }
 
-func (c *Client) GetUserOperationReceipt(result *UserOperationResult) (*UserOperationReceipt, error) {
-	return c.BundlerClient.GetUserOperationReceipt(result.UserOperationHash, c.ReceiptPollingDelay, c.ReceiptPollingRetries)
+// IsAccountDeployed checks whether the smart account has code deployed, see AccountClient.IsDeployed
+func (c *Client) IsAccountDeployed(ctx context.Context, account common.Address) (bool, error) {
+	return c.AccountClient.IsDeployed(ctx, account)
 }
 
+// GetSmartAccountSigner creates a signer of the account at address owned by pk. It reads the account metadata through the
+// network client of the client, with the RequestDecorator and the RetryPolicy
 func (c *Client) GetSmartAccountSigner(address common.Address, pk *ecdsa.PrivateKey) (types.AccountSigner, error) {
-	return account.NewSmartAccountPrivateKeySigner(c.RpcClients.Network, address, pk)
+	return account.NewSmartAccountPrivateKeySigner(c.AccountClient.Client, address, pk)
 }
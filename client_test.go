@@ -0,0 +1,598 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/go-zerodev/account"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient creates a client of a deployed custom sender backed by mock RPC clients with the given latency
+func newTestClient(t testing.TB, latency time.Duration) (client *Client, network *zerodevtest.MockRPCClient, paymaster *zerodevtest.MockRPCClient) {
+	chainID := big.NewInt(ChainPolygon)
+
+	network = zerodevtest.NewMockRPCClient().
+		On("eth_call", "0x0000000000000000000000000000000000000000000000000000000000000005", nil)
+	network.Latency = latency
+
+	bundler := zerodevtest.NewMockRPCClient().
+		On("zd_getUserOperationGasPrice", json.RawMessage(`{
+			"slow": {"maxFeePerGas": "0x4a817c800", "maxPriorityFeePerGas": "0x3b9aca00"},
+			"standard": {"maxFeePerGas": "0x6fc23ac00", "maxPriorityFeePerGas": "0x59682f00"},
+			"fast": {"maxFeePerGas": "0xba43b7400", "maxPriorityFeePerGas": "0x77359400"}
+		}`), nil)
+	bundler.Latency = latency
+
+	paymaster = zerodevtest.NewMockRPCClient().
+		On("zd_sponsorUserOperation", json.RawMessage(`{
+			"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633",
+			"paymasterData": "0x000000000000000000000000000000000000000000000000000000006791f7a1ababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababab",
+			"paymasterVerificationGasLimit": "0xafc8",
+			"paymasterPostOpGasLimit": "0x1",
+			"preVerificationGas": "0xc350",
+			"verificationGasLimit": "0x30d40",
+			"callGasLimit": "0x186a0"
+		}`), nil)
+	paymaster.Latency = latency
+
+	entrypoint, err := NewEntrypoint07(network, chainID)
+	require.NoError(t, err)
+
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	signer, err := account.NewSmartAccountPrivateKeySigner(network, common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), privateKey)
+	require.NoError(t, err)
+
+	return &Client{
+		Signer:          signer,
+		EntryPoint:      entrypoint,
+		PaymasterClient: &PaymasterClient{Client: paymaster, EntryPoint: entrypoint, ChainID: chainID},
+		BundlerClient:   &BundlerClient{Client: bundler, EntryPoint: entrypoint, ChainID: chainID},
+		AccountClient:   &AccountClient{Client: network},
+		ChainID:         chainID,
+	}, network, paymaster
+}
+
+func TestClient_GetUserOperationAndHashToSign(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	callData := common.FromHex("0xdeadbeef")
+
+	op, hash, err := client.GetUserOperationAndHashToSign(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData)
+	require.NoError(t, err)
+
+	assert.Equal(t, big.NewInt(5), op.Nonce)
+	assert.Equal(t, big.NewInt(30_000_000_000), op.MaxFeePerGas)
+	assert.Equal(t, "0xe361e4b3ddb22445e07c6d63862332f3313663b87dec5297c0a0ee33eac68876", hash.Hex())
+}
+
+func TestClient_GetUserOperationAndHashToSign_Nonce(t *testing.T) {
+	client, network, _ := newTestClient(t, 0)
+	sender := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	callData := common.FromHex("0xdeadbeef")
+
+	nonce := big.NewInt(5)
+	op, hash, err := client.GetUserOperationAndHashToSignWithOptions(context.Background(), sender, &callData, &UserOperationOptions{Nonce: nonce})
+	require.NoError(t, err)
+
+	// the nonce isn't fetched from the entrypoint, the operation matches the one built with the on-chain nonce
+	assert.Equal(t, 0, network.CallCount("eth_call"))
+	assert.Equal(t, nonce, op.Nonce)
+	assert.NotSame(t, nonce, op.Nonce)
+	assert.Equal(t, "0xe361e4b3ddb22445e07c6d63862332f3313663b87dec5297c0a0ee33eac68876", hash.Hex())
+
+	_, _, err = client.GetUserOperationAndHashToSignWithOptions(context.Background(), sender, &callData, &UserOperationOptions{Nonce: big.NewInt(-1)})
+	assert.EqualError(t, err, "nonce must be a non-negative 256-bit integer")
+	_, _, err = client.GetUserOperationAndHashToSignWithOptions(context.Background(), sender, &callData, &UserOperationOptions{Nonce: new(big.Int).Lsh(big.NewInt(1), 256)})
+	assert.EqualError(t, err, "nonce must be a non-negative 256-bit integer")
+}
+
+func TestClient_GetUserOperationAndHashToSign_GasPriceError(t *testing.T) {
+	client, network, paymaster := newTestClient(t, 0)
+	network.Latency = time.Minute
+
+	gasPriceErr := errors.New("gas price unavailable")
+	client.BundlerClient.Client = zerodevtest.NewMockRPCClient().On("zd_getUserOperationGasPrice", nil, gasPriceErr)
+
+	callData := common.FromHex("0xdeadbeef")
+	start := time.Now()
+
+	_, _, err := client.GetUserOperationAndHashToSign(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData)
+
+	// the pending nonce call is canceled and the gas price error is returned
+	assert.ErrorIs(t, err, gasPriceErr)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 0, paymaster.CallCount("zd_sponsorUserOperation"))
+}
+
+func TestClient_GetUserOperationAndHashToSign_GasOverrides(t *testing.T) {
+	tests := []struct {
+		name                 string
+		overrides            *GasOverrides
+		expectedMaxFee       *big.Int
+		expectedPriorityFee  *big.Int
+		expectedGasPriceCall bool
+		expectedError        bool
+	}{
+		{
+			name:                 "default_standard",
+			expectedMaxFee:       big.NewInt(30_000_000_000),
+			expectedPriorityFee:  big.NewInt(1_500_000_000),
+			expectedGasPriceCall: true,
+		},
+		{
+			name:                 "fast",
+			overrides:            &GasOverrides{Speed: GasSpeedFast},
+			expectedMaxFee:       big.NewInt(50_000_000_000),
+			expectedPriorityFee:  big.NewInt(2_000_000_000),
+			expectedGasPriceCall: true,
+		},
+		{
+			name:                 "slow_with_priority_fee",
+			overrides:            &GasOverrides{Speed: GasSpeedSlow, MaxPriorityFeePerGas: big.NewInt(100)},
+			expectedMaxFee:       big.NewInt(20_000_000_000),
+			expectedPriorityFee:  big.NewInt(100),
+			expectedGasPriceCall: true,
+		},
+		{
+			name:                "custom_fees",
+			overrides:           &GasOverrides{MaxFeePerGas: big.NewInt(2_000), MaxPriorityFeePerGas: big.NewInt(1_000)},
+			expectedMaxFee:      big.NewInt(2_000),
+			expectedPriorityFee: big.NewInt(1_000),
+		},
+		{
+			name:          "priority_fee_above_max_fee",
+			overrides:     &GasOverrides{MaxFeePerGas: big.NewInt(1_000), MaxPriorityFeePerGas: big.NewInt(2_000)},
+			expectedError: true,
+		},
+		{
+			name:                 "unknown_speed",
+			overrides:            &GasOverrides{Speed: "instant"},
+			expectedGasPriceCall: true,
+			expectedError:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, _ := newTestClient(t, 0)
+			callData := common.FromHex("0xdeadbeef")
+
+			op, _, err := client.GetUserOperationAndHashToSignWithOptions(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData, &UserOperationOptions{
+				GasOverrides: tt.overrides,
+			})
+
+			gasPriceCalls := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).CallCount("zd_getUserOperationGasPrice")
+			assert.Equal(t, tt.expectedGasPriceCall, gasPriceCalls > 0)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMaxFee, op.MaxFeePerGas)
+			assert.Equal(t, tt.expectedPriorityFee, op.MaxPriorityFeePerGas)
+		})
+	}
+}
+
+func BenchmarkClient_GetUserOperationAndHashToSign(b *testing.B) {
+	// with 5ms per call, fetching nonce and gas price concurrently saves one round trip per op
+	client, _, _ := newTestClient(b, 5*time.Millisecond)
+	callData := common.FromHex("0xdeadbeef")
+	sender := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err := client.GetUserOperationAndHashToSign(context.Background(), sender, &callData)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestClient_BuildSignedUserOperation(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	sender := client.Signer.GetAddress()
+	callData := common.FromHex("0xdeadbeef")
+
+	op, err := client.BuildSignedUserOperation(context.Background(), sender, &callData)
+	require.NoError(t, err)
+
+	hash, err := client.EntryPoint.GetUserOperationHash(op)
+	require.NoError(t, err)
+
+	expectedSignature, err := client.Signer.SignUserOperationHash(*hash)
+	require.NoError(t, err)
+	assert.Equal(t, expectedSignature, op.Signature)
+}
+
+func TestSignUserOperation(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	op := newTestUserOperation()
+	hash := common.HexToHash("0xe361e4b3ddb22445e07c6d63862332f3313663b87dec5297c0a0ee33eac68876")
+
+	err := SignUserOperation(op, hash, client.Signer)
+	require.NoError(t, err)
+
+	expectedSignature, err := client.Signer.SignUserOperationHash(hash)
+	require.NoError(t, err)
+	assert.Equal(t, expectedSignature, op.Signature)
+
+	assert.Error(t, SignUserOperation(op, hash, nil))
+}
+
+// contextSigner records the context it's called with
+type contextSigner struct {
+	types.AccountSigner
+	ctx context.Context
+}
+
+func (s *contextSigner) SignHashContext(ctx context.Context, hash common.Hash) ([]byte, error) {
+	s.ctx = ctx
+	return s.SignHash(hash)
+}
+
+func (s *contextSigner) SignUserOperationHashContext(ctx context.Context, hash common.Hash) ([]byte, error) {
+	s.ctx = ctx
+	return s.SignUserOperationHash(hash)
+}
+
+func TestSignUserOperationContext(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	signer := &contextSigner{AccountSigner: client.Signer}
+	hash := common.HexToHash("0xe361e4b3ddb22445e07c6d63862332f3313663b87dec5297c0a0ee33eac68876")
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	op := newTestUserOperation()
+	err := SignUserOperationContext(ctx, op, hash, signer)
+	require.NoError(t, err)
+
+	assert.Equal(t, ctx, signer.ctx)
+	assert.NotEmpty(t, op.Signature)
+}
+
+func TestClient_SendTransaction(t *testing.T) {
+	to := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	data := common.FromHex("0xa9059cbb")
+
+	tests := []struct {
+		name          string
+		value         *big.Int
+		expectedError string
+	}{
+		{
+			name:  "value",
+			value: big.NewInt(1_000),
+		},
+		{
+			name: "no_value",
+		},
+		{
+			name:          "negative_value",
+			value:         big.NewInt(-1),
+			expectedError: "negative call value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, _ := newTestClient(t, 0)
+			bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient)
+			bundler.On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil)
+
+			result, err := client.SendTransaction(context.Background(), to, tt.value, data, false)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Equal(t, 0, bundler.CallCount("eth_sendUserOperation"))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"), result.UserOperationHash)
+			assert.Equal(t, client.EntryPoint.GetAddress(), result.EntryPoint)
+			assert.Equal(t, client.EntryPoint.GetVersion(), result.EntryPointVersion)
+			assert.Equal(t, client.ChainID, result.ChainID)
+
+			var sent *UserOperation
+			for _, call := range bundler.Calls() {
+				if call.Method == "eth_sendUserOperation" {
+					sent = call.Args[0].(*UserOperation)
+				}
+			}
+			require.NotNil(t, sent)
+
+			expectedCallData, err := EncodeExecute(to, tt.value, data)
+			require.NoError(t, err)
+			assert.Equal(t, client.Signer.GetAddress(), sent.Sender)
+			assert.Equal(t, expectedCallData, sent.CallData)
+			assert.NotEmpty(t, sent.Signature)
+
+			// the result carries the submitted operation, persisted and restored with its paymaster data and signature
+			require.NotNil(t, result.UserOperation)
+			assert.Equal(t, *sent, *result.UserOperation)
+			assert.Equal(t, common.HexToAddress("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633").Bytes(), result.UserOperation.Paymaster)
+
+			persisted, err := json.Marshal(result)
+			require.NoError(t, err)
+			var restored UserOperationResult
+			require.NoError(t, json.Unmarshal(persisted, &restored))
+			require.NotNil(t, restored.UserOperation)
+			assert.Equal(t, sent.Signature, restored.UserOperation.Signature)
+			assert.Equal(t, sent.PaymasterData, restored.UserOperation.PaymasterData)
+			assert.Equal(t, sent.CallGasLimit, restored.UserOperation.CallGasLimit)
+		})
+	}
+}
+
+func TestClient_SendTransaction_WaitForReceipt(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.ReceiptPollingDelay = 0
+	client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil).
+		On("eth_getUserOperationReceipt", json.RawMessage(`{
+			"userOpHash": "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77",
+			"success": true,
+			"receipt": {"transactionHash": "0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d", "blockNumber": "0x3d0900"}
+		}`), nil)
+
+	result, err := client.SendTransaction(context.Background(), common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"), nil, common.FromHex("0xa9059cbb"), true)
+	require.NoError(t, err)
+
+	require.NotNil(t, result.Receipt)
+	assert.Equal(t, common.HexToHash("0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"), result.Receipt.TransactionHash)
+	assert.Equal(t, result.Receipt.TransactionHash, result.TransactionHash)
+	assert.Equal(t, big.NewInt(4_000_000), result.BlockNumber)
+}
+
+func TestClient_SendUserOperation_ReceiptError(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.ReceiptPollingDelay = 0
+	client.ReceiptPollingRetries = 1
+	client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil).
+		On("eth_getUserOperationReceipt", nil, nil)
+	callData := common.FromHex("0xdeadbeef")
+
+	// the operation was sent, its result is returned along with the receipt error
+	result, err := client.SendUserOperation(context.Background(), &callData, true)
+	assert.ErrorContains(t, err, "user operation sent, failed to get its receipt")
+	require.NotNil(t, result)
+	assert.Equal(t, common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"), result.UserOperationHash)
+	assert.Nil(t, result.Receipt)
+}
+
+func TestClient_Close(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+
+	// the test client has no connected endpoints, closing is nil-safe and idempotent
+	require.NoError(t, client.Close())
+	require.NoError(t, client.Close())
+}
+
+func TestNewClient_RequiredConfig(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		config        *ClientConfig
+		expectedError string
+	}{
+		{
+			name:          "missing_bundler_url",
+			config:        &ClientConfig{AccountPK: privateKey, EntryPointVersion: EntryPointVersion07, ChainID: big.NewInt(ChainPolygon)},
+			expectedError: "accountPK, bundlerURL and chainID are required",
+		},
+		{
+			name:          "missing_entrypoint_version",
+			config:        &ClientConfig{AccountPK: privateKey, BundlerURL: &url.URL{Scheme: "http", Host: "bundler"}, ChainID: big.NewInt(ChainPolygon)},
+			expectedError: `unsupported entryPointVersion "", supported versions are 0.6, 0.7 and 0.8`,
+		},
+		{
+			name:          "unsupported_entrypoint_version",
+			config:        &ClientConfig{AccountPK: privateKey, EntryPointVersion: "0.9", BundlerURL: &url.URL{Scheme: "http", Host: "bundler"}, ChainID: big.NewInt(ChainPolygon)},
+			expectedError: `unsupported entryPointVersion "0.9", supported versions are 0.6, 0.7 and 0.8`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.config)
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestNewClient_ClosesRPCClientsOnError(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		accountAddress common.Address
+		bundlerDialErr error
+		bundlerChainID string
+		expectedError  bool
+	}{
+		{
+			name:           "bundler_dial_fails",
+			bundlerDialErr: errors.New("connection refused"),
+			expectedError:  true,
+		},
+		{
+			name:           "signer_fails",
+			accountAddress: crypto.PubkeyToAddress(privateKey.PublicKey),
+			bundlerChainID: "0x89",
+			expectedError:  true,
+		},
+		{
+			name:           "chain_id_mismatch",
+			bundlerChainID: "0x1",
+			expectedError:  true,
+		},
+		{
+			name:           "success",
+			bundlerChainID: "0x89",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialed := map[string]*zerodevtest.MockRPCClient{}
+			dial := func(rpcURL *url.URL, _ http.Header, _ *http.Client) (types.RPCClient, error) {
+				if rpcURL.Host == "bundler" && tt.bundlerDialErr != nil {
+					return nil, tt.bundlerDialErr
+				}
+
+				mock := zerodevtest.NewMockRPCClient().On("eth_chainId", "0x89", nil)
+				if rpcURL.Host == "bundler" {
+					mock = zerodevtest.NewMockRPCClient().
+						On("eth_chainId", tt.bundlerChainID, nil).
+						On("eth_supportedEntryPoints", []string{"0x0000000071727De22E5E9d8BAf0edAc6f37da032"}, nil)
+				}
+				dialed[rpcURL.Host] = mock
+				return mock, nil
+			}
+
+			client, err := NewClient(&ClientConfig{
+				AccountAddress:    tt.accountAddress,
+				AccountPK:         privateKey,
+				EntryPointVersion: EntryPointVersion07,
+				RpcURL:            &url.URL{Scheme: "http", Host: "network"},
+				PaymasterURL:      &url.URL{Scheme: "http", Host: "paymaster"},
+				BundlerURL:        &url.URL{Scheme: "http", Host: "bundler"},
+				ChainID:           big.NewInt(ChainPolygon),
+				dial:              dial,
+			})
+
+			if tt.expectedError {
+				require.Error(t, err)
+				assert.NotEmpty(t, dialed)
+				for host, mock := range dialed {
+					assert.True(t, mock.Closed(), "%s client not closed", host)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			for host, mock := range dialed {
+				assert.False(t, mock.Closed(), "%s client closed", host)
+			}
+
+			require.NoError(t, client.Close())
+			for host, mock := range dialed {
+				assert.True(t, mock.Closed(), "%s client not closed", host)
+			}
+		})
+	}
+}
+
+// roundTripperFunc is an http.RoundTripper calling the function
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestNewClient_EndpointHeadersAndHTTPClient(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	authorizations := map[string][]string{}
+	newEndpoint := func(name string) *url.URL {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request struct {
+				ID     json.RawMessage `json:"id"`
+				Method string          `json:"method"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+			mu.Lock()
+			authorizations[name] = append(authorizations[name], r.Header.Get("Authorization"))
+			mu.Unlock()
+
+			var result interface{} = "0x89"
+			if request.Method == "eth_supportedEntryPoints" {
+				result = []string{entryPointAddress07}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			assert.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": result}))
+		}))
+		t.Cleanup(server.Close)
+
+		endpoint, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		return endpoint
+	}
+
+	var requests int
+	httpClient := &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		return http.DefaultTransport.RoundTrip(request)
+	})}
+
+	client, err := NewClient(&ClientConfig{
+		AccountPK:         privateKey,
+		EntryPointVersion: EntryPointVersion07,
+		RpcURL:            newEndpoint("network"),
+		PaymasterURL:      newEndpoint("paymaster"),
+		BundlerURL:        newEndpoint("bundler"),
+		ChainID:           big.NewInt(ChainPolygon),
+		RpcHeaders:        http.Header{"Authorization": []string{"network"}},
+		PaymasterHeaders:  http.Header{"Authorization": []string{"paymaster"}},
+		BundlerHeaders:    http.Header{"Authorization": []string{"bundler"}},
+		HTTPClient:        httpClient,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	// NewClient verified the chain ID and the entrypoint, the paymaster is called directly
+	var chainID string
+	require.NoError(t, client.RpcClients.Paymaster.CallContext(context.Background(), &chainID, "eth_chainId"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, name := range []string{"network", "paymaster", "bundler"} {
+		require.NotEmpty(t, authorizations[name], name)
+		for _, authorization := range authorizations[name] {
+			assert.Equal(t, name, authorization)
+		}
+	}
+	assert.Equal(t, len(authorizations["network"])+len(authorizations["paymaster"])+len(authorizations["bundler"]), requests)
+}
+
+func TestClient_GetSmartAccountSigner(t *testing.T) {
+	client, network, _ := newTestClient(t, 0)
+	client.AccountClient.Client = newDecoratingRPCClient(network, func(_ context.Context, method string, args []interface{}) (string, []interface{}) {
+		return "tenant1_" + method, args
+	})
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer, err := client.GetSmartAccountSigner(common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), privateKey)
+	require.NoError(t, err)
+
+	// the signer reads the account metadata through the decorated network client
+	assert.Same(t, client.AccountClient.Client, signer.(*account.SmartAccountPrivateKeySigner).Client)
+}
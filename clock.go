@@ -0,0 +1,31 @@
+package zerodev
+
+import (
+	"time"
+)
+
+// Clock is the time source of receipt polling and confirmations, tests can use a fake clock to advance time without real delays
+type Clock interface {
+	Now() time.Time
+	// After returns a channel receiving the current time once d elapsed, like time.After
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the Clock of the time package, used when no Clock is set
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// clockOrSystem returns clock, or SystemClock if it's nil
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock{}
+	}
+	return clock
+}
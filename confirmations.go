@@ -0,0 +1,54 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// ErrTransactionReorged is returned when the transaction of a receipt is no longer found on the network, e.g. after a reorg
+var ErrTransactionReorged = errors.New("transaction was reorged")
+
+// transactionReceipt is the part of a network transaction receipt used to count confirmations
+type transactionReceipt struct {
+	BlockNumber *hexutil.Big `json:"blockNumber"`
+}
+
+// WaitForConfirmations polls the network RPC until n blocks have been mined on top of the block of the receipt's transaction, or ctx is done.
+// The transaction is looked up on every poll, ErrTransactionReorged is returned if it disappears. If it was re-included in another block,
+// confirmations are counted from that block. Polling uses the delay of receipt polling.
+func (c *Client) WaitForConfirmations(ctx context.Context, receipt *UserOperationReceipt, n uint64) error {
+	if receipt == nil || receipt.TransactionHash == (common.Hash{}) {
+		return errors.New("receipt has no transaction hash")
+	}
+
+	delay := c.receiptPollingDelay()
+	clock := clockOrSystem(c.Clock)
+	for attempt := 0; ; attempt++ {
+		var blockNumber hexutil.Big
+		if err := c.AccountClient.Client.CallContext(ctx, &blockNumber, "eth_blockNumber"); err != nil {
+			return errors.Wrap(err, "failed to call eth_blockNumber")
+		}
+
+		var txReceipt *transactionReceipt
+		if err := c.AccountClient.Client.CallContext(ctx, &txReceipt, "eth_getTransactionReceipt", receipt.TransactionHash); err != nil {
+			return errors.Wrap(err, "failed to call eth_getTransactionReceipt")
+		}
+		if txReceipt == nil || txReceipt.BlockNumber == nil {
+			return errors.Wrapf(ErrTransactionReorged, "transaction %s not found", receipt.TransactionHash.Hex())
+		}
+
+		confirmed := new(big.Int).Add(txReceipt.BlockNumber.ToInt(), new(big.Int).SetUint64(n))
+		if blockNumber.ToInt().Cmp(confirmed) >= 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "timed out waiting for %d confirmations of transaction %s", n, receipt.TransactionHash.Hex())
+		case <-clock.After(delay(attempt)):
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package zerodev
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WaitForConfirmations(t *testing.T) {
+	txHash := common.HexToHash("0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d")
+
+	tests := []struct {
+		name                  string
+		blockNumbers          []string
+		txReceipts            []interface{}
+		expectedError         error
+		expectedBlockNumCalls int
+	}{
+		{
+			name:                  "already_confirmed",
+			blockNumbers:          []string{"0x70"},
+			txReceipts:            []interface{}{map[string]string{"blockNumber": "0x64"}},
+			expectedBlockNumCalls: 1,
+		},
+		{
+			name:                  "waits_for_blocks",
+			blockNumbers:          []string{"0x64", "0x66", "0x67"},
+			txReceipts:            []interface{}{map[string]string{"blockNumber": "0x64"}},
+			expectedBlockNumCalls: 3,
+		},
+		{
+			name:                  "reincluded_in_later_block",
+			blockNumbers:          []string{"0x66", "0x67", "0x69"},
+			txReceipts:            []interface{}{map[string]string{"blockNumber": "0x64"}, map[string]string{"blockNumber": "0x66"}},
+			expectedBlockNumCalls: 3,
+		},
+		{
+			name:                  "reorged",
+			blockNumbers:          []string{"0x65", "0x66"},
+			txReceipts:            []interface{}{map[string]string{"blockNumber": "0x64"}, nil},
+			expectedError:         ErrTransactionReorged,
+			expectedBlockNumCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, network, _ := newTestClient(t, 0)
+			client.ReceiptPollingDelay = 0
+			for _, blockNumber := range tt.blockNumbers {
+				network.On("eth_blockNumber", blockNumber, nil)
+			}
+			for _, txReceipt := range tt.txReceipts {
+				network.On("eth_getTransactionReceipt", txReceipt, nil)
+			}
+
+			err := client.WaitForConfirmations(context.Background(), &UserOperationReceipt{TransactionHash: txHash}, 3)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedBlockNumCalls, network.CallCount("eth_blockNumber"))
+		})
+	}
+}
+
+func TestClient_WaitForConfirmations_Timeout(t *testing.T) {
+	client, network, _ := newTestClient(t, 0)
+	network.On("eth_blockNumber", "0x64", nil).On("eth_getTransactionReceipt", map[string]string{"blockNumber": "0x64"}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.WaitForConfirmations(ctx, &UserOperationReceipt{TransactionHash: common.HexToHash("0x01")}, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.EqualError(t, client.WaitForConfirmations(context.Background(), &UserOperationReceipt{}, 1), "receipt has no transaction hash")
+}
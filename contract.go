@@ -0,0 +1,171 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"sync"
+)
+
+// EncodeContractCall encodes the call data of a UserOperation calling contract with input packed by abi.Pack, sending value wei along.
+func EncodeContractCall(contract common.Address, value *big.Int, input []byte) (*[]byte, error) {
+	callData, err := EncodeExecute(contract, value, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &callData, nil
+}
+
+// PackContractCall packs method of contractAbi with args, e.g. the ABI of an abigen binding, and encodes the call data of a UserOperation calling it on contract.
+func PackContractCall(contract common.Address, contractAbi *abi.ABI, method string, args ...interface{}) (*[]byte, error) {
+	input, err := contractAbi.Pack(method, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to pack %s call", method)
+	}
+
+	return EncodeContractCall(contract, nil, input)
+}
+
+// maxContractTransactorResults bounds the results a ContractTransactor keeps for UserOperationResult, the oldest are dropped first
+const maxContractTransactorResults = 1024
+
+// ContractTransactor is a bind.ContractTransactor sending the transactions of bound contracts as UserOperations of the client's account.
+// Use it with the options of TransactOpts, the nonce, gas and fees of the transactions are not used, the UserOperation sets its own.
+type ContractTransactor struct {
+	Client         *Client
+	WaitForReceipt bool
+
+	mu      sync.Mutex
+	nonce   uint64
+	results map[common.Hash]*UserOperationResult
+	// order is the order results were stored in, to drop the oldest
+	order []common.Hash
+}
+
+// NewContractTransactor creates a ContractTransactor of client, waitForReceipt makes transactions of bound contracts wait for the UserOperation receipt.
+func NewContractTransactor(client *Client, waitForReceipt bool) *ContractTransactor {
+	return &ContractTransactor{
+		Client:         client,
+		WaitForReceipt: waitForReceipt,
+		results:        make(map[common.Hash]*UserOperationResult),
+	}
+}
+
+// TransactOpts returns the options to transact with bound contracts from the client's account, the transactions are not signed.
+func (t *ContractTransactor) TransactOpts(ctx context.Context) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: t.Client.Signer.GetAddress(),
+		Signer: func(_ common.Address, tx *ethtypes.Transaction) (*ethtypes.Transaction, error) {
+			return tx, nil
+		},
+		Context: ctx,
+	}
+}
+
+// UserOperationResult returns the result of the UserOperation sent for the transaction of a bound contract, once.
+// Returns nil if no UserOperation was sent for txHash, its result was already returned or it was dropped,
+// only the results of the last 1024 transactions are kept.
+func (t *ContractTransactor) UserOperationResult(txHash common.Hash) *UserOperationResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := t.results[txHash]
+	delete(t.results, txHash)
+	return result
+}
+
+// SendTransaction sends the call of tx as a UserOperation of the client's account.
+func (t *ContractTransactor) SendTransaction(ctx context.Context, tx *ethtypes.Transaction) error {
+	if tx.To() == nil {
+		return errors.New("contract deployment is not supported by the account")
+	}
+
+	result, err := t.Client.SendTransaction(ctx, *tx.To(), tx.Value(), tx.Data(), t.WaitForReceipt)
+	// the UserOperation was sent if there's a result, e.g. when waiting for its receipt failed
+	if result != nil {
+		t.storeResult(tx.Hash(), result)
+	}
+
+	return err
+}
+
+// storeResult stores result for UserOperationResult, dropping the oldest results beyond maxContractTransactorResults
+func (t *ContractTransactor) storeResult(txHash common.Hash, result *UserOperationResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.results == nil {
+		t.results = make(map[common.Hash]*UserOperationResult)
+	}
+	t.results[txHash] = result
+	t.order = append(t.order, txHash)
+
+	for len(t.order) > maxContractTransactorResults {
+		delete(t.results, t.order[0])
+		t.order = t.order[1:]
+	}
+}
+
+// EstimateGas estimates the gas of the call from the account with the network RPC, reverting calls fail before a UserOperation is built.
+func (t *ContractTransactor) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	callArgs := map[string]interface{}{
+		"from": call.From,
+		"data": hexutil.Bytes(call.Data),
+	}
+	if call.To != nil {
+		callArgs["to"] = call.To
+	}
+	if call.Value != nil {
+		callArgs["value"] = (*hexutil.Big)(call.Value)
+	}
+
+	var gas hexutil.Uint64
+	if err := t.Client.AccountClient.Client.CallContext(ctx, &gas, "eth_estimateGas", callArgs); err != nil {
+		return 0, errors.Wrap(err, "failed to call eth_estimateGas")
+	}
+
+	return uint64(gas), nil
+}
+
+// PendingCodeAt returns the code of account with the network RPC, bound contracts check the contract is deployed.
+func (t *ContractTransactor) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	var code hexutil.Bytes
+	if err := t.Client.AccountClient.Client.CallContext(ctx, &code, "eth_getCode", account, "pending"); err != nil {
+		return nil, errors.Wrap(err, "failed to call eth_getCode")
+	}
+
+	return code, nil
+}
+
+// PendingNonceAt returns a nonce unique to the transactor, so every transaction of bound contracts has its own hash
+// to get its result with UserOperationResult. The nonce of the UserOperation is set from the entrypoint when it's sent.
+func (t *ContractTransactor) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nonce := t.nonce
+	t.nonce++
+	return nonce, nil
+}
+
+// HeaderByNumber returns a header with a zero base fee, bound contracts use it to pick dynamic fee transactions whose fees are not used.
+func (t *ContractTransactor) HeaderByNumber(context.Context, *big.Int) (*ethtypes.Header, error) {
+	return &ethtypes.Header{BaseFee: new(big.Int)}, nil
+}
+
+// SuggestGasPrice returns 0, the UserOperation pays the gas price suggested by the bundler.
+func (t *ContractTransactor) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return new(big.Int), nil
+}
+
+// SuggestGasTipCap returns 0, the UserOperation pays the gas price suggested by the bundler.
+func (t *ContractTransactor) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return new(big.Int), nil
+}
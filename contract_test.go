@@ -0,0 +1,126 @@
+package zerodev
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTransferABI = `[{"inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}], "name": "transfer", "outputs": [{"name": "", "type": "bool"}], "stateMutability": "nonpayable", "type": "function"}]`
+
+func TestPackContractCall(t *testing.T) {
+	token := common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359")
+	recipient := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	parsedAbi, err := abi.JSON(strings.NewReader(testTransferABI))
+	require.NoError(t, err)
+
+	callData, err := PackContractCall(token, &parsedAbi, "transfer", recipient, big.NewInt(1_000))
+	require.NoError(t, err)
+
+	input, err := parsedAbi.Pack("transfer", recipient, big.NewInt(1_000))
+	require.NoError(t, err)
+	expected, err := EncodeExecute(token, big.NewInt(0), input)
+	require.NoError(t, err)
+	assert.Equal(t, expected, *callData)
+
+	_, err = PackContractCall(token, &parsedAbi, "approve", recipient, big.NewInt(1_000))
+	assert.Error(t, err)
+}
+
+func TestContractTransactor_SendTransaction(t *testing.T) {
+	var _ bind.ContractTransactor = (*ContractTransactor)(nil)
+
+	to := common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359")
+	input := common.FromHex("0xa9059cbb")
+
+	client, _, _ := newTestClient(t, 0)
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient)
+	bundler.On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil)
+
+	transactor := NewContractTransactor(client, false)
+	opts := transactor.TransactOpts(context.Background())
+	assert.Equal(t, client.Signer.GetAddress(), opts.From)
+
+	tx := ethtypes.NewTx(&ethtypes.DynamicFeeTx{To: &to, Value: big.NewInt(7), Data: input})
+	signedTx, err := opts.Signer(opts.From, tx)
+	require.NoError(t, err)
+
+	require.NoError(t, transactor.SendTransaction(context.Background(), signedTx))
+
+	result := transactor.UserOperationResult(signedTx.Hash())
+	require.NotNil(t, result)
+	assert.Equal(t, common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"), result.UserOperationHash)
+	assert.Nil(t, transactor.UserOperationResult(signedTx.Hash()))
+
+	sent := bundler.Calls()[len(bundler.Calls())-1].Args[0].(*UserOperation)
+	expectedCallData, err := EncodeExecute(to, big.NewInt(7), input)
+	require.NoError(t, err)
+	assert.Equal(t, expectedCallData, sent.CallData)
+
+	deployment := ethtypes.NewTx(&ethtypes.DynamicFeeTx{Data: input})
+	assert.EqualError(t, transactor.SendTransaction(context.Background(), deployment), "contract deployment is not supported by the account")
+}
+
+func TestContractTransactor_IdenticalTransactions(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil).
+		On("eth_sendUserOperation", "0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d", nil)
+
+	parsedAbi, err := abi.JSON(strings.NewReader(testTransferABI))
+	require.NoError(t, err)
+
+	transactor := NewContractTransactor(client, false)
+	contract := bind.NewBoundContract(common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359"), parsedAbi, nil, transactor, nil)
+	opts := transactor.TransactOpts(context.Background())
+	opts.GasLimit = 1
+
+	// identical calls are distinct transactions, each with its own result
+	first, err := contract.Transact(opts, "transfer", common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"), big.NewInt(1_000))
+	require.NoError(t, err)
+	second, err := contract.Transact(opts, "transfer", common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"), big.NewInt(1_000))
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Hash(), second.Hash())
+
+	assert.Equal(t, common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"), transactor.UserOperationResult(first.Hash()).UserOperationHash)
+	assert.Equal(t, common.FromHex("0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"), transactor.UserOperationResult(second.Hash()).UserOperationHash)
+}
+
+func TestContractTransactor_ResultsBounded(t *testing.T) {
+	transactor := NewContractTransactor(nil, false)
+
+	for i := 0; i <= maxContractTransactorResults; i++ {
+		transactor.storeResult(common.BigToHash(big.NewInt(int64(i))), &UserOperationResult{})
+	}
+
+	// the oldest result is dropped
+	assert.Nil(t, transactor.UserOperationResult(common.BigToHash(big.NewInt(0))))
+	assert.NotNil(t, transactor.UserOperationResult(common.BigToHash(big.NewInt(1))))
+	assert.NotNil(t, transactor.UserOperationResult(common.BigToHash(big.NewInt(maxContractTransactorResults))))
+}
+
+func TestContractTransactor_EstimateGas(t *testing.T) {
+	client, network, _ := newTestClient(t, 0)
+	network.On("eth_estimateGas", "0xb411", nil)
+	transactor := NewContractTransactor(client, false)
+
+	to := common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359")
+	gas, err := transactor.EstimateGas(context.Background(), ethereum.CallMsg{From: client.Signer.GetAddress(), To: &to, Data: common.FromHex("0xa9059cbb")})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(46_097), gas)
+
+	call := network.Calls()[len(network.Calls())-1]
+	assert.Equal(t, "eth_estimateGas", call.Method)
+	assert.Equal(t, client.Signer.GetAddress(), call.Args[0].(map[string]interface{})["from"])
+}
@@ -0,0 +1,94 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"strings"
+)
+
+// CreateCallAddress is the Safe CreateCall library (v1.3.0), deployed at the same address on most chains.
+// Kernel v3 has no CREATE execution mode, the account deploys contracts by delegatecalling its performCreate.
+const CreateCallAddress = "0x7cbB62EaA69F79e6873cD1ecB2392971036cFAa4"
+
+const createCallABI = `[{"inputs": [{"name": "value", "type": "uint256"}, {"name": "deploymentData", "type": "bytes"}], "name": "performCreate", "outputs": [{"name": "newContract", "type": "address"}], "stateMutability": "nonpayable", "type": "function"}]`
+
+// contractCreationTopic is emitted by CreateCall with the address of the deployed contract, from the account as it's delegatecalled
+var contractCreationTopic = crypto.Keccak256Hash([]byte("ContractCreation(address)"))
+
+// EncodeDeployContract encodes the call data of a UserOperation deploying bytecode (creation code with its constructor arguments)
+// with CREATE from the account, through a delegatecall to the CreateCall library at createCall. value wei of the account are sent to the contract.
+func EncodeDeployContract(createCall common.Address, bytecode []byte, value *big.Int) ([]byte, error) {
+	if len(bytecode) == 0 {
+		return nil, errors.New("contract bytecode is required")
+	}
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	if value.Sign() < 0 {
+		return nil, errors.New("negative deployment value")
+	}
+
+	parsedAbi, err := abi.JSON(strings.NewReader(createCallABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse create call abi")
+	}
+
+	data, err := parsedAbi.Pack("performCreate", value, bytecode)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack performCreate call data")
+	}
+
+	return EncodeExecuteDelegateCall(createCall, data)
+}
+
+// DeployContract deploys bytecode with CREATE from the client's account, sending value wei to the contract.
+// The deployed address is parsed from the receipt logs, it's zero if waitForReceipt is false or the receipt is not known yet.
+func (c *Client) DeployContract(ctx context.Context, bytecode []byte, value *big.Int, waitForReceipt bool) (*UserOperationResult, common.Address, error) {
+	callData, err := EncodeDeployContract(common.HexToAddress(CreateCallAddress), bytecode, value)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+
+	result, err := c.SendUserOperation(ctx, &callData, waitForReceipt)
+	if err != nil {
+		return result, common.Address{}, err
+	}
+	if result.Receipt == nil {
+		return result, common.Address{}, nil
+	}
+	if !result.Receipt.Success {
+		return result, common.Address{}, errors.Errorf("contract deployment reverted: %s", result.Receipt.RevertReason)
+	}
+
+	contract, err := FindDeployedContract(c.Signer.GetAddress(), result.Receipt.Logs)
+	if err != nil {
+		return result, common.Address{}, err
+	}
+
+	return result, contract, nil
+}
+
+// FindDeployedContract returns the address of the contract account deployed through CreateCall, from the ContractCreation event in logs.
+func FindDeployedContract(account common.Address, logs []ethtypes.Log) (common.Address, error) {
+	for _, log := range logs {
+		if log.Address != account || len(log.Topics) == 0 || log.Topics[0] != contractCreationTopic {
+			continue
+		}
+
+		// the address is indexed by later versions of CreateCall
+		if len(log.Topics) > 1 {
+			return common.BytesToAddress(log.Topics[1].Bytes()), nil
+		}
+		if len(log.Data) != common.HashLength {
+			return common.Address{}, errors.Errorf("malformed ContractCreation event of %d bytes", len(log.Data))
+		}
+		return common.BytesToAddress(log.Data), nil
+	}
+
+	return common.Address{}, errors.Errorf("no ContractCreation event of account %s in the receipt logs", account.Hex())
+}
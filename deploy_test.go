@@ -0,0 +1,132 @@
+package zerodev
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testContractBytecode = "0x6080604052348015600f57600080fd5b50603f80601d6000396000f3fe6080604052600080fdfea164736f6c6343000814000a"
+
+func TestEncodeDeployContract(t *testing.T) {
+	createCall := common.HexToAddress(CreateCallAddress)
+	bytecode := common.FromHex(testContractBytecode)
+
+	callData, err := EncodeDeployContract(createCall, bytecode, big.NewInt(7))
+	require.NoError(t, err)
+
+	executeAbi, err := abi.JSON(strings.NewReader(kernelAccountExecuteABI))
+	require.NoError(t, err)
+	args, err := executeAbi.Methods["execute"].Inputs.Unpack(callData[4:])
+	require.NoError(t, err)
+
+	execMode := args[0].([32]byte)
+	assert.Equal(t, kernelCallTypeDelegateCall, execMode[0])
+
+	createCallAbi, err := abi.JSON(strings.NewReader(createCallABI))
+	require.NoError(t, err)
+	performCreate, err := createCallAbi.Pack("performCreate", big.NewInt(7), bytecode)
+	require.NoError(t, err)
+	assert.Equal(t, append(createCall.Bytes(), performCreate...), args[1].([]byte))
+
+	_, err = EncodeDeployContract(createCall, nil, nil)
+	assert.EqualError(t, err, "contract bytecode is required")
+	_, err = EncodeDeployContract(createCall, bytecode, big.NewInt(-1))
+	assert.EqualError(t, err, "negative deployment value")
+}
+
+func TestFindDeployedContract(t *testing.T) {
+	account := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	contract := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	tests := []struct {
+		name             string
+		logs             []ethtypes.Log
+		expectedContract common.Address
+		expectedError    string
+	}{
+		{
+			name:             "data",
+			logs:             []ethtypes.Log{{Address: account, Topics: []common.Hash{contractCreationTopic}, Data: common.LeftPadBytes(contract.Bytes(), 32)}},
+			expectedContract: contract,
+		},
+		{
+			name:             "indexed",
+			logs:             []ethtypes.Log{{Address: account, Topics: []common.Hash{contractCreationTopic, common.BytesToHash(contract.Bytes())}}},
+			expectedContract: contract,
+		},
+		{
+			name: "other_account",
+			logs: []ethtypes.Log{
+				{Address: common.HexToAddress("0x01"), Topics: []common.Hash{contractCreationTopic}, Data: common.LeftPadBytes(common.HexToAddress("0x02").Bytes(), 32)},
+				{Address: account, Topics: []common.Hash{contractCreationTopic}, Data: common.LeftPadBytes(contract.Bytes(), 32)},
+			},
+			expectedContract: contract,
+		},
+		{
+			name:          "missing",
+			logs:          []ethtypes.Log{{Address: account, Topics: []common.Hash{userOperationEventTopic}}},
+			expectedError: "no ContractCreation event of account",
+		},
+		{
+			name:          "malformed",
+			logs:          []ethtypes.Log{{Address: account, Topics: []common.Hash{contractCreationTopic}, Data: []byte{0x01}}},
+			expectedError: "malformed ContractCreation event of 1 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployed, err := FindDeployedContract(account, tt.logs)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedContract, deployed)
+		})
+	}
+}
+
+func TestClient_DeployContract(t *testing.T) {
+	contract := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	userOpHash := hexutil.Bytes(common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"))
+
+	client, _, _ := newTestClient(t, 0)
+	client.ReceiptPollingDelay = 0
+	receipt := GetUserOperationReceiptResponse{UserOpHash: &userOpHash, Success: true}
+	receipt.Receipt.Logs = []ethtypes.Log{{
+		Address: client.Signer.GetAddress(),
+		Topics:  []common.Hash{contractCreationTopic},
+		Data:    common.LeftPadBytes(contract.Bytes(), 32),
+	}}
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_sendUserOperation", userOpHash.String(), nil).
+		On("eth_getUserOperationReceipt", receipt, nil)
+
+	result, deployed, err := client.DeployContract(context.Background(), common.FromHex(testContractBytecode), nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, contract, deployed)
+	assert.Equal(t, []byte(userOpHash), result.UserOperationHash)
+
+	expectedCallData, err := EncodeDeployContract(common.HexToAddress(CreateCallAddress), common.FromHex(testContractBytecode), nil)
+	require.NoError(t, err)
+	assert.Equal(t, expectedCallData, result.UserOperation.CallData)
+	assert.Equal(t, 1, bundler.CallCount("eth_sendUserOperation"))
+
+	// without the receipt the address is not known
+	_, deployed, err = client.DeployContract(context.Background(), common.FromHex(testContractBytecode), nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, common.Address{}, deployed)
+}
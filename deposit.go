@@ -0,0 +1,72 @@
+package zerodev
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"strings"
+)
+
+// DepositInfo is the deposit and stake of an account on the entrypoint, self-funded UserOperations are paid from the deposit
+type DepositInfo struct {
+	Deposit         *big.Int
+	Staked          bool
+	Stake           *big.Int
+	UnstakeDelaySec uint32
+	// WithdrawTime is the unix time the stake can be withdrawn at after unlocking, 0 if it's locked
+	WithdrawTime uint64
+}
+
+// depositInfo is the DepositInfo struct returned by getDepositInfo
+type depositInfo struct {
+	Deposit         *big.Int
+	Staked          bool
+	Stake           *big.Int
+	UnstakeDelaySec uint32
+	WithdrawTime    *big.Int
+}
+
+// getDepositInfo calls getDepositInfo on the entrypoint contract, the ABI of the call is the same for all supported versions
+func getDepositInfo(ctx context.Context, client types.RPCClient, entrypointAbi *abi.ABI, entrypoint common.Address, account common.Address) (*DepositInfo, error) {
+	unpacked, err := callEntryPoint(ctx, client, entrypointAbi, entrypoint, "getDepositInfo", account)
+	if err != nil {
+		return nil, err
+	}
+
+	info := *abi.ConvertType(unpacked[0], new(depositInfo)).(*depositInfo)
+
+	return &DepositInfo{
+		Deposit:         info.Deposit,
+		Staked:          info.Staked,
+		Stake:           info.Stake,
+		UnstakeDelaySec: info.UnstakeDelaySec,
+		WithdrawTime:    info.WithdrawTime.Uint64(),
+	}, nil
+}
+
+// DepositTo adds amount wei to the entrypoint deposit of account, which pays for its UserOperations without a paymaster.
+// The transaction is signed by from, which pays the amount and the gas, and sent through the network RPC. Returns the transaction hash.
+func (c *Client) DepositTo(ctx context.Context, account common.Address, amount *big.Int, from *ecdsa.PrivateKey) (common.Hash, error) {
+	if from == nil {
+		return common.Hash{}, errors.New("from is required")
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return common.Hash{}, errors.New("deposit amount must be positive")
+	}
+
+	entrypointAbi, err := abi.JSON(strings.NewReader(entrypointAbi07))
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to parse entrypoint abi")
+	}
+
+	data, err := entrypointAbi.Pack("depositTo", account)
+	if err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to pack depositTo call data")
+	}
+
+	return c.sendEntryPointTransaction(ctx, "depositTo", data, amount, from)
+}
@@ -0,0 +1,127 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntrypoint_GetDepositInfo(t *testing.T) {
+	account := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	result := "0x" +
+		"00000000000000000000000000000000000000000000000000038d7ea4c68000" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000de0b6b3a7640000" +
+		"0000000000000000000000000000000000000000000000000000000000015180" +
+		"0000000000000000000000000000000000000000000000000000000067b3f4c0"
+
+	for _, version := range []string{EntryPointVersion06, EntryPointVersion07, EntryPointVersion08} {
+		t.Run(version, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient().On("eth_call", result, nil)
+			entrypoint, err := newEntrypoint(version, mock, big.NewInt(ChainPolygon), common.Address{})
+			require.NoError(t, err)
+
+			info, err := entrypoint.(EntrypointDepositReader).GetDepositInfo(context.Background(), account)
+			require.NoError(t, err)
+			assert.Equal(t, &DepositInfo{
+				Deposit:         big.NewInt(1_000_000_000_000_000),
+				Staked:          true,
+				Stake:           big.NewInt(1_000_000_000_000_000_000),
+				UnstakeDelaySec: 86_400,
+				WithdrawTime:    0x67b3f4c0,
+			}, info)
+
+			var call struct {
+				Data hexutil.Bytes `json:"data"`
+			}
+			encoded, err := json.Marshal(mock.Calls()[0].Args[0])
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(encoded, &call))
+			assert.Equal(t, append(crypto.Keccak256([]byte("getDepositInfo(address)"))[:4], common.LeftPadBytes(account.Bytes(), 32)...), []byte(call.Data))
+		})
+	}
+
+	entrypoint, err := NewEntrypoint07(zerodevtest.NewMockRPCClient().On("eth_call", "0x", nil), big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	_, err = entrypoint.GetDepositInfo(context.Background(), account)
+	assert.EqualError(t, err, "getDepositInfo returned no data, no entrypoint deployed at "+entrypoint.Address.Hex())
+}
+
+func TestClient_DepositTo(t *testing.T) {
+	account := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	client, network, _ := newTestClient(t, 0)
+	network.On("eth_getTransactionCount", "0x7", nil).
+		On("eth_estimateGas", nil, &mockJSONRPCError{code: 3, message: "execution reverted"})
+
+	_, err = client.DepositTo(context.Background(), account, big.NewInt(1_000), privateKey)
+	assert.ErrorContains(t, err, "failed to estimate depositTo gas")
+
+	estimate := network.Calls()[len(network.Calls())-1]
+	require.Equal(t, "eth_estimateGas", estimate.Method)
+	callArgs := estimate.Args[0].(map[string]interface{})
+	assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), callArgs["from"])
+	assert.Equal(t, client.EntryPoint.GetAddress(), callArgs["to"])
+	assert.Equal(t, (*hexutil.Big)(big.NewInt(1_000)), callArgs["value"])
+	assert.Equal(t, hexutil.Bytes(append(crypto.Keccak256([]byte("depositTo(address)"))[:4], common.LeftPadBytes(account.Bytes(), 32)...)), callArgs["data"])
+
+	_, err = client.DepositTo(context.Background(), account, big.NewInt(0), privateKey)
+	assert.EqualError(t, err, "deposit amount must be positive")
+	_, err = client.DepositTo(context.Background(), account, big.NewInt(1_000), nil)
+	assert.EqualError(t, err, "from is required")
+}
+
+func TestClient_DepositTo_Send(t *testing.T) {
+	account := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+	txHash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+
+	client, network, _ := newTestClient(t, 0)
+	network.On("eth_getTransactionCount", "0x7", nil).
+		On("eth_estimateGas", "0x186a0", nil).
+		On("eth_maxPriorityFeePerGas", "0x5f5e100", nil).
+		On("eth_getBlockByNumber", json.RawMessage(`{"baseFeePerGas": "0x3b9aca00"}`), nil).
+		On("eth_sendRawTransaction", txHash, nil)
+
+	hash, err := client.DepositTo(context.Background(), account, big.NewInt(1_000), privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, txHash, hash)
+
+	tx := decodeSentTransaction(t, network)
+	assert.Equal(t, client.EntryPoint.GetAddress(), *tx.To())
+	assert.Equal(t, big.NewInt(1_000), tx.Value())
+	assert.Equal(t, append(crypto.Keccak256([]byte("depositTo(address)"))[:4], common.LeftPadBytes(account.Bytes(), 32)...), tx.Data())
+	assert.Equal(t, uint64(7), tx.Nonce())
+	assert.Equal(t, uint64(100_000), tx.Gas())
+	assert.Equal(t, uint8(ethtypes.DynamicFeeTxType), tx.Type())
+	assert.Equal(t, big.NewInt(100_000_000), tx.GasTipCap())
+	assert.Equal(t, big.NewInt(2_100_000_000), tx.GasFeeCap())
+	assert.Equal(t, client.ChainID, tx.ChainId())
+
+	sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(client.ChainID), tx)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), sender)
+}
+
+// decodeSentTransaction decodes the raw transaction of the last eth_sendRawTransaction call to network
+func decodeSentTransaction(t *testing.T, network *zerodevtest.MockRPCClient) *ethtypes.Transaction {
+	calls := network.Calls()
+	send := calls[len(calls)-1]
+	require.Equal(t, "eth_sendRawTransaction", send.Method)
+
+	tx := new(ethtypes.Transaction)
+	require.NoError(t, tx.UnmarshalBinary(send.Args[0].(hexutil.Bytes)))
+	return tx
+}
@@ -0,0 +1,159 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// PaymasterSponsor is the optional sponsor information returned by EIP-7677 paymasters
+type PaymasterSponsor struct {
+	Name string `json:"name"`
+	Icon string `json:"icon,omitempty"`
+}
+
+// PaymasterDataResponse is the result of the EIP-7677 pm_getPaymasterStubData and pm_getPaymasterData calls.
+// Entrypoint 0.6 paymasters return PaymasterAndData, newer ones Paymaster and PaymasterData.
+// Gas limits, IsFinal and Sponsor are only returned with stub data.
+type PaymasterDataResponse struct {
+	Paymaster                     []byte
+	PaymasterData                 []byte
+	PaymasterAndData              []byte
+	PaymasterVerificationGasLimit *big.Int
+	PaymasterPostOpGasLimit       *big.Int
+	// IsFinal reports that the stub data is the final paymaster data, pm_getPaymasterData is not needed
+	IsFinal bool
+	Sponsor *PaymasterSponsor
+}
+
+type PaymasterDataResponseHex struct {
+	Paymaster                     string            `json:"paymaster,omitempty"`
+	PaymasterData                 string            `json:"paymasterData,omitempty"`
+	PaymasterAndData              string            `json:"paymasterAndData,omitempty"`
+	PaymasterVerificationGasLimit string            `json:"paymasterVerificationGasLimit,omitempty"`
+	PaymasterPostOpGasLimit       string            `json:"paymasterPostOpGasLimit,omitempty"`
+	IsFinal                       bool              `json:"isFinal,omitempty"`
+	Sponsor                       *PaymasterSponsor `json:"sponsor,omitempty"`
+}
+
+func (r *PaymasterDataResponse) UnmarshalJSON(b []byte) error {
+	var unmarshal PaymasterDataResponseHex
+	err := json.Unmarshal(b, &unmarshal)
+	if err != nil {
+		return err
+	}
+
+	*r = PaymasterDataResponse{
+		Paymaster:        common.FromHex(unmarshal.Paymaster),
+		PaymasterData:    common.FromHex(unmarshal.PaymasterData),
+		PaymasterAndData: common.FromHex(unmarshal.PaymasterAndData),
+		IsFinal:          unmarshal.IsFinal,
+		Sponsor:          unmarshal.Sponsor,
+	}
+
+	r.PaymasterVerificationGasLimit, err = decodeBigInt(unmarshal.PaymasterVerificationGasLimit)
+	if err != nil {
+		return err
+	}
+
+	r.PaymasterPostOpGasLimit, err = decodeBigInt(unmarshal.PaymasterPostOpGasLimit)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// apply sets the paymaster fields of op, and the paymaster gas limits if returned
+func (r *PaymasterDataResponse) apply(op *UserOperation) {
+	op.Paymaster = r.Paymaster
+	op.PaymasterData = r.PaymasterData
+	if len(r.PaymasterAndData) >= common.AddressLength {
+		op.Paymaster = r.PaymasterAndData[:common.AddressLength]
+		op.PaymasterData = r.PaymasterAndData[common.AddressLength:]
+	}
+
+	if r.PaymasterVerificationGasLimit != nil {
+		op.PaymasterVerificationGasLimit = r.PaymasterVerificationGasLimit
+	}
+	if r.PaymasterPostOpGasLimit != nil {
+		op.PaymasterPostOpGasLimit = r.PaymasterPostOpGasLimit
+	}
+}
+
+// GetPaymasterStubData calls the EIP-7677 pm_getPaymasterStubData, returning paymaster data suitable for gas estimation.
+// paymasterContext is the paymaster specific context, e.g. the ERC-20 token, it can be nil.
+func (p *PaymasterClient) GetPaymasterStubData(ctx context.Context, op *UserOperation, paymasterContext map[string]interface{}) (*PaymasterDataResponse, error) {
+	return p.getPaymasterData(ctx, "pm_getPaymasterStubData", op, paymasterContext)
+}
+
+// GetPaymasterData calls the EIP-7677 pm_getPaymasterData, returning the final paymaster data of an op with its gas limits set.
+func (p *PaymasterClient) GetPaymasterData(ctx context.Context, op *UserOperation, paymasterContext map[string]interface{}) (*PaymasterDataResponse, error) {
+	return p.getPaymasterData(ctx, "pm_getPaymasterData", op, paymasterContext)
+}
+
+func (p *PaymasterClient) getPaymasterData(ctx context.Context, method string, op *UserOperation, paymasterContext map[string]interface{}) (*PaymasterDataResponse, error) {
+	if paymasterContext == nil {
+		paymasterContext = map[string]interface{}{}
+	}
+
+	var response PaymasterDataResponse
+
+	err := p.Client.CallContext(ctx, &response, method, toRPCUserOperation(op, p.EntryPoint.GetVersion()), p.EntryPoint.GetAddress(), hexutil.EncodeBig(p.ChainID), paymasterContext)
+	if err != nil {
+		return nil, errors.Wrap(newPaymasterError(err), "failed to call "+method)
+	}
+
+	return &response, nil
+}
+
+// sponsorEIP7677 sponsors op with the EIP-7677 flow: the gas limits are estimated by the bundler with the paymaster stub data,
+// or reused from the GasEstimateCache, then the final paymaster data is requested, unless the stub data is already final.
+// Reports whether a cached estimate was used
+func (c *Client) sponsorEIP7677(ctx context.Context, op *UserOperation, paymasterContext map[string]interface{}, speed GasSpeed) (bool, error) {
+	if c.PaymasterConfig.Mode == PaymasterModeERC20 {
+		configContext := paymasterContext
+		paymasterContext = map[string]interface{}{"token": c.PaymasterConfig.Token}
+		for key, value := range configContext {
+			paymasterContext[key] = value
+		}
+	}
+
+	stubData, err := c.PaymasterClient.GetPaymasterStubData(ctx, op, paymasterContext)
+	if err != nil {
+		return false, err
+	}
+	stubData.apply(op)
+
+	gasEstimate, cached, err := c.estimateUserOperationGas(ctx, op, speed)
+	if err != nil {
+		return false, err
+	}
+
+	op.PreVerificationGas = gasEstimate.PreVerificationGas
+	op.VerificationGasLimit = gasEstimate.VerificationGasLimit
+	op.CallGasLimit = gasEstimate.CallGasLimit
+	if gasEstimate.PaymasterVerificationGasLimit != nil && gasEstimate.PaymasterVerificationGasLimit.Sign() > 0 {
+		op.PaymasterVerificationGasLimit = gasEstimate.PaymasterVerificationGasLimit
+	}
+	if gasEstimate.PaymasterPostOpGasLimit != nil && gasEstimate.PaymasterPostOpGasLimit.Sign() > 0 {
+		op.PaymasterPostOpGasLimit = gasEstimate.PaymasterPostOpGasLimit
+	}
+	// the paymaster already signed final stub data, the limits are only raised before it signs the final data
+	if stubData.IsFinal {
+		return cached, nil
+	}
+	c.applyGasLimitMultiplier(ctx, op)
+	c.applyPaymasterGasLimitFloors(op)
+
+	paymasterData, err := c.PaymasterClient.GetPaymasterData(ctx, op, paymasterContext)
+	if err != nil {
+		return false, err
+	}
+	paymasterData.apply(op)
+
+	return cached, nil
+}
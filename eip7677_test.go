@@ -0,0 +1,145 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymasterClient_GetPaymasterStubData(t *testing.T) {
+	entrypoint07, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	entrypoint06, err := NewEntrypoint06(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                  string
+		entrypoint            Entrypoint
+		response              json.RawMessage
+		expectedPaymasterData []byte
+		expectedVerification  *big.Int
+		expectedFinal         bool
+	}{
+		{
+			name:       "entrypoint_07",
+			entrypoint: entrypoint07,
+			response: json.RawMessage(`{
+				"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633",
+				"paymasterData": "0xabab",
+				"paymasterVerificationGasLimit": "0xafc8",
+				"paymasterPostOpGasLimit": "0x1",
+				"sponsor": {"name": "DIMO"}
+			}`),
+			expectedPaymasterData: common.FromHex("0xabab"),
+			expectedVerification:  big.NewInt(45_000),
+		},
+		{
+			name:                  "entrypoint_06_final",
+			entrypoint:            entrypoint06,
+			response:              json.RawMessage(`{"paymasterAndData": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633cdcd", "isFinal": true}`),
+			expectedPaymasterData: common.FromHex("0xcdcd"),
+			expectedFinal:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient().On("pm_getPaymasterStubData", tt.response, nil)
+			paymaster, err := NewPaymasterClient(mock, tt.entrypoint, big.NewInt(ChainPolygon))
+			require.NoError(t, err)
+
+			stubData, err := paymaster.GetPaymasterStubData(context.Background(), newTestUserOperation(), map[string]interface{}{"sponsorshipPolicyId": "sp_1"})
+			require.NoError(t, err)
+
+			args := mock.Calls()[0].Args
+			require.Len(t, args, 4)
+			assert.Equal(t, tt.entrypoint.GetAddress(), args[1])
+			assert.Equal(t, "0x89", args[2])
+			assert.Equal(t, map[string]interface{}{"sponsorshipPolicyId": "sp_1"}, args[3])
+
+			op := newTestUserOperation()
+			stubData.apply(op)
+
+			assert.Equal(t, common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633"), op.Paymaster)
+			assert.Equal(t, tt.expectedPaymasterData, op.PaymasterData)
+			assert.Equal(t, tt.expectedVerification, op.PaymasterVerificationGasLimit)
+			assert.Equal(t, tt.expectedFinal, stubData.IsFinal)
+		})
+	}
+}
+
+func TestClient_SponsorEIP7677(t *testing.T) {
+	tests := []struct {
+		name                  string
+		paymasterConfig       *PaymasterConfig
+		stubData              json.RawMessage
+		expectedPaymasterData []byte
+		expectedDataCall      bool
+		expectedContext       map[string]interface{}
+	}{
+		{
+			name:            "stub_then_final",
+			paymasterConfig: &PaymasterConfig{Mode: PaymasterModeSponsored, EIP7677: true},
+			stubData: json.RawMessage(`{
+				"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633",
+				"paymasterData": "0x00",
+				"paymasterVerificationGasLimit": "0xafc8",
+				"paymasterPostOpGasLimit": "0x1"
+			}`),
+			expectedPaymasterData: common.FromHex("0xabab"),
+			expectedDataCall:      true,
+			expectedContext:       map[string]interface{}{},
+		},
+		{
+			name:                  "final_stub",
+			paymasterConfig:       &PaymasterConfig{Mode: PaymasterModeSponsored, EIP7677: true, Context: map[string]interface{}{"sponsorshipPolicyId": "sp_1"}},
+			stubData:              json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0xcdcd", "paymasterVerificationGasLimit": "0xafc8", "paymasterPostOpGasLimit": "0x1", "isFinal": true}`),
+			expectedPaymasterData: common.FromHex("0xcdcd"),
+			expectedContext:       map[string]interface{}{"sponsorshipPolicyId": "sp_1"},
+		},
+		{
+			name:                  "erc20",
+			paymasterConfig:       &PaymasterConfig{Mode: PaymasterModeERC20, Token: common.HexToAddress("0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174"), EIP7677: true},
+			stubData:              json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0x00", "paymasterVerificationGasLimit": "0xafc8", "paymasterPostOpGasLimit": "0x1"}`),
+			expectedPaymasterData: common.FromHex("0xabab"),
+			expectedDataCall:      true,
+			expectedContext:       map[string]interface{}{"token": common.HexToAddress("0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, paymaster := newTestClient(t, 0)
+			client.PaymasterConfig = tt.paymasterConfig
+			paymaster.
+				On("pm_getPaymasterStubData", tt.stubData, nil).
+				On("pm_getPaymasterData", json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0xabab"}`), nil)
+			client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+				On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x186a0", "paymasterVerificationGasLimit": "0xc350"}`), nil)
+
+			callData := common.FromHex("0xdeadbeef")
+			op, _, err := client.GetUserOperationAndHashToSign(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData)
+			require.NoError(t, err)
+
+			assert.Equal(t, common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633"), op.Paymaster)
+			assert.Equal(t, tt.expectedPaymasterData, op.PaymasterData)
+			assert.Equal(t, big.NewInt(50_000), op.PreVerificationGas)
+			assert.Equal(t, big.NewInt(200_000), op.VerificationGasLimit)
+			assert.Equal(t, big.NewInt(100_000), op.CallGasLimit)
+			// the bundler estimate replaces the stub paymaster verification gas limit
+			assert.Equal(t, big.NewInt(50_000), op.PaymasterVerificationGasLimit)
+			assert.Equal(t, big.NewInt(1), op.PaymasterPostOpGasLimit)
+
+			assert.Equal(t, 0, paymaster.CallCount("zd_sponsorUserOperation"))
+			assert.Equal(t, 1, paymaster.CallCount("pm_getPaymasterStubData"))
+			assert.Equal(t, tt.expectedDataCall, paymaster.CallCount("pm_getPaymasterData") == 1)
+			assert.Equal(t, tt.expectedContext, paymaster.Calls()[0].Args[3])
+		})
+	}
+}
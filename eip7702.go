@@ -0,0 +1,198 @@
+package zerodev
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// EIP7702MarkerAddress is the factory of UserOperations of EIP-7702 delegated accounts, Entrypoint 0.8 hashes
+// the delegate of the sender in its place
+const EIP7702MarkerAddress = "0x7702000000000000000000000000000000000000"
+
+// eip7702AuthorizationMagic prefixes the RLP encoded authorization signed by the EOA
+const eip7702AuthorizationMagic = 0x05
+
+// eip7702DelegationPrefix prefixes the delegate address in the code of a delegated EOA
+var eip7702DelegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// EIP7702Authorization is a signed EIP-7702 authorization delegating the code of the EOA signing it to Address
+type EIP7702Authorization struct {
+	ChainID *big.Int
+	Address common.Address
+	Nonce   uint64
+	YParity uint8
+	R       *big.Int
+	S       *big.Int
+}
+
+type eip7702AuthorizationHex struct {
+	ChainID *hexutil.Big   `json:"chainId"`
+	Address common.Address `json:"address"`
+	Nonce   hexutil.Uint64 `json:"nonce"`
+	YParity hexutil.Uint64 `json:"yParity"`
+	R       *hexutil.Big   `json:"r"`
+	S       *hexutil.Big   `json:"s"`
+}
+
+// MarshalJSON encodes the authorization as the eip7702Auth field of UserOperations expected by bundlers
+func (a *EIP7702Authorization) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&eip7702AuthorizationHex{
+		ChainID: (*hexutil.Big)(zeroIfNil(a.ChainID)),
+		Address: a.Address,
+		Nonce:   hexutil.Uint64(a.Nonce),
+		YParity: hexutil.Uint64(a.YParity),
+		R:       (*hexutil.Big)(zeroIfNil(a.R)),
+		S:       (*hexutil.Big)(zeroIfNil(a.S)),
+	})
+}
+
+// UnmarshalJSON decodes an authorization encoded by MarshalJSON
+func (a *EIP7702Authorization) UnmarshalJSON(b []byte) error {
+	var hexAuth eip7702AuthorizationHex
+	if err := json.Unmarshal(b, &hexAuth); err != nil {
+		return err
+	}
+	if hexAuth.YParity > 1 {
+		return errors.Errorf("invalid eip7702Auth yParity %d", uint64(hexAuth.YParity))
+	}
+
+	a.ChainID = hexAuth.ChainID.ToInt()
+	a.Address = hexAuth.Address
+	a.Nonce = uint64(hexAuth.Nonce)
+	a.YParity = uint8(hexAuth.YParity)
+	a.R = hexAuth.R.ToInt()
+	a.S = hexAuth.S.ToInt()
+	return nil
+}
+
+// Authority recovers the address of the EOA which signed the authorization
+func (a *EIP7702Authorization) Authority() (common.Address, error) {
+	if a.R == nil || a.S == nil || a.YParity > 1 {
+		return common.Address{}, errors.New("authorization is not signed")
+	}
+
+	signature := make([]byte, crypto.SignatureLength)
+	a.R.FillBytes(signature[:32])
+	a.S.FillBytes(signature[32:64])
+	signature[64] = a.YParity
+
+	hash := EIP7702AuthorizationHash(a.ChainID, a.Address, a.Nonce)
+	publicKey, err := crypto.SigToPub(hash.Bytes(), signature)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "failed to recover authorization signer")
+	}
+
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// EIP7702AuthorizationHash returns the hash the EOA signs to delegate its code to delegate, keccak256(0x05 || rlp([chainId, address, nonce])).
+// Chain ID 0 authorizes the delegation on all chains.
+func EIP7702AuthorizationHash(chainID *big.Int, delegate common.Address, nonce uint64) common.Hash {
+	payload := rlpEncodeList(
+		rlpEncodeBytes(zeroIfNil(chainID).Bytes()),
+		rlpEncodeBytes(delegate.Bytes()),
+		rlpEncodeBytes(new(big.Int).SetUint64(nonce).Bytes()),
+	)
+
+	return crypto.Keccak256Hash([]byte{eip7702AuthorizationMagic}, payload)
+}
+
+// SignEIP7702Authorization signs the authorization delegating the EOA of privateKey to delegate, nonce is the EOA's transaction nonce
+func SignEIP7702Authorization(privateKey *ecdsa.PrivateKey, chainID *big.Int, delegate common.Address, nonce uint64) (*EIP7702Authorization, error) {
+	hash := EIP7702AuthorizationHash(chainID, delegate, nonce)
+	signature, err := crypto.Sign(hash.Bytes(), privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign eip-7702 authorization")
+	}
+
+	return &EIP7702Authorization{
+		ChainID: new(big.Int).Set(zeroIfNil(chainID)),
+		Address: delegate,
+		Nonce:   nonce,
+		YParity: signature[64],
+		R:       new(big.Int).SetBytes(signature[:32]),
+		S:       new(big.Int).SetBytes(signature[32:64]),
+	}, nil
+}
+
+// SetEIP7702Authorization makes op delegate its sender with auth, the factory is set to EIP7702MarkerAddress without factory data
+func (op *UserOperation) SetEIP7702Authorization(auth *EIP7702Authorization) {
+	op.EIP7702Auth = auth
+	op.Factory = common.HexToAddress(EIP7702MarkerAddress)
+	op.FactoryData = nil
+}
+
+// isEIP7702 reports whether op's factory is the EIP-7702 marker, i.e. its sender is a delegated EOA
+func (op *UserOperation) isEIP7702() bool {
+	return op.Factory == common.HexToAddress(EIP7702MarkerAddress)
+}
+
+// EIP7702Account is the EOA of the client's signer acting as a Kernel account through EIP-7702 delegation to Delegate.
+// The first UserOperation carries the authorization signed by SignAuthorization, once the EOA is delegated it's not needed anymore.
+type EIP7702Account struct {
+	Delegate common.Address
+	// SignAuthorization signs the authorization delegating the EOA to delegate with its transaction nonce
+	SignAuthorization func(ctx context.Context, chainID *big.Int, delegate common.Address, nonce uint64) (*EIP7702Authorization, error)
+}
+
+// NewEIP7702Account creates an EIP7702Account of the EOA of privateKey delegating to the Kernel implementation delegate
+func NewEIP7702Account(privateKey *ecdsa.PrivateKey, delegate common.Address) (*EIP7702Account, error) {
+	if privateKey == nil || delegate == (common.Address{}) {
+		return nil, errors.New("privateKey and delegate are required")
+	}
+
+	return &EIP7702Account{
+		Delegate: delegate,
+		SignAuthorization: func(_ context.Context, chainID *big.Int, delegate common.Address, nonce uint64) (*EIP7702Authorization, error) {
+			return SignEIP7702Authorization(privateKey, chainID, delegate, nonce)
+		},
+	}, nil
+}
+
+// eip7702Authorization returns the signed authorization the UserOperation of the EOA has to carry, nil if it's already delegated to the delegate
+func (c *Client) eip7702Authorization(ctx context.Context, eoa common.Address) (*EIP7702Authorization, error) {
+	var code hexutil.Bytes
+	if err := c.AccountClient.Client.CallContext(ctx, &code, "eth_getCode", eoa, "latest"); err != nil {
+		return nil, errors.Wrap(err, "failed to call eth_getCode")
+	}
+	if bytes.Equal(code, append(append([]byte{}, eip7702DelegationPrefix...), c.EIP7702.Delegate.Bytes()...)) {
+		return nil, nil
+	}
+
+	var nonce hexutil.Uint64
+	if err := c.AccountClient.Client.CallContext(ctx, &nonce, "eth_getTransactionCount", eoa, "pending"); err != nil {
+		return nil, errors.Wrap(err, "failed to call eth_getTransactionCount")
+	}
+
+	return c.EIP7702.SignAuthorization(ctx, c.ChainID, c.EIP7702.Delegate, uint64(nonce))
+}
+
+// rlpEncodeBytes encodes value as an RLP string, integers are encoded as their big-endian bytes without leading zeros
+func rlpEncodeBytes(value []byte) []byte {
+	if len(value) == 1 && value[0] < 0x80 {
+		return value
+	}
+	return append(rlpLengthPrefix(0x80, len(value)), value...)
+}
+
+// rlpEncodeList encodes the already encoded items as an RLP list
+func rlpEncodeList(items ...[]byte) []byte {
+	payload := bytes.Join(items, nil)
+	return append(rlpLengthPrefix(0xc0, len(payload)), payload...)
+}
+
+func rlpLengthPrefix(offset byte, length int) []byte {
+	if length < 56 {
+		return []byte{offset + byte(length)}
+	}
+
+	lengthBytes := big.NewInt(int64(length)).Bytes()
+	return append([]byte{offset + 55 + byte(len(lengthBytes))}, lengthBytes...)
+}
@@ -0,0 +1,181 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEIP7702Delegate = "0xd6CEDDe84be40893d153Be9d467CD6aD37875b28"
+
+func TestEIP7702AuthorizationHash(t *testing.T) {
+	delegate := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	// rlp([1, delegate, 0]): list of 23 bytes, the 20-byte string and the empty string of nonce 0
+	payload := common.FromHex("0xd7" + "01" + "94" + strings.Repeat("11", 20) + "80")
+	expected := crypto.Keccak256Hash([]byte{0x05}, payload)
+
+	assert.Equal(t, expected, EIP7702AuthorizationHash(big.NewInt(1), delegate, 0))
+	assert.NotEqual(t, expected, EIP7702AuthorizationHash(big.NewInt(1), delegate, 1))
+}
+
+func TestRLPEncodeBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    []byte
+		expected []byte
+	}{
+		{name: "empty", value: nil, expected: []byte{0x80}},
+		{name: "single_byte", value: []byte{0x7f}, expected: []byte{0x7f}},
+		{name: "single_high_byte", value: []byte{0x80}, expected: []byte{0x81, 0x80}},
+		{name: "long", value: make([]byte, 56), expected: append([]byte{0xb8, 56}, make([]byte, 56)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rlpEncodeBytes(tt.value))
+		})
+	}
+}
+
+func TestSignEIP7702Authorization(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	auth, err := SignEIP7702Authorization(privateKey, big.NewInt(ChainPolygon), common.HexToAddress(testEIP7702Delegate), 3)
+	require.NoError(t, err)
+
+	authority, err := auth.Authority()
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), authority)
+
+	marshaled, err := json.Marshal(auth)
+	require.NoError(t, err)
+
+	var fields map[string]string
+	require.NoError(t, json.Unmarshal(marshaled, &fields))
+	assert.Equal(t, "0x89", fields["chainId"])
+	assert.Equal(t, "0x3", fields["nonce"])
+	assert.Equal(t, hexutil.EncodeUint64(uint64(auth.YParity)), fields["yParity"])
+
+	var unmarshaled EIP7702Authorization
+	require.NoError(t, json.Unmarshal(marshaled, &unmarshaled))
+	assert.Equal(t, *auth, unmarshaled)
+}
+
+func TestEntrypointClient08_GetUserOperationHash_EIP7702(t *testing.T) {
+	entrypoint, err := NewEntrypoint08(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	delegate := common.HexToAddress(testEIP7702Delegate)
+
+	op := newTestUserOperation()
+	op.SetEIP7702Authorization(&EIP7702Authorization{ChainID: big.NewInt(ChainPolygon), Address: delegate, Nonce: 3, R: big.NewInt(1), S: big.NewInt(2)})
+	assert.Equal(t, common.HexToAddress(EIP7702MarkerAddress), op.Factory)
+
+	hash, err := entrypoint.GetUserOperationHash(op)
+	require.NoError(t, err)
+
+	// the entrypoint hashes the delegate in place of the marker
+	withDelegate := newTestUserOperation()
+	withDelegate.Factory = delegate
+	expected, err := entrypoint.GetUserOperationHash(withDelegate)
+	require.NoError(t, err)
+	assert.Equal(t, expected, hash)
+
+	op.EIP7702Auth = nil
+	_, err = entrypoint.GetUserOperationHash(op)
+	assert.Error(t, err)
+}
+
+func TestUserOperation_JSON_EIP7702(t *testing.T) {
+	op := newTestUserOperation()
+	op.SetEIP7702Authorization(&EIP7702Authorization{ChainID: big.NewInt(ChainPolygon), Address: common.HexToAddress(testEIP7702Delegate), Nonce: 3, YParity: 1, R: big.NewInt(1), S: big.NewInt(2)})
+
+	marshaled, err := json.Marshal(op)
+	require.NoError(t, err)
+	assert.Contains(t, string(marshaled), `"eip7702Auth":{"chainId":"0x89"`)
+
+	var unmarshaled UserOperation
+	require.NoError(t, json.Unmarshal(marshaled, &unmarshaled))
+	assert.Equal(t, op.EIP7702Auth, unmarshaled.EIP7702Auth)
+	assert.Equal(t, op.Factory, unmarshaled.Factory)
+
+	withoutAuth, err := json.Marshal(newTestUserOperation())
+	require.NoError(t, err)
+	assert.NotContains(t, string(withoutAuth), "eip7702Auth")
+}
+
+func TestClient_GetUserOperationAndHashToSign_EIP7702(t *testing.T) {
+	delegate := common.HexToAddress(testEIP7702Delegate)
+
+	tests := []struct {
+		name         string
+		code         string
+		expectedAuth bool
+	}{
+		{
+			name:         "not_delegated",
+			code:         "0x",
+			expectedAuth: true,
+		},
+		{
+			name: "delegated",
+			code: hexutil.Encode(append([]byte{0xef, 0x01, 0x00}, delegate.Bytes()...)),
+		},
+		{
+			name:         "delegated_elsewhere",
+			code:         hexutil.Encode(append([]byte{0xef, 0x01, 0x00}, common.HexToAddress("0x1111111111111111111111111111111111111111").Bytes()...)),
+			expectedAuth: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, network, _ := newTestClient(t, 0)
+			entrypoint, err := NewEntrypoint08(network, client.ChainID)
+			require.NoError(t, err)
+			client.EntryPoint = entrypoint
+			client.BundlerClient.EntryPoint = entrypoint
+			client.PaymasterClient.EntryPoint = entrypoint
+
+			privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+			require.NoError(t, err)
+			client.EIP7702, err = NewEIP7702Account(privateKey, delegate)
+			require.NoError(t, err)
+			network.On("eth_getCode", tt.code, nil).On("eth_getTransactionCount", "0x3", nil)
+
+			callData := common.FromHex("0xdeadbeef")
+			op, hash, err := client.GetUserOperationAndHashToSign(context.Background(), client.Signer.GetAddress(), &callData)
+			require.NoError(t, err)
+
+			if !tt.expectedAuth {
+				assert.Nil(t, op.EIP7702Auth)
+				assert.Equal(t, common.Address{}, op.Factory)
+				assert.Equal(t, 0, network.CallCount("eth_getTransactionCount"))
+				return
+			}
+
+			require.NotNil(t, op.EIP7702Auth)
+			assert.Equal(t, delegate, op.EIP7702Auth.Address)
+			assert.Equal(t, uint64(3), op.EIP7702Auth.Nonce)
+			assert.Equal(t, big.NewInt(ChainPolygon), op.EIP7702Auth.ChainID)
+			assert.Equal(t, common.HexToAddress(EIP7702MarkerAddress), op.Factory)
+
+			authority, err := op.EIP7702Auth.Authority()
+			require.NoError(t, err)
+			assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), authority)
+
+			expectedHash, err := entrypoint.GetUserOperationHash(op)
+			require.NoError(t, err)
+			assert.Equal(t, expectedHash, hash)
+		})
+	}
+}
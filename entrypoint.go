@@ -3,6 +3,7 @@ package zerodev
 import (
 	"bytes"
 	"context"
+	"github.com/DIMO-Network/go-zerodev/abis"
 	"github.com/DIMO-Network/go-zerodev/types"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -15,31 +16,49 @@ import (
 
 const (
 	EntryPointVersion07 = "0.7"
-	entrypointAbi07     = `[{"inputs": [{ "name": "sender", "type": "address" }, { "name": "key", "type": "uint192" }], "name": "getNonce", "outputs": [{ "name": "nonce", "type": "uint256" }], "stateMutability": "view", "type": "function"}]`
+	entrypointAbi07     = abis.EntryPoint07Abi
 	entryPointAddress07 = "0x0000000071727De22E5E9d8BAf0edAc6f37da032"
 )
 
-const (
-	keySeparatorStart = ">"
-	keySeparatorEnd   = "<"
-)
+// nonceKeyBits is the size of the nonce key, the remaining 64 bits of the nonce are the sequence
+const nonceKeyBits = 192
 
 type Entrypoint interface {
 	GetAddress() common.Address
-	GetNonce(account common.Address) (*big.Int, error)
+	GetVersion() string
+	GetNonce(ctx context.Context, account common.Address) (*big.Int, error)
+	GetNonceWithKey(ctx context.Context, account common.Address, key *big.Int) (*big.Int, error)
 	GetUserOperationHash(op *UserOperation) (*common.Hash, error)
 	PackUserOperation(op *UserOperation) ([]byte, error)
 }
 
+// EntrypointDepositReader is an optional interface of an Entrypoint reading the deposits of accounts, the entrypoints of this package implement it
+type EntrypointDepositReader interface {
+	GetDepositInfo(ctx context.Context, account common.Address) (*DepositInfo, error)
+	BalanceOf(ctx context.Context, account common.Address) (*big.Int, error)
+}
+
+// EntrypointOnChainHasher is an optional interface of an Entrypoint calling its getUserOpHash, the entrypoints of this package implement it
+type EntrypointOnChainHasher interface {
+	GetUserOperationHashOnChain(ctx context.Context, op *UserOperation) (*common.Hash, error)
+}
+
 type EntrypointClient07 struct {
 	Client  types.RPCClient
 	Address common.Address
 	Abi     *abi.ABI
 	ChainID *big.Int
+	// Hasher computes the UserOperation hashes, StandardUserOperationHasher07 if not set
+	Hasher UserOperationHasher
 }
 
-// NewEntrypoint07 creates a new EntrypointClient07 instance.
+// NewEntrypoint07 creates a new EntrypointClient07 instance at the canonical address.
 func NewEntrypoint07(rpcClient types.RPCClient, chainID *big.Int) (*EntrypointClient07, error) {
+	return NewEntrypoint07WithAddress(rpcClient, chainID, common.HexToAddress(entryPointAddress07))
+}
+
+// NewEntrypoint07WithAddress creates a new EntrypointClient07 instance at address, for chains where the entrypoint isn't deployed at the canonical address.
+func NewEntrypoint07WithAddress(rpcClient types.RPCClient, chainID *big.Int, address common.Address) (*EntrypointClient07, error) {
 	parsedAbi, err := abi.JSON(strings.NewReader(entrypointAbi07))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse entrypoint abi")
@@ -47,7 +66,7 @@ func NewEntrypoint07(rpcClient types.RPCClient, chainID *big.Int) (*EntrypointCl
 
 	return &EntrypointClient07{
 		Client:  rpcClient,
-		Address: common.HexToAddress(entryPointAddress07),
+		Address: address,
 		Abi:     &parsedAbi,
 		ChainID: chainID,
 	}, nil
@@ -57,58 +76,42 @@ func (e *EntrypointClient07) GetAddress() common.Address {
 	return e.Address
 }
 
-// GetNonce retrieves the nonce of a specific account.
-func (e *EntrypointClient07) GetNonce(account common.Address) (*big.Int, error) {
-	key := computeKey(account)
-	callData, err := e.Abi.Pack("getNonce", account, key)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to pack getNonce call data")
-	}
-
-	msg := struct {
-		To   common.Address `json:"to"`
-		Data hexutil.Bytes  `json:"data"`
-	}{
-		To:   e.Address,
-		Data: callData,
-	}
+func (e *EntrypointClient07) GetVersion() string {
+	return EntryPointVersion07
+}
 
-	var hex hexutil.Bytes
-	if err := e.Client.CallContext(context.Background(), &hex, "eth_call", msg); err != nil {
-		return nil, errors.Wrap(err, "failed to call getNonce eth_call")
-	}
+// GetNonce retrieves the nonce of a specific account using the default nonce key.
+func (e *EntrypointClient07) GetNonce(ctx context.Context, account common.Address) (*big.Int, error) {
+	return e.GetNonceWithKey(ctx, account, defaultNonceKey())
+}
 
-	decoded, err := hexutil.Decode(hex.String())
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to decode getNonce hex")
-	}
-	return big.NewInt(0).SetBytes(decoded), nil
+// GetNonceWithKey retrieves the nonce of a specific account for the given 192-bit nonce key.
+func (e *EntrypointClient07) GetNonceWithKey(ctx context.Context, account common.Address, key *big.Int) (*big.Int, error) {
+	return getNonce(ctx, e.Client, e.Abi, e.Address, account, key)
 }
 
-// GetUserOperationHash calculates the hash of a UserOperation.
-func (e *EntrypointClient07) GetUserOperationHash(op *UserOperation) (*common.Hash, error) {
-	packedOp, err := e.PackUserOperation(op)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to pack user operation")
-	}
+// GetDepositInfo retrieves the deposit and stake of account on the entrypoint.
+func (e *EntrypointClient07) GetDepositInfo(ctx context.Context, account common.Address) (*DepositInfo, error) {
+	return getDepositInfo(ctx, e.Client, e.Abi, e.Address, account)
+}
 
-	args := abi.Arguments{
-		{Type: bytes32},
-		{Type: address},
-		{Type: uint256},
-	}
+// BalanceOf retrieves the deposit of account on the entrypoint.
+func (e *EntrypointClient07) BalanceOf(ctx context.Context, account common.Address) (*big.Int, error) {
+	return getBalance(ctx, e.Client, e.Abi, e.Address, account)
+}
 
-	packed, err := args.Pack(
-		crypto.Keccak256Hash(packedOp),
-		e.Address,
-		e.ChainID,
-	)
+// GetUserOperationHashOnChain calls getUserOpHash on the entrypoint, e.g. to cross-check GetUserOperationHash.
+func (e *EntrypointClient07) GetUserOperationHashOnChain(ctx context.Context, op *UserOperation) (*common.Hash, error) {
+	return getUserOpHash(ctx, e.Client, e.Abi, e.Address, *op.ToPacked())
+}
 
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to pack user operation for hashing")
+// GetUserOperationHash calculates the hash of a UserOperation with the entrypoint's Hasher.
+func (e *EntrypointClient07) GetUserOperationHash(op *UserOperation) (*common.Hash, error) {
+	if e.Hasher != nil {
+		return e.Hasher.HashUserOperation(op, e.Address, e.ChainID)
 	}
-	hash := crypto.Keccak256Hash(packed)
-	return &hash, nil
+
+	return StandardUserOperationHasher07{}.HashUserOperation(op, e.Address, e.ChainID)
 }
 
 // PackUserOperation creates a packed representation of a UserOperation compliant with Entrypoint 0.7
@@ -124,7 +127,7 @@ func (*EntrypointClient07) PackUserOperation(op *UserOperation) ([]byte, error)
 		{Name: "hashPaymasterAndData", Type: bytes32},
 	}
 
-	hashedInitCode := crypto.Keccak256Hash(common.FromHex("0x"))
+	hashedInitCode := crypto.Keccak256Hash(op.initCode())
 	hashedCallData := crypto.Keccak256Hash(op.CallData)
 
 	accountGasLimits := createPackedBuffer(
@@ -137,14 +140,16 @@ func (*EntrypointClient07) PackUserOperation(op *UserOperation) ([]byte, error)
 		op.MaxFeePerGas.Bytes(),
 	)
 
-	//paymasterAndData := createPaymasterDataBuffer(
-	//	op.Paymaster,
-	//	op.PaymasterVerificationGasLimit.Bytes(),
-	//	op.PaymasterPostOpGasLimit.Bytes(),
-	//	op.PaymasterData,
-	//)
-
 	hashedPaymasterAndData := crypto.Keccak256Hash(common.FromHex("0x"))
+	if op.hasPaymaster() {
+		paymasterAndData := createPaymasterDataBuffer(
+			op.Paymaster,
+			bigIntBytes(op.PaymasterVerificationGasLimit),
+			bigIntBytes(op.PaymasterPostOpGasLimit),
+			op.PaymasterData,
+		)
+		hashedPaymasterAndData = crypto.Keccak256Hash(paymasterAndData.Bytes())
+	}
 
 	packed, err := args.Pack(
 		op.Sender,
@@ -162,14 +167,134 @@ func (*EntrypointClient07) PackUserOperation(op *UserOperation) ([]byte, error)
 	return packed, nil
 }
 
-// computeKey generates a key for an account using separators.
-func computeKey(account common.Address) *big.Int {
-	return big.NewInt(0)
-	//partialHex := account.Hex()[5:10]
-	//return new(big.Int).SetBytes([]byte(keySeparatorStart + partialHex + keySeparatorEnd))
+// getNonce calls getNonce on the entrypoint contract, the ABI of the call is the same for all supported versions.
+// The entrypoint keeps nonces of accounts which are not deployed yet, the returned nonce is key << 64 | sequence.
+func getNonce(ctx context.Context, client types.RPCClient, entrypointAbi *abi.ABI, entrypoint common.Address, account common.Address, key *big.Int) (*big.Int, error) {
+	if key == nil {
+		key = big.NewInt(0)
+	}
+	if key.Sign() < 0 || key.BitLen() > nonceKeyBits {
+		return nil, errors.New("nonce key must be a non-negative 192-bit integer")
+	}
+
+	callData, err := entrypointAbi.Pack("getNonce", account, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack getNonce call data")
+	}
+
+	msg := struct {
+		To   common.Address `json:"to"`
+		Data hexutil.Bytes  `json:"data"`
+	}{
+		To:   entrypoint,
+		Data: callData,
+	}
+
+	var hex hexutil.Bytes
+	if err := client.CallContext(ctx, &hex, "eth_call", msg); err != nil {
+		return nil, errors.Wrap(err, "failed to call getNonce eth_call")
+	}
+
+	if len(hex) == 0 {
+		return nil, errors.Errorf("getNonce returned no data, no entrypoint deployed at %s", entrypoint.Hex())
+	}
+
+	return encodeNonce(key, new(big.Int).SetBytes(hex))
+}
+
+// callEntryPoint calls the view method of the entrypoint contract with args and unpacks its outputs
+func callEntryPoint(ctx context.Context, client types.RPCClient, entrypointAbi *abi.ABI, entrypoint common.Address, method string, args ...interface{}) ([]interface{}, error) {
+	callData, err := entrypointAbi.Pack(method, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to pack %s call data", method)
+	}
+
+	msg := struct {
+		To   common.Address `json:"to"`
+		Data hexutil.Bytes  `json:"data"`
+	}{
+		To:   entrypoint,
+		Data: callData,
+	}
+
+	var hex hexutil.Bytes
+	if err := client.CallContext(ctx, &hex, "eth_call", msg); err != nil {
+		return nil, errors.Wrapf(err, "failed to call %s eth_call", method)
+	}
+
+	if len(hex) == 0 {
+		return nil, errors.Errorf("%s returned no data, no entrypoint deployed at %s", method, entrypoint.Hex())
+	}
+
+	unpacked, err := entrypointAbi.Unpack(method, hex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unpack %s result", method)
+	}
+
+	return unpacked, nil
+}
+
+// getBalance calls balanceOf on the entrypoint contract, the ABI of the call is the same for all supported versions
+func getBalance(ctx context.Context, client types.RPCClient, entrypointAbi *abi.ABI, entrypoint common.Address, account common.Address) (*big.Int, error) {
+	unpacked, err := callEntryPoint(ctx, client, entrypointAbi, entrypoint, "balanceOf", account)
+	if err != nil {
+		return nil, err
+	}
+
+	return unpacked[0].(*big.Int), nil
 }
 
-// createPackedBuffer combines two byte slices into a single buffer with padding.
+// getUserOpHash calls getUserOpHash on the entrypoint contract with op in the representation of entrypointAbi
+func getUserOpHash(ctx context.Context, client types.RPCClient, entrypointAbi *abi.ABI, entrypoint common.Address, op interface{}) (*common.Hash, error) {
+	unpacked, err := callEntryPoint(ctx, client, entrypointAbi, entrypoint, "getUserOpHash", op)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := common.Hash(unpacked[0].([32]byte))
+	return &hash, nil
+}
+
+// encodeNonce checks that nonce returned by getNonce is of key, nonces returned without the key in the high bits get it set
+func encodeNonce(key *big.Int, nonce *big.Int) (*big.Int, error) {
+	returnedKey := nonceKeyOf(nonce)
+	if returnedKey.Sign() == 0 && key.Sign() != 0 {
+		return new(big.Int).Or(new(big.Int).Lsh(key, 64), nonce), nil
+	}
+	if returnedKey.Cmp(key) != 0 {
+		return nil, errors.Errorf("getNonce returned nonce %s of key %s, requested key %s", nonce, returnedKey, key)
+	}
+
+	return nonce, nil
+}
+
+// hashPackedUserOperation computes the final user operation hash from the packed representation, common for 0.6 and 0.7.
+func hashPackedUserOperation(packedOp []byte, entrypoint common.Address, chainID *big.Int) (*common.Hash, error) {
+	args := abi.Arguments{
+		{Type: bytes32},
+		{Type: address},
+		{Type: uint256},
+	}
+
+	packed, err := args.Pack(
+		crypto.Keccak256Hash(packedOp),
+		entrypoint,
+		chainID,
+	)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack user operation for hashing")
+	}
+	hash := crypto.Keccak256Hash(packed)
+	return &hash, nil
+}
+
+// defaultNonceKey is the nonce key of GetNonce, 0 is the key used by the Kernel root validator
+func defaultNonceKey() *big.Int {
+	return new(big.Int)
+}
+
+// createPackedBuffer packs two uint128 values into 32 bytes, first in the high and second in the low half, as the entrypoint unpacks them.
 func createPackedBuffer(first, second []byte) bytes.Buffer {
 	var buffer bytes.Buffer
 	buffer.Write(common.LeftPadBytes(first, 16))
@@ -187,6 +312,14 @@ func createPaymasterDataBuffer(paymaster, verificationGas, postOpGas []byte, pay
 	return buffer
 }
 
+// bigIntBytes returns the big-endian bytes of value, treating nil as zero.
+func bigIntBytes(value *big.Int) []byte {
+	if value == nil {
+		return nil
+	}
+	return value.Bytes()
+}
+
 // toArray32 converts a buffer into a fixed 32-byte array.
 func toArray32(buffer bytes.Buffer) [32]byte {
 	var array [32]byte
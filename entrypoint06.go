@@ -0,0 +1,145 @@
+package zerodev
+
+import (
+	"bytes"
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	EntryPointVersion06 = "0.6"
+	entryPointAddress06 = "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"
+	// getUserOpHashAbi06 takes the unpacked UserOperation of Entrypoint 0.6, its other views have the same ABI as in 0.7
+	getUserOpHashAbi06 = `[{"inputs": [{"components": [{"name": "sender", "type": "address"}, {"name": "nonce", "type": "uint256"}, {"name": "initCode", "type": "bytes"}, {"name": "callData", "type": "bytes"}, {"name": "callGasLimit", "type": "uint256"}, {"name": "verificationGasLimit", "type": "uint256"}, {"name": "preVerificationGas", "type": "uint256"}, {"name": "maxFeePerGas", "type": "uint256"}, {"name": "maxPriorityFeePerGas", "type": "uint256"}, {"name": "paymasterAndData", "type": "bytes"}, {"name": "signature", "type": "bytes"}], "name": "userOp", "type": "tuple"}], "name": "getUserOpHash", "outputs": [{"name": "", "type": "bytes32"}], "stateMutability": "view", "type": "function"}]`
+)
+
+type EntrypointClient06 struct {
+	Client  types.RPCClient
+	Address common.Address
+	Abi     *abi.ABI
+	ChainID *big.Int
+}
+
+// NewEntrypoint06 creates a new EntrypointClient06 instance at the canonical address.
+func NewEntrypoint06(rpcClient types.RPCClient, chainID *big.Int) (*EntrypointClient06, error) {
+	return NewEntrypoint06WithAddress(rpcClient, chainID, common.HexToAddress(entryPointAddress06))
+}
+
+// NewEntrypoint06WithAddress creates a new EntrypointClient06 instance at address, for chains where the entrypoint isn't deployed at the canonical address.
+func NewEntrypoint06WithAddress(rpcClient types.RPCClient, chainID *big.Int, address common.Address) (*EntrypointClient06, error) {
+	// the views used have the same signatures in 0.6 and 0.7, but getUserOpHash which is parsed from getUserOpHashAbi06
+	parsedAbi, err := abi.JSON(strings.NewReader(entrypointAbi07))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse entrypoint abi")
+	}
+
+	return &EntrypointClient06{
+		Client:  rpcClient,
+		Address: address,
+		Abi:     &parsedAbi,
+		ChainID: chainID,
+	}, nil
+}
+
+func (e *EntrypointClient06) GetAddress() common.Address {
+	return e.Address
+}
+
+func (e *EntrypointClient06) GetVersion() string {
+	return EntryPointVersion06
+}
+
+// GetNonce retrieves the nonce of a specific account using the default nonce key.
+func (e *EntrypointClient06) GetNonce(ctx context.Context, account common.Address) (*big.Int, error) {
+	return e.GetNonceWithKey(ctx, account, defaultNonceKey())
+}
+
+// GetNonceWithKey retrieves the nonce of a specific account for the given 192-bit nonce key.
+func (e *EntrypointClient06) GetNonceWithKey(ctx context.Context, account common.Address, key *big.Int) (*big.Int, error) {
+	return getNonce(ctx, e.Client, e.Abi, e.Address, account, key)
+}
+
+// GetDepositInfo retrieves the deposit and stake of account on the entrypoint.
+func (e *EntrypointClient06) GetDepositInfo(ctx context.Context, account common.Address) (*DepositInfo, error) {
+	return getDepositInfo(ctx, e.Client, e.Abi, e.Address, account)
+}
+
+// BalanceOf retrieves the deposit of account on the entrypoint.
+func (e *EntrypointClient06) BalanceOf(ctx context.Context, account common.Address) (*big.Int, error) {
+	return getBalance(ctx, e.Client, e.Abi, e.Address, account)
+}
+
+// GetUserOperationHashOnChain calls getUserOpHash on the entrypoint, e.g. to cross-check GetUserOperationHash.
+func (e *EntrypointClient06) GetUserOperationHashOnChain(ctx context.Context, op *UserOperation) (*common.Hash, error) {
+	parsedAbi, err := abi.JSON(strings.NewReader(getUserOpHashAbi06))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse getUserOpHash abi")
+	}
+
+	return getUserOpHash(ctx, e.Client, &parsedAbi, e.Address, toUserOperation06(op))
+}
+
+// GetUserOperationHash calculates the hash of a UserOperation.
+func (e *EntrypointClient06) GetUserOperationHash(op *UserOperation) (*common.Hash, error) {
+	packedOp, err := e.PackUserOperation(op)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack user operation")
+	}
+
+	return hashPackedUserOperation(packedOp, e.Address, e.ChainID)
+}
+
+// PackUserOperation creates a packed representation of a UserOperation compliant with Entrypoint 0.6
+func (*EntrypointClient06) PackUserOperation(op *UserOperation) ([]byte, error) {
+	args := abi.Arguments{
+		{Name: "sender", Type: address},
+		{Name: "nonce", Type: uint256},
+		{Name: "hashInitCode", Type: bytes32},
+		{Name: "hashCallData", Type: bytes32},
+		{Name: "callGasLimit", Type: uint256},
+		{Name: "verificationGasLimit", Type: uint256},
+		{Name: "preVerificationGas", Type: uint256},
+		{Name: "maxFeePerGas", Type: uint256},
+		{Name: "maxPriorityFeePerGas", Type: uint256},
+		{Name: "hashPaymasterAndData", Type: bytes32},
+	}
+
+	hashedInitCode := crypto.Keccak256Hash(op.initCode())
+	hashedCallData := crypto.Keccak256Hash(op.CallData)
+	hashedPaymasterAndData := crypto.Keccak256Hash(op.paymasterAndData06())
+
+	packed, err := args.Pack(
+		op.Sender,
+		op.Nonce,
+		hashedInitCode,
+		hashedCallData,
+		op.CallGasLimit,
+		op.VerificationGasLimit,
+		op.PreVerificationGas,
+		op.MaxFeePerGas,
+		op.MaxPriorityFeePerGas,
+		hashedPaymasterAndData,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return packed, nil
+}
+
+// paymasterAndData06 builds the 0.6 paymasterAndData field, 0.6 has no separate paymaster gas limits.
+func (op *UserOperation) paymasterAndData06() []byte {
+	if !op.hasPaymaster() {
+		return nil
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(op.Paymaster)
+	buffer.Write(op.PaymasterData)
+	return buffer.Bytes()
+}
@@ -0,0 +1,235 @@
+package zerodev
+
+import (
+	"bytes"
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+const (
+	EntryPointVersion08 = "0.8"
+	entryPointAddress08 = "0x4337084D9E255Ff0702461CF8895CE9E3b5Ff108"
+)
+
+// EIP-712 domain and type of UserOperations hashed by Entrypoint 0.8
+const (
+	entrypoint08DomainName    = "ERC4337"
+	entrypoint08DomainVersion = "1"
+)
+
+var (
+	eip712DomainTypeHash        = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	packedUserOperationTypeHash = crypto.Keccak256Hash([]byte("PackedUserOperation(address sender,uint256 nonce,bytes initCode,bytes callData,bytes32 accountGasLimits,uint256 preVerificationGas,bytes32 gasFees,bytes paymasterAndData)"))
+)
+
+type EntrypointClient08 struct {
+	Client  types.RPCClient
+	Address common.Address
+	Abi     *abi.ABI
+	ChainID *big.Int
+
+	// domain caches the domain separator of the Address and ChainID it was computed for
+	domainMu sync.Mutex
+	domain   *cachedDomainSeparator
+}
+
+type cachedDomainSeparator struct {
+	address   common.Address
+	chainID   *big.Int
+	separator common.Hash
+}
+
+// NewEntrypoint08 creates a new EntrypointClient08 instance at the canonical address.
+func NewEntrypoint08(rpcClient types.RPCClient, chainID *big.Int) (*EntrypointClient08, error) {
+	return NewEntrypoint08WithAddress(rpcClient, chainID, common.HexToAddress(entryPointAddress08))
+}
+
+// NewEntrypoint08WithAddress creates a new EntrypointClient08 instance at address, for chains where the entrypoint isn't deployed at the canonical address.
+func NewEntrypoint08WithAddress(rpcClient types.RPCClient, chainID *big.Int, address common.Address) (*EntrypointClient08, error) {
+	// the views used have the same signatures in 0.7 and 0.8
+	parsedAbi, err := abi.JSON(strings.NewReader(entrypointAbi07))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse entrypoint abi")
+	}
+
+	return &EntrypointClient08{
+		Client:  rpcClient,
+		Address: address,
+		Abi:     &parsedAbi,
+		ChainID: chainID,
+	}, nil
+}
+
+func (e *EntrypointClient08) GetAddress() common.Address {
+	return e.Address
+}
+
+func (e *EntrypointClient08) GetVersion() string {
+	return EntryPointVersion08
+}
+
+// GetNonce retrieves the nonce of a specific account using the default nonce key.
+func (e *EntrypointClient08) GetNonce(ctx context.Context, account common.Address) (*big.Int, error) {
+	return e.GetNonceWithKey(ctx, account, defaultNonceKey())
+}
+
+// GetNonceWithKey retrieves the nonce of a specific account for the given 192-bit nonce key.
+func (e *EntrypointClient08) GetNonceWithKey(ctx context.Context, account common.Address, key *big.Int) (*big.Int, error) {
+	return getNonce(ctx, e.Client, e.Abi, e.Address, account, key)
+}
+
+// GetDepositInfo retrieves the deposit and stake of account on the entrypoint.
+func (e *EntrypointClient08) GetDepositInfo(ctx context.Context, account common.Address) (*DepositInfo, error) {
+	return getDepositInfo(ctx, e.Client, e.Abi, e.Address, account)
+}
+
+// BalanceOf retrieves the deposit of account on the entrypoint.
+func (e *EntrypointClient08) BalanceOf(ctx context.Context, account common.Address) (*big.Int, error) {
+	return getBalance(ctx, e.Client, e.Abi, e.Address, account)
+}
+
+// GetUserOperationHashOnChain calls getUserOpHash on the entrypoint, e.g. to cross-check GetUserOperationHash.
+func (e *EntrypointClient08) GetUserOperationHashOnChain(ctx context.Context, op *UserOperation) (*common.Hash, error) {
+	return getUserOpHash(ctx, e.Client, e.Abi, e.Address, *op.ToPacked())
+}
+
+// GetUserOperationHash calculates the EIP-712 typed data hash of a UserOperation:
+// keccak256(0x1901 || domainSeparator || keccak256(PackUserOperation(op)))
+func (e *EntrypointClient08) GetUserOperationHash(op *UserOperation) (*common.Hash, error) {
+	packedOp, err := e.PackUserOperation(op)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack user operation")
+	}
+
+	domainSeparator, err := e.DomainSeparator()
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write([]byte{0x19, 0x01})
+	buffer.Write(domainSeparator.Bytes())
+	buffer.Write(crypto.Keccak256(packedOp))
+
+	hash := crypto.Keccak256Hash(buffer.Bytes())
+	return &hash, nil
+}
+
+// DomainSeparator returns the EIP-712 domain separator of the entrypoint on its chain, computed once and recomputed only if
+// Address or ChainID change
+func (e *EntrypointClient08) DomainSeparator() (*common.Hash, error) {
+	e.domainMu.Lock()
+	defer e.domainMu.Unlock()
+
+	if e.domain != nil && e.domain.address == e.Address && e.ChainID != nil && e.domain.chainID.Cmp(e.ChainID) == 0 {
+		separator := e.domain.separator
+		return &separator, nil
+	}
+
+	separator, err := e.computeDomainSeparator()
+	if err != nil {
+		return nil, err
+	}
+	e.domain = &cachedDomainSeparator{address: e.Address, chainID: new(big.Int).Set(e.ChainID), separator: *separator}
+
+	return separator, nil
+}
+
+// computeDomainSeparator computes the EIP-712 domain separator of the entrypoint on its chain
+func (e *EntrypointClient08) computeDomainSeparator() (*common.Hash, error) {
+	if e.ChainID == nil {
+		return nil, errors.New("chainID is required")
+	}
+
+	args := abi.Arguments{
+		{Type: bytes32},
+		{Type: bytes32},
+		{Type: bytes32},
+		{Type: uint256},
+		{Type: address},
+	}
+
+	packed, err := args.Pack(
+		eip712DomainTypeHash,
+		crypto.Keccak256Hash([]byte(entrypoint08DomainName)),
+		crypto.Keccak256Hash([]byte(entrypoint08DomainVersion)),
+		e.ChainID,
+		e.Address,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack domain separator")
+	}
+
+	hash := crypto.Keccak256Hash(packed)
+	return &hash, nil
+}
+
+// PackUserOperation creates the EIP-712 struct encoding of a UserOperation compliant with Entrypoint 0.8,
+// dynamic fields are hashed and gas fields are packed like in 0.7. The EIP-7702 initCode of delegated accounts is hashed
+// with the delegate of op.EIP7702Auth in place of the marker.
+func (*EntrypointClient08) PackUserOperation(op *UserOperation) ([]byte, error) {
+	args := abi.Arguments{
+		{Name: "typeHash", Type: bytes32},
+		{Name: "sender", Type: address},
+		{Name: "nonce", Type: uint256},
+		{Name: "hashInitCode", Type: bytes32},
+		{Name: "hashCallData", Type: bytes32},
+		{Name: "accountGasLimits", Type: bytes32},
+		{Name: "preVerificationGas", Type: uint256},
+		{Name: "gasFees", Type: bytes32},
+		{Name: "hashPaymasterAndData", Type: bytes32},
+	}
+
+	hashedInitCode := crypto.Keccak256Hash(op.initCode())
+	if op.isEIP7702() {
+		if op.EIP7702Auth == nil {
+			return nil, errors.New("eip7702Auth is required to hash the initCode of an EIP-7702 account")
+		}
+		hashedInitCode = crypto.Keccak256Hash(op.EIP7702Auth.Address.Bytes(), op.FactoryData)
+	}
+	hashedCallData := crypto.Keccak256Hash(op.CallData)
+
+	accountGasLimits := createPackedBuffer(
+		op.VerificationGasLimit.Bytes(),
+		op.CallGasLimit.Bytes(),
+	)
+
+	gasFees := createPackedBuffer(
+		op.MaxPriorityFeePerGas.Bytes(),
+		op.MaxFeePerGas.Bytes(),
+	)
+
+	hashedPaymasterAndData := crypto.Keccak256Hash(nil)
+	if op.hasPaymaster() {
+		paymasterAndData := createPaymasterDataBuffer(
+			op.Paymaster,
+			bigIntBytes(op.PaymasterVerificationGasLimit),
+			bigIntBytes(op.PaymasterPostOpGasLimit),
+			op.PaymasterData,
+		)
+		hashedPaymasterAndData = crypto.Keccak256Hash(paymasterAndData.Bytes())
+	}
+
+	packed, err := args.Pack(
+		packedUserOperationTypeHash,
+		op.Sender,
+		op.Nonce,
+		hashedInitCode,
+		hashedCallData,
+		toArray32(accountGasLimits),
+		op.PreVerificationGas,
+		toArray32(gasFees),
+		hashedPaymasterAndData,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return packed, nil
+}
@@ -0,0 +1,429 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUserOperation() *UserOperation {
+	return &UserOperation{
+		Sender:               common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"),
+		Nonce:                big.NewInt(5),
+		CallData:             common.FromHex("0xdeadbeef"),
+		CallGasLimit:         big.NewInt(100_000),
+		VerificationGasLimit: big.NewInt(200_000),
+		PreVerificationGas:   big.NewInt(50_000),
+		MaxFeePerGas:         big.NewInt(30_000_000_000),
+		MaxPriorityFeePerGas: big.NewInt(1_500_000_000),
+	}
+}
+
+func TestEntrypointClient06(t *testing.T) {
+	entrypoint, err := NewEntrypoint06(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		paymaster     []byte
+		paymasterData []byte
+		expectedPack  string
+		expectedHash  string
+	}{
+		{
+			name:         "no_paymaster",
+			expectedPack: "0x000000000000000000000000c81d8fa063a7c73795c8455f6b766dd245d8f47a0000000000000000000000000000000000000000000000000000000000000005c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470d4fd4e189132273036449fc9e11198c739161b4c0116a9a2dccdfa1c492006f100000000000000000000000000000000000000000000000000000000000186a00000000000000000000000000000000000000000000000000000000000030d40000000000000000000000000000000000000000000000000000000000000c35000000000000000000000000000000000000000000000000000000006fc23ac000000000000000000000000000000000000000000000000000000000059682f00c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+			expectedHash: "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77",
+		},
+		{
+			name:          "with_paymaster",
+			paymaster:     common.FromHex("0x1111111111111111111111111111111111111111"),
+			paymasterData: common.FromHex("0xcafe"),
+			expectedHash:  "0x7badcbf68939476e4c3f6aa95de2486cf92cb3ab36cf0fc5f24138c6587a1ea1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := newTestUserOperation()
+			op.Paymaster = tt.paymaster
+			op.PaymasterData = tt.paymasterData
+
+			if tt.expectedPack != "" {
+				packed, err := entrypoint.PackUserOperation(op)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedPack, hexutil.Encode(packed))
+			}
+
+			hash, err := entrypoint.GetUserOperationHash(op)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedHash, hash.Hex())
+		})
+	}
+}
+
+func TestEntrypointClient07_GetUserOperationHash(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		modify       func(op *UserOperation)
+		expectedHash string
+	}{
+		{
+			name:         "no_paymaster",
+			modify:       func(op *UserOperation) {},
+			expectedHash: "0xf8de7629ce84fdc2606c777963ec14151d0fdc0f70defefd86c4c5ed43cda452",
+		},
+		{
+			name: "zero_address_paymaster",
+			modify: func(op *UserOperation) {
+				op.Paymaster = common.HexToAddress(AddressZero).Bytes()
+			},
+			expectedHash: "0xf8de7629ce84fdc2606c777963ec14151d0fdc0f70defefd86c4c5ed43cda452",
+		},
+		{
+			name: "with_paymaster",
+			modify: func(op *UserOperation) {
+				op.Paymaster = common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633")
+				op.PaymasterVerificationGasLimit = big.NewInt(45_000)
+				op.PaymasterPostOpGasLimit = big.NewInt(1)
+				op.PaymasterData = common.FromHex("0x000000000000000000000000000000000000000000000000000000006791f7a1ababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababab")
+			},
+			expectedHash: "0xe361e4b3ddb22445e07c6d63862332f3313663b87dec5297c0a0ee33eac68876",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := newTestUserOperation()
+			tt.modify(op)
+
+			hash, err := entrypoint.GetUserOperationHash(op)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedHash, hash.Hex())
+		})
+	}
+}
+
+func TestEntrypointClient07_GetUserOperationHash_CustomAddress(t *testing.T) {
+	address := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	entrypoint, err := NewEntrypoint07WithAddress(nil, big.NewInt(ChainPolygon), address)
+	require.NoError(t, err)
+	assert.Equal(t, address, entrypoint.GetAddress())
+
+	// the entrypoint address is part of the final packing, the hash differs from the canonical entrypoint one
+	hash, err := entrypoint.GetUserOperationHash(newTestUserOperation())
+	require.NoError(t, err)
+	assert.Equal(t, "0x187719da16f4b6e63a4c5cd90461d099a6b66148292443ceb8ae6af3faec27b1", hash.Hex())
+}
+
+func TestNewEntrypoint_Address(t *testing.T) {
+	custom := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	tests := []struct {
+		version         string
+		address         common.Address
+		expectedAddress common.Address
+	}{
+		{version: EntryPointVersion06, expectedAddress: common.HexToAddress(entryPointAddress06)},
+		{version: EntryPointVersion06, address: custom, expectedAddress: custom},
+		{version: EntryPointVersion07, expectedAddress: common.HexToAddress(entryPointAddress07)},
+		{version: EntryPointVersion07, address: custom, expectedAddress: custom},
+		{version: EntryPointVersion08, expectedAddress: common.HexToAddress(entryPointAddress08)},
+		{version: EntryPointVersion08, address: custom, expectedAddress: custom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+"_"+tt.address.Hex(), func(t *testing.T) {
+			entrypoint, err := newEntrypoint(tt.version, nil, big.NewInt(ChainPolygon), tt.address)
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, entrypoint.GetVersion())
+			assert.Equal(t, tt.expectedAddress, entrypoint.GetAddress())
+		})
+	}
+}
+
+func TestEntrypointClient08_GetUserOperationHash(t *testing.T) {
+	entrypoint, err := NewEntrypoint08(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	domainSeparator, err := entrypoint.DomainSeparator()
+	require.NoError(t, err)
+	assert.Equal(t, "0xa5668c355c960b2c49ec7284aa868381cd46cab23733892034a4f10100c3b141", domainSeparator.Hex())
+
+	tests := []struct {
+		name         string
+		modify       func(op *UserOperation)
+		expectedHash string
+	}{
+		{
+			name:         "no_paymaster",
+			modify:       func(op *UserOperation) {},
+			expectedHash: "0x6966004511032d65e696e53a76c0952761fadae2f4092b35d739cecc13fcd4a6",
+		},
+		{
+			name: "with_paymaster",
+			modify: func(op *UserOperation) {
+				op.Paymaster = common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633")
+				op.PaymasterVerificationGasLimit = big.NewInt(45_000)
+				op.PaymasterPostOpGasLimit = big.NewInt(1)
+				op.PaymasterData = common.FromHex("0x000000000000000000000000000000000000000000000000000000006791f7a1ababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababab")
+			},
+			expectedHash: "0x810039a8a4ec058e66c2f1265834b9fa72ead6a3e8c54a277cec7afb7b7a2550",
+		},
+		{
+			name: "with_factory",
+			modify: func(op *UserOperation) {
+				op.Factory = common.HexToAddress(KernelMetaFactoryAddress)
+				op.FactoryData = common.FromHex("0xc0ffee")
+			},
+			expectedHash: "0xef90615d1df917c16638283007494102ead349bf3a1a293ec5ca77b9532e2e7b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := newTestUserOperation()
+			tt.modify(op)
+
+			hash, err := entrypoint.GetUserOperationHash(op)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedHash, hash.Hex())
+		})
+	}
+}
+
+// TestPackUserOperation_GasOrder pins the halves of accountGasLimits and gasFees to the PackedUserOperation of EntryPoint 0.7 and 0.8:
+// verificationGasLimit and maxPriorityFeePerGas in the high 16 bytes, callGasLimit and maxFeePerGas in the low 16 bytes.
+func TestPackUserOperation_GasOrder(t *testing.T) {
+	entrypoint07, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	entrypoint08, err := NewEntrypoint08(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	expectedAccountGasLimits := common.HexToHash("0x0000000000000000000000000000000a0000000000000000000000000000000b")
+	expectedGasFees := common.HexToHash("0x0000000000000000000000000000000c0000000000000000000000000000000d")
+
+	tests := []struct {
+		name                   string
+		pack                   func(op *UserOperation) ([]byte, error)
+		accountGasLimitsOffset int
+		gasFeesOffset          int
+	}{
+		{
+			name:                   "entrypoint_07",
+			pack:                   entrypoint07.PackUserOperation,
+			accountGasLimitsOffset: 4 * 32,
+			gasFeesOffset:          6 * 32,
+		},
+		{
+			// the EIP-712 struct hash is preceded by the type hash
+			name:                   "entrypoint_08",
+			pack:                   entrypoint08.PackUserOperation,
+			accountGasLimitsOffset: 5 * 32,
+			gasFeesOffset:          7 * 32,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := newTestUserOperation()
+			op.VerificationGasLimit = big.NewInt(0x0a)
+			op.CallGasLimit = big.NewInt(0x0b)
+			op.MaxPriorityFeePerGas = big.NewInt(0x0c)
+			op.MaxFeePerGas = big.NewInt(0x0d)
+
+			packed, err := tt.pack(op)
+			require.NoError(t, err)
+			assert.Equal(t, expectedAccountGasLimits, common.BytesToHash(packed[tt.accountGasLimitsOffset:tt.accountGasLimitsOffset+32]))
+			assert.Equal(t, expectedGasFees, common.BytesToHash(packed[tt.gasFeesOffset:tt.gasFeesOffset+32]))
+
+			// the hashed and the submitted representations agree
+			assert.Equal(t, expectedAccountGasLimits, common.Hash(op.ToPacked().AccountGasLimits))
+			assert.Equal(t, expectedGasFees, common.Hash(op.ToPacked().GasFees))
+		})
+	}
+}
+
+func TestEntrypointClient08_DomainSeparator_Cache(t *testing.T) {
+	entrypoint, err := NewEntrypoint08(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	polygon, err := entrypoint.DomainSeparator()
+	require.NoError(t, err)
+	assert.Equal(t, "0xa5668c355c960b2c49ec7284aa868381cd46cab23733892034a4f10100c3b141", polygon.Hex())
+
+	// the returned hash is a copy, modifying it doesn't alter the cache
+	polygon[0] = 0
+	cached, err := entrypoint.DomainSeparator()
+	require.NoError(t, err)
+	assert.Equal(t, "0xa5668c355c960b2c49ec7284aa868381cd46cab23733892034a4f10100c3b141", cached.Hex())
+
+	entrypoint.ChainID = big.NewInt(1)
+	mainnet, err := entrypoint.DomainSeparator()
+	require.NoError(t, err)
+	assert.NotEqual(t, cached.Hex(), mainnet.Hex())
+
+	entrypoint.Address = common.HexToAddress("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633")
+	customAddress, err := entrypoint.DomainSeparator()
+	require.NoError(t, err)
+	assert.NotEqual(t, mainnet.Hex(), customAddress.Hex())
+
+	entrypoint.Address = common.HexToAddress(entryPointAddress08)
+	entrypoint.ChainID = big.NewInt(ChainPolygon)
+	recomputed, err := entrypoint.DomainSeparator()
+	require.NoError(t, err)
+	assert.Equal(t, cached.Hex(), recomputed.Hex())
+
+	entrypoint.ChainID = nil
+	_, err = entrypoint.DomainSeparator()
+	assert.EqualError(t, err, "chainID is required")
+}
+
+func TestEntrypoint_GetNonceWithKey(t *testing.T) {
+	account := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	key := new(big.Int).SetBytes(common.FromHex("0x01845adb2c711129d4f3966735ed98a9f09fc4ce570001"))
+	keyedNonce := new(big.Int).Or(new(big.Int).Lsh(key, 64), big.NewInt(5))
+
+	tests := []struct {
+		name          string
+		key           *big.Int
+		result        string
+		expectedNonce *big.Int
+		expectedError string
+	}{
+		{
+			name:          "default_key",
+			result:        "0x0000000000000000000000000000000000000000000000000000000000000005",
+			expectedNonce: big.NewInt(5),
+		},
+		{
+			name:          "key",
+			key:           key,
+			result:        hexutil.Encode(common.BigToHash(keyedNonce).Bytes()),
+			expectedNonce: keyedNonce,
+		},
+		{
+			// the entrypoint returns the key of accounts which are not deployed yet, some RPCs return the sequence only
+			name:          "key_not_encoded",
+			key:           key,
+			result:        "0x0000000000000000000000000000000000000000000000000000000000000005",
+			expectedNonce: keyedNonce,
+		},
+		{
+			name:          "key_mismatch",
+			key:           big.NewInt(1),
+			result:        hexutil.Encode(common.BigToHash(keyedNonce).Bytes()),
+			expectedError: "requested key 1",
+		},
+		{
+			name:          "no_entrypoint",
+			result:        "0x",
+			expectedError: "getNonce returned no data",
+		},
+		{
+			name:          "key_too_large",
+			key:           new(big.Int).Lsh(big.NewInt(1), nonceKeyBits),
+			expectedError: "nonce key must be a non-negative 192-bit integer",
+		},
+	}
+
+	for _, version := range []string{EntryPointVersion06, EntryPointVersion07, EntryPointVersion08} {
+		for _, tt := range tests {
+			t.Run(version+"_"+tt.name, func(t *testing.T) {
+				network := zerodevtest.NewMockRPCClient().On("eth_call", tt.result, nil)
+				entrypoint, err := newEntrypoint(version, network, big.NewInt(ChainPolygon), common.Address{})
+				require.NoError(t, err)
+
+				nonce, err := entrypoint.GetNonceWithKey(context.Background(), account, tt.key)
+				if tt.expectedError != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.expectedError)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedNonce, nonce)
+				assert.Equal(t, nonceKeyOf(tt.expectedNonce), nonceKeyOf(nonce))
+			})
+		}
+	}
+}
+
+// entryPointCallData returns the data of the first eth_call made to mock
+func entryPointCallData(t *testing.T, mock *zerodevtest.MockRPCClient) []byte {
+	t.Helper()
+
+	var call struct {
+		Data hexutil.Bytes `json:"data"`
+	}
+	encoded, err := json.Marshal(mock.Calls()[0].Args[0])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(encoded, &call))
+	return call.Data
+}
+
+func TestEntrypoint_BalanceOf(t *testing.T) {
+	account := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+
+	for _, version := range []string{EntryPointVersion06, EntryPointVersion07, EntryPointVersion08} {
+		t.Run(version, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient().On("eth_call", "0x00000000000000000000000000000000000000000000000000038d7ea4c68000", nil)
+			entrypoint, err := newEntrypoint(version, mock, big.NewInt(ChainPolygon), common.Address{})
+			require.NoError(t, err)
+
+			balance, err := entrypoint.(EntrypointDepositReader).BalanceOf(context.Background(), account)
+			require.NoError(t, err)
+			assert.Equal(t, big.NewInt(1_000_000_000_000_000), balance)
+			assert.Equal(t, append(crypto.Keccak256([]byte("balanceOf(address)"))[:4], common.LeftPadBytes(account.Bytes(), 32)...), entryPointCallData(t, mock))
+		})
+	}
+}
+
+func TestEntrypoint_GetUserOperationHashOnChain(t *testing.T) {
+	tests := []struct {
+		version           string
+		expectedSignature string
+	}{
+		{
+			version:           EntryPointVersion06,
+			expectedSignature: "getUserOpHash((address,uint256,bytes,bytes,uint256,uint256,uint256,uint256,uint256,bytes,bytes))",
+		},
+		{
+			version:           EntryPointVersion07,
+			expectedSignature: "getUserOpHash((address,uint256,bytes,bytes,bytes32,uint256,bytes32,bytes,bytes))",
+		},
+		{
+			version:           EntryPointVersion08,
+			expectedSignature: "getUserOpHash((address,uint256,bytes,bytes,bytes32,uint256,bytes32,bytes,bytes))",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			hash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+			mock := zerodevtest.NewMockRPCClient().On("eth_call", hash.Hex(), nil)
+			entrypoint, err := newEntrypoint(tt.version, mock, big.NewInt(ChainPolygon), common.Address{})
+			require.NoError(t, err)
+
+			onChainHash, err := entrypoint.(EntrypointOnChainHasher).GetUserOperationHashOnChain(context.Background(), newTestUserOperation())
+			require.NoError(t, err)
+			assert.Equal(t, hash, *onChainHash)
+
+			data := entryPointCallData(t, mock)
+			assert.Equal(t, crypto.Keccak256([]byte(tt.expectedSignature))[:4], data[:4])
+			// the sender is the first word of the tuple encoded after its offset
+			assert.Equal(t, common.LeftPadBytes(newTestUserOperation().Sender.Bytes(), 32), data[4+32:4+64])
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package zerodev
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// FundAccount sends amount wei from the EOA of from to the client's account, e.g. to pay for self-funded user operations.
+// The plain value transfer is signed by from, which pays the amount and the gas, and sent through the network RPC.
+// Returns the transaction hash.
+func (c *Client) FundAccount(ctx context.Context, from *ecdsa.PrivateKey, amount *big.Int) (common.Hash, error) {
+	if from == nil {
+		return common.Hash{}, errors.New("from is required")
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return common.Hash{}, errors.New("fund amount must be positive")
+	}
+
+	account := c.Signer.GetAddress()
+	if account == (common.Address{}) {
+		return common.Hash{}, errors.New("account address is required")
+	}
+
+	return c.sendTransaction(ctx, "fund account", account, nil, amount, from)
+}
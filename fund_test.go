@@ -0,0 +1,76 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_FundAccount(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	client, network, _ := newTestClient(t, 0)
+	network.On("eth_getTransactionCount", "0x7", nil).
+		On("eth_estimateGas", nil, &mockJSONRPCError{code: -32000, message: "insufficient funds for transfer"})
+
+	_, err = client.FundAccount(context.Background(), privateKey, big.NewInt(1_000))
+	assert.ErrorContains(t, err, "failed to estimate fund account gas")
+
+	estimate := network.Calls()[len(network.Calls())-1]
+	require.Equal(t, "eth_estimateGas", estimate.Method)
+	callArgs := estimate.Args[0].(map[string]interface{})
+	assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), callArgs["from"])
+	assert.Equal(t, client.Signer.GetAddress(), callArgs["to"])
+	assert.Equal(t, (*hexutil.Big)(big.NewInt(1_000)), callArgs["value"])
+	assert.Empty(t, callArgs["data"])
+
+	_, err = client.FundAccount(context.Background(), privateKey, nil)
+	assert.EqualError(t, err, "fund amount must be positive")
+	_, err = client.FundAccount(context.Background(), nil, big.NewInt(1_000))
+	assert.EqualError(t, err, "from is required")
+}
+
+func TestClient_FundAccount_Send(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+	txHash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+
+	client, network, _ := newTestClient(t, 0)
+	// a chain without a base fee gets a legacy transaction priced with eth_gasPrice
+	network.On("eth_getTransactionCount", "0x3", nil).
+		On("eth_estimateGas", "0x5208", nil).
+		On("eth_getBlockByNumber", json.RawMessage(`{}`), nil).
+		On("eth_gasPrice", "0x77359400", nil).
+		On("eth_sendRawTransaction", txHash, nil)
+
+	hash, err := client.FundAccount(context.Background(), privateKey, big.NewInt(1_000))
+	require.NoError(t, err)
+	assert.Equal(t, txHash, hash)
+
+	tx := decodeSentTransaction(t, network)
+	assert.Equal(t, client.Signer.GetAddress(), *tx.To())
+	assert.Equal(t, big.NewInt(1_000), tx.Value())
+	assert.Empty(t, tx.Data())
+	assert.Equal(t, uint64(3), tx.Nonce())
+	assert.Equal(t, uint64(21_000), tx.Gas())
+	assert.Equal(t, uint8(ethtypes.LegacyTxType), tx.Type())
+	assert.Equal(t, big.NewInt(2_000_000_000), tx.GasPrice())
+	assert.Equal(t, client.ChainID, tx.ChainId())
+
+	sender, err := ethtypes.Sender(ethtypes.LatestSignerForChainID(client.ChainID), tx)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), sender)
+
+	getTransactionCount := network.Calls()[0]
+	require.Equal(t, "eth_getTransactionCount", getTransactionCount.Method)
+	assert.Equal(t, []interface{}{sender, "pending"}, getTransactionCount.Args)
+}
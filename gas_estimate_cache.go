@@ -0,0 +1,181 @@
+package zerodev
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// GasEstimateCache stores recent gas estimates of user operations, so similar operations reuse them instead of estimating again.
+// Keys hash the sender, the target, selector and call data length of each call executed, the paymaster, the gas speed and whether
+// the account is deployed. Implement it to share estimates between processes, e.g. with Redis.
+type GasEstimateCache interface {
+	// Get returns the estimate stored for key, or nil if there is none
+	Get(ctx context.Context, key common.Hash) (*GasEstimate, error)
+	// Set stores estimate for key, replacing the estimate stored before
+	Set(ctx context.Context, key common.Hash, estimate *GasEstimate) error
+}
+
+// MemoryGasEstimateCache is an in-memory GasEstimateCache, estimates expire TTL after they were stored.
+type MemoryGasEstimateCache struct {
+	TTL time.Duration
+
+	mu        sync.Mutex
+	estimates map[common.Hash]memoryGasEstimateEntry
+	now       func() time.Time
+}
+
+type memoryGasEstimateEntry struct {
+	estimate  *GasEstimate
+	expiresAt time.Time
+}
+
+// NewMemoryGasEstimateCache creates a MemoryGasEstimateCache keeping estimates for ttl, keep it short as estimates depend on the chain state
+func NewMemoryGasEstimateCache(ttl time.Duration) *MemoryGasEstimateCache {
+	return &MemoryGasEstimateCache{
+		TTL:       ttl,
+		estimates: make(map[common.Hash]memoryGasEstimateEntry),
+		now:       time.Now,
+	}
+}
+
+func (m *MemoryGasEstimateCache) Get(_ context.Context, key common.Hash) (*GasEstimate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.estimates[key]
+	if !ok {
+		return nil, nil
+	}
+	if !m.currentTime().Before(entry.expiresAt) {
+		delete(m.estimates, key)
+		return nil, nil
+	}
+	return entry.estimate, nil
+}
+
+// Set stores estimate and evicts the expired estimates
+func (m *MemoryGasEstimateCache) Set(_ context.Context, key common.Hash, estimate *GasEstimate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.estimates == nil {
+		m.estimates = make(map[common.Hash]memoryGasEstimateEntry)
+	}
+
+	now := m.currentTime()
+	for k, entry := range m.estimates {
+		if !now.Before(entry.expiresAt) {
+			delete(m.estimates, k)
+		}
+	}
+
+	m.estimates[key] = memoryGasEstimateEntry{estimate: estimate, expiresAt: now.Add(m.TTL)}
+	return nil
+}
+
+// Len returns the number of stored estimates, including expired estimates not evicted yet
+func (m *MemoryGasEstimateCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.estimates)
+}
+
+func (m *MemoryGasEstimateCache) currentTime() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+// gasEstimateKey hashes what the estimate of op depends on: its sender, the calls it executes, its paymaster, the gas speed its fees
+// come from, so estimates aren't reused across fee tiers, and whether it deploys or delegates the account.
+func gasEstimateKey(op *UserOperation, speed GasSpeed) common.Hash {
+	initializes := byte(0)
+	if op.Factory != (common.Address{}) || op.EIP7702Auth != nil {
+		initializes = 1
+	}
+
+	paymaster := common.BytesToAddress(op.Paymaster)
+	return crypto.Keccak256Hash(op.Sender.Bytes(), callDataShape(op.CallData), paymaster.Bytes(), []byte{initializes}, []byte(speed))
+}
+
+// callDataShape hashes the target, selector, data length and whether value is sent of each call executed by the Kernel call data,
+// the execute selector alone is the same for every call. Other call data is hashed whole, its estimate is only reused for the same call data
+func callDataShape(callData []byte) []byte {
+	calls, err := DecodeCallData(callData)
+	if err != nil {
+		return crypto.Keccak256(callData)
+	}
+
+	var shape bytes.Buffer
+	for _, call := range calls {
+		var selector [4]byte
+		copy(selector[:], call.Data)
+
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(call.Data)))
+
+		sendsValue := byte(0)
+		if call.Value != nil && call.Value.Sign() > 0 {
+			sendsValue = 1
+		}
+
+		shape.Write(call.To.Bytes())
+		shape.Write(selector[:])
+		shape.Write(length[:])
+		shape.WriteByte(sendsValue)
+	}
+	return crypto.Keccak256(shape.Bytes())
+}
+
+// estimateUserOperationGas estimates the gas limits of op with the bundler, or reuses a cached estimate of a similar operation
+func (c *Client) estimateUserOperationGas(ctx context.Context, op *UserOperation, speed GasSpeed) (*GasEstimate, bool, error) {
+	if c.GasEstimateCache == nil {
+		gasEstimate, err := c.BundlerClient.EstimateUserOperationGas(ctx, op)
+		return gasEstimate, false, err
+	}
+
+	key := gasEstimateKey(op, speed)
+	cached, err := c.GasEstimateCache.Get(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if cached != nil {
+		return copyGasEstimate(cached), true, nil
+	}
+
+	gasEstimate, err := c.BundlerClient.EstimateUserOperationGas(ctx, op)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := c.GasEstimateCache.Set(ctx, key, copyGasEstimate(gasEstimate)); err != nil {
+		return nil, false, err
+	}
+
+	return gasEstimate, false, nil
+}
+
+// copyGasEstimate copies estimate so operations using a cached estimate don't share its values
+func copyGasEstimate(estimate *GasEstimate) *GasEstimate {
+	copyBig := func(value *big.Int) *big.Int {
+		if value == nil {
+			return nil
+		}
+		return new(big.Int).Set(value)
+	}
+
+	return &GasEstimate{
+		PreVerificationGas:            copyBig(estimate.PreVerificationGas),
+		VerificationGasLimit:          copyBig(estimate.VerificationGasLimit),
+		CallGasLimit:                  copyBig(estimate.CallGasLimit),
+		PaymasterVerificationGasLimit: copyBig(estimate.PaymasterVerificationGasLimit),
+		PaymasterPostOpGasLimit:       copyBig(estimate.PaymasterPostOpGasLimit),
+	}
+}
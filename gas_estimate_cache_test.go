@@ -0,0 +1,235 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryGasEstimateCache(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cache := NewMemoryGasEstimateCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	first := common.HexToHash("0x01")
+	second := common.HexToHash("0x02")
+	estimate := &GasEstimate{CallGasLimit: big.NewInt(100_000)}
+
+	cached, err := cache.Get(context.Background(), first)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+
+	require.NoError(t, cache.Set(context.Background(), first, estimate))
+	cached, err = cache.Get(context.Background(), first)
+	require.NoError(t, err)
+	assert.Equal(t, estimate, cached)
+
+	// expired estimates are not returned and are evicted by Get and Set
+	now = now.Add(time.Minute)
+	cached, err = cache.Get(context.Background(), first)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+	assert.Equal(t, 0, cache.Len())
+
+	require.NoError(t, cache.Set(context.Background(), first, estimate))
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, cache.Set(context.Background(), second, estimate))
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestGasEstimateKey(t *testing.T) {
+	token := common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359")
+	transfer := func(amount byte) []byte {
+		return append(common.FromHex("0xa9059cbb0000000000000000000000005fbdb2315678afecb367f032d93f642f64180aa3"), common.LeftPadBytes([]byte{amount}, 32)...)
+	}
+	execute := func(to common.Address, value *big.Int, data []byte) []byte {
+		callData, err := EncodeExecute(to, value, data)
+		require.NoError(t, err)
+		return callData
+	}
+
+	base := func() *UserOperation {
+		return &UserOperation{
+			Sender:   common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"),
+			Nonce:    big.NewInt(1),
+			CallData: execute(token, big.NewInt(0), transfer(1)),
+		}
+	}
+	key := gasEstimateKey(base(), GasSpeedStandard)
+
+	tests := []struct {
+		name         string
+		modify       func(op *UserOperation)
+		speed        GasSpeed
+		expectedSame bool
+	}{
+		{
+			name: "same_shape",
+			modify: func(op *UserOperation) {
+				op.Nonce = big.NewInt(2)
+				op.CallData = execute(token, big.NewInt(0), transfer(2))
+				op.MaxFeePerGas = big.NewInt(30_000_000_000)
+			},
+			speed:        GasSpeedStandard,
+			expectedSame: true,
+		},
+		{
+			name:   "sender",
+			modify: func(op *UserOperation) { op.Sender = common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3") },
+			speed:  GasSpeedStandard,
+		},
+		{
+			// the Kernel execute selector and length are the same, the target differs
+			name: "target",
+			modify: func(op *UserOperation) {
+				op.CallData = execute(common.HexToAddress("0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174"), big.NewInt(0), transfer(1))
+			},
+			speed: GasSpeedStandard,
+		},
+		{
+			name: "selector",
+			modify: func(op *UserOperation) {
+				data := transfer(1)
+				data[0] = 0x09
+				op.CallData = execute(token, big.NewInt(0), data)
+			},
+			speed: GasSpeedStandard,
+		},
+		{
+			name:   "length",
+			modify: func(op *UserOperation) { op.CallData = execute(token, big.NewInt(0), append(transfer(1), 0x00)) },
+			speed:  GasSpeedStandard,
+		},
+		{
+			name:   "value",
+			modify: func(op *UserOperation) { op.CallData = execute(token, big.NewInt(1), transfer(1)) },
+			speed:  GasSpeedStandard,
+		},
+		{
+			name: "batch",
+			modify: func(op *UserOperation) {
+				callData, err := EncodeExecuteBatchCall([]BatchCall{{To: token, Data: transfer(1)}, {To: token, Data: transfer(1)}})
+				require.NoError(t, err)
+				op.CallData = *callData
+			},
+			speed: GasSpeedStandard,
+		},
+		{
+			name:   "not_kernel_call_data",
+			modify: func(op *UserOperation) { op.CallData = transfer(1) },
+			speed:  GasSpeedStandard,
+		},
+		{
+			name:   "paymaster",
+			modify: func(op *UserOperation) { op.Paymaster = common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633") },
+			speed:  GasSpeedStandard,
+		},
+		{
+			name:   "speed",
+			modify: func(op *UserOperation) {},
+			speed:  GasSpeedFast,
+		},
+		{
+			name: "deployment",
+			modify: func(op *UserOperation) {
+				op.Factory = common.HexToAddress("0xd703aaE79538628d27099B8c4f621bE4CCd142d5")
+			},
+			speed: GasSpeedStandard,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := base()
+			tt.modify(op)
+			assert.Equal(t, tt.expectedSame, gasEstimateKey(op, tt.speed) == key)
+		})
+	}
+
+	// call data that isn't a Kernel execute call is only reused for the same call data
+	op := base()
+	op.CallData = transfer(1)
+	same := base()
+	same.CallData = transfer(1)
+	assert.Equal(t, gasEstimateKey(op, GasSpeedStandard), gasEstimateKey(same, GasSpeedStandard))
+	same.CallData = transfer(2)
+	assert.NotEqual(t, gasEstimateKey(op, GasSpeedStandard), gasEstimateKey(same, GasSpeedStandard))
+}
+
+func TestClient_GasEstimateCache(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.GasEstimateCache = NewMemoryGasEstimateCache(time.Minute)
+	client.GasLimitMultiplier = 1.2
+	tracer := &recordingTracer{}
+	client.Tracer = tracer
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x186a0"}`), nil)
+
+	sender := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	build := func(callData []byte, speed GasSpeed) *UserOperation {
+		op, _, err := client.GetUserOperationAndHashToSignWithOptions(context.Background(), sender, &callData, &UserOperationOptions{
+			SelfFunded:   true,
+			GasOverrides: &GasOverrides{Speed: speed},
+		})
+		require.NoError(t, err)
+		return op
+	}
+
+	execute := func(data string) []byte {
+		callData, err := EncodeExecute(common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359"), big.NewInt(0), common.FromHex(data))
+		require.NoError(t, err)
+		return callData
+	}
+
+	first := build(execute("0xdeadbeef01"), GasSpeedStandard)
+	assert.Equal(t, 1, bundler.CallCount("eth_estimateUserOperationGas"))
+	assert.NotContains(t, tracer.span(SpanEstimateUserOperationGas).attributes, AttributeGasEstimateCached)
+
+	// the cached estimate is reused as estimated, the multiplier is applied again
+	tracer = &recordingTracer{}
+	client.Tracer = tracer
+	second := build(execute("0xdeadbeef02"), GasSpeedStandard)
+	assert.Equal(t, 1, bundler.CallCount("eth_estimateUserOperationGas"))
+	assert.Equal(t, "true", tracer.span(SpanEstimateUserOperationGas).attributes[AttributeGasEstimateCached])
+	assert.Equal(t, first.CallGasLimit, second.CallGasLimit)
+	assert.Equal(t, big.NewInt(120_000), second.CallGasLimit)
+	assert.Equal(t, big.NewInt(240_000), second.VerificationGasLimit)
+	assert.Equal(t, big.NewInt(50_000), second.PreVerificationGas)
+
+	// another fee tier estimates again
+	build(execute("0xdeadbeef03"), GasSpeedFast)
+	assert.Equal(t, 2, bundler.CallCount("eth_estimateUserOperationGas"))
+}
+
+func TestClient_GasEstimateCache_Sponsored(t *testing.T) {
+	client, _, paymaster := newTestClient(t, 0)
+	client.GasEstimateCache = NewMemoryGasEstimateCache(time.Minute)
+	client.PaymasterConfig = &PaymasterConfig{Mode: PaymasterModeSponsored, EIP7677: true}
+	paymaster.
+		On("pm_getPaymasterStubData", json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0x00", "paymasterVerificationGasLimit": "0xafc8", "paymasterPostOpGasLimit": "0x1"}`), nil).
+		On("pm_getPaymasterData", json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0xabab"}`), nil)
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x186a0", "paymasterVerificationGasLimit": "0xc350"}`), nil)
+
+	sender := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	for _, data := range []string{"0xdeadbeef01", "0xdeadbeef02"} {
+		callData, err := EncodeExecute(common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359"), big.NewInt(0), common.FromHex(data))
+		require.NoError(t, err)
+
+		op, _, err := client.GetUserOperationAndHashToSign(context.Background(), sender, &callData)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(100_000), op.CallGasLimit)
+		assert.Equal(t, big.NewInt(50_000), op.PaymasterVerificationGasLimit)
+	}
+
+	// the EIP-7677 estimate is reused, the paymaster still signs every operation
+	assert.Equal(t, 1, bundler.CallCount("eth_estimateUserOperationGas"))
+	assert.Equal(t, 2, paymaster.CallCount("pm_getPaymasterData"))
+}
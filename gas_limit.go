@@ -0,0 +1,84 @@
+package zerodev
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Bounds of ClientConfig.GasLimitMultiplier, multipliers outside of them are clamped
+const (
+	minGasLimitMultiplier = 1.0
+	maxGasLimitMultiplier = 3.0
+)
+
+// gasLimitMultiplierPrecision is the precision the multiplier is applied with, 1.2 multiplies by 1200/1000
+const gasLimitMultiplierPrecision = 1000
+
+// clampGasLimitMultiplier bounds multiplier to [minGasLimitMultiplier, maxGasLimitMultiplier], unset (0) and invalid multipliers disable it
+func clampGasLimitMultiplier(multiplier float64) float64 {
+	if multiplier == 0 || math.IsNaN(multiplier) {
+		return 0
+	}
+	return math.Min(math.Max(multiplier, minGasLimitMultiplier), maxGasLimitMultiplier)
+}
+
+// multiplyGasLimits raises the estimated call and verification gas limits of op by multiplier, rounding up.
+// It reports whether the limits were changed, multipliers up to 1 leave them as estimated.
+func multiplyGasLimits(op *UserOperation, multiplier float64) bool {
+	if multiplier <= minGasLimitMultiplier {
+		return false
+	}
+
+	scale := big.NewInt(int64(math.Round(multiplier * gasLimitMultiplierPrecision)))
+	op.CallGasLimit = multiplyGasLimit(op.CallGasLimit, scale)
+	op.VerificationGasLimit = multiplyGasLimit(op.VerificationGasLimit, scale)
+	return true
+}
+
+func multiplyGasLimit(limit *big.Int, scale *big.Int) *big.Int {
+	if limit == nil {
+		return nil
+	}
+
+	multiplied := new(big.Int).Mul(limit, scale)
+	multiplied.Add(multiplied, big.NewInt(gasLimitMultiplierPrecision-1))
+	return multiplied.Div(multiplied, big.NewInt(gasLimitMultiplierPrecision))
+}
+
+// applyGasLimitMultiplier raises the estimated gas limits of op by the client's GasLimitMultiplier and logs the adjustment
+func (c *Client) applyGasLimitMultiplier(ctx context.Context, op *UserOperation) bool {
+	callGasLimit, verificationGasLimit := op.CallGasLimit, op.VerificationGasLimit
+	if !multiplyGasLimits(op, c.GasLimitMultiplier) {
+		return false
+	}
+
+	c.logger().InfoContext(ctx, "raised the estimated gas limits of the user operation",
+		"sender", op.Sender,
+		"multiplier", c.GasLimitMultiplier,
+		"callGasLimit", callGasLimit,
+		"adjustedCallGasLimit", op.CallGasLimit,
+		"verificationGasLimit", verificationGasLimit,
+		"adjustedVerificationGasLimit", op.VerificationGasLimit,
+	)
+	return true
+}
+
+// applyPaymasterGasLimitFloors raises the paymaster gas limits of op estimated below the client's floors, nil floors are not applied
+func (c *Client) applyPaymasterGasLimitFloors(op *UserOperation) {
+	op.PaymasterVerificationGasLimit = maxGasLimit(op.PaymasterVerificationGasLimit, c.MinPaymasterVerificationGasLimit)
+	op.PaymasterPostOpGasLimit = maxGasLimit(op.PaymasterPostOpGasLimit, c.MinPaymasterPostOpGasLimit)
+}
+
+// maxGasLimit returns floor if limit is unset or below it, limit otherwise
+func maxGasLimit(limit *big.Int, floor *big.Int) *big.Int {
+	if floor == nil || (limit != nil && limit.Cmp(floor) >= 0) {
+		return limit
+	}
+	return new(big.Int).Set(floor)
+}
+
+func gasLimitMultiplierAttribute(multiplier float64) Attribute {
+	return Attribute{Key: AttributeGasLimitMultiplier, Value: strconv.FormatFloat(multiplier, 'f', -1, 64)}
+}
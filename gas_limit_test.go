@@ -0,0 +1,206 @@
+package zerodev
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"math/big"
+	"net/url"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClampGasLimitMultiplier(t *testing.T) {
+	tests := []struct {
+		multiplier float64
+		expected   float64
+	}{
+		{multiplier: 0, expected: 0},
+		{multiplier: math.NaN(), expected: 0},
+		{multiplier: 0.5, expected: 1},
+		{multiplier: 1.2, expected: 1.2},
+		{multiplier: 10, expected: 3},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, clampGasLimitMultiplier(tt.multiplier), "multiplier %v", tt.multiplier)
+	}
+}
+
+func TestMultiplyGasLimits(t *testing.T) {
+	tests := []struct {
+		name                         string
+		multiplier                   float64
+		expectedChanged              bool
+		expectedCallGasLimit         *big.Int
+		expectedVerificationGasLimit *big.Int
+	}{
+		{
+			name:                         "disabled",
+			multiplier:                   0,
+			expectedCallGasLimit:         big.NewInt(100_001),
+			expectedVerificationGasLimit: big.NewInt(200_000),
+		},
+		{
+			name:                         "one",
+			multiplier:                   1,
+			expectedCallGasLimit:         big.NewInt(100_001),
+			expectedVerificationGasLimit: big.NewInt(200_000),
+		},
+		{
+			name:                         "rounded_up",
+			multiplier:                   1.2,
+			expectedChanged:              true,
+			expectedCallGasLimit:         big.NewInt(120_002),
+			expectedVerificationGasLimit: big.NewInt(240_000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := newTestUserOperation()
+			op.CallGasLimit = big.NewInt(100_001)
+			preVerificationGas := new(big.Int).Set(op.PreVerificationGas)
+
+			assert.Equal(t, tt.expectedChanged, multiplyGasLimits(op, tt.multiplier))
+			assert.Equal(t, tt.expectedCallGasLimit, op.CallGasLimit)
+			assert.Equal(t, tt.expectedVerificationGasLimit, op.VerificationGasLimit)
+			assert.Equal(t, preVerificationGas, op.PreVerificationGas)
+		})
+	}
+}
+
+func TestClient_GetUserOperationAndHashToSign_GasLimitMultiplier(t *testing.T) {
+	tests := []struct {
+		name                         string
+		selfFunded                   bool
+		expectedCallGasLimit         *big.Int
+		expectedVerificationGasLimit *big.Int
+		expectedLog                  string
+	}{
+		{
+			name:                         "self_funded",
+			selfFunded:                   true,
+			expectedCallGasLimit:         big.NewInt(120_000),
+			expectedVerificationGasLimit: big.NewInt(240_000),
+			expectedLog:                  "multiplier=1.2 callGasLimit=100000 adjustedCallGasLimit=120000 verificationGasLimit=200000 adjustedVerificationGasLimit=240000",
+		},
+		{
+			// limits returned by zd_sponsorUserOperation are covered by the paymaster signature
+			name:                         "sponsored",
+			expectedCallGasLimit:         big.NewInt(100_000),
+			expectedVerificationGasLimit: big.NewInt(200_000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			client, _, _ := newTestClient(t, 0)
+			client.GasLimitMultiplier = 1.2
+			client.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+			client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+				On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x186a0"}`), nil)
+
+			callData := common.FromHex("0xdeadbeef")
+			op, _, err := client.GetUserOperationAndHashToSignWithOptions(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData, &UserOperationOptions{
+				SelfFunded: tt.selfFunded,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedCallGasLimit, op.CallGasLimit)
+			assert.Equal(t, tt.expectedVerificationGasLimit, op.VerificationGasLimit)
+			assert.Equal(t, big.NewInt(50_000), op.PreVerificationGas)
+			if tt.expectedLog != "" {
+				assert.Contains(t, logs.String(), tt.expectedLog)
+			} else {
+				assert.Empty(t, logs.String())
+			}
+		})
+	}
+}
+
+func TestMaxGasLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    *big.Int
+		floor    *big.Int
+		expected *big.Int
+	}{
+		{name: "no_floor", limit: big.NewInt(10), expected: big.NewInt(10)},
+		{name: "below_floor", limit: big.NewInt(10), floor: big.NewInt(50), expected: big.NewInt(50)},
+		{name: "above_floor", limit: big.NewInt(100), floor: big.NewInt(50), expected: big.NewInt(100)},
+		{name: "unset", floor: big.NewInt(50), expected: big.NewInt(50)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, maxGasLimit(tt.limit, tt.floor))
+		})
+	}
+}
+
+func TestClient_SponsorEIP7677_PaymasterGasLimitFloors(t *testing.T) {
+	client, _, paymaster := newTestClient(t, 0)
+	client.PaymasterConfig = &PaymasterConfig{Mode: PaymasterModeSponsored, EIP7677: true}
+	client.MinPaymasterVerificationGasLimit = big.NewInt(100_000)
+	client.MinPaymasterPostOpGasLimit = big.NewInt(1)
+	paymaster.
+		On("pm_getPaymasterStubData", json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0x00", "paymasterVerificationGasLimit": "0xafc8", "paymasterPostOpGasLimit": "0x2"}`), nil).
+		On("pm_getPaymasterData", json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0xabab"}`), nil)
+	client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x186a0", "paymasterVerificationGasLimit": "0xc350"}`), nil)
+
+	callData := common.FromHex("0xdeadbeef")
+	op, _, err := client.GetUserOperationAndHashToSign(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData)
+	require.NoError(t, err)
+
+	// the estimate below the floor is raised, the one above is kept
+	assert.Equal(t, big.NewInt(100_000), op.PaymasterVerificationGasLimit)
+	assert.Equal(t, big.NewInt(2), op.PaymasterPostOpGasLimit)
+
+	// the paymaster signs the raised limits
+	final := paymaster.Calls()[1].Args[0].(*UserOperation)
+	assert.Equal(t, big.NewInt(100_000), final.PaymasterVerificationGasLimit)
+}
+
+func TestClient_SponsorEIP7677_FinalStubData(t *testing.T) {
+	client, _, paymaster := newTestClient(t, 0)
+	client.PaymasterConfig = &PaymasterConfig{Mode: PaymasterModeSponsored, EIP7677: true}
+	client.GasLimitMultiplier = 1.5
+	client.MinPaymasterVerificationGasLimit = big.NewInt(100_000)
+	paymaster.On("pm_getPaymasterStubData", json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0xcdcd", "paymasterVerificationGasLimit": "0xafc8", "paymasterPostOpGasLimit": "0x1", "isFinal": true}`), nil)
+	client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x186a0", "paymasterVerificationGasLimit": "0xc350"}`), nil)
+
+	callData := common.FromHex("0xdeadbeef")
+	op, _, err := client.GetUserOperationAndHashToSign(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData)
+	require.NoError(t, err)
+
+	// the paymaster already signed the final stub data, neither the multiplier nor the floor raise the limits
+	assert.Equal(t, big.NewInt(100_000), op.CallGasLimit)
+	assert.Equal(t, big.NewInt(200_000), op.VerificationGasLimit)
+	assert.Equal(t, big.NewInt(50_000), op.PaymasterVerificationGasLimit)
+	assert.Equal(t, 0, paymaster.CallCount("pm_getPaymasterData"))
+}
+
+func TestNewClient_PaymasterGasLimitFloorsRequireEIP7677(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	_, err = NewClient(&ClientConfig{
+		AccountPK:                        privateKey,
+		EntryPointVersion:                EntryPointVersion07,
+		BundlerURL:                       &url.URL{Scheme: "http", Host: "bundler"},
+		ChainID:                          big.NewInt(ChainPolygon),
+		MinPaymasterVerificationGasLimit: big.NewInt(100_000),
+	})
+	assert.EqualError(t, err, "minPaymasterVerificationGasLimit and minPaymasterPostOpGasLimit require an EIP-7677 paymaster")
+}
@@ -0,0 +1,115 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/friendsofgo/errors"
+	"math"
+	"math/big"
+)
+
+// jsonRPCErrorCodeMethodNotFound is the JSON-RPC error code of methods the server doesn't implement
+const jsonRPCErrorCodeMethodNotFound = -32601
+
+// isMethodNotFound reports whether err is a JSON-RPC "method not found" error. Only the error code is checked,
+// other errors may be transient and must not switch the bundler to the network gas price for good
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+
+	return rpcErr.ErrorCode() == jsonRPCErrorCodeMethodNotFound
+}
+
+// getNetworkGasPrice derives the gas price from the network RPC for bundlers without zd_getUserOperationGasPrice.
+// maxFeePerGas is twice the base fee of the latest block plus the priority fee, so it stays valid through a few full blocks,
+// chains without base fee use eth_gasPrice. The network has a single suggestion, all speeds get the same gas price.
+func getNetworkGasPrice(ctx context.Context, rpcClient types.RPCClient) (*GetUserOperationGasPriceResponse, error) {
+	var priorityFee hexutil.Big
+	if err := rpcClient.CallContext(ctx, &priorityFee, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, errors.Wrap(err, "failed to call eth_maxPriorityFeePerGas")
+	}
+
+	baseFee, err := getBaseFee(ctx, rpcClient)
+	if err != nil {
+		return nil, err
+	}
+
+	specification := &GasPriceSpecification{
+		MaxPriorityFeePerGas: priorityFee.ToInt(),
+	}
+
+	if baseFee != nil {
+		specification.MaxFeePerGas = maxFeePerGas(baseFee, specification.MaxPriorityFeePerGas)
+	} else {
+		var gasPrice hexutil.Big
+		if err := rpcClient.CallContext(ctx, &gasPrice, "eth_gasPrice"); err != nil {
+			return nil, errors.Wrap(err, "failed to call eth_gasPrice")
+		}
+
+		specification.MaxFeePerGas = gasPrice.ToInt()
+		if specification.MaxPriorityFeePerGas.Cmp(specification.MaxFeePerGas) > 0 {
+			specification.MaxPriorityFeePerGas = specification.MaxFeePerGas
+		}
+	}
+
+	return &GetUserOperationGasPriceResponse{
+		Slow:     specification,
+		Standard: specification,
+		Fast:     specification,
+	}, nil
+}
+
+// maxFeePerGas is twice baseFee plus priorityFee
+func maxFeePerGas(baseFee, priorityFee *big.Int) *big.Int {
+	maxFee := new(big.Int).Mul(baseFee, big.NewInt(2))
+	return maxFee.Add(maxFee, priorityFee)
+}
+
+// getBaseFee returns the base fee of the latest block, nil on chains without EIP-1559
+func getBaseFee(ctx context.Context, rpcClient types.RPCClient) (*big.Int, error) {
+	var block struct {
+		BaseFeePerGas *hexutil.Big `json:"baseFeePerGas"`
+	}
+	if err := rpcClient.CallContext(ctx, &block, "eth_getBlockByNumber", "latest", false); err != nil {
+		return nil, errors.Wrap(err, "failed to call eth_getBlockByNumber")
+	}
+
+	if block.BaseFeePerGas == nil {
+		return nil, nil
+	}
+	return block.BaseFeePerGas.ToInt(), nil
+}
+
+// capsMaxFeePerGas reports whether the maxFeePerGas suggested by the bundler is capped, fees overridden by the caller are not
+func (c *Client) capsMaxFeePerGas(overrides *GasOverrides) bool {
+	return c.MaxFeePerGasBaseFeeMultiplier > 0 && (overrides == nil || overrides.MaxFeePerGas == nil)
+}
+
+// capMaxFeePerGas lowers maxFeePerGas of op to baseFee * MaxFeePerGasBaseFeeMultiplier + maxPriorityFeePerGas.
+// Capped fees are reported with the AttributeMaxFeePerGasCapped attribute of a SpanCapMaxFeePerGas span.
+func (c *Client) capMaxFeePerGas(ctx context.Context, op *UserOperation, baseFee *big.Int) {
+	scale := big.NewInt(int64(math.Round(c.MaxFeePerGasBaseFeeMultiplier * gasLimitMultiplierPrecision)))
+	maxFee := multiplyGasLimit(baseFee, scale)
+	maxFee.Add(maxFee, op.MaxPriorityFeePerGas)
+	if op.MaxFeePerGas.Cmp(maxFee) <= 0 {
+		return
+	}
+
+	_, span := c.startSpan(ctx, SpanCapMaxFeePerGas,
+		Attribute{Key: AttributeMaxFeePerGasCapped, Value: op.MaxFeePerGas.String() + " capped to " + maxFee.String()},
+		bigIntAttribute(AttributeBaseFee, baseFee),
+	)
+	span.End()
+
+	op.MaxFeePerGas = maxFee
+}
+
+// GetUserOperationGasPrice returns the slow, standard and fast gas prices suggested by the bundler, e.g. to show fees before sending an operation.
+// See BundlerClient.GetUserOperationGasPrice.
+func (c *Client) GetUserOperationGasPrice(ctx context.Context) (*GetUserOperationGasPriceResponse, error) {
+	return c.BundlerClient.GetUserOperationGasPrice(ctx)
+}
@@ -0,0 +1,196 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundlerClient_GetUserOperationGasPrice_NetworkFallback(t *testing.T) {
+	tests := []struct {
+		name                string
+		bundlerErr          error
+		block               json.RawMessage
+		expectedMaxFee      *big.Int
+		expectedPriorityFee *big.Int
+		expectedError       bool
+	}{
+		{
+			name:                "method_not_found",
+			bundlerErr:          &mockJSONRPCError{code: -32601, message: "the method zd_getUserOperationGasPrice does not exist/is not available"},
+			block:               json.RawMessage(`{"baseFeePerGas": "0x3b9aca00"}`),
+			expectedMaxFee:      big.NewInt(2_100_000_000),
+			expectedPriorityFee: big.NewInt(100_000_000),
+		},
+		{
+			name:                "no_base_fee",
+			bundlerErr:          &mockJSONRPCError{code: -32601, message: "method not found"},
+			block:               json.RawMessage(`{}`),
+			expectedMaxFee:      big.NewInt(50_000_000),
+			expectedPriorityFee: big.NewInt(50_000_000),
+		},
+		{
+			name:          "other_error",
+			bundlerErr:    &mockJSONRPCError{code: -32603, message: "internal error"},
+			expectedError: true,
+		},
+		{
+			name:          "not_supported_message",
+			bundlerErr:    &mockJSONRPCError{code: -32603, message: "operation not supported, the backend does not exist"},
+			expectedError: true,
+		},
+		{
+			name:          "network_error",
+			bundlerErr:    errors.New("connection refused"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+			require.NoError(t, err)
+
+			network := zerodevtest.NewMockRPCClient().
+				On("eth_maxPriorityFeePerGas", "0x5f5e100", nil).
+				On("eth_getBlockByNumber", tt.block, nil).
+				On("eth_gasPrice", "0x2faf080", nil)
+			mock := zerodevtest.NewMockRPCClient().On("zd_getUserOperationGasPrice", nil, tt.bundlerErr)
+
+			bundler := &BundlerClient{
+				Client:        mock,
+				EntryPoint:    entrypoint,
+				NetworkClient: network,
+			}
+
+			gasPrice, err := bundler.GetUserOperationGasPrice(context.Background())
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Equal(t, 0, network.CallCount("eth_maxPriorityFeePerGas"))
+
+				// the error isn't latched, the bundler is asked again
+				_, err = bundler.GetUserOperationGasPrice(context.Background())
+				assert.Error(t, err)
+				assert.Equal(t, 2, mock.CallCount("zd_getUserOperationGasPrice"))
+				return
+			}
+			require.NoError(t, err)
+
+			for _, specification := range []*GasPriceSpecification{gasPrice.Slow, gasPrice.Standard, gasPrice.Fast} {
+				assert.Equal(t, tt.expectedMaxFee, specification.MaxFeePerGas)
+				assert.Equal(t, tt.expectedPriorityFee, specification.MaxPriorityFeePerGas)
+			}
+
+			// the unsupported bundler method isn't called again
+			_, err = bundler.GetUserOperationGasPrice(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, mock.CallCount("zd_getUserOperationGasPrice"))
+			assert.Equal(t, 2, network.CallCount("eth_maxPriorityFeePerGas"))
+		})
+	}
+}
+
+func TestBundlerClient_GetUserOperationGasPrice_NoFallback(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	bundler := &BundlerClient{
+		Client:     zerodevtest.NewMockRPCClient().On("zd_getUserOperationGasPrice", nil, &mockJSONRPCError{code: -32601, message: "method not found"}),
+		EntryPoint: entrypoint,
+	}
+
+	_, err = bundler.GetUserOperationGasPrice(context.Background())
+	assert.Error(t, err)
+}
+
+func TestClient_GetUserOperationGasPrice(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+
+	gasPrice, err := client.GetUserOperationGasPrice(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, big.NewInt(20_000_000_000), gasPrice.Slow.MaxFeePerGas)
+	assert.Equal(t, big.NewInt(1_000_000_000), gasPrice.Slow.MaxPriorityFeePerGas)
+	assert.Equal(t, big.NewInt(30_000_000_000), gasPrice.Standard.MaxFeePerGas)
+	assert.Equal(t, big.NewInt(1_500_000_000), gasPrice.Standard.MaxPriorityFeePerGas)
+	assert.Equal(t, big.NewInt(50_000_000_000), gasPrice.Fast.MaxFeePerGas)
+	assert.Equal(t, big.NewInt(2_000_000_000), gasPrice.Fast.MaxPriorityFeePerGas)
+}
+
+func TestClient_GetUserOperationAndHashToSign_MaxFeePerGasCap(t *testing.T) {
+	tests := []struct {
+		name                 string
+		multiplier           float64
+		block                json.RawMessage
+		overrides            *GasOverrides
+		expectedMaxFee       *big.Int
+		expectedBlockCalls   int
+		expectedCappedReport string
+	}{
+		{
+			name:                 "capped",
+			multiplier:           2,
+			block:                json.RawMessage(`{"baseFeePerGas": "0x2540be400"}`),
+			expectedMaxFee:       big.NewInt(21_500_000_000),
+			expectedBlockCalls:   1,
+			expectedCappedReport: "30000000000 capped to 21500000000",
+		},
+		{
+			name:               "below_cap",
+			multiplier:         3,
+			block:              json.RawMessage(`{"baseFeePerGas": "0x2540be400"}`),
+			expectedMaxFee:     big.NewInt(30_000_000_000),
+			expectedBlockCalls: 1,
+		},
+		{
+			name:               "no_base_fee",
+			multiplier:         2,
+			block:              json.RawMessage(`{}`),
+			expectedMaxFee:     big.NewInt(30_000_000_000),
+			expectedBlockCalls: 1,
+		},
+		{
+			name:           "overridden",
+			multiplier:     2,
+			overrides:      &GasOverrides{MaxFeePerGas: big.NewInt(40_000_000_000)},
+			expectedMaxFee: big.NewInt(40_000_000_000),
+		},
+		{
+			name:           "disabled",
+			expectedMaxFee: big.NewInt(30_000_000_000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, network, _ := newTestClient(t, 0)
+			tracer := &recordingTracer{}
+			client.Tracer = tracer
+			client.MaxFeePerGasBaseFeeMultiplier = tt.multiplier
+			network.On("eth_getBlockByNumber", tt.block, nil)
+
+			callData := common.FromHex("0xdeadbeef")
+			op, _, err := client.GetUserOperationAndHashToSignWithOptions(context.Background(), client.Signer.GetAddress(), &callData, &UserOperationOptions{GasOverrides: tt.overrides})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedMaxFee, op.MaxFeePerGas)
+			assert.Equal(t, tt.expectedBlockCalls, network.CallCount("eth_getBlockByNumber"))
+
+			span := tracer.span(SpanCapMaxFeePerGas)
+			if tt.expectedCappedReport == "" {
+				assert.Nil(t, span)
+				return
+			}
+			require.NotNil(t, span)
+			assert.Equal(t, tt.expectedCappedReport, span.attributes[AttributeMaxFeePerGasCapped])
+			assert.Equal(t, "10000000000", span.attributes[AttributeBaseFee])
+		})
+	}
+}
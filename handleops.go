@@ -0,0 +1,193 @@
+package zerodev
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	handleOpsAbi07 = `[{"inputs": [{"components": [{"name": "sender", "type": "address"}, {"name": "nonce", "type": "uint256"}, {"name": "initCode", "type": "bytes"}, {"name": "callData", "type": "bytes"}, {"name": "accountGasLimits", "type": "bytes32"}, {"name": "preVerificationGas", "type": "uint256"}, {"name": "gasFees", "type": "bytes32"}, {"name": "paymasterAndData", "type": "bytes"}, {"name": "signature", "type": "bytes"}], "name": "ops", "type": "tuple[]"}, {"name": "beneficiary", "type": "address"}], "name": "handleOps", "outputs": [], "stateMutability": "nonpayable", "type": "function"}]`
+	handleOpsAbi06 = `[{"inputs": [{"components": [{"name": "sender", "type": "address"}, {"name": "nonce", "type": "uint256"}, {"name": "initCode", "type": "bytes"}, {"name": "callData", "type": "bytes"}, {"name": "callGasLimit", "type": "uint256"}, {"name": "verificationGasLimit", "type": "uint256"}, {"name": "preVerificationGas", "type": "uint256"}, {"name": "maxFeePerGas", "type": "uint256"}, {"name": "maxPriorityFeePerGas", "type": "uint256"}, {"name": "paymasterAndData", "type": "bytes"}, {"name": "signature", "type": "bytes"}], "name": "ops", "type": "tuple[]"}, {"name": "beneficiary", "type": "address"}], "name": "handleOps", "outputs": [], "stateMutability": "nonpayable", "type": "function"}]`
+)
+
+// userOperation06 is the on-chain representation of a UserOperation passed to handleOps of Entrypoint 0.6
+type userOperation06 struct {
+	Sender               common.Address
+	Nonce                *big.Int
+	InitCode             []byte
+	CallData             []byte
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	PaymasterAndData     []byte
+	Signature            []byte
+}
+
+// toUserOperation06 converts op to its on-chain representation in Entrypoint 0.6, nil values are zero
+func toUserOperation06(op *UserOperation) userOperation06 {
+	return userOperation06{
+		Sender:               op.Sender,
+		Nonce:                zeroIfNil(op.Nonce),
+		InitCode:             op.initCode(),
+		CallData:             op.CallData,
+		CallGasLimit:         zeroIfNil(op.CallGasLimit),
+		VerificationGasLimit: zeroIfNil(op.VerificationGasLimit),
+		PreVerificationGas:   zeroIfNil(op.PreVerificationGas),
+		MaxFeePerGas:         zeroIfNil(op.MaxFeePerGas),
+		MaxPriorityFeePerGas: zeroIfNil(op.MaxPriorityFeePerGas),
+		PaymasterAndData:     op.paymasterAndData06(),
+		Signature:            op.Signature,
+	}
+}
+
+// SubmitViaEntryPoint submits signed ops with handleOps directly to the entrypoint, bypassing the bundler.
+// The transaction is signed by submitter, which pays for gas and is refunded to beneficiary, and sent through the network RPC.
+func (c *Client) SubmitViaEntryPoint(ctx context.Context, ops []*UserOperation, beneficiary common.Address, submitter *ecdsa.PrivateKey) (common.Hash, error) {
+	if submitter == nil {
+		return common.Hash{}, errors.New("submitter is required")
+	}
+
+	data, err := packHandleOps(c.EntryPoint.GetVersion(), ops, beneficiary)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return c.sendEntryPointTransaction(ctx, "handleOps", data, new(big.Int), submitter)
+}
+
+// sendEntryPointTransaction sends a call of method to the entrypoint with value wei, signed by from and sent through the network RPC.
+func (c *Client) sendEntryPointTransaction(ctx context.Context, method string, data []byte, value *big.Int, from *ecdsa.PrivateKey) (common.Hash, error) {
+	return c.sendTransaction(ctx, method, c.EntryPoint.GetAddress(), data, value, from)
+}
+
+// sendTransaction sends a transaction to to with value wei and data, signed by from and sent through the network RPC.
+// The nonce, gas limit and fees are taken from the network, chains without base fee get a legacy transaction.
+// method names the transaction in errors.
+func (c *Client) sendTransaction(ctx context.Context, method string, to common.Address, data []byte, value *big.Int, from *ecdsa.PrivateKey) (common.Hash, error) {
+	rpcClient := c.AccountClient.Client
+	sender := crypto.PubkeyToAddress(from.PublicKey)
+
+	var nonce hexutil.Uint64
+	if err := rpcClient.CallContext(ctx, &nonce, "eth_getTransactionCount", sender, "pending"); err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to call eth_getTransactionCount")
+	}
+
+	var gas hexutil.Uint64
+	callArgs := map[string]interface{}{
+		"from": sender,
+		"to":   to,
+		"data": hexutil.Bytes(data),
+	}
+	if value.Sign() > 0 {
+		callArgs["value"] = (*hexutil.Big)(value)
+	}
+	if err := rpcClient.CallContext(ctx, &gas, "eth_estimateGas", callArgs); err != nil {
+		return common.Hash{}, errors.Wrapf(err, "failed to estimate %s gas", method)
+	}
+
+	baseFee, err := getBaseFee(ctx, rpcClient)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	var tx *ethtypes.Transaction
+	if baseFee == nil {
+		var gasPrice hexutil.Big
+		if err := rpcClient.CallContext(ctx, &gasPrice, "eth_gasPrice"); err != nil {
+			return common.Hash{}, errors.Wrap(err, "failed to call eth_gasPrice")
+		}
+
+		tx = ethtypes.NewTx(&ethtypes.LegacyTx{
+			Nonce:    uint64(nonce),
+			GasPrice: gasPrice.ToInt(),
+			Gas:      uint64(gas),
+			To:       &to,
+			Value:    value,
+			Data:     data,
+		})
+	} else {
+		var priorityFee hexutil.Big
+		if err := rpcClient.CallContext(ctx, &priorityFee, "eth_maxPriorityFeePerGas"); err != nil {
+			return common.Hash{}, errors.Wrap(err, "failed to call eth_maxPriorityFeePerGas")
+		}
+
+		tx = ethtypes.NewTx(&ethtypes.DynamicFeeTx{
+			ChainID:   c.ChainID,
+			Nonce:     uint64(nonce),
+			GasTipCap: priorityFee.ToInt(),
+			GasFeeCap: maxFeePerGas(baseFee, priorityFee.ToInt()),
+			Gas:       uint64(gas),
+			To:        &to,
+			Value:     value,
+			Data:      data,
+		})
+	}
+
+	signedTx, err := ethtypes.SignTx(tx, ethtypes.LatestSignerForChainID(c.ChainID), from)
+	if err != nil {
+		return common.Hash{}, errors.Wrapf(err, "failed to sign %s transaction", method)
+	}
+
+	rawTx, err := signedTx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, errors.Wrapf(err, "failed to encode %s transaction", method)
+	}
+
+	var txHash common.Hash
+	if err := rpcClient.CallContext(ctx, &txHash, "eth_sendRawTransaction", hexutil.Bytes(rawTx)); err != nil {
+		return common.Hash{}, errors.Wrap(err, "failed to call eth_sendRawTransaction")
+	}
+
+	return txHash, nil
+}
+
+// packHandleOps encodes the handleOps call of the entrypoint version, 0.7 and 0.8 take the packed representation of ops
+func packHandleOps(entryPointVersion string, ops []*UserOperation, beneficiary common.Address) ([]byte, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("at least one user operation is required")
+	}
+	for i, op := range ops {
+		if op == nil || len(op.Signature) == 0 {
+			return nil, errors.Errorf("user operation %d is not signed", i)
+		}
+	}
+
+	abiJSON := handleOpsAbi07
+	var args interface{}
+	if entryPointVersion == EntryPointVersion06 {
+		abiJSON = handleOpsAbi06
+		unpacked := make([]userOperation06, len(ops))
+		for i, op := range ops {
+			unpacked[i] = toUserOperation06(op)
+		}
+		args = unpacked
+	} else {
+		packed := make([]PackedUserOperation, len(ops))
+		for i, op := range ops {
+			packed[i] = *op.ToPacked()
+		}
+		args = packed
+	}
+
+	parsedAbi, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse handleOps abi")
+	}
+
+	data, err := parsedAbi.Pack("handleOps", args, beneficiary)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack handleOps")
+	}
+
+	return data, nil
+}
@@ -0,0 +1,134 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackHandleOps(t *testing.T) {
+	beneficiary := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	tests := []struct {
+		name             string
+		version          string
+		abiJSON          string
+		expectedSelector string
+	}{
+		{
+			name:             "entrypoint_06",
+			version:          EntryPointVersion06,
+			abiJSON:          handleOpsAbi06,
+			expectedSelector: "0x1fad948c",
+		},
+		{
+			name:             "entrypoint_07",
+			version:          EntryPointVersion07,
+			abiJSON:          handleOpsAbi07,
+			expectedSelector: "0x765e827f",
+		},
+		{
+			name:             "entrypoint_08",
+			version:          EntryPointVersion08,
+			abiJSON:          handleOpsAbi07,
+			expectedSelector: "0x765e827f",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := newTestUserOperation()
+			op.Paymaster = common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633")
+			op.PaymasterData = common.FromHex("0xabab")
+			op.Signature = common.FromHex("0x01")
+
+			data, err := packHandleOps(tt.version, []*UserOperation{op, op}, beneficiary)
+			require.NoError(t, err)
+			assert.Equal(t, common.FromHex(tt.expectedSelector), data[:4])
+
+			parsedAbi, err := abi.JSON(strings.NewReader(tt.abiJSON))
+			require.NoError(t, err)
+			unpacked, err := parsedAbi.Methods["handleOps"].Inputs.Unpack(data[4:])
+			require.NoError(t, err)
+			require.Len(t, unpacked, 2)
+			assert.Equal(t, 2, reflect.ValueOf(unpacked[0]).Len())
+			assert.Equal(t, beneficiary, unpacked[1])
+		})
+	}
+}
+
+func TestPackHandleOps_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		ops           []*UserOperation
+		expectedError string
+	}{
+		{
+			name:          "no_ops",
+			expectedError: "at least one user operation is required",
+		},
+		{
+			name:          "unsigned",
+			ops:           []*UserOperation{newTestUserOperation()},
+			expectedError: "user operation 0 is not signed",
+		},
+		{
+			name:          "nil",
+			ops:           []*UserOperation{nil},
+			expectedError: "user operation 0 is not signed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := packHandleOps(EntryPointVersion07, tt.ops, common.Address{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestClient_SubmitViaEntryPoint(t *testing.T) {
+	submitter, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+	beneficiary := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	txHash := common.HexToHash("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+
+	client, network, _ := newTestClient(t, 0)
+	network.On("eth_getTransactionCount", "0x1", nil).
+		On("eth_estimateGas", "0x30d40", nil).
+		On("eth_getBlockByNumber", json.RawMessage(`{}`), nil).
+		On("eth_gasPrice", "0x77359400", nil).
+		On("eth_sendRawTransaction", txHash, nil)
+
+	op := newTestUserOperation()
+	op.Signature = common.FromHex("0x01")
+
+	_, err = client.SubmitViaEntryPoint(context.Background(), []*UserOperation{op}, beneficiary, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "submitter is required")
+
+	hash, err := client.SubmitViaEntryPoint(context.Background(), []*UserOperation{op}, beneficiary, submitter)
+	require.NoError(t, err)
+	assert.Equal(t, txHash, hash)
+
+	expectedData, err := packHandleOps(client.EntryPoint.GetVersion(), []*UserOperation{op}, beneficiary)
+	require.NoError(t, err)
+
+	tx := decodeSentTransaction(t, network)
+	assert.Equal(t, client.EntryPoint.GetAddress(), *tx.To())
+	assert.Equal(t, expectedData, tx.Data())
+	assert.Equal(t, uint64(1), tx.Nonce())
+	assert.Equal(t, uint8(ethtypes.LegacyTxType), tx.Type())
+	assert.Equal(t, big.NewInt(2_000_000_000), tx.GasPrice())
+}
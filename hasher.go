@@ -0,0 +1,58 @@
+package zerodev
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// UserOperationHasher computes the hash of a UserOperation for an entrypoint, for chains hashing differently than the canonical
+// entrypoint, e.g. with chain specific domain handling. Set it as EntrypointClient07.Hasher or ClientConfig.UserOperationHasher.
+//
+// HashUserOperation must return the hash the entrypoint at entrypoint on chainID validates the signature against, the one its
+// getUserOpHash returns, signatures over any other hash are rejected. The signature of op must not be part of the hash.
+// It must not modify op and must be safe for concurrent use, it's called for every UserOperation built, signed or verified.
+type UserOperationHasher interface {
+	HashUserOperation(op *UserOperation, entrypoint common.Address, chainID *big.Int) (*common.Hash, error)
+}
+
+// UserOperationHasherFunc adapts a function to UserOperationHasher
+type UserOperationHasherFunc func(op *UserOperation, entrypoint common.Address, chainID *big.Int) (*common.Hash, error)
+
+func (f UserOperationHasherFunc) HashUserOperation(op *UserOperation, entrypoint common.Address, chainID *big.Int) (*common.Hash, error) {
+	return f(op, entrypoint, chainID)
+}
+
+// StandardUserOperationHasher07 is the UserOperationHasher of the canonical Entrypoint 0.7, used when EntrypointClient07.Hasher is not set:
+// keccak256(abi.encode(keccak256(packed op), entrypoint, chainID)) with op packed by EntrypointClient07.PackUserOperation.
+type StandardUserOperationHasher07 struct{}
+
+func (StandardUserOperationHasher07) HashUserOperation(op *UserOperation, entrypoint common.Address, chainID *big.Int) (*common.Hash, error) {
+	packedOp, err := (*EntrypointClient07)(nil).PackUserOperation(op)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pack user operation")
+	}
+
+	return hashPackedUserOperation(packedOp, entrypoint, chainID)
+}
+
+// ComputeUserOperationHash07 computes the hash of op for the Entrypoint 0.7 at entryPoint on chainID like StandardUserOperationHasher07,
+// without an entrypoint client or any RPC, e.g. to sign on an air-gapped machine. The fields covered by the hash have to be set.
+func ComputeUserOperationHash07(op *UserOperation, entryPoint common.Address, chainID *big.Int) (common.Hash, error) {
+	if op == nil {
+		return common.Hash{}, errors.New("user operation is required")
+	}
+	if chainID == nil {
+		return common.Hash{}, errors.New("chainID is required")
+	}
+	if op.Nonce == nil || op.CallGasLimit == nil || op.VerificationGasLimit == nil || op.PreVerificationGas == nil ||
+		op.MaxFeePerGas == nil || op.MaxPriorityFeePerGas == nil {
+		return common.Hash{}, errors.New("nonce, gas limits and fees of the user operation are required")
+	}
+
+	hash, err := StandardUserOperationHasher07{}.HashUserOperation(op, entryPoint, chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return *hash, nil
+}
@@ -0,0 +1,75 @@
+package zerodev
+
+import (
+	"math/big"
+	"net/url"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntrypointClient07_Hasher(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	op := newTestUserOperation()
+
+	standard, err := StandardUserOperationHasher07{}.HashUserOperation(op, entrypoint.GetAddress(), entrypoint.ChainID)
+	require.NoError(t, err)
+	assert.Equal(t, "0xf8de7629ce84fdc2606c777963ec14151d0fdc0f70defefd86c4c5ed43cda452", standard.Hex())
+
+	defaultHash, err := entrypoint.GetUserOperationHash(op)
+	require.NoError(t, err)
+	assert.Equal(t, standard, defaultHash)
+
+	// a chain hashing the standard hash with its own domain
+	entrypoint.Hasher = UserOperationHasherFunc(func(op *UserOperation, address common.Address, chainID *big.Int) (*common.Hash, error) {
+		assert.Equal(t, entrypoint.GetAddress(), address)
+		assert.Equal(t, big.NewInt(ChainPolygon), chainID)
+
+		hash, err := StandardUserOperationHasher07{}.HashUserOperation(op, address, chainID)
+		if err != nil {
+			return nil, err
+		}
+		domainHash := crypto.Keccak256Hash([]byte("custom domain"), hash.Bytes())
+		return &domainHash, nil
+	})
+
+	customHash, err := entrypoint.GetUserOperationHash(op)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash([]byte("custom domain"), standard.Bytes()), *customHash)
+}
+
+func TestNewClient_UserOperationHasherVersion(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	_, err = NewClient(&ClientConfig{
+		AccountPK:           privateKey,
+		EntryPointVersion:   EntryPointVersion06,
+		BundlerURL:          &url.URL{Scheme: "http", Host: "bundler"},
+		ChainID:             big.NewInt(ChainPolygon),
+		UserOperationHasher: StandardUserOperationHasher07{},
+	})
+	assert.EqualError(t, err, "userOperationHasher is only supported with entryPointVersion 0.7")
+}
+
+func TestComputeUserOperationHash07(t *testing.T) {
+	op := newTestUserOperation()
+	entryPoint := common.HexToAddress(entryPointAddress07)
+
+	hash, err := ComputeUserOperationHash07(op, entryPoint, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+	assert.Equal(t, "0xf8de7629ce84fdc2606c777963ec14151d0fdc0f70defefd86c4c5ed43cda452", hash.Hex())
+
+	_, err = ComputeUserOperationHash07(nil, entryPoint, big.NewInt(ChainPolygon))
+	assert.EqualError(t, err, "user operation is required")
+	_, err = ComputeUserOperationHash07(op, entryPoint, nil)
+	assert.EqualError(t, err, "chainID is required")
+
+	op.MaxFeePerGas = nil
+	_, err = ComputeUserOperationHash07(op, entryPoint, big.NewInt(ChainPolygon))
+	assert.EqualError(t, err, "nonce, gas limits and fees of the user operation are required")
+}
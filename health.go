@@ -0,0 +1,110 @@
+package zerodev
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointHealth is the outcome of pinging one endpoint. ChainID is set for the network RPC and the paymaster,
+// SupportedEntryPoints for the bundler. Error is nil if the endpoint is healthy
+type EndpointHealth struct {
+	Latency              time.Duration
+	ChainID              *big.Int
+	SupportedEntryPoints []common.Address
+	Error                error
+}
+
+// Healthy reports whether the endpoint answered without error
+func (e *EndpointHealth) Healthy() bool {
+	return e.Error == nil
+}
+
+// HealthStatus is the health of the client's endpoints, Paymaster is nil if the client has no paymaster
+type HealthStatus struct {
+	Network   *EndpointHealth
+	Bundler   *EndpointHealth
+	Paymaster *EndpointHealth
+}
+
+// HealthCheckError is returned by HealthCheck when some endpoints are unhealthy, Errors maps their name to their error
+type HealthCheckError struct {
+	Errors map[string]error
+}
+
+func (e *HealthCheckError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for _, name := range []string{"network RPC", "bundler", "paymaster"} {
+		if err, ok := e.Errors[name]; ok {
+			names = append(names, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	return "unhealthy endpoints: " + strings.Join(names, "; ")
+}
+
+// Unwrap returns the errors of the unhealthy endpoints, so errors.Is and errors.As match any of them
+func (e *HealthCheckError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// HealthCheck pings the endpoints concurrently, eth_chainId of the network RPC and the paymaster and eth_supportedEntryPoints of
+// the bundler, e.g. for readiness probes. The status of every endpoint is returned along with a *HealthCheckError if any is unhealthy.
+func (c *Client) HealthCheck(ctx context.Context) (*HealthStatus, error) {
+	status := &HealthStatus{
+		Network: &EndpointHealth{},
+		Bundler: &EndpointHealth{},
+	}
+
+	type endpointCheck struct {
+		name   string
+		health *EndpointHealth
+		check  func(health *EndpointHealth)
+	}
+	checks := []endpointCheck{
+		{"network RPC", status.Network, func(health *EndpointHealth) {
+			health.ChainID, health.Error = getChainID(ctx, c.AccountClient.Client)
+		}},
+		{"bundler", status.Bundler, func(health *EndpointHealth) {
+			health.SupportedEntryPoints, health.Error = c.BundlerClient.SupportedEntryPoints(ctx)
+		}},
+	}
+	if c.PaymasterClient != nil {
+		status.Paymaster = &EndpointHealth{}
+		checks = append(checks, endpointCheck{"paymaster", status.Paymaster, func(health *EndpointHealth) {
+			health.ChainID, health.Error = getChainID(ctx, c.PaymasterClient.Client)
+		}})
+	}
+
+	var wg sync.WaitGroup
+	for _, endpoint := range checks {
+		wg.Add(1)
+		go func(endpoint endpointCheck) {
+			defer wg.Done()
+			start := time.Now()
+			endpoint.check(endpoint.health)
+			endpoint.health.Latency = time.Since(start)
+		}(endpoint)
+	}
+	wg.Wait()
+
+	// every endpoint is checked, the errors are aggregated instead of failing on the first
+	errs := map[string]error{}
+	for _, endpoint := range checks {
+		if endpoint.health.Error != nil {
+			errs[endpoint.name] = endpoint.health.Error
+		}
+	}
+	if len(errs) > 0 {
+		return status, &HealthCheckError{Errors: errs}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,56 @@
+package zerodev
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_HealthCheck(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	entrypoint := client.EntryPoint.GetAddress()
+
+	client.AccountClient.Client = zerodevtest.NewMockRPCClient().On("eth_chainId", "0x89", nil)
+	client.BundlerClient.Client = zerodevtest.NewMockRPCClient().On("eth_supportedEntryPoints", []string{entrypoint.Hex()}, nil)
+	client.PaymasterClient.Client = zerodevtest.NewMockRPCClient().On("eth_chainId", "0x89", nil)
+
+	status, err := client.HealthCheck(context.Background())
+	require.NoError(t, err)
+	assert.True(t, status.Network.Healthy())
+	assert.Equal(t, big.NewInt(ChainPolygon), status.Network.ChainID)
+	assert.True(t, status.Bundler.Healthy())
+	assert.Equal(t, []common.Address{entrypoint}, status.Bundler.SupportedEntryPoints)
+	assert.True(t, status.Paymaster.Healthy())
+	assert.Equal(t, big.NewInt(ChainPolygon), status.Paymaster.ChainID)
+
+	// every endpoint is checked, the errors are aggregated
+	networkErr := errors.New("connection refused")
+	paymasterErr := errors.New("unauthorized")
+	client.AccountClient.Client = zerodevtest.NewMockRPCClient().On("eth_chainId", nil, networkErr)
+	client.PaymasterClient.Client = zerodevtest.NewMockRPCClient().On("eth_chainId", nil, paymasterErr)
+
+	status, err = client.HealthCheck(context.Background())
+	require.Error(t, err)
+	assert.False(t, status.Network.Healthy())
+	assert.True(t, status.Bundler.Healthy())
+	assert.False(t, status.Paymaster.Healthy())
+	assert.ErrorIs(t, err, networkErr)
+	assert.ErrorIs(t, err, paymasterErr)
+
+	var healthErr *HealthCheckError
+	require.True(t, errors.As(err, &healthErr))
+	assert.Len(t, healthErr.Errors, 2)
+	assert.Contains(t, err.Error(), "network RPC: failed to call eth_chainId: connection refused")
+	assert.Contains(t, err.Error(), "paymaster: failed to call eth_chainId: unauthorized")
+
+	// without a paymaster
+	client.PaymasterClient = nil
+	status, _ = client.HealthCheck(context.Background())
+	assert.Nil(t, status.Paymaster)
+}
@@ -0,0 +1,131 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"sync"
+	"time"
+)
+
+// ErrUserOperationInProgress is returned when an identical user operation is being sent concurrently, its result isn't stored yet.
+// Retry later to get the stored result
+var ErrUserOperationInProgress = errors.New("identical user operation is being sent")
+
+// IdempotencyCache stores the results of sent user operations by user operation hash, so resending an identical operation
+// returns the stored result instead of submitting it again. Implement it to share results between processes, e.g. with Redis.
+type IdempotencyCache interface {
+	// Get returns the result stored for hash, or nil if there is none or hash is only reserved
+	Get(ctx context.Context, hash common.Hash) (*UserOperationResult, error)
+	// Reserve atomically reserves hash for sending, e.g. with SET NX. It returns false if hash is already reserved or has a result,
+	// so concurrent senders of an identical operation don't both submit it
+	Reserve(ctx context.Context, hash common.Hash) (bool, error)
+	// Release removes the reservation of hash without a result, when sending the operation failed
+	Release(ctx context.Context, hash common.Hash) error
+	// Set stores result for hash, replacing the result or reservation stored before, e.g. once the receipt is known
+	Set(ctx context.Context, hash common.Hash, result *UserOperationResult) error
+}
+
+// MemoryIdempotencyCache is an in-memory IdempotencyCache, results and reservations expire TTL after they were stored.
+type MemoryIdempotencyCache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	results map[common.Hash]memoryIdempotencyEntry
+	now     func() time.Time
+}
+
+// memoryIdempotencyEntry is a result, or a reservation if result is nil
+type memoryIdempotencyEntry struct {
+	result    *UserOperationResult
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyCache creates a MemoryIdempotencyCache keeping results for ttl
+func NewMemoryIdempotencyCache(ttl time.Duration) *MemoryIdempotencyCache {
+	return &MemoryIdempotencyCache{
+		TTL:     ttl,
+		results: make(map[common.Hash]memoryIdempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryIdempotencyCache) Get(_ context.Context, hash common.Hash) (*UserOperationResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.results[hash]
+	if !ok {
+		return nil, nil
+	}
+	if !m.currentTime().Before(entry.expiresAt) {
+		delete(m.results, hash)
+		return nil, nil
+	}
+	return entry.result, nil
+}
+
+// Reserve reserves hash unless it's already reserved or has a result, and evicts the expired entries
+func (m *MemoryIdempotencyCache) Reserve(_ context.Context, hash common.Hash) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.evictExpired()
+	if _, ok := m.results[hash]; ok {
+		return false, nil
+	}
+
+	m.results[hash] = memoryIdempotencyEntry{expiresAt: now.Add(m.TTL)}
+	return true, nil
+}
+
+// Release removes the reservation of hash, a stored result is kept
+func (m *MemoryIdempotencyCache) Release(_ context.Context, hash common.Hash) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.results[hash]; ok && entry.result == nil {
+		delete(m.results, hash)
+	}
+	return nil
+}
+
+// Set stores result and evicts the expired entries
+func (m *MemoryIdempotencyCache) Set(_ context.Context, hash common.Hash, result *UserOperationResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.evictExpired()
+	m.results[hash] = memoryIdempotencyEntry{result: result, expiresAt: now.Add(m.TTL)}
+	return nil
+}
+
+// evictExpired deletes the expired entries and returns the current time, m.mu must be held
+func (m *MemoryIdempotencyCache) evictExpired() time.Time {
+	if m.results == nil {
+		m.results = make(map[common.Hash]memoryIdempotencyEntry)
+	}
+
+	now := m.currentTime()
+	for key, entry := range m.results {
+		if !now.Before(entry.expiresAt) {
+			delete(m.results, key)
+		}
+	}
+	return now
+}
+
+// Len returns the number of stored results and reservations, including expired ones not evicted yet
+func (m *MemoryIdempotencyCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.results)
+}
+
+func (m *MemoryIdempotencyCache) currentTime() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
@@ -0,0 +1,178 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIdempotencyCache(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cache := NewMemoryIdempotencyCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	first := common.HexToHash("0x01")
+	second := common.HexToHash("0x02")
+	result := &UserOperationResult{UserOperationHash: first.Bytes()}
+
+	cached, err := cache.Get(context.Background(), first)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+
+	require.NoError(t, cache.Set(context.Background(), first, result))
+	cached, err = cache.Get(context.Background(), first)
+	require.NoError(t, err)
+	assert.Equal(t, result, cached)
+
+	// expired results are not returned and are evicted by Get and Set
+	now = now.Add(time.Minute)
+	cached, err = cache.Get(context.Background(), first)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+	assert.Equal(t, 0, cache.Len())
+
+	require.NoError(t, cache.Set(context.Background(), first, result))
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, cache.Set(context.Background(), second, result))
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestMemoryIdempotencyCache_Reserve(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	cache := NewMemoryIdempotencyCache(time.Minute)
+	cache.now = func() time.Time { return now }
+	hash := common.HexToHash("0x01")
+
+	reserved, err := cache.Reserve(context.Background(), hash)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	// a reservation has no result and can't be reserved again
+	cached, err := cache.Get(context.Background(), hash)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+	reserved, err = cache.Reserve(context.Background(), hash)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+
+	require.NoError(t, cache.Release(context.Background(), hash))
+	reserved, err = cache.Reserve(context.Background(), hash)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	// a stored result is not released
+	result := &UserOperationResult{UserOperationHash: hash.Bytes()}
+	require.NoError(t, cache.Set(context.Background(), hash, result))
+	require.NoError(t, cache.Release(context.Background(), hash))
+	reserved, err = cache.Reserve(context.Background(), hash)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+
+	// reservations of crashed senders expire
+	other := common.HexToHash("0x02")
+	reserved, err = cache.Reserve(context.Background(), other)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	now = now.Add(time.Minute)
+	reserved, err = cache.Reserve(context.Background(), other)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
+
+func TestClient_SendSignedUserOperation_Idempotent(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.IdempotencyCache = NewMemoryIdempotencyCache(time.Hour)
+	client.ReceiptPollingDelay = 0
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil).
+		On("eth_getUserOperationReceipt", json.RawMessage(`{"userOpHash":"0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77","success":true,"receipt":{"transactionHash":"0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"}}`), nil)
+
+	op := newTestUserOperation()
+	op.Signature = common.FromHex("0xdeadbeef")
+
+	result, err := client.SendSignedUserOperation(context.Background(), op, false)
+	require.NoError(t, err)
+	assert.Nil(t, result.Receipt)
+
+	// resending the identical operation doesn't submit it again, the receipt is waited for once requested
+	resent, err := client.SendSignedUserOperation(context.Background(), op, true)
+	require.NoError(t, err)
+	assert.Equal(t, result.UserOperationHash, resent.UserOperationHash)
+	require.NotNil(t, resent.Receipt)
+	assert.Equal(t, common.HexToHash("0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"), resent.Receipt.TransactionHash)
+
+	resent, err = client.SendSignedUserOperation(context.Background(), op, true)
+	require.NoError(t, err)
+	require.NotNil(t, resent.Receipt)
+
+	assert.Equal(t, 1, bundler.CallCount("eth_sendUserOperation"))
+	assert.Equal(t, 1, bundler.CallCount("eth_getUserOperationReceipt"))
+
+	// a different operation is submitted
+	op.Nonce.SetInt64(6)
+	_, err = client.SendSignedUserOperation(context.Background(), op, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bundler.CallCount("eth_sendUserOperation"))
+}
+
+func TestClient_SendSignedUserOperation_IdempotentConcurrent(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.IdempotencyCache = NewMemoryIdempotencyCache(time.Hour)
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil)
+	bundler.Latency = 50 * time.Millisecond
+
+	op := newTestUserOperation()
+	op.Signature = common.FromHex("0xdeadbeef")
+
+	// the same job retried concurrently submits the operation once
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := client.SendSignedUserOperation(context.Background(), op, false)
+			errs <- err
+		}()
+	}
+
+	var inProgress int
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			assert.ErrorIs(t, err, ErrUserOperationInProgress)
+			inProgress++
+		}
+	}
+	assert.Equal(t, 1, inProgress)
+	assert.Equal(t, 1, bundler.CallCount("eth_sendUserOperation"))
+
+	// once stored, the result is returned
+	result, err := client.SendSignedUserOperation(context.Background(), op, false)
+	require.NoError(t, err)
+	assert.Equal(t, common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"), result.UserOperationHash)
+	assert.Equal(t, 1, bundler.CallCount("eth_sendUserOperation"))
+}
+
+func TestClient_SendSignedUserOperation_IdempotentSendFails(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.IdempotencyCache = NewMemoryIdempotencyCache(time.Hour)
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_sendUserOperation", nil, errors.New("connection refused")).
+		On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil)
+
+	op := newTestUserOperation()
+	op.Signature = common.FromHex("0xdeadbeef")
+
+	_, err := client.SendSignedUserOperation(context.Background(), op, false)
+	require.Error(t, err)
+
+	// the failed send released its reservation, the operation can be sent again
+	_, err = client.SendSignedUserOperation(context.Background(), op, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bundler.CallCount("eth_sendUserOperation"))
+}
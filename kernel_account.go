@@ -6,9 +6,40 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/friendsofgo/errors"
+	"math/big"
 	"strings"
 )
 
+// BatchCall is a single call of a batch executed atomically by the account
+type BatchCall struct {
+	To    common.Address
+	Value *big.Int
+	Data  []byte
+}
+
+// Kernel v3 call types, first byte of the execution mode
+const (
+	kernelCallTypeSingle       = byte(0x00)
+	kernelCallTypeBatch        = byte(0x01)
+	kernelCallTypeDelegateCall = byte(0xff)
+)
+
+// kernelExecTypeDefault reverts the whole execution if a call fails
+const kernelExecTypeDefault = byte(0x00)
+
+var kernelExecutions, _ = abi.NewType("tuple[]", "", []abi.ArgumentMarshaling{
+	{Name: "target", Type: "address"},
+	{Name: "value", Type: "uint256"},
+	{Name: "callData", Type: "bytes"},
+})
+
+// kernelExecution is an element of kernelExecutions
+type kernelExecution struct {
+	Target   common.Address
+	Value    *big.Int
+	CallData []byte
+}
+
 const kernelAccountExecuteABI = `[{
         "type": "function",
         "name": "execute",
@@ -21,32 +52,163 @@ const kernelAccountExecuteABI = `[{
     }]`
 
 func EncodeExecuteCall(msg *ethereum.CallMsg) (*[]byte, error) {
+	if msg.To == nil {
+		return nil, errors.New("call target is required")
+	}
+
+	callData, err := EncodeExecute(*msg.To, msg.Value, msg.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &callData, nil
+}
+
+// EncodeExecute encodes the Kernel v3 execute call of a single call to target, sending value wei along with data.
+func EncodeExecute(to common.Address, value *big.Int, data []byte) ([]byte, error) {
 	// based on https://github.com/zerodevapp/sdk/blob/main/packages/core/accounts/kernel/utils/ep0_7/encodeExecuteCall.ts#L24
 
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	if value.Sign() < 0 {
+		return nil, errors.New("negative call value")
+	}
+
 	parsedABI, err := abi.JSON(strings.NewReader(kernelAccountExecuteABI))
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse execute call abi")
 	}
 
-	data := bytes.Buffer{}
-	data.Write(msg.To.Bytes())
-	data.Write(common.RightPadBytes(msg.Value.Bytes(), 32))
-	data.Write(msg.Data)
+	// single call execution data is packed: target (20 bytes) | value (32 bytes) | callData
+	executionData := bytes.Buffer{}
+	executionData.Write(to.Bytes())
+	executionData.Write(common.LeftPadBytes(value.Bytes(), 32))
+	executionData.Write(data)
+
+	callData, err := parsedABI.Pack("execute", kernelExecMode(kernelCallTypeSingle), executionData.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode execute call data")
+	}
+
+	return callData, nil
+}
 
-	execMode := bytes.Buffer{}
-	execMode.Write([]byte{0x00}) // call type
-	execMode.Write([]byte{0x00}) // exec type
-	execMode.Write([]byte{0x00000000})
-	execMode.Write([]byte{0x00000000})
-	execMode.Write(common.LeftPadBytes([]byte{0x00000000}, 32))
+// EncodeExecuteDelegateCall encodes the Kernel v3 execute call delegatecalling target with data, running its code as the account.
+// Only delegatecall trusted code, it can change the account's storage including its validators.
+func EncodeExecuteDelegateCall(target common.Address, data []byte) ([]byte, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(kernelAccountExecuteABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse execute call abi")
+	}
 
-	var execModeArray [32]byte
-	copy(execModeArray[:], execMode.Bytes())
+	// delegatecall execution data is packed: target (20 bytes) | callData
+	executionData := append(target.Bytes(), data...)
 
-	callData, err := parsedABI.Pack("execute", execModeArray, data.Bytes())
+	callData, err := parsedABI.Pack("execute", kernelExecMode(kernelCallTypeDelegateCall), executionData)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode execute call data")
+	}
+
+	return callData, nil
+}
+
+// kernelExecMode builds the Kernel v3 execution mode with the default exec type, reverting on failure:
+// callType (1 byte) | execType (1 byte) | unused (4 bytes) | mode selector (4 bytes) | mode payload (22 bytes)
+func kernelExecMode(callType byte) [32]byte {
+	var execMode [32]byte
+	execMode[0] = callType
+	execMode[1] = kernelExecTypeDefault
+	return execMode
+}
+
+// EncodeExecuteBatchCall encodes the Kernel execute call running all calls atomically, if any of them reverts the whole batch reverts.
+// The batch is executed within the callGasLimit of a single UserOperation, which has to cover all the calls.
+func EncodeExecuteBatchCall(calls []BatchCall) (*[]byte, error) {
+	if len(calls) == 0 {
+		return nil, errors.New("batch must contain at least one call")
+	}
+
+	parsedABI, err := abi.JSON(strings.NewReader(kernelAccountExecuteABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse execute call abi")
+	}
+
+	executions := make([]kernelExecution, len(calls))
+	for i, call := range calls {
+		value := call.Value
+		if value == nil {
+			value = big.NewInt(0)
+		}
+		if value.Sign() < 0 {
+			return nil, errors.Errorf("negative value of batch call %d", i)
+		}
+
+		executions[i] = kernelExecution{
+			Target:   call.To,
+			Value:    value,
+			CallData: call.Data,
+		}
+	}
+
+	data, err := abi.Arguments{{Type: kernelExecutions}}.Pack(executions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode batch executions")
+	}
+
+	callData, err := parsedABI.Pack("execute", kernelExecMode(kernelCallTypeBatch), data)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to encode execute call data")
 	}
 
 	return &callData, nil
 }
+
+// DecodeCallData decodes the Kernel v3 execute call data of a UserOperation back into its calls, the reverse of EncodeExecute
+// and EncodeExecuteBatchCall. A single call is returned as a batch of one, delegatecalls are not supported.
+func DecodeCallData(callData []byte) ([]BatchCall, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(kernelAccountExecuteABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse execute call abi")
+	}
+
+	method := parsedABI.Methods["execute"]
+	if len(callData) < 4 || !bytes.Equal(callData[:4], method.ID) {
+		return nil, errors.New("call data is not a Kernel execute call")
+	}
+
+	args, err := method.Inputs.Unpack(callData[4:])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode execute call data")
+	}
+	execMode := args[0].([32]byte)
+	executionData := args[1].([]byte)
+
+	switch execMode[0] {
+	case kernelCallTypeSingle:
+		// target (20 bytes) | value (32 bytes) | callData
+		if len(executionData) < common.AddressLength+32 {
+			return nil, errors.Errorf("single call execution data of %d bytes is too short", len(executionData))
+		}
+		return []BatchCall{{
+			To:    common.BytesToAddress(executionData[:common.AddressLength]),
+			Value: new(big.Int).SetBytes(executionData[common.AddressLength : common.AddressLength+32]),
+			Data:  executionData[common.AddressLength+32:],
+		}}, nil
+	case kernelCallTypeBatch:
+		unpacked, err := abi.Arguments{{Type: kernelExecutions}}.Unpack(executionData)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode batch executions")
+		}
+
+		executions := *abi.ConvertType(unpacked[0], new([]kernelExecution)).(*[]kernelExecution)
+
+		calls := make([]BatchCall, len(executions))
+		for i, execution := range executions {
+			calls[i] = BatchCall{To: execution.Target, Value: execution.Value, Data: execution.CallData}
+		}
+		return calls, nil
+	default:
+		return nil, errors.Errorf("unsupported Kernel call type 0x%02x", execMode[0])
+	}
+}
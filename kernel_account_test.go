@@ -0,0 +1,85 @@
+package zerodev
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeExecute(t *testing.T) {
+	callData, err := EncodeExecute(common.HexToAddress("0x1111111111111111111111111111111111111111"), big.NewInt(1_000_000_000_000_000), common.FromHex("0xdeadbeef"))
+	require.NoError(t, err)
+
+	// value is left padded to 32 bytes after the 20 bytes target
+	assert.Equal(t, "0xe9ae5c53000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000400000000000000000000000000000000000000000000000000000000000000038111111111111111111111111111111111111111100000000000000000000000000000000000000000000000000038d7ea4c68000deadbeef0000000000000000", hexutil.Encode(callData))
+}
+
+func TestEncodeExecuteBatchCall(t *testing.T) {
+	callData, err := EncodeExecuteBatchCall([]BatchCall{
+		{
+			To:   common.HexToAddress("0x1111111111111111111111111111111111111111"),
+			Data: common.FromHex("0x095ea7b300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"),
+		},
+		{
+			To:    common.HexToAddress("0x2222222222222222222222222222222222222222"),
+			Value: big.NewInt(1_000_000_000_000_000),
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "0xe9ae5c530100000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000001e000000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000004000000000000000000000000000000000000000000000000000000000000001200000000000000000000000001111111111111111111111111111111111111111000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000600000000000000000000000000000000000000000000000000000000000000044095ea7b30000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000222222222222222222222222222222222222222200000000000000000000000000000000000000000000000000038d7ea4c6800000000000000000000000000000000000000000000000000000000000000000600000000000000000000000000000000000000000000000000000000000000000", hexutil.Encode(*callData))
+}
+
+func TestEncodeExecuteBatchCall_Invalid(t *testing.T) {
+	_, err := EncodeExecuteBatchCall(nil)
+	assert.Error(t, err)
+
+	_, err = EncodeExecuteBatchCall([]BatchCall{{Value: big.NewInt(-1)}})
+	assert.Error(t, err)
+}
+
+// assertBatchCalls compares the values of the calls, big.Int values equal by value may differ in their representation
+func assertBatchCalls(t *testing.T, expected, actual []BatchCall) {
+	t.Helper()
+
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].To, actual[i].To)
+		assert.Equal(t, expected[i].Value.String(), actual[i].Value.String())
+		assert.Equal(t, expected[i].Data, actual[i].Data)
+	}
+}
+
+func TestDecodeCallData(t *testing.T) {
+	approve := common.FromHex("0x095ea7b300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000")
+	calls := []BatchCall{
+		{To: common.HexToAddress("0x1111111111111111111111111111111111111111"), Value: big.NewInt(0), Data: approve},
+		{To: common.HexToAddress("0x2222222222222222222222222222222222222222"), Value: big.NewInt(1_000_000_000_000_000), Data: []byte{}},
+	}
+
+	single, err := EncodeExecute(calls[0].To, nil, calls[0].Data)
+	require.NoError(t, err)
+	decoded, err := DecodeCallData(single)
+	require.NoError(t, err)
+	assertBatchCalls(t, calls[:1], decoded)
+
+	batch, err := EncodeExecuteBatchCall(calls)
+	require.NoError(t, err)
+	decoded, err = DecodeCallData(*batch)
+	require.NoError(t, err)
+	assertBatchCalls(t, calls, decoded)
+
+	delegateCall, err := EncodeExecuteDelegateCall(calls[0].To, calls[0].Data)
+	require.NoError(t, err)
+	_, err = DecodeCallData(delegateCall)
+	assert.EqualError(t, err, "unsupported Kernel call type 0xff")
+
+	_, err = DecodeCallData(approve)
+	assert.EqualError(t, err, "call data is not a Kernel execute call")
+	_, err = DecodeCallData(single[:40])
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package zerodev
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"github.com/DIMO-Network/go-zerodev/account"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"strings"
+)
+
+// Kernel v3.1 factory addresses
+const (
+	KernelFactoryAddress     = "0xaac5D4240AF87249B3f71BC8E4A2cae074A3E419"
+	KernelMetaFactoryAddress = "0xd703aaE79538628d27099B8c4f621bE4CCd142d5"
+)
+
+const kernelFactoryABI = `[{
+        "type": "function",
+        "name": "deployWithFactory",
+        "inputs": [
+            { "name": "factory", "type": "address", "internalType": "contract KernelFactory" },
+            { "name": "createData", "type": "bytes", "internalType": "bytes" },
+            { "name": "salt", "type": "bytes32", "internalType": "bytes32" }
+        ],
+        "outputs": [{ "name": "", "type": "address", "internalType": "address" }],
+        "stateMutability": "payable"
+    },
+    {
+        "type": "function",
+        "name": "initialize",
+        "inputs": [
+            { "name": "_rootValidator", "type": "bytes21", "internalType": "ValidationId" },
+            { "name": "hook", "type": "address", "internalType": "contract IHook" },
+            { "name": "validatorData", "type": "bytes", "internalType": "bytes" },
+            { "name": "hookData", "type": "bytes", "internalType": "bytes" },
+            { "name": "initConfig", "type": "bytes[]", "internalType": "bytes[]" }
+        ],
+        "outputs": [],
+        "stateMutability": "nonpayable"
+    },
+    {
+        "type": "function",
+        "name": "getAddress",
+        "inputs": [
+            { "name": "data", "type": "bytes", "internalType": "bytes" },
+            { "name": "salt", "type": "bytes32", "internalType": "bytes32" }
+        ],
+        "outputs": [{ "name": "", "type": "address", "internalType": "address" }],
+        "stateMutability": "view"
+    }]`
+
+// KernelFactory builds factory data deploying Kernel v3.1 accounts owned by an ECDSA key
+type KernelFactory struct {
+	Address            common.Address
+	MetaFactoryAddress common.Address
+	Validator          account.Validator
+	Abi                *abi.ABI
+}
+
+func NewKernelFactory() (*KernelFactory, error) {
+	parsedAbi, err := abi.JSON(strings.NewReader(kernelFactoryABI))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse kernel factory abi")
+	}
+
+	return &KernelFactory{
+		Address:            common.HexToAddress(KernelFactoryAddress),
+		MetaFactoryAddress: common.HexToAddress(KernelMetaFactoryAddress),
+		Validator:          account.NewEcdsaValidator(),
+		Abi:                &parsedAbi,
+	}, nil
+}
+
+// GetInitializeData encodes the Kernel initialize call setting the owner's ECDSA validator as root validator.
+func (f *KernelFactory) GetInitializeData(owner common.Address) ([]byte, error) {
+	var rootValidator [21]byte
+	copy(rootValidator[:], f.Validator.GetIdentifier())
+
+	initData, err := f.Abi.Pack("initialize", rootValidator, common.Address{}, owner.Bytes(), []byte{}, [][]byte{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode initialize call data")
+	}
+
+	return initData, nil
+}
+
+// GetFactoryData encodes the meta factory deployWithFactory call deploying the account of owner at the given index.
+func (f *KernelFactory) GetFactoryData(owner common.Address, index *big.Int) ([]byte, error) {
+	if index == nil {
+		index = big.NewInt(0)
+	}
+
+	initData, err := f.GetInitializeData(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	factoryData, err := f.Abi.Pack("deployWithFactory", f.Address, initData, common.BigToHash(index))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode deployWithFactory call data")
+	}
+
+	return factoryData, nil
+}
+
+// SetFactory populates the factory fields of op, so that the first UserOperation deploys the account.
+func (f *KernelFactory) SetFactory(op *UserOperation, owner common.Address, index *big.Int) error {
+	factoryData, err := f.GetFactoryData(owner, index)
+	if err != nil {
+		return err
+	}
+
+	op.Factory = f.MetaFactoryAddress
+	op.FactoryData = factoryData
+	return nil
+}
+
+// GetAccountAddress computes the counterfactual address of the account of owner at the given index by calling the factory's getAddress,
+// the address is the same before and after deployment, so it can be funded before the first UserOperation.
+func (f *KernelFactory) GetAccountAddress(ctx context.Context, client types.RPCClient, owner common.Address, index *big.Int) (common.Address, error) {
+	if index == nil {
+		index = big.NewInt(0)
+	}
+
+	initData, err := f.GetInitializeData(owner)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	callData, err := f.Abi.Pack("getAddress", initData, common.BigToHash(index))
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "failed to pack getAddress call data")
+	}
+
+	msg := struct {
+		To   common.Address `json:"to"`
+		Data hexutil.Bytes  `json:"data"`
+	}{
+		To:   f.Address,
+		Data: callData,
+	}
+
+	var result hexutil.Bytes
+	if err := client.CallContext(ctx, &result, "eth_call", msg); err != nil {
+		return common.Address{}, errors.Wrap(err, "failed to call getAddress eth_call")
+	}
+
+	unpacked, err := f.Abi.Unpack("getAddress", result)
+	if err != nil {
+		return common.Address{}, errors.Wrap(err, "failed to decode getAddress result")
+	}
+
+	address, ok := unpacked[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected getAddress result")
+	}
+
+	return address, nil
+}
+
+// GetAccountAddressAtIndex computes the counterfactual address of account #index of owner, see GetAccountAddress.
+// One owner controls any number of accounts, told apart by the index used as the factory salt.
+func (f *KernelFactory) GetAccountAddressAtIndex(ctx context.Context, client types.RPCClient, owner common.Address, index uint64) (common.Address, error) {
+	return f.GetAccountAddress(ctx, client, owner, new(big.Int).SetUint64(index))
+}
+
+// NewSmartAccountPrivateKeySignerAtIndex creates a signer of account #index of the owner privateKey, its address is computed by the factory
+func (f *KernelFactory) NewSmartAccountPrivateKeySignerAtIndex(ctx context.Context, client types.RPCClient, privateKey *ecdsa.PrivateKey, index uint64) (*account.SmartAccountPrivateKeySigner, error) {
+	if privateKey == nil {
+		return nil, errors.New("privateKey is required")
+	}
+
+	address, err := f.GetAccountAddressAtIndex(ctx, client, crypto.PubkeyToAddress(privateKey.PublicKey), index)
+	if err != nil {
+		return nil, err
+	}
+
+	return account.NewSmartAccountPrivateKeySigner(client, address, privateKey)
+}
@@ -0,0 +1,213 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKernelFactoryData = "0xc5265d5d000000000000000000000000aac5d4240af87249b3f71bc8e4a2cae074a3e4190000000000000000000000000000000000000000000000000000000000000060000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001243c3b752b01845adb2c711129d4f3966735ed98a9f09fc4ce570000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000000e0000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000000149858effd232b4033e47d90003d41ec34ecaeda940000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000"
+
+func TestKernelFactory_GetFactoryData(t *testing.T) {
+	factory, err := NewKernelFactory()
+	require.NoError(t, err)
+
+	factoryData, err := factory.GetFactoryData(common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), big.NewInt(0))
+	require.NoError(t, err)
+
+	assert.Equal(t, testKernelFactoryData, hexutil.Encode(factoryData))
+}
+
+func TestKernelFactory_DeployAccount(t *testing.T) {
+	factory, err := NewKernelFactory()
+	require.NoError(t, err)
+
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	op := newTestUserOperation()
+	op.Nonce = big.NewInt(0)
+
+	err = factory.SetFactory(op, common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, common.HexToAddress(KernelMetaFactoryAddress), op.Factory)
+	assert.Equal(t, testKernelFactoryData, hexutil.Encode(op.FactoryData))
+
+	hash, err := entrypoint.GetUserOperationHash(op)
+	require.NoError(t, err)
+	assert.Equal(t, "0x0e52642bd2e0406a277868b43df2300e914390711d6da3fd291d373f3dbad907", hash.Hex())
+
+	marshaled, err := op.MarshalJSON()
+	require.NoError(t, err)
+
+	var unmarshaled UserOperation
+	require.NoError(t, unmarshaled.UnmarshalJSON(marshaled))
+	assert.Equal(t, op.Factory, unmarshaled.Factory)
+	assert.Equal(t, op.FactoryData, unmarshaled.FactoryData)
+}
+
+func TestKernelFactory_GetAccountAddress(t *testing.T) {
+	factory, err := NewKernelFactory()
+	require.NoError(t, err)
+
+	network := zerodevtest.NewMockRPCClient().
+		On("eth_call", "0x000000000000000000000000c81d8fa063a7c73795c8455f6b766dd245d8f47a", nil)
+
+	address, err := factory.GetAccountAddress(context.Background(), network, common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), big.NewInt(7))
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), address)
+
+	calls := network.Calls()
+	require.Len(t, calls, 1)
+
+	msg, err := json.Marshal(calls[0].Args[0])
+	require.NoError(t, err)
+
+	var call struct {
+		To   common.Address `json:"to"`
+		Data hexutil.Bytes  `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(msg, &call))
+	assert.Equal(t, common.HexToAddress(KernelFactoryAddress), call.To)
+	assert.Equal(t, "0x48aac3920000000000000000000000000000000000000000000000000000000000000040000000000000000000000000000000000000000000000000000000000000000700000000000000000000000000000000000000000000000000000000000001243c3b752b01845adb2c711129d4f3966735ed98a9f09fc4ce570000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a000000000000000000000000000000000000000000000000000000000000000e0000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000000149858effd232b4033e47d90003d41ec34ecaeda940000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000", call.Data.String())
+}
+
+// getAddressSalt decodes the salt of the getAddress eth_call of the factory
+func getAddressSalt(t *testing.T, call zerodevtest.Call) common.Hash {
+	msg, err := json.Marshal(call.Args[0])
+	require.NoError(t, err)
+
+	var decoded struct {
+		Data hexutil.Bytes `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(msg, &decoded))
+	require.True(t, len(decoded.Data) >= 4+2*common.HashLength)
+	return common.BytesToHash(decoded.Data[4+common.HashLength : 4+2*common.HashLength])
+}
+
+func TestKernelFactory_NewSmartAccountPrivateKeySignerAtIndex(t *testing.T) {
+	factory, err := NewKernelFactory()
+	require.NoError(t, err)
+
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	network := zerodevtest.NewMockRPCClient().
+		On("eth_call", "0x000000000000000000000000c81d8fa063a7c73795c8455f6b766dd245d8f47a", nil).
+		On("eth_call", "0x0000000000000000000000005fbdb2315678afecb367f032d93f642f64180aa3", nil)
+
+	first, err := factory.NewSmartAccountPrivateKeySignerAtIndex(context.Background(), network, privateKey, 0)
+	require.NoError(t, err)
+	second, err := factory.NewSmartAccountPrivateKeySignerAtIndex(context.Background(), network, privateKey, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), first.GetAddress())
+	assert.Equal(t, common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"), second.GetAddress())
+	assert.Equal(t, first.OwnerAddress(), second.OwnerAddress())
+
+	calls := network.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, common.Hash{}, getAddressSalt(t, calls[0]))
+	assert.Equal(t, common.BigToHash(big.NewInt(1)), getAddressSalt(t, calls[1]))
+
+	// the derivation is deterministic, the same index always requests the same address
+	_, err = factory.GetAccountAddressAtIndex(context.Background(), network, first.OwnerAddress(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, calls[1].Args, network.Calls()[2].Args)
+
+	_, err = factory.NewSmartAccountPrivateKeySignerAtIndex(context.Background(), network, nil, 0)
+	assert.EqualError(t, err, "privateKey is required")
+}
+
+func TestNewClient_AccountIndex(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	var network *zerodevtest.MockRPCClient
+	dial := func(rpcURL *url.URL, _ http.Header, _ *http.Client) (types.RPCClient, error) {
+		if rpcURL.Host == "bundler" {
+			return zerodevtest.NewMockRPCClient(), nil
+		}
+		network = zerodevtest.NewMockRPCClient().
+			On("eth_call", "0x0000000000000000000000005fbdb2315678afecb367f032d93f642f64180aa3", nil)
+		return network, nil
+	}
+
+	client, err := NewClient(&ClientConfig{
+		AccountPK:                  privateKey,
+		AccountIndex:               big.NewInt(3),
+		EntryPointVersion:          EntryPointVersion07,
+		RpcURL:                     &url.URL{Scheme: "http", Host: "network"},
+		BundlerURL:                 &url.URL{Scheme: "http", Host: "bundler"},
+		ChainID:                    big.NewInt(ChainPolygon),
+		SkipChainIDVerification:    true,
+		SkipEntryPointVerification: true,
+		dial:                       dial,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"), client.Signer.GetAddress())
+	require.Equal(t, 1, network.CallCount("eth_call"))
+	assert.Equal(t, common.BigToHash(big.NewInt(3)), getAddressSalt(t, network.Calls()[0]))
+}
+
+func TestNewClient_AccountAddress(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	dial := func(*url.URL, http.Header, *http.Client) (types.RPCClient, error) {
+		return zerodevtest.NewMockRPCClient(), nil
+	}
+
+	tests := []struct {
+		name          string
+		address       common.Address
+		expectedError string
+	}{
+		{
+			name:    "smart_account",
+			address: common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"),
+		},
+		{
+			name:          "owner",
+			address:       crypto.PubkeyToAddress(privateKey.PublicKey),
+			expectedError: "accountAddress is the owner address of accountPK, it must be the smart account address",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(&ClientConfig{
+				AccountAddress:             tt.address,
+				AccountPK:                  privateKey,
+				EntryPointVersion:          EntryPointVersion07,
+				RpcURL:                     &url.URL{Scheme: "http", Host: "network"},
+				BundlerURL:                 &url.URL{Scheme: "http", Host: "bundler"},
+				ChainID:                    big.NewInt(ChainPolygon),
+				SkipChainIDVerification:    true,
+				SkipEntryPointVerification: true,
+				dial:                       dial,
+			})
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.address, client.Signer.GetAddress())
+			assert.Equal(t, tt.address, client.AccountAddress)
+		})
+	}
+}
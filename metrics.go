@@ -0,0 +1,61 @@
+package zerodev
+
+import (
+	"github.com/friendsofgo/errors"
+	"time"
+)
+
+// Metrics records outcomes of user operations sent by the client.
+// Implement it to export them to Prometheus, statsd or any other collector.
+type Metrics interface {
+	// IncUserOperationsSubmitted counts user operations accepted by the bundler
+	IncUserOperationsSubmitted()
+	// IncUserOperationsSucceeded counts receipts of successfully executed user operations
+	IncUserOperationsSucceeded()
+	// IncUserOperationsReverted counts receipts of included user operations whose execution reverted
+	IncUserOperationsReverted()
+	// IncUserOperationsFailed counts user operations rejected by the bundler or failing to be sent
+	IncUserOperationsFailed()
+	// IncBundlerErrors counts bundler errors by their AAxx code, e.g. "AA25"
+	IncBundlerErrors(code string)
+	// ObserveTimeToReceipt records the time from starting to wait for a receipt until it's available,
+	// when waiting right after sending it's the time to receipt of the user operation
+	ObserveTimeToReceipt(duration time.Duration)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) IncUserOperationsSubmitted()        {}
+func (noopMetrics) IncUserOperationsSucceeded()        {}
+func (noopMetrics) IncUserOperationsReverted()         {}
+func (noopMetrics) IncUserOperationsFailed()           {}
+func (noopMetrics) IncBundlerErrors(string)            {}
+func (noopMetrics) ObserveTimeToReceipt(time.Duration) {}
+
+// metrics returns the client's metrics, if any
+func (c *Client) metrics() Metrics {
+	if c.Metrics == nil {
+		return noopMetrics{}
+	}
+	return c.Metrics
+}
+
+// recordSendError records a failed submission and the AAxx code of the bundler error, if any
+func (c *Client) recordSendError(err error) {
+	c.metrics().IncUserOperationsFailed()
+
+	var bundlerErr *BundlerError
+	if errors.As(err, &bundlerErr) && bundlerErr.Reason != "" {
+		c.metrics().IncBundlerErrors(bundlerErr.Reason)
+	}
+}
+
+// recordReceipt records the outcome of an included user operation and the time waited for its receipt
+func (c *Client) recordReceipt(receipt *UserOperationReceipt, waited time.Duration) {
+	c.metrics().ObserveTimeToReceipt(waited)
+	if receipt.Success {
+		c.metrics().IncUserOperationsSucceeded()
+	} else {
+		c.metrics().IncUserOperationsReverted()
+	}
+}
@@ -0,0 +1,100 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingMetrics struct {
+	submitted      int
+	succeeded      int
+	reverted       int
+	failed         int
+	bundlerErrors  map[string]int
+	timesToReceipt []time.Duration
+}
+
+func (m *recordingMetrics) IncUserOperationsSubmitted() { m.submitted++ }
+func (m *recordingMetrics) IncUserOperationsSucceeded() { m.succeeded++ }
+func (m *recordingMetrics) IncUserOperationsReverted()  { m.reverted++ }
+func (m *recordingMetrics) IncUserOperationsFailed()    { m.failed++ }
+func (m *recordingMetrics) IncBundlerErrors(code string) {
+	m.bundlerErrors[code]++
+}
+func (m *recordingMetrics) ObserveTimeToReceipt(duration time.Duration) {
+	m.timesToReceipt = append(m.timesToReceipt, duration)
+}
+
+func TestClient_SendUserOperation_Metrics(t *testing.T) {
+	const opHash = "0xe361e4b3c1b1e3d82bb8e55a1a0b8f8b35b28b5d0c6dd0bfa11e9c2b1b6b8876"
+
+	tests := []struct {
+		name                  string
+		sendError             error
+		receipt               interface{}
+		expectedSubmitted     int
+		expectedSucceeded     int
+		expectedReverted      int
+		expectedFailed        int
+		expectedBundlerErrors map[string]int
+		expectedReceipts      int
+	}{
+		{
+			name:                  "succeeded",
+			receipt:               json.RawMessage(`{"userOpHash":"` + opHash + `","success":true,"receipt":{"transactionHash":"0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"}}`),
+			expectedSubmitted:     1,
+			expectedSucceeded:     1,
+			expectedBundlerErrors: map[string]int{},
+			expectedReceipts:      1,
+		},
+		{
+			name:                  "reverted",
+			receipt:               json.RawMessage(`{"userOpHash":"` + opHash + `","success":false,"receipt":{"transactionHash":"0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"}}`),
+			expectedSubmitted:     1,
+			expectedReverted:      1,
+			expectedBundlerErrors: map[string]int{},
+			expectedReceipts:      1,
+		},
+		{
+			name:                  "rejected",
+			sendError:             &mockJSONRPCError{code: -32507, message: "UserOperation reverted during simulation with reason: AA25 invalid account nonce"},
+			expectedFailed:        1,
+			expectedBundlerErrors: map[string]int{"AA25": 1},
+		},
+		{
+			name:                  "failed_without_code",
+			sendError:             &mockJSONRPCError{code: -32602, message: "invalid params"},
+			expectedFailed:        1,
+			expectedBundlerErrors: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, _ := newTestClient(t, 0)
+			metrics := &recordingMetrics{bundlerErrors: map[string]int{}}
+			client.Metrics = metrics
+
+			bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient)
+			bundler.On("eth_sendUserOperation", opHash, tt.sendError)
+			// a failed submission might be looked up by hash, it's not known to the bundler
+			bundler.On("eth_getUserOperationByHash", nil, nil)
+			bundler.On("eth_getUserOperationReceipt", tt.receipt, nil)
+
+			callData := []byte{0xde, 0xad, 0xbe, 0xef}
+			_, _ = client.SendUserOperation(context.Background(), &callData, true)
+
+			assert.Equal(t, tt.expectedSubmitted, metrics.submitted)
+			assert.Equal(t, tt.expectedSucceeded, metrics.succeeded)
+			assert.Equal(t, tt.expectedReverted, metrics.reverted)
+			assert.Equal(t, tt.expectedFailed, metrics.failed)
+			assert.Equal(t, tt.expectedBundlerErrors, metrics.bundlerErrors)
+			assert.Len(t, metrics.timesToReceipt, tt.expectedReceipts)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package zerodev
+
+import (
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// MultiChainClient holds a Client per chain, for services operating the same account on several chains.
+type MultiChainClient struct {
+	clients map[string]*Client
+}
+
+// NewMultiChainClient creates a Client for each of configs, the configs have to be of different chains.
+// Clients already created are closed if one of them fails.
+func NewMultiChainClient(configs []*ClientConfig) (_ *MultiChainClient, err error) {
+	if len(configs) == 0 {
+		return nil, errors.New("at least one client config is required")
+	}
+
+	m := &MultiChainClient{clients: make(map[string]*Client, len(configs))}
+	defer func() {
+		if err != nil {
+			_ = m.Close()
+		}
+	}()
+
+	for _, config := range configs {
+		if config == nil || config.ChainID == nil {
+			return nil, errors.New("chainID is required for each client config")
+		}
+
+		key := config.ChainID.String()
+		if _, ok := m.clients[key]; ok {
+			return nil, errors.Errorf("duplicate client config for chainID %s", key)
+		}
+
+		client, err := NewClient(config)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create client for chainID %s", key)
+		}
+		m.clients[key] = client
+	}
+
+	return m, nil
+}
+
+// Client returns the Client of chainID.
+func (m *MultiChainClient) Client(chainID *big.Int) (*Client, error) {
+	if chainID == nil {
+		return nil, errors.New("chainID is required")
+	}
+
+	client, ok := m.clients[chainID.String()]
+	if !ok {
+		return nil, errors.Errorf("no client for chainID %s", chainID)
+	}
+
+	return client, nil
+}
+
+// ChainIDs returns the chains the MultiChainClient has a Client for, in no particular order.
+func (m *MultiChainClient) ChainIDs() []*big.Int {
+	chainIDs := make([]*big.Int, 0, len(m.clients))
+	for _, client := range m.clients {
+		chainIDs = append(chainIDs, new(big.Int).Set(client.ChainID))
+	}
+	return chainIDs
+}
+
+// Close closes the clients of all chains, it's safe to call more than once.
+func (m *MultiChainClient) Close() error {
+	var closeErr error
+	for key, client := range m.clients {
+		if err := client.Close(); err != nil && closeErr == nil {
+			closeErr = errors.Wrapf(err, "failed to close client for chainID %s", key)
+		}
+	}
+	return closeErr
+}
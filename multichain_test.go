@@ -0,0 +1,105 @@
+package zerodev
+
+import (
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMultiChainClient(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01166370f36")
+	require.NoError(t, err)
+
+	// the endpoints of each chain are dialed at hosts named by their chainID, the bundler of unsupportedChain fails the chainID check
+	const unsupportedChain = 1
+	newConfig := func(chainID int64) *ClientConfig {
+		host := big.NewInt(chainID).String()
+		return &ClientConfig{
+			AccountPK:         privateKey,
+			EntryPointVersion: EntryPointVersion07,
+			RpcURL:            &url.URL{Scheme: "http", Host: host, Path: "/network"},
+			BundlerURL:        &url.URL{Scheme: "http", Host: host, Path: "/bundler"},
+			ChainID:           big.NewInt(chainID),
+		}
+	}
+
+	tests := []struct {
+		name          string
+		chainIDs      []int64
+		expectedError string
+	}{
+		{
+			name:     "success",
+			chainIDs: []int64{ChainPolygon, ChainPolygonAmoy},
+		},
+		{
+			name:          "duplicate_chain",
+			chainIDs:      []int64{ChainPolygon, ChainPolygonAmoy, ChainPolygon},
+			expectedError: "duplicate client config for chainID 137",
+		},
+		{
+			name:          "client_fails",
+			chainIDs:      []int64{ChainPolygon, unsupportedChain},
+			expectedError: "failed to create client for chainID 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dialed []*zerodevtest.MockRPCClient
+			dial := func(rpcURL *url.URL, _ http.Header, _ *http.Client) (types.RPCClient, error) {
+				chainID, _ := new(big.Int).SetString(rpcURL.Host, 10)
+				if rpcURL.Path == "/bundler" && chainID.Int64() == unsupportedChain {
+					chainID = big.NewInt(ChainPolygon)
+				}
+
+				mock := zerodevtest.NewMockRPCClient().
+					On("eth_chainId", hexutil.EncodeBig(chainID), nil).
+					On("eth_supportedEntryPoints", []string{"0x0000000071727De22E5E9d8BAf0edAc6f37da032"}, nil)
+				dialed = append(dialed, mock)
+				return mock, nil
+			}
+
+			configs := make([]*ClientConfig, len(tt.chainIDs))
+			for i, chainID := range tt.chainIDs {
+				configs[i] = newConfig(chainID)
+				configs[i].dial = dial
+			}
+
+			multiChainClient, err := NewMultiChainClient(configs)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				for _, mock := range dialed {
+					assert.True(t, mock.Closed())
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			for _, chainID := range tt.chainIDs {
+				client, err := multiChainClient.Client(big.NewInt(chainID))
+				require.NoError(t, err)
+				assert.Equal(t, big.NewInt(chainID), client.ChainID)
+			}
+			assert.Len(t, multiChainClient.ChainIDs(), len(tt.chainIDs))
+
+			_, err = multiChainClient.Client(big.NewInt(unsupportedChain))
+			assert.EqualError(t, err, "no client for chainID 1")
+
+			require.NoError(t, multiChainClient.Close())
+			require.NoError(t, multiChainClient.Close())
+			for _, mock := range dialed {
+				assert.True(t, mock.Closed())
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// DefaultNonceStallTimeout is a StallTimeout giving bundlers ample time to include an operation
+const DefaultNonceStallTimeout = 5 * time.Minute
+
+type nonceManagerKey struct {
+	account common.Address
+	key     string
+}
+
+// NonceManager hands out monotonically increasing nonces per account and nonce key, so concurrent user operations of an account
+// built in the same process don't get the same nonce. Every call reconciles with the on-chain nonce, which wins when it's ahead,
+// e.g. after operations sent by another process. Calls for the same account and key are serialized, others don't wait on each other.
+//
+// An operation that is never included leaves a gap the following nonces can't be included after. With StallTimeout set, e.g. to
+// DefaultNonceStallTimeout, the gap is recovered from by handing out the on-chain nonce again once it was handed out StallTimeout ago
+// without being included. 0, the default, disables the recovery, Reset recovers manually.
+type NonceManager struct {
+	EntryPoint   Entrypoint
+	StallTimeout time.Duration
+
+	mu     sync.Mutex
+	nonces map[nonceManagerKey]*nonceState
+	now    func() time.Time
+}
+
+type nonceState struct {
+	mu   sync.Mutex
+	next *big.Int
+	// issued is when each nonce not included yet was handed out
+	issued map[string]time.Time
+}
+
+// NewNonceManager creates a new NonceManager instance reading on-chain nonces from entrypoint.
+func NewNonceManager(entrypoint Entrypoint) *NonceManager {
+	return &NonceManager{
+		EntryPoint: entrypoint,
+		nonces:     make(map[nonceManagerKey]*nonceState),
+		now:        time.Now,
+	}
+}
+
+// Next returns the next nonce of account for key, the larger of the on-chain nonce and the nonce following the last one handed out
+func (m *NonceManager) Next(ctx context.Context, account common.Address, key *big.Int) (*big.Int, error) {
+	state := m.state(account, key)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	onChain, err := m.EntryPoint.GetNonceWithKey(ctx, account, key)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.currentTime()
+	state.forgetIncluded(onChain)
+
+	// the on-chain nonce was handed out StallTimeout ago and is still not used, its operation was never included
+	if state.next != nil && state.next.Cmp(onChain) > 0 && m.StallTimeout > 0 {
+		if issued, ok := state.issued[onChain.String()]; ok && now.Sub(issued) >= m.StallTimeout {
+			state.reset()
+		}
+	}
+
+	nonce := onChain
+	if state.next != nil && state.next.Cmp(onChain) > 0 {
+		nonce = state.next
+	}
+	state.next = new(big.Int).Add(nonce, big.NewInt(1))
+	if state.issued == nil {
+		state.issued = make(map[string]time.Time)
+	}
+	state.issued[nonce.String()] = now
+
+	return new(big.Int).Set(nonce), nil
+}
+
+// Reset forgets the nonces handed out for account and key, the next nonce is the on-chain one.
+// Called when a user operation fails to be submitted, so its nonce isn't skipped.
+func (m *NonceManager) Reset(account common.Address, key *big.Int) {
+	state := m.state(account, key)
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.reset()
+}
+
+// reset forgets the nonces handed out
+func (s *nonceState) reset() {
+	s.next = nil
+	s.issued = nil
+}
+
+// forgetIncluded forgets when the nonces below onChain were handed out, they were used on-chain
+func (s *nonceState) forgetIncluded(onChain *big.Int) {
+	for nonce := range s.issued {
+		if issued, ok := new(big.Int).SetString(nonce, 10); ok && issued.Cmp(onChain) < 0 {
+			delete(s.issued, nonce)
+		}
+	}
+}
+
+func (m *NonceManager) currentTime() time.Time {
+	if m.now == nil {
+		return time.Now()
+	}
+	return m.now()
+}
+
+func (m *NonceManager) state(account common.Address, key *big.Int) *nonceState {
+	if key == nil {
+		key = big.NewInt(0)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mapKey := nonceManagerKey{account: account, key: key.String()}
+	state, ok := m.nonces[mapKey]
+	if !ok {
+		state = &nonceState{}
+		m.nonces[mapKey] = state
+	}
+	return state
+}
+
+// nonceKeyOf returns the 192-bit key of nonce
+func nonceKeyOf(nonce *big.Int) *big.Int {
+	if nonce == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Rsh(nonce, 256-nonceKeyBits)
+}
@@ -0,0 +1,255 @@
+package zerodev
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestNonceManager(t *testing.T, onChainNonces ...string) (*NonceManager, *zerodevtest.MockRPCClient) {
+	network := zerodevtest.NewMockRPCClient()
+	for _, nonce := range onChainNonces {
+		network.On("eth_call", nonce, nil)
+	}
+
+	entrypoint, err := NewEntrypoint07(network, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	return NewNonceManager(entrypoint), network
+}
+
+func TestNonceManager_Next_Concurrent(t *testing.T) {
+	manager, _ := newTestNonceManager(t, "0x0000000000000000000000000000000000000000000000000000000000000005")
+	account := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+
+	const calls = 20
+	nonces := make(chan int64, calls)
+
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := manager.Next(context.Background(), account, nil)
+			assert.NoError(t, err)
+			nonces <- nonce.Int64()
+		}()
+	}
+	wg.Wait()
+	close(nonces)
+
+	seen := make(map[int64]bool)
+	for nonce := range nonces {
+		assert.False(t, seen[nonce], "nonce %d handed out twice", nonce)
+		seen[nonce] = true
+	}
+	for nonce := int64(5); nonce < 5+calls; nonce++ {
+		assert.True(t, seen[nonce], "nonce %d not handed out", nonce)
+	}
+}
+
+func TestNonceManager_Next(t *testing.T) {
+	account := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+
+	t.Run("on_chain_ahead", func(t *testing.T) {
+		manager, _ := newTestNonceManager(t,
+			"0x0000000000000000000000000000000000000000000000000000000000000005",
+			"0x0000000000000000000000000000000000000000000000000000000000000009",
+		)
+
+		nonce, err := manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(5), nonce)
+
+		// operations sent by another process moved the on-chain nonce
+		nonce, err = manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(9), nonce)
+	})
+
+	t.Run("keys_are_independent", func(t *testing.T) {
+		manager, _ := newTestNonceManager(t, "0x0000000000000000000000000000000000000000000000000000000000000005")
+
+		nonce, err := manager.Next(context.Background(), account, big.NewInt(0))
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(5), nonce)
+
+		// the key is encoded in the high bits of the nonce
+		nonce, err = manager.Next(context.Background(), account, big.NewInt(1))
+		require.NoError(t, err)
+		assert.Equal(t, new(big.Int).Or(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(5)), nonce)
+
+		nonce, err = manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(6), nonce)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		manager, _ := newTestNonceManager(t, "0x0000000000000000000000000000000000000000000000000000000000000005")
+
+		_, err := manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+
+		manager.Reset(account, nil)
+
+		nonce, err := manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(5), nonce)
+	})
+
+	t.Run("stall", func(t *testing.T) {
+		manager, _ := newTestNonceManager(t, "0x0000000000000000000000000000000000000000000000000000000000000005")
+		now := time.Unix(1_700_000_000, 0)
+		manager.now = func() time.Time { return now }
+		manager.StallTimeout = time.Minute
+
+		for _, expected := range []int64{5, 6} {
+			nonce, err := manager.Next(context.Background(), account, nil)
+			require.NoError(t, err)
+			assert.Equal(t, big.NewInt(expected), nonce)
+		}
+
+		// within StallTimeout the nonces handed out are pending
+		now = now.Add(30 * time.Second)
+		nonce, err := manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(7), nonce)
+
+		// nonce 5 was never included, the on-chain nonce is handed out again
+		now = now.Add(30 * time.Second)
+		nonce, err = manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(5), nonce)
+
+		// the window restarts when the nonce is handed out again
+		now = now.Add(time.Second)
+		nonce, err = manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(6), nonce)
+	})
+
+	t.Run("stall_after_reset", func(t *testing.T) {
+		manager, _ := newTestNonceManager(t, "0x0000000000000000000000000000000000000000000000000000000000000005")
+		now := time.Unix(1_700_000_000, 0)
+		manager.now = func() time.Time { return now }
+		manager.StallTimeout = time.Minute
+
+		// nonce 5 fails to be submitted and is given back
+		_, err := manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		manager.Reset(account, nil)
+
+		// the account is quiet longer than StallTimeout, the on-chain nonce doesn't move
+		now = now.Add(time.Hour)
+		nonce, err := manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(5), nonce)
+
+		// nonce 5 was just handed out and is still pending
+		now = now.Add(time.Second)
+		nonce, err = manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(6), nonce)
+	})
+
+	t.Run("stall_included", func(t *testing.T) {
+		manager, _ := newTestNonceManager(t,
+			"0x0000000000000000000000000000000000000000000000000000000000000005",
+			"0x0000000000000000000000000000000000000000000000000000000000000006",
+		)
+		now := time.Unix(1_700_000_000, 0)
+		manager.now = func() time.Time { return now }
+		manager.StallTimeout = time.Minute
+
+		for _, expected := range []int64{5, 6, 7} {
+			nonce, err := manager.Next(context.Background(), account, nil)
+			require.NoError(t, err)
+			assert.Equal(t, big.NewInt(expected), nonce)
+		}
+
+		// nonce 5 was included, nonce 6 was handed out StallTimeout ago without being included
+		now = now.Add(time.Minute)
+		nonce, err := manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(6), nonce)
+	})
+
+	t.Run("stall_disabled", func(t *testing.T) {
+		// the recovery is opt-in
+		manager, _ := newTestNonceManager(t, "0x0000000000000000000000000000000000000000000000000000000000000005")
+		now := time.Unix(1_700_000_000, 0)
+		manager.now = func() time.Time { return now }
+		assert.Zero(t, manager.StallTimeout)
+
+		_, err := manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+
+		now = now.Add(time.Hour)
+		nonce, err := manager.Next(context.Background(), account, nil)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(6), nonce)
+	})
+
+	t.Run("on_chain_error", func(t *testing.T) {
+		manager, network := newTestNonceManager(t)
+		network.On("eth_call", nil, errors.New("connection refused"))
+
+		_, err := manager.Next(context.Background(), account, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_ManagedNonces(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.NonceManager = NewNonceManager(client.EntryPoint)
+	sender := client.Signer.GetAddress()
+	callData := common.FromHex("0xdeadbeef")
+
+	op, _, err := client.GetUserOperationAndHashToSign(context.Background(), sender, &callData)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), op.Nonce)
+
+	op, _, err = client.GetUserOperationAndHashToSign(context.Background(), sender, &callData)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(6), op.Nonce)
+
+	// a failed submission gives its nonce back
+	op.Signature = common.FromHex("0xdeadbeef")
+	client.BundlerClient.Client.(*zerodevtest.MockRPCClient).On("eth_sendUserOperation", nil, errors.New("connection refused"))
+	_, err = client.SendSignedUserOperation(context.Background(), op, false)
+	require.Error(t, err)
+
+	op, _, err = client.GetUserOperationAndHashToSign(context.Background(), sender, &callData)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), op.Nonce)
+}
+
+func TestClient_ResetNonce(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	sender := client.Signer.GetAddress()
+	callData := common.FromHex("0xdeadbeef")
+
+	// no-op without a NonceManager
+	client.ResetNonce(sender, nil)
+
+	client.NonceManager = NewNonceManager(client.EntryPoint)
+	for _, expected := range []int64{5, 6} {
+		op, _, err := client.GetUserOperationAndHashToSign(context.Background(), sender, &callData)
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(expected), op.Nonce)
+	}
+
+	client.ResetNonce(sender, nil)
+
+	op, _, err := client.GetUserOperationAndHashToSign(context.Background(), sender, &callData)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), op.Nonce)
+}
@@ -0,0 +1,57 @@
+// Package otelzerodev traces go-zerodev clients with OpenTelemetry.
+// It's a separate module, so that go-zerodev doesn't depend on OpenTelemetry.
+package otelzerodev
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/DIMO-Network/go-zerodev"
+
+// Tracer implements zerodev.Tracer with an OpenTelemetry tracer
+type Tracer struct {
+	Tracer trace.Tracer
+}
+
+// NewTracer creates a new Tracer instance from the provider, set it as ClientConfig.Tracer to trace the client.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{
+		Tracer: provider.Tracer(instrumentationName),
+	}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, attributes ...zerodev.Attribute) (context.Context, zerodev.Span) {
+	ctx, span := t.Tracer.Start(ctx, name, trace.WithAttributes(toKeyValues(attributes)...))
+	return ctx, &Span{Span: span}
+}
+
+// Span implements zerodev.Span with an OpenTelemetry span
+type Span struct {
+	Span trace.Span
+}
+
+func (s *Span) SetAttributes(attributes ...zerodev.Attribute) {
+	s.Span.SetAttributes(toKeyValues(attributes)...)
+}
+
+// RecordError records err as an exception event and marks the span as failed
+func (s *Span) RecordError(err error) {
+	s.Span.RecordError(err)
+	s.Span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *Span) End() {
+	s.Span.End()
+}
+
+func toKeyValues(attributes []zerodev.Attribute) []attribute.KeyValue {
+	keyValues := make([]attribute.KeyValue, len(attributes))
+	for i, a := range attributes {
+		keyValues[i] = attribute.String(a.Key, a.Value)
+	}
+	return keyValues
+}
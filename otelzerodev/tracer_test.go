@@ -0,0 +1,48 @@
+package otelzerodev
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx, parent := tracer.Start(context.Background(), zerodev.SpanSendUserOperation,
+		zerodev.Attribute{Key: zerodev.AttributeSender, Value: "0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"},
+		zerodev.Attribute{Key: zerodev.AttributeChainID, Value: "137"},
+	)
+
+	_, child := tracer.Start(ctx, zerodev.SpanSubmitUserOperation)
+	child.RecordError(errors.New("AA25 invalid account nonce"))
+	child.End()
+
+	parent.SetAttributes(zerodev.Attribute{Key: zerodev.AttributeUserOperationHash, Value: "0x01"})
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	submit, send := spans[0], spans[1]
+	assert.Equal(t, zerodev.SpanSubmitUserOperation, submit.Name())
+	assert.Equal(t, send.SpanContext().SpanID(), submit.Parent().SpanID())
+	assert.Equal(t, codes.Error, submit.Status().Code)
+	assert.Equal(t, "AA25 invalid account nonce", submit.Status().Description)
+
+	assert.Equal(t, zerodev.SpanSendUserOperation, send.Name())
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String(zerodev.AttributeSender, "0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"),
+		attribute.String(zerodev.AttributeChainID, "137"),
+		attribute.String(zerodev.AttributeUserOperationHash, "0x01"),
+	}, send.Attributes())
+}
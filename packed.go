@@ -0,0 +1,108 @@
+package zerodev
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// packedGasLength is the size of each uint128 half of accountGasLimits, gasFees and the paymaster gas limits.
+const packedGasLength = 16
+
+// PackedUserOperation is the on-chain representation of a UserOperation passed to handleOps of Entrypoint 0.7 and 0.8.
+type PackedUserOperation struct {
+	Sender             common.Address
+	Nonce              *big.Int
+	InitCode           []byte
+	CallData           []byte
+	AccountGasLimits   [32]byte
+	PreVerificationGas *big.Int
+	GasFees            [32]byte
+	PaymasterAndData   []byte
+	Signature          []byte
+}
+
+// ToPacked packs the gas limits, fees, factory and paymaster fields of op, nil values are packed as zero.
+func (op *UserOperation) ToPacked() *PackedUserOperation {
+	accountGasLimits := createPackedBuffer(
+		bigIntBytes(op.VerificationGasLimit),
+		bigIntBytes(op.CallGasLimit),
+	)
+
+	gasFees := createPackedBuffer(
+		bigIntBytes(op.MaxPriorityFeePerGas),
+		bigIntBytes(op.MaxFeePerGas),
+	)
+
+	var paymasterAndData []byte
+	if op.hasPaymaster() {
+		buffer := createPaymasterDataBuffer(
+			op.Paymaster,
+			bigIntBytes(op.PaymasterVerificationGasLimit),
+			bigIntBytes(op.PaymasterPostOpGasLimit),
+			op.PaymasterData,
+		)
+		paymasterAndData = buffer.Bytes()
+	}
+
+	return &PackedUserOperation{
+		Sender:             op.Sender,
+		Nonce:              zeroIfNil(op.Nonce),
+		InitCode:           op.initCode(),
+		CallData:           op.CallData,
+		AccountGasLimits:   toArray32(accountGasLimits),
+		PreVerificationGas: zeroIfNil(op.PreVerificationGas),
+		GasFees:            toArray32(gasFees),
+		PaymasterAndData:   paymasterAndData,
+		Signature:          op.Signature,
+	}
+}
+
+// FromPacked sets the fields of op from packed, splitting initCode into factory and factoryData and paymasterAndData into its parts.
+func (op *UserOperation) FromPacked(packed *PackedUserOperation) error {
+	if packed == nil {
+		return errors.New("packed user operation is required")
+	}
+	if len(packed.InitCode) > 0 && len(packed.InitCode) < common.AddressLength {
+		return errors.Errorf("initCode of %d bytes is shorter than a factory address", len(packed.InitCode))
+	}
+	paymasterPrefixLength := common.AddressLength + 2*packedGasLength
+	if len(packed.PaymasterAndData) > 0 && len(packed.PaymasterAndData) < paymasterPrefixLength {
+		return errors.Errorf("paymasterAndData of %d bytes is shorter than %d bytes", len(packed.PaymasterAndData), paymasterPrefixLength)
+	}
+
+	*op = UserOperation{
+		Sender:               packed.Sender,
+		Nonce:                packed.Nonce,
+		CallData:             packed.CallData,
+		VerificationGasLimit: new(big.Int).SetBytes(packed.AccountGasLimits[:packedGasLength]),
+		CallGasLimit:         new(big.Int).SetBytes(packed.AccountGasLimits[packedGasLength:]),
+		PreVerificationGas:   packed.PreVerificationGas,
+		MaxPriorityFeePerGas: new(big.Int).SetBytes(packed.GasFees[:packedGasLength]),
+		MaxFeePerGas:         new(big.Int).SetBytes(packed.GasFees[packedGasLength:]),
+		Signature:            packed.Signature,
+	}
+
+	if len(packed.InitCode) > 0 {
+		op.Factory = common.BytesToAddress(packed.InitCode[:common.AddressLength])
+		op.FactoryData = packed.InitCode[common.AddressLength:]
+	}
+
+	if len(packed.PaymasterAndData) > 0 {
+		gasLimits := packed.PaymasterAndData[common.AddressLength:paymasterPrefixLength]
+		op.Paymaster = packed.PaymasterAndData[:common.AddressLength]
+		op.PaymasterVerificationGasLimit = new(big.Int).SetBytes(gasLimits[:packedGasLength])
+		op.PaymasterPostOpGasLimit = new(big.Int).SetBytes(gasLimits[packedGasLength:])
+		op.PaymasterData = packed.PaymasterAndData[paymasterPrefixLength:]
+	}
+
+	return nil
+}
+
+// zeroIfNil returns value, or zero if value is nil.
+func zeroIfNil(value *big.Int) *big.Int {
+	if value == nil {
+		return new(big.Int)
+	}
+	return value
+}
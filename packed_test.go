@@ -0,0 +1,81 @@
+package zerodev
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserOperation_ToPacked(t *testing.T) {
+	op := newTestUserOperation()
+	op.Factory = common.HexToAddress(KernelMetaFactoryAddress)
+	op.FactoryData = common.FromHex("0xc5265d5d")
+	op.Paymaster = common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633")
+	op.PaymasterData = common.FromHex("0xabab")
+	op.PaymasterVerificationGasLimit = big.NewInt(45_000)
+	op.PaymasterPostOpGasLimit = big.NewInt(1)
+	op.Signature = common.FromHex("0x01")
+
+	packed := op.ToPacked()
+
+	assert.Equal(t, op.Sender, packed.Sender)
+	assert.Equal(t, append(common.HexToAddress(KernelMetaFactoryAddress).Bytes(), 0xc5, 0x26, 0x5d, 0x5d), packed.InitCode)
+	assert.Equal(t, common.HexToHash("0x00000000000000000000000000030d40000000000000000000000000000186a0"), common.Hash(packed.AccountGasLimits))
+	assert.Equal(t, common.HexToHash("0x00000000000000000000000059682f00000000000000000000000006fc23ac00"), common.Hash(packed.GasFees))
+	assert.Equal(t, common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633"+
+		"0000000000000000000000000000afc8"+
+		"00000000000000000000000000000001"+
+		"abab"), packed.PaymasterAndData)
+	assert.Equal(t, op.Signature, packed.Signature)
+
+	var unpacked UserOperation
+	require.NoError(t, unpacked.FromPacked(packed))
+	assert.Equal(t, op, &unpacked)
+}
+
+func TestUserOperation_ToPacked_Minimal(t *testing.T) {
+	op := newTestUserOperation()
+
+	packed := op.ToPacked()
+	assert.Empty(t, packed.InitCode)
+	assert.Empty(t, packed.PaymasterAndData)
+
+	var unpacked UserOperation
+	require.NoError(t, unpacked.FromPacked(packed))
+	assert.Equal(t, op, &unpacked)
+}
+
+func TestUserOperation_FromPacked_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		packed        *PackedUserOperation
+		expectedError string
+	}{
+		{
+			name:          "nil",
+			expectedError: "packed user operation is required",
+		},
+		{
+			name:          "short_init_code",
+			packed:        &PackedUserOperation{InitCode: common.FromHex("0x01")},
+			expectedError: "initCode of 1 bytes is shorter than a factory address",
+		},
+		{
+			name:          "short_paymaster_and_data",
+			packed:        &PackedUserOperation{PaymasterAndData: common.HexToAddress("0x01").Bytes()},
+			expectedError: "paymasterAndData of 20 bytes is shorter than 52 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var op UserOperation
+			err := op.FromPacked(tt.packed)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
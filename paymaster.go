@@ -10,12 +10,60 @@ import (
 	"math/big"
 )
 
+// Paymaster modes
+const (
+	PaymasterModeSponsored = "sponsored"
+	PaymasterModeERC20     = "erc20"
+)
+
+// PaymasterConfig selects how the paymaster pays for gas, ERC-20 mode charges Token from the account.
+type PaymasterConfig struct {
+	Mode  string
+	Token common.Address
+	// EIP7677 sponsors with the standard pm_getPaymasterStubData and pm_getPaymasterData calls instead of zd_sponsorUserOperation
+	EIP7677 bool
+	// Context is the paymaster specific context, e.g. a sponsorship policy id. It's the context param of EIP-7677 calls,
+	// its fields are merged into the request of zd_sponsorUserOperation
+	Context map[string]interface{}
+}
+
+type GasTokenData struct {
+	TokenAddress common.Address `json:"tokenAddress"`
+}
+
 type SponsorUserOperationRequest struct {
 	ChainID           *big.Int       `json:"chainId"`
-	Operation         *UserOperation `json:"userOp"`
+	Operation         interface{}    `json:"userOp"`
 	EntryPointAddress common.Address `json:"entryPointAddress"`
+	GasTokenData      *GasTokenData  `json:"gasTokenData,omitempty"`
 	ShouldOverrideFee bool           `json:"shouldOverrideFee"`
 	ShouldConsume     bool           `json:"shouldConsume"`
+	// Context holds paymaster specific fields sent along with the request fields, e.g. a sponsorship policy id
+	Context map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON merges Context into the request object, context fields don't override the request fields
+func (r *SponsorUserOperationRequest) MarshalJSON() ([]byte, error) {
+	type request SponsorUserOperationRequest
+	encoded, err := json.Marshal((*request)(r))
+	if err != nil || len(r.Context) == 0 {
+		return encoded, err
+	}
+
+	var requestFields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &requestFields); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{}, len(r.Context)+len(requestFields))
+	for key, value := range r.Context {
+		fields[key] = value
+	}
+	for key, value := range requestFields {
+		fields[key] = value
+	}
+
+	return json.Marshal(fields)
 }
 
 type SponsorUserOperationResponse struct {
@@ -27,6 +75,7 @@ type SponsorUserOperationResponse struct {
 	Paymaster                     []byte   `json:"paymaster"`
 	MaxFeePerGas                  *big.Int `json:"maxFeePerGas"`
 	PaymasterData                 []byte   `json:"paymasterData"`
+	PaymasterAndData              []byte   `json:"paymasterAndData"`
 	PreVerificationGas            *big.Int `json:"preVerificationGas"`
 }
 
@@ -39,6 +88,7 @@ type SponsorUserOperationResponseHex struct {
 	Paymaster                     string `json:"paymaster"`
 	MaxFeePerGas                  string `json:"maxFeePerGas"`
 	PaymasterData                 string `json:"paymasterData"`
+	PaymasterAndData              string `json:"paymasterAndData,omitempty"`
 	PreVerificationGas            string `json:"preVerificationGas"`
 }
 
@@ -52,6 +102,7 @@ func (r *SponsorUserOperationResponse) MarshalJSON() ([]byte, error) {
 		Paymaster:                     hexutil.Encode(r.Paymaster),
 		MaxFeePerGas:                  hexutil.EncodeBig(r.MaxFeePerGas),
 		PaymasterData:                 hexutil.Encode(r.PaymasterData),
+		PaymasterAndData:              encodeBytes(r.PaymasterAndData),
 		PreVerificationGas:            hexutil.EncodeBig(r.PreVerificationGas),
 	}
 
@@ -74,6 +125,7 @@ func (r *SponsorUserOperationResponse) UnmarshalJSON(b []byte) error {
 		Paymaster:                     common.FromHex(unmarshal.Paymaster),
 		MaxFeePerGas:                  big.NewInt(0).SetBytes(common.FromHex(unmarshal.MaxFeePerGas)),
 		PaymasterData:                 common.FromHex(unmarshal.PaymasterData),
+		PaymasterAndData:              common.FromHex(unmarshal.PaymasterAndData),
 		PreVerificationGas:            big.NewInt(0).SetBytes(common.FromHex(unmarshal.PreVerificationGas)),
 	}
 
@@ -84,6 +136,7 @@ type PaymasterClient struct {
 	Client     types.RPCClient
 	EntryPoint Entrypoint
 	ChainID    *big.Int
+	Capture    *RPCCapture
 }
 
 func NewPaymasterClient(rpcClient types.RPCClient, entrypoint Entrypoint, chainID *big.Int) (*PaymasterClient, error) {
@@ -106,23 +159,60 @@ func (p *PaymasterClient) GetChainID() *big.Int {
 	return p.ChainID
 }
 
-func (p *PaymasterClient) SponsorUserOperation(op *UserOperation) (*SponsorUserOperationResponse, error) {
+func (p *PaymasterClient) SponsorUserOperation(ctx context.Context, op *UserOperation) (*SponsorUserOperationResponse, error) {
+	return p.sponsorUserOperation(ctx, op, nil, nil)
+}
+
+// SponsorUserOperationWithContext works like SponsorUserOperation and sends the fields of paymasterContext along, see PaymasterConfig.Context.
+func (p *PaymasterClient) SponsorUserOperationWithContext(ctx context.Context, op *UserOperation, paymasterContext map[string]interface{}) (*SponsorUserOperationResponse, error) {
+	return p.sponsorUserOperation(ctx, op, nil, paymasterContext)
+}
+
+// SponsorUserOperationWithERC20 requests the ERC-20 paymaster to pay for gas in token.
+// The account has to approve the paymaster to spend the token, e.g. in the same UserOperation.
+func (p *PaymasterClient) SponsorUserOperationWithERC20(ctx context.Context, op *UserOperation, token common.Address) (*SponsorUserOperationResponse, error) {
+	return p.sponsorUserOperation(ctx, op, &GasTokenData{TokenAddress: token}, nil)
+}
+
+func (p *PaymasterClient) sponsorUserOperation(ctx context.Context, op *UserOperation, gasTokenData *GasTokenData, paymasterContext map[string]interface{}) (*SponsorUserOperationResponse, error) {
 	op.Signature = common.FromHex(SignatureDummy)
 
 	var request = SponsorUserOperationRequest{
 		ChainID:           p.ChainID,
 		EntryPointAddress: p.EntryPoint.GetAddress(),
-		Operation:         op,
+		Operation:         toRPCUserOperation(op, p.EntryPoint.GetVersion()),
+		GasTokenData:      gasTokenData,
 		ShouldOverrideFee: false,
 		ShouldConsume:     true,
+		Context:           paymasterContext,
 	}
 
 	var response SponsorUserOperationResponse
 
-	err := p.Client.CallContext(context.Background(), &response, "zd_sponsorUserOperation", request)
+	err := p.Client.CallContext(ctx, &response, "zd_sponsorUserOperation", &request)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to call zd_sponsorUserOperation")
+		return nil, errors.Wrap(newPaymasterError(err), "failed to call zd_sponsorUserOperation")
 	}
 
 	return &response, nil
 }
+
+// EnableCapture records the params and raw results of the last limit JSON-RPC calls, see LastRequests.
+// Enabling it again only changes the limit
+func (p *PaymasterClient) EnableCapture(limit int) {
+	if p.Capture != nil {
+		p.Capture.setLimit(limit)
+		return
+	}
+
+	p.Capture = NewRPCCapture(limit)
+	p.Client = &capturingRPCClient{Client: p.Client, Capture: p.Capture}
+}
+
+// LastRequests returns the captured JSON-RPC calls, oldest first, or nil if capturing isn't enabled
+func (p *PaymasterClient) LastRequests() []CapturedCall {
+	if p.Capture == nil {
+		return nil
+	}
+	return p.Capture.LastRequests()
+}
@@ -0,0 +1,28 @@
+package zerodev
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
+)
+
+// ErrPaymasterNotAllowed is returned when the paymaster endpoint sponsors a UserOperation with a paymaster outside of AllowedPaymasters
+var ErrPaymasterNotAllowed = errors.New("paymaster is not allowed")
+
+// checkPaymasterAllowed checks the paymaster of a sponsored op is one of AllowedPaymasters, any paymaster is allowed if the list is empty
+func (c *Client) checkPaymasterAllowed(op *UserOperation) error {
+	if len(c.AllowedPaymasters) == 0 {
+		return nil
+	}
+
+	if len(op.Paymaster) == common.AddressLength {
+		paymaster := common.BytesToAddress(op.Paymaster)
+		for _, allowed := range c.AllowedPaymasters {
+			if paymaster == allowed {
+				return nil
+			}
+		}
+	}
+
+	return errors.Wrapf(ErrPaymasterNotAllowed, "sponsored by paymaster %s", hexutil.Encode(op.Paymaster))
+}
@@ -0,0 +1,71 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AllowedPaymasters(t *testing.T) {
+	sponsoringPaymaster := common.HexToAddress("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633")
+	otherPaymaster := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	tests := []struct {
+		name              string
+		eip7677           bool
+		allowedPaymasters []common.Address
+		expectedError     error
+	}{
+		{
+			name: "no_allowlist",
+		},
+		{
+			name:              "allowed",
+			allowedPaymasters: []common.Address{otherPaymaster, sponsoringPaymaster},
+		},
+		{
+			name:              "not_allowed",
+			allowedPaymasters: []common.Address{otherPaymaster},
+			expectedError:     ErrPaymasterNotAllowed,
+		},
+		{
+			name:              "eip7677_allowed",
+			eip7677:           true,
+			allowedPaymasters: []common.Address{sponsoringPaymaster},
+		},
+		{
+			name:              "eip7677_not_allowed",
+			eip7677:           true,
+			allowedPaymasters: []common.Address{otherPaymaster},
+			expectedError:     ErrPaymasterNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, paymaster := newTestClient(t, 0)
+			client.AllowedPaymasters = tt.allowedPaymasters
+			if tt.eip7677 {
+				client.PaymasterConfig = &PaymasterConfig{Mode: PaymasterModeSponsored, EIP7677: true}
+				paymaster.On("pm_getPaymasterStubData", json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0xcdcd", "paymasterVerificationGasLimit": "0xafc8", "paymasterPostOpGasLimit": "0x1", "isFinal": true}`), nil)
+				client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+					On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x186a0"}`), nil)
+			}
+
+			callData := common.FromHex("0xdeadbeef")
+			op, _, err := client.GetUserOperationAndHashToSign(context.Background(), client.Signer.GetAddress(), &callData)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Contains(t, err.Error(), "0x2cc0c7981d846b9f2a16276556f6e8cb52bfb633")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, sponsoringPaymaster.Bytes(), op.Paymaster)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package zerodev
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/friendsofgo/errors"
+	"regexp"
+)
+
+// ErrPaymasterOutOfFunds is returned when the paymaster can't sponsor because its deposit or the sponsorship policy is exhausted.
+// The operation can be sent self-funded instead, see UserOperationOptions.SelfFunded
+var ErrPaymasterOutOfFunds = errors.New("paymaster is out of funds")
+
+// paymasterOutOfFundsMessages match paymaster error messages reporting an exhausted deposit or policy.
+// The AA31 reason code is matched as a whole word only, so hex data or addresses containing it don't match.
+var paymasterOutOfFundsMessages = []*regexp.Regexp{
+	regexp.MustCompile(`\bAA31\b`),
+	regexp.MustCompile(`(?i)\bpaymaster deposit too low\b`),
+	regexp.MustCompile(`(?i)\binsufficient paymaster balance\b`),
+	regexp.MustCompile(`(?i)\bpaymaster balance too low\b`),
+	regexp.MustCompile(`(?i)\binsufficient funds in paymaster\b`),
+	regexp.MustCompile(`(?i)\bpolicy limit (reached|exceeded)\b`),
+	regexp.MustCompile(`(?i)\bpolicy (has been )?exhausted\b`),
+	regexp.MustCompile(`(?i)\bexceeded the spending limit\b`),
+}
+
+// PaymasterError is a JSON-RPC error returned by the paymaster, Message is the raw message for diagnostics.
+type PaymasterError struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+func (e *PaymasterError) Error() string {
+	return fmt.Sprintf("paymaster error %d: %s", e.Code, e.Message)
+}
+
+// Unwrap returns ErrPaymasterOutOfFunds if the message reports an exhausted deposit or policy, so errors.Is can be used against it.
+func (e *PaymasterError) Unwrap() error {
+	for _, known := range paymasterOutOfFundsMessages {
+		if known.MatchString(e.Message) {
+			return ErrPaymasterOutOfFunds
+		}
+	}
+
+	return nil
+}
+
+// newPaymasterError converts a JSON-RPC error into a PaymasterError, other errors are returned as is.
+func newPaymasterError(err error) error {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return err
+	}
+
+	paymasterErr := &PaymasterError{
+		Code:    rpcErr.ErrorCode(),
+		Message: rpcErr.Error(),
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		paymasterErr.Data = dataErr.ErrorData()
+	}
+
+	return paymasterErr
+}
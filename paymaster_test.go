@@ -0,0 +1,179 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymasterClient_SponsorUserOperationWithERC20(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	token := common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359")
+
+	paymaster := &PaymasterClient{
+		Client: &mockRPCClient{
+			callContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				assert.Equal(t, "zd_sponsorUserOperation", method)
+
+				request, err := json.Marshal(args[0])
+				require.NoError(t, err)
+				// addresses are marshalled lowercase
+				assert.Contains(t, string(request), `"gasTokenData":{"tokenAddress":"`+strings.ToLower(token.Hex())+`"}`)
+
+				return json.Unmarshal([]byte(`{
+					"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633",
+					"paymasterData": "0x000000000000000000000000000000000000000000000000000000006791f7a1ababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababab",
+					"paymasterVerificationGasLimit": "0xafc8",
+					"paymasterPostOpGasLimit": "0x1",
+					"preVerificationGas": "0xc350",
+					"verificationGasLimit": "0x30d40",
+					"callGasLimit": "0x186a0"
+				}`), result)
+			},
+		},
+		EntryPoint: entrypoint,
+		ChainID:    big.NewInt(ChainPolygon),
+	}
+
+	op := newTestUserOperation()
+	response, err := paymaster.SponsorUserOperationWithERC20(context.Background(), op, token)
+	require.NoError(t, err)
+
+	op.Paymaster = response.Paymaster
+	op.PaymasterData = response.PaymasterData
+	op.PaymasterVerificationGasLimit = response.PaymasterVerificationGasLimit
+	op.PaymasterPostOpGasLimit = response.PaymasterPostOpGasLimit
+
+	hash, err := entrypoint.GetUserOperationHash(op)
+	require.NoError(t, err)
+	assert.Equal(t, "0xe361e4b3ddb22445e07c6d63862332f3313663b87dec5297c0a0ee33eac68876", hash.Hex())
+}
+
+func TestNewClient_ERC20RequiresPaymasterURL(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	_, err = NewClient(&ClientConfig{
+		AccountPK:         privateKey,
+		EntryPointVersion: EntryPointVersion07,
+		BundlerURL:        &url.URL{Scheme: "http", Host: "bundler"},
+		ChainID:           big.NewInt(ChainPolygon),
+		Paymaster:         &PaymasterConfig{Mode: PaymasterModeERC20, Token: common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359")},
+	})
+	assert.EqualError(t, err, "paymasterURL is required in erc20 paymaster mode")
+}
+
+func TestPaymasterClient_SponsorUserOperationWithContext(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	paymaster := &PaymasterClient{
+		Client: &mockRPCClient{
+			callContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				assert.Equal(t, "zd_sponsorUserOperation", method)
+
+				request, err := json.Marshal(args[0])
+				require.NoError(t, err)
+				assert.Contains(t, string(request), `"sponsorshipPolicyId":"sp_1"`)
+				assert.Contains(t, string(request), `"chainId":137`)
+				assert.Contains(t, string(request), `"shouldConsume":true`)
+				assert.NotContains(t, string(request), `"Context"`)
+
+				return json.Unmarshal([]byte(`{
+					"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633",
+					"paymasterData": "0xabab",
+					"paymasterVerificationGasLimit": "0xafc8",
+					"paymasterPostOpGasLimit": "0x1",
+					"preVerificationGas": "0xc350",
+					"verificationGasLimit": "0x30d40",
+					"callGasLimit": "0x186a0"
+				}`), result)
+			},
+		},
+		EntryPoint: entrypoint,
+		ChainID:    big.NewInt(ChainPolygon),
+	}
+
+	_, err = paymaster.SponsorUserOperationWithContext(context.Background(), newTestUserOperation(), map[string]interface{}{
+		"sponsorshipPolicyId": "sp_1",
+		"chainId":             1,
+		"shouldConsume":       false,
+	})
+	require.NoError(t, err)
+}
+
+func TestPaymasterClient_SponsorUserOperation_PaymasterError(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		rpcError    error
+		outOfFunds  bool
+		expectedMsg string
+	}{
+		{
+			name:        "deposit_too_low",
+			rpcError:    &mockJSONRPCError{code: -32500, message: "UserOperation reverted during simulation with reason: AA31 paymaster deposit too low"},
+			outOfFunds:  true,
+			expectedMsg: "UserOperation reverted during simulation with reason: AA31 paymaster deposit too low",
+		},
+		{
+			name:        "policy_exhausted",
+			rpcError:    &mockJSONRPCError{code: -32603, message: "Gas Policy Limit reached for project"},
+			outOfFunds:  true,
+			expectedMsg: "Gas Policy Limit reached for project",
+		},
+		{
+			name:        "hex_containing_aa31",
+			rpcError:    &mockJSONRPCError{code: -32602, message: "invalid sender 0x12aa31ff0000000000000000000000000000aa31"},
+			expectedMsg: "invalid sender 0x12aa31ff0000000000000000000000000000aa31",
+		},
+		{
+			name:        "policy_limit_setting",
+			rpcError:    &mockJSONRPCError{code: -32602, message: "invalid policy limit configuration"},
+			expectedMsg: "invalid policy limit configuration",
+		},
+		{
+			name:        "other",
+			rpcError:    &mockJSONRPCError{code: -32602, message: "invalid params"},
+			expectedMsg: "invalid params",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paymaster := &PaymasterClient{
+				Client: &mockRPCClient{
+					callContextFunc: func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+						return tt.rpcError
+					},
+				},
+				EntryPoint: entrypoint,
+				ChainID:    big.NewInt(ChainPolygon),
+			}
+
+			_, err := paymaster.SponsorUserOperation(context.Background(), newTestUserOperation())
+			require.Error(t, err)
+
+			var paymasterErr *PaymasterError
+			require.True(t, errors.As(err, &paymasterErr))
+			assert.Equal(t, tt.expectedMsg, paymasterErr.Message)
+			assert.Equal(t, tt.outOfFunds, errors.Is(err, ErrPaymasterOutOfFunds))
+
+			_, err = paymaster.GetPaymasterStubData(context.Background(), newTestUserOperation(), nil)
+			assert.Equal(t, tt.outOfFunds, errors.Is(err, ErrPaymasterOutOfFunds))
+		})
+	}
+}
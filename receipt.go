@@ -0,0 +1,54 @@
+package zerodev
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+)
+
+// erc20TransferTopic is emitted by ERC-20 tokens on transfers, ERC-20 paymasters charge the account with a transfer to themselves
+var erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// ActualGasPrice returns the effective gas price paid by the UserOperation, ActualGasCost / ActualGasUsed, or nil if they're unknown.
+func (r *UserOperationReceipt) ActualGasPrice() *big.Int {
+	if r.ActualGasCost == nil || r.ActualGasUsed == nil || r.ActualGasUsed.Sign() == 0 {
+		return nil
+	}
+
+	return new(big.Int).Div(r.ActualGasCost, r.ActualGasUsed)
+}
+
+// setUserOperationCost sets the gas and paymaster cost of the UserOperation on receipt
+func setUserOperationCost(receipt *UserOperationReceipt, response *GetUserOperationReceiptResponse) {
+	if response.ActualGasUsed != nil {
+		receipt.ActualGasUsed = response.ActualGasUsed.ToInt()
+	}
+	if response.ActualGasCost != nil {
+		receipt.ActualGasCost = response.ActualGasCost.ToInt()
+	}
+
+	if response.Paymaster == (common.Address{}) {
+		return
+	}
+	paymaster := response.Paymaster
+	receipt.Paymaster = &paymaster
+
+	// the logs of the response are the logs of the UserOperation only, including the transfer in the paymaster's postOp
+	for _, log := range response.Logs {
+		if len(log.Topics) != 3 || log.Topics[0] != erc20TransferTopic || len(log.Data) != common.HashLength {
+			continue
+		}
+		if common.BytesToAddress(log.Topics[1].Bytes()) != response.Sender || common.BytesToAddress(log.Topics[2].Bytes()) != paymaster {
+			continue
+		}
+
+		if receipt.TokenCharged == nil {
+			token := log.Address
+			receipt.GasToken = &token
+			receipt.TokenCharged = new(big.Int)
+		} else if log.Address != *receipt.GasToken {
+			continue
+		}
+		receipt.TokenCharged.Add(receipt.TokenCharged, new(big.Int).SetBytes(log.Data))
+	}
+}
@@ -0,0 +1,174 @@
+package zerodev
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUserOperationReceipt_Cost(t *testing.T) {
+	userOpHash := hexutil.Bytes(common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"))
+	sender := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	paymaster := common.HexToAddress("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633")
+	token := common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359")
+	other := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	transfer := func(token, from, to common.Address, amount int64) ethtypes.Log {
+		return ethtypes.Log{
+			Address: token,
+			Topics:  []common.Hash{erc20TransferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+			Data:    common.BigToHash(big.NewInt(amount)).Bytes(),
+		}
+	}
+
+	tests := []struct {
+		name                 string
+		paymaster            common.Address
+		logs                 []ethtypes.Log
+		expectedPaymaster    *common.Address
+		expectedGasToken     *common.Address
+		expectedTokenCharged *big.Int
+	}{
+		{
+			name: "not_sponsored",
+			logs: []ethtypes.Log{transfer(token, sender, other, 10)},
+		},
+		{
+			name:              "sponsored",
+			paymaster:         paymaster,
+			logs:              []ethtypes.Log{transfer(token, sender, other, 10)},
+			expectedPaymaster: &paymaster,
+		},
+		{
+			name:                 "erc20",
+			paymaster:            paymaster,
+			logs:                 []ethtypes.Log{transfer(token, sender, other, 10), transfer(token, sender, paymaster, 1_500)},
+			expectedPaymaster:    &paymaster,
+			expectedGasToken:     &token,
+			expectedTokenCharged: big.NewInt(1_500),
+		},
+		{
+			name:      "erc20_multiple_transfers",
+			paymaster: paymaster,
+			logs: []ethtypes.Log{
+				transfer(token, sender, paymaster, 1_500),
+				transfer(other, sender, paymaster, 7),
+				transfer(token, sender, paymaster, 25),
+			},
+			expectedPaymaster:    &paymaster,
+			expectedGasToken:     &token,
+			expectedTokenCharged: big.NewInt(1_525),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt, err := newUserOperationReceipt(common.HexToAddress(entryPointAddress07), &GetUserOperationReceiptResponse{
+				UserOpHash:    &userOpHash,
+				Sender:        sender,
+				Paymaster:     tt.paymaster,
+				ActualGasUsed: (*hexutil.Big)(big.NewInt(150_000)),
+				ActualGasCost: (*hexutil.Big)(big.NewInt(4_500_000_000_000_000)),
+				Success:       true,
+				Logs:          tt.logs,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, big.NewInt(150_000), receipt.ActualGasUsed)
+			assert.Equal(t, big.NewInt(4_500_000_000_000_000), receipt.ActualGasCost)
+			assert.Equal(t, big.NewInt(30_000_000_000), receipt.ActualGasPrice())
+			assert.Equal(t, tt.expectedPaymaster, receipt.Paymaster)
+			assert.Equal(t, tt.expectedGasToken, receipt.GasToken)
+			assert.Equal(t, tt.expectedTokenCharged, receipt.TokenCharged)
+		})
+	}
+}
+
+func TestUserOperationReceipt_ActualGasPrice_Unknown(t *testing.T) {
+	assert.Nil(t, (&UserOperationReceipt{}).ActualGasPrice())
+	assert.Nil(t, (&UserOperationReceipt{ActualGasCost: big.NewInt(1), ActualGasUsed: big.NewInt(0)}).ActualGasPrice())
+}
+
+func TestNewUserOperationReceipt_Event(t *testing.T) {
+	userOpHash := hexutil.Bytes(common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"))
+	sender := common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A")
+	paymaster := common.HexToAddress("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633")
+
+	eventData := append(common.BigToHash(big.NewInt(5)).Bytes(), common.BigToHash(big.NewInt(1)).Bytes()...)
+	eventData = append(eventData, common.BigToHash(big.NewInt(4_500_000_000_000_000)).Bytes()...)
+	eventData = append(eventData, common.BigToHash(big.NewInt(150_000)).Bytes()...)
+	entrypoint := common.HexToAddress(entryPointAddress07)
+	event := func(hash []byte, data []byte) ethtypes.Log {
+		return ethtypes.Log{
+			Address: entrypoint,
+			Topics:  []common.Hash{userOperationEventTopic, common.BytesToHash(hash), common.BytesToHash(sender.Bytes()), common.BytesToHash(paymaster.Bytes())},
+			Data:    data,
+		}
+	}
+	impostor := event(userOpHash, eventData)
+	impostor.Address = common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	tests := []struct {
+		name          string
+		logs          []ethtypes.Log
+		receiptLogs   []ethtypes.Log
+		expectedEvent *UserOperationEvent
+		expectedError bool
+	}{
+		{
+			name: "missing",
+		},
+		{
+			name:        "other_user_operation",
+			receiptLogs: []ethtypes.Log{event(common.FromHex("0x01"), eventData)},
+		},
+		{
+			name:        "other_contract",
+			receiptLogs: []ethtypes.Log{impostor},
+		},
+		{
+			name:        "receipt_logs",
+			receiptLogs: []ethtypes.Log{event(userOpHash, eventData)},
+			expectedEvent: &UserOperationEvent{
+				UserOperationHash: common.BytesToHash(userOpHash),
+				Sender:            sender,
+				Paymaster:         paymaster,
+				Nonce:             big.NewInt(5),
+				Success:           true,
+				ActualGasCost:     big.NewInt(4_500_000_000_000_000),
+				ActualGasUsed:     big.NewInt(150_000),
+			},
+		},
+		{
+			name:          "malformed",
+			logs:          []ethtypes.Log{event(userOpHash, eventData[:64])},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := &GetUserOperationReceiptResponse{
+				UserOpHash: &userOpHash,
+				Sender:     sender,
+				Success:    true,
+				Logs:       tt.logs,
+			}
+			response.Receipt.Logs = tt.receiptLogs
+
+			receipt, err := newUserOperationReceipt(entrypoint, response)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedEvent, receipt.Event)
+		})
+	}
+}
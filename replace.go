@@ -0,0 +1,118 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// minReplacementFeeIncreasePercent is the minimum fee increase bundlers require to replace an operation in their mempool
+const minReplacementFeeIncreasePercent = 10
+
+// ErrUserOperationIncluded is returned when replacing a UserOperation whose nonce was already used on-chain
+var ErrUserOperationIncluded = errors.New("user operation already included")
+
+// ReplaceUserOperation resubmits original, stuck in the bundler mempool, with the same nonce and higher fees.
+// Fees are resolved from newGas like UserOperationOptions.GasOverrides and raised to at least 10% above the fees of original,
+// the minimum increment bundlers require to replace an operation. Sponsored operations are sponsored again for the new fees.
+// The replacement is signed by the client's Signer, so only operations of the client's account can be replaced,
+// and sponsored operations only if the client has a paymaster.
+func (c *Client) ReplaceUserOperation(ctx context.Context, original *UserOperation, newGas GasOverrides) (*UserOperationResult, error) {
+	if original.Sender != c.Signer.GetAddress() {
+		return nil, errors.New("only user operations of the client's account can be replaced")
+	}
+	if original.hasPaymaster() && c.PaymasterClient == nil {
+		return nil, errors.New("sponsored user operations can only be replaced by a client with a paymaster")
+	}
+
+	ctx, span := c.startSpan(ctx, SpanSendUserOperation, c.userOperationAttributes(original.Sender)...)
+	result, err := c.replaceUserOperation(ctx, span, original, &newGas)
+	endSpan(span, err)
+	return result, err
+}
+
+// replaceUserOperation builds, signs and sends the replacement of original, tracing it as children of parent
+func (c *Client) replaceUserOperation(ctx context.Context, parent Span, original *UserOperation, newGas *GasOverrides) (*UserOperationResult, error) {
+	nonceKey := nonceKeyOf(original.Nonce)
+
+	var nonce *big.Int
+	var gasPrice *GetUserOperationGasPriceResponse
+
+	err := runConcurrently(ctx,
+		func(ctx context.Context) error {
+			var err error
+			nonce, err = c.EntryPoint.GetNonceWithKey(ctx, original.Sender, nonceKey)
+			return err
+		},
+		func(ctx context.Context) error {
+			if !newGas.needsGasPrice() {
+				return nil
+			}
+
+			var err error
+			gasPrice, err = c.BundlerClient.GetUserOperationGasPrice(ctx)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if nonce.Cmp(original.Nonce) > 0 {
+		return nil, ErrUserOperationIncluded
+	}
+
+	op := *original
+	op.Signature = nil
+
+	err = newGas.apply(&op, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	op.MaxFeePerGas = maxBigInt(op.MaxFeePerGas, minReplacementFee(original.MaxFeePerGas))
+	op.MaxPriorityFeePerGas = maxBigInt(op.MaxPriorityFeePerGas, minReplacementFee(original.MaxPriorityFeePerGas))
+
+	// the paymaster signs over the fees, the replacement needs a new sponsorship
+	if original.hasPaymaster() {
+		op.Paymaster = nil
+		op.PaymasterData = nil
+		op.PaymasterVerificationGasLimit = nil
+		op.PaymasterPostOpGasLimit = nil
+
+		err = c.sponsor(ctx, &op, c.paymasterContext(nil), newGas.speed())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opHash, err := c.EntryPoint.GetUserOperationHash(&op)
+	if err != nil {
+		return nil, err
+	}
+
+	err = SignUserOperationContext(ctx, &op, *opHash, c.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.sendSignedUserOperation(ctx, parent, &op, false)
+}
+
+// minReplacementFee is fee increased by minReplacementFeeIncreasePercent, rounded up
+func minReplacementFee(fee *big.Int) *big.Int {
+	if fee == nil {
+		return big.NewInt(0)
+	}
+
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+minReplacementFeeIncreasePercent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+func maxBigInt(a, b *big.Int) *big.Int {
+	if a == nil || a.Cmp(b) < 0 {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,131 @@
+package zerodev
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ReplaceUserOperation(t *testing.T) {
+	tests := []struct {
+		name                string
+		nonce               int64
+		newGas              GasOverrides
+		expectedMaxFee      *big.Int
+		expectedPriorityFee *big.Int
+		expectedIncluded    bool
+	}{
+		{
+			name:                "standard_bumped_to_minimum",
+			nonce:               5,
+			expectedMaxFee:      big.NewInt(33_000_000_000),
+			expectedPriorityFee: big.NewInt(1_650_000_000),
+		},
+		{
+			name:                "fast",
+			nonce:               5,
+			newGas:              GasOverrides{Speed: GasSpeedFast},
+			expectedMaxFee:      big.NewInt(50_000_000_000),
+			expectedPriorityFee: big.NewInt(2_000_000_000),
+		},
+		{
+			name:                "custom_fees_below_minimum",
+			nonce:               5,
+			newGas:              GasOverrides{MaxFeePerGas: big.NewInt(31_000_000_000), MaxPriorityFeePerGas: big.NewInt(1_000_000_000)},
+			expectedMaxFee:      big.NewInt(33_000_000_000),
+			expectedPriorityFee: big.NewInt(1_650_000_000),
+		},
+		{
+			name:             "already_included",
+			nonce:            4,
+			expectedIncluded: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, paymaster := newTestClient(t, 0)
+			bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient)
+			bundler.On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil)
+
+			original := &UserOperation{
+				Sender:               client.Signer.GetAddress(),
+				Nonce:                big.NewInt(tt.nonce),
+				CallData:             common.FromHex("0xdeadbeef"),
+				MaxFeePerGas:         big.NewInt(30_000_000_000),
+				MaxPriorityFeePerGas: big.NewInt(1_500_000_000),
+				Paymaster:            common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633"),
+				Signature:            common.FromHex("0x01"),
+			}
+
+			result, err := client.ReplaceUserOperation(context.Background(), original, tt.newGas)
+			if tt.expectedIncluded {
+				assert.ErrorIs(t, err, ErrUserOperationIncluded)
+				assert.Equal(t, 0, bundler.CallCount("eth_sendUserOperation"))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"), result.UserOperationHash)
+			assert.Equal(t, 1, paymaster.CallCount("zd_sponsorUserOperation"))
+
+			var sent *UserOperation
+			for _, call := range bundler.Calls() {
+				if call.Method == "eth_sendUserOperation" {
+					sent = call.Args[0].(*UserOperation)
+				}
+			}
+			require.NotNil(t, sent)
+
+			assert.Equal(t, original.Nonce, sent.Nonce)
+			assert.Equal(t, tt.expectedMaxFee, sent.MaxFeePerGas)
+			assert.Equal(t, tt.expectedPriorityFee, sent.MaxPriorityFeePerGas)
+
+			hash, err := client.EntryPoint.GetUserOperationHash(sent)
+			require.NoError(t, err)
+			expectedSignature, err := client.Signer.SignUserOperationHash(*hash)
+			require.NoError(t, err)
+			assert.Equal(t, expectedSignature, sent.Signature)
+
+			// original is left untouched
+			assert.Equal(t, big.NewInt(30_000_000_000), original.MaxFeePerGas)
+			assert.Equal(t, common.FromHex("0x01"), original.Signature)
+		})
+	}
+}
+
+func TestClient_ReplaceUserOperation_OtherSender(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+
+	_, err := client.ReplaceUserOperation(context.Background(), &UserOperation{
+		Sender: common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"),
+		Nonce:  big.NewInt(5),
+	}, GasOverrides{})
+	assert.Error(t, err)
+}
+
+func TestClient_ReplaceUserOperation_NoPaymaster(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.PaymasterClient = nil
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient)
+
+	_, err := client.ReplaceUserOperation(context.Background(), &UserOperation{
+		Sender:    client.Signer.GetAddress(),
+		Nonce:     big.NewInt(5),
+		Paymaster: common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633"),
+	}, GasOverrides{})
+	assert.EqualError(t, err, "sponsored user operations can only be replaced by a client with a paymaster")
+	assert.Empty(t, bundler.Calls())
+}
+
+func TestMinReplacementFee(t *testing.T) {
+	assert.Equal(t, big.NewInt(0), minReplacementFee(nil))
+	assert.Equal(t, big.NewInt(11), minReplacementFee(big.NewInt(10)))
+	// rounded up so the increase is never below 10%
+	assert.Equal(t, big.NewInt(13), minReplacementFee(big.NewInt(11)))
+}
@@ -0,0 +1,149 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/friendsofgo/errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const defaultRetryDelay = 500 * time.Millisecond
+
+// idempotentMethods are the RPC methods safe to retry, they don't change any state.
+// Sponsorships are not retried, they are signed by the paymaster and consume its policy limits.
+// pm_getPaymasterStubData is side-effect free, EIP-7677 stub data is unsigned and consumes nothing
+var idempotentMethods = map[string]bool{
+	"eth_call":                     true,
+	"eth_getCode":                  true,
+	"eth_chainId":                  true,
+	"eth_estimateUserOperationGas": true,
+	"eth_getUserOperationByHash":   true,
+	"eth_getUserOperationReceipt":  true,
+	"zd_getUserOperationGasPrice":  true,
+	"pm_getPaymasterStubData":      true,
+	"eth_blockNumber":              true,
+	"eth_getBlockByNumber":         true,
+	"eth_gasPrice":                 true,
+	"eth_maxPriorityFeePerGas":     true,
+	"eth_getTransactionCount":      true,
+	"eth_getTransactionReceipt":    true,
+}
+
+// RetryPolicy controls retries of RPC calls failing with transient errors
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts of a call, 1 disables retries
+	MaxAttempts int
+	// Delay between attempts, defaults to 500ms
+	Delay time.Duration
+	// Retryable classifies errors worth retrying, defaults to IsTransientError
+	Retryable func(err error) bool
+	// RetrySendUserOperation also retries eth_sendUserOperation, an op already submitted by a previous attempt
+	// is recognized by its userOpHash in BundlerClient.SendUserOperation
+	RetrySendUserOperation bool
+}
+
+// DefaultRetryPolicy retries idempotent reads up to 3 attempts, sending user operations is never retried
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		Delay:       defaultRetryDelay,
+	}
+}
+
+// shouldRetry reports whether calls of method can be retried under the policy
+func (p *RetryPolicy) shouldRetry(method string) bool {
+	if p.MaxAttempts <= 1 {
+		return false
+	}
+	return idempotentMethods[method] || (method == "eth_sendUserOperation" && p.RetrySendUserOperation)
+}
+
+func (p *RetryPolicy) isRetryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsTransientError(err)
+}
+
+// IsTransientError reports whether err is a network failure or a rate limiting / unavailable HTTP response.
+// JSON-RPC errors returned by the server and context cancellations are not transient.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// RetryingRPCClient wraps an RPCClient retrying the calls allowed by Policy
+type RetryingRPCClient struct {
+	Client types.RPCClient
+	Policy *RetryPolicy
+	// Clock times the delay between attempts, SystemClock if not set
+	Clock Clock
+}
+
+// NewRetryingRPCClient creates a new RetryingRPCClient instance, a nil policy uses DefaultRetryPolicy.
+func NewRetryingRPCClient(rpcClient types.RPCClient, policy *RetryPolicy) *RetryingRPCClient {
+	if policy == nil {
+		policy = DefaultRetryPolicy()
+	}
+
+	return &RetryingRPCClient{
+		Client: rpcClient,
+		Policy: policy,
+	}
+}
+
+func (r *RetryingRPCClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if !r.Policy.shouldRetry(method) {
+		return r.Client.CallContext(ctx, result, method, args...)
+	}
+
+	delay := r.Policy.Delay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	clock := clockOrSystem(r.Clock)
+
+	for attempt := 1; ; attempt++ {
+		err := r.Client.CallContext(ctx, result, method, args...)
+		if err == nil || attempt >= r.Policy.MaxAttempts || !r.Policy.isRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-clock.After(delay):
+		}
+	}
+}
+
+// BatchCallContext sends batch with the wrapped client, batch requests are not retried.
+// The calls are made one by one with retries if the wrapped client doesn't support batches
+func (r *RetryingRPCClient) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	if batchClient, ok := r.Client.(types.BatchRPCClient); ok {
+		return batchClient.BatchCallContext(ctx, batch)
+	}
+	return callSequentially(ctx, r, batch)
+}
+
+func (r *RetryingRPCClient) Close() {
+	r.Client.Close()
+}
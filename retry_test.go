@@ -0,0 +1,190 @@
+package zerodev
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil, expected: false},
+		{name: "connection_refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, expected: true},
+		{name: "unexpected_eof", err: errors.Wrap(io.ErrUnexpectedEOF, "read response"), expected: true},
+		{name: "too_many_requests", err: rpc.HTTPError{StatusCode: 429, Status: "429 Too Many Requests"}, expected: true},
+		{name: "bad_gateway", err: rpc.HTTPError{StatusCode: 502, Status: "502 Bad Gateway"}, expected: true},
+		{name: "bad_request", err: rpc.HTTPError{StatusCode: 400, Status: "400 Bad Request"}, expected: false},
+		{name: "json_rpc_error", err: &mockJSONRPCError{code: -32602, message: "invalid params"}, expected: false},
+		{name: "context_canceled", err: context.Canceled, expected: false},
+		{name: "deadline_exceeded", err: errors.Wrap(context.DeadlineExceeded, "call"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsTransientError(tt.err))
+		})
+	}
+}
+
+func TestRetryingRPCClient_CallContext(t *testing.T) {
+	transientErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	tests := []struct {
+		name             string
+		method           string
+		policy           *RetryPolicy
+		errs             []error
+		expectedAttempts int
+		expectedError    bool
+	}{
+		{
+			name:             "read_recovers",
+			method:           "eth_call",
+			errs:             []error{transientErr, transientErr, nil},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "read_gives_up",
+			method:           "eth_call",
+			errs:             []error{transientErr},
+			expectedAttempts: 3,
+			expectedError:    true,
+		},
+		{
+			name:             "read_not_retryable",
+			method:           "eth_call",
+			errs:             []error{&mockJSONRPCError{code: -32602, message: "invalid params"}},
+			expectedAttempts: 1,
+			expectedError:    true,
+		},
+		{
+			name:             "sponsor_not_retried",
+			method:           "zd_sponsorUserOperation",
+			errs:             []error{transientErr, nil},
+			expectedAttempts: 1,
+			expectedError:    true,
+		},
+		{
+			name:             "stub_data_retried",
+			method:           "pm_getPaymasterStubData",
+			errs:             []error{transientErr, nil},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "paymaster_data_not_retried",
+			method:           "pm_getPaymasterData",
+			errs:             []error{transientErr, nil},
+			expectedAttempts: 1,
+			expectedError:    true,
+		},
+		{
+			name:             "send_not_retried_by_default",
+			method:           "eth_sendUserOperation",
+			errs:             []error{transientErr, nil},
+			expectedAttempts: 1,
+			expectedError:    true,
+		},
+		{
+			name:             "send_retried_when_enabled",
+			method:           "eth_sendUserOperation",
+			policy:           &RetryPolicy{MaxAttempts: 3, Delay: time.Millisecond, RetrySendUserOperation: true},
+			errs:             []error{transientErr, nil},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "disabled",
+			method:           "eth_call",
+			policy:           &RetryPolicy{MaxAttempts: 1},
+			errs:             []error{transientErr, nil},
+			expectedAttempts: 1,
+			expectedError:    true,
+		},
+		{
+			name:   "custom_classifier",
+			method: "eth_call",
+			policy: &RetryPolicy{MaxAttempts: 2, Delay: time.Millisecond, Retryable: func(err error) bool {
+				return true
+			}},
+			errs:             []error{&mockJSONRPCError{code: -32000, message: "header not found"}, nil},
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient()
+			for _, err := range tt.errs {
+				mock.On(tt.method, "0x01", err)
+			}
+
+			policy := tt.policy
+			if policy == nil {
+				policy = DefaultRetryPolicy()
+				policy.Delay = time.Millisecond
+			}
+
+			var result string
+			err := NewRetryingRPCClient(mock, policy).CallContext(context.Background(), &result, tt.method)
+
+			assert.Equal(t, tt.expectedAttempts, mock.CallCount(tt.method))
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "0x01", result)
+		})
+	}
+}
+
+func TestRetryingRPCClient_CallContext_NetworkReads(t *testing.T) {
+	methods := []string{
+		"eth_blockNumber",
+		"eth_getBlockByNumber",
+		"eth_gasPrice",
+		"eth_maxPriorityFeePerGas",
+		"eth_getTransactionCount",
+		"eth_getTransactionReceipt",
+	}
+
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient().On(method, nil, io.EOF).On(method, "0x01", nil)
+			clock := zerodevtest.NewFakeClock(time.Unix(1_700_000_000, 0))
+			client := NewRetryingRPCClient(mock, nil)
+			client.Clock = clock
+
+			var result string
+			require.NoError(t, client.CallContext(context.Background(), &result, method))
+			assert.Equal(t, "0x01", result)
+			assert.Equal(t, 2, mock.CallCount(method))
+
+			// the delay between attempts is waited on the clock
+			assert.Equal(t, []time.Duration{defaultRetryDelay}, clock.Waits())
+		})
+	}
+}
+
+func TestRetryingRPCClient_CallContext_ContextCanceled(t *testing.T) {
+	mock := zerodevtest.NewMockRPCClient().On("eth_call", nil, io.EOF)
+	client := NewRetryingRPCClient(mock, &RetryPolicy{MaxAttempts: 3, Delay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := client.CallContext(ctx, nil, "eth_call")
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 1, mock.CallCount("eth_call"))
+}
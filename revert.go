@@ -0,0 +1,97 @@
+package zerodev
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// userOperationRevertReasonTopic is emitted by the entrypoint (0.6 and 0.7) when the inner call of a UserOperation reverts
+var userOperationRevertReasonTopic = crypto.Keccak256Hash([]byte("UserOperationRevertReason(bytes32,address,uint256,bytes)"))
+
+var (
+	errorSelector = [4]byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector = [4]byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// RevertReason is the decoded revert data of a call, Name and Args are set for the standard Error(string) and Panic(uint256) errors only.
+type RevertReason struct {
+	Selector [4]byte
+	Name     string
+	Args     []interface{}
+	Message  string
+}
+
+// DecodeRevertReason decodes the revert data of a call, custom errors are returned with their selector and raw data as message.
+func DecodeRevertReason(data []byte) (*RevertReason, error) {
+	if len(data) < 4 {
+		return nil, errors.New("revert data too short: " + hexutil.Encode(data))
+	}
+
+	reason := &RevertReason{}
+	copy(reason.Selector[:], data[:4])
+
+	switch reason.Selector {
+	case errorSelector:
+		message, err := abi.UnpackRevert(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode Error(string)")
+		}
+
+		reason.Name = "Error"
+		reason.Args = []interface{}{message}
+		reason.Message = message
+	case panicSelector:
+		message, err := abi.UnpackRevert(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode Panic(uint256)")
+		}
+
+		reason.Name = "Panic"
+		reason.Args = []interface{}{new(big.Int).SetBytes(data[4:])}
+		reason.Message = "panic: " + message
+	default:
+		reason.Message = fmt.Sprintf("custom error %s: %s", hexutil.Encode(data[:4]), hexutil.Encode(data[4:]))
+	}
+
+	return reason, nil
+}
+
+// DecodeRevertReason decodes RevertData of the UserOperation, returns nil if the UserOperation didn't revert with data.
+func (r *UserOperationReceipt) DecodeRevertReason() (*RevertReason, error) {
+	if len(r.RevertData) == 0 {
+		return nil, nil
+	}
+
+	return DecodeRevertReason(r.RevertData)
+}
+
+// findRevertData looks for the UserOperationRevertReason event of the UserOperation and returns its revert data
+func findRevertData(entrypoint common.Address, userOpHash []byte, logGroups ...[]ethtypes.Log) ([]byte, error) {
+	args := abi.Arguments{
+		{Name: "nonce", Type: uint256},
+		{Name: "revertReason", Type: bytesType},
+	}
+
+	for _, logs := range logGroups {
+		for _, log := range logs {
+			if log.Address != entrypoint || len(log.Topics) < 2 || log.Topics[0] != userOperationRevertReasonTopic || log.Topics[1] != common.BytesToHash(userOpHash) {
+				continue
+			}
+
+			unpacked, err := args.Unpack(log.Data)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to decode UserOperationRevertReason event")
+			}
+
+			return unpacked[1].([]byte), nil
+		}
+	}
+
+	return nil, nil
+}
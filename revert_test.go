@@ -0,0 +1,100 @@
+package zerodev
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testErrorRevertData = "0x08c379a0000000000000000000000000000000000000000000000000000000000000002000000000000000000000000000000000000000000000000000000000000000046e6f706500000000000000000000000000000000000000000000000000000000"
+
+func TestDecodeRevertReason(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            string
+		expectedName    string
+		expectedArgs    []interface{}
+		expectedMessage string
+		expectedError   bool
+	}{
+		{
+			name:            "error_string",
+			data:            testErrorRevertData,
+			expectedName:    "Error",
+			expectedArgs:    []interface{}{"nope"},
+			expectedMessage: "nope",
+		},
+		{
+			name:            "panic",
+			data:            "0x4e487b710000000000000000000000000000000000000000000000000000000000000011",
+			expectedName:    "Panic",
+			expectedArgs:    []interface{}{big.NewInt(0x11)},
+			expectedMessage: "panic: arithmetic underflow or overflow",
+		},
+		{
+			name:            "custom_error",
+			data:            "0xfb8f41b2000000000000000000000000000000000000000000000000000000000000002a",
+			expectedMessage: "custom error 0xfb8f41b2: 0x000000000000000000000000000000000000000000000000000000000000002a",
+		},
+		{
+			name:          "too_short",
+			data:          "0x08c3",
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := DecodeRevertReason(common.FromHex(tt.data))
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, common.FromHex(tt.data)[:4], reason.Selector[:])
+			assert.Equal(t, tt.expectedName, reason.Name)
+			assert.Equal(t, tt.expectedArgs, reason.Args)
+			assert.Equal(t, tt.expectedMessage, reason.Message)
+		})
+	}
+}
+
+func TestNewUserOperationReceipt_RevertReason(t *testing.T) {
+	userOpHash := hexutil.Bytes(common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77"))
+
+	eventData, err := abi.Arguments{{Type: uint256}, {Type: bytesType}}.Pack(big.NewInt(5), common.FromHex(testErrorRevertData))
+	require.NoError(t, err)
+
+	entrypoint := common.HexToAddress(entryPointAddress07)
+	receipt, err := newUserOperationReceipt(entrypoint, &GetUserOperationReceiptResponse{
+		UserOpHash: &userOpHash,
+		Success:    false,
+		Logs: []ethtypes.Log{
+			{
+				Address: entrypoint,
+				Topics: []common.Hash{
+					userOperationRevertReasonTopic,
+					common.BytesToHash(userOpHash),
+					common.HexToHash("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"),
+				},
+				Data: eventData,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.False(t, receipt.Success)
+	assert.Equal(t, &userOpHash, receipt.UserOperationHash)
+	assert.Equal(t, "nope", receipt.RevertReason)
+
+	reason, err := receipt.DecodeRevertReason()
+	require.NoError(t, err)
+	assert.Equal(t, "Error", reason.Name)
+}
@@ -0,0 +1,52 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RequestDecorator rewrites the method and params of an outgoing JSON-RPC request, e.g. to prefix the method with a project ID
+// of a multi-tenant endpoint. It must not modify args in place, and be safe for concurrent use
+type RequestDecorator func(ctx context.Context, method string, args []interface{}) (string, []interface{})
+
+// decoratingRPCClient applies Decorator to every call made through it
+type decoratingRPCClient struct {
+	Client    types.RPCClient
+	Decorator RequestDecorator
+}
+
+// newDecoratingRPCClient wraps rpcClient with decorator, rpcClient is returned as is if decorator is nil
+func newDecoratingRPCClient(rpcClient types.RPCClient, decorator RequestDecorator) types.RPCClient {
+	if decorator == nil || rpcClient == nil {
+		return rpcClient
+	}
+	return &decoratingRPCClient{Client: rpcClient, Decorator: decorator}
+}
+
+func (d *decoratingRPCClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	method, args = d.Decorator(ctx, method, args)
+	return d.Client.CallContext(ctx, result, method, args...)
+}
+
+// BatchCallContext decorates every call of batch, the elements of batch keep their method and params
+func (d *decoratingRPCClient) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	decorated := make([]rpc.BatchElem, len(batch))
+	for i, elem := range batch {
+		method, args := d.Decorator(ctx, elem.Method, elem.Args)
+		decorated[i] = rpc.BatchElem{Method: method, Args: args, Result: elem.Result}
+	}
+
+	if err := batchCallContext(ctx, d.Client, decorated); err != nil {
+		return err
+	}
+
+	for i := range batch {
+		batch[i].Error = decorated[i].Error
+	}
+	return nil
+}
+
+func (d *decoratingRPCClient) Close() {
+	d.Client.Close()
+}
@@ -0,0 +1,51 @@
+package zerodev
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoratingRPCClient(t *testing.T) {
+	ctx := context.Background()
+	mock := zerodevtest.NewMockRPCClient().
+		On("tenant1_eth_chainId", "0x89", nil).
+		On("tenant1_eth_blockNumber", "0x10", nil)
+
+	decorator := func(_ context.Context, method string, args []interface{}) (string, []interface{}) {
+		return "tenant1_" + method, append([]interface{}{"project"}, args...)
+	}
+	assert.Same(t, mock, newDecoratingRPCClient(mock, nil))
+
+	client := newDecoratingRPCClient(mock, decorator)
+
+	var chainID string
+	require.NoError(t, client.CallContext(ctx, &chainID, "eth_chainId", "0x1"))
+	assert.Equal(t, "0x89", chainID)
+
+	var blockNumber string
+	batch := []rpc.BatchElem{
+		{Method: "eth_chainId", Result: &chainID},
+		{Method: "eth_blockNumber", Args: []interface{}{"latest"}, Result: &blockNumber},
+	}
+	require.NoError(t, client.(*decoratingRPCClient).BatchCallContext(ctx, batch))
+	assert.NoError(t, batch[0].Error)
+	assert.NoError(t, batch[1].Error)
+	assert.Equal(t, "0x10", blockNumber)
+	assert.Equal(t, "eth_blockNumber", batch[1].Method)
+	assert.Equal(t, []interface{}{"latest"}, batch[1].Args)
+
+	assert.Equal(t, []zerodevtest.Call{
+		{Method: "tenant1_eth_chainId", Args: []interface{}{"project", "0x1"}},
+		{Method: "tenant1_eth_chainId", Args: []interface{}{"project"}},
+		{Method: "tenant1_eth_blockNumber", Args: []interface{}{"project", "latest"}},
+	}, mock.Calls())
+	assert.Equal(t, 1, mock.Batches())
+
+	client.Close()
+	assert.True(t, mock.Closed())
+}
@@ -0,0 +1,49 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/account"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// SignMessage signs message for the client's account the way dApps verify it with ERC-1271, e.g. in Sign-In with Ethereum:
+// the EIP-191 hash of message is wrapped in the Kernel typed data of the account's domain, making the signature replay-safe across accounts.
+// Signers implementing types.ContextHashSigner sign within ctx.
+func (c *Client) SignMessage(ctx context.Context, message []byte) ([]byte, error) {
+	if contextSigner, ok := c.Signer.(types.ContextHashSigner); ok {
+		return contextSigner.SignHashContext(ctx, common.BytesToHash(accounts.TextHash(message)))
+	}
+	return c.Signer.SignMessage(message)
+}
+
+// SignTypedData signs EIP-712 typed data for the client's account, e.g. a Permit2 permit, to be verified with ERC-1271.
+// The typed data hash is wrapped in the Kernel typed data of the account's domain. The chainId of the typed data domain,
+// if set, must be the client's ChainID. Signers implementing types.ContextHashSigner sign within ctx.
+func (c *Client) SignTypedData(ctx context.Context, typedData signer.TypedData) ([]byte, error) {
+	if typedData.Domain.ChainId != nil {
+		chainID := (*big.Int)(typedData.Domain.ChainId)
+		if chainID.Cmp(c.ChainID) != 0 {
+			return nil, errors.Errorf("typed data chainId %s does not match client chainID %s", chainID, c.ChainID)
+		}
+	}
+
+	if contextSigner, ok := c.Signer.(types.ContextHashSigner); ok {
+		hash, _, err := signer.TypedDataAndHash(typedData)
+		if err != nil {
+			return nil, err
+		}
+		return contextSigner.SignHashContext(ctx, common.BytesToHash(hash))
+	}
+	return c.Signer.SignTypedData(&typedData)
+}
+
+// IsValidSignature checks signature of hash with the ERC-1271 isValidSignature of the client's account.
+// For messages signed with SignMessage, hash is the EIP-191 hash of the message.
+func (c *Client) IsValidSignature(ctx context.Context, hash common.Hash, signature []byte) (bool, error) {
+	return account.IsValidSignature(ctx, c.AccountClient.Client, c.Signer.GetAddress(), hash, signature)
+}
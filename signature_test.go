@@ -0,0 +1,93 @@
+package zerodev
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/account"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SignMessage(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	signer := client.Signer.(*account.SmartAccountPrivateKeySigner)
+	signer.AccountMetadata = &account.AccountMetadata{
+		Name:              "Kernel",
+		Version:           "0.3.1",
+		ChainId:           client.ChainID,
+		VerifyingContract: signer.Address,
+	}
+
+	message := []byte("example.com wants you to sign in with your Ethereum account")
+
+	signature, err := client.SignMessage(context.Background(), message)
+	require.NoError(t, err)
+
+	// the EIP-191 hash is signed, not the raw message hash
+	eip191Hash := crypto.Keccak256Hash([]byte("\x19Ethereum Signed Message:\n59" + string(message)))
+	expected, err := signer.SignHash(eip191Hash)
+	require.NoError(t, err)
+	assert.Equal(t, expected, signature)
+
+	unexpected, err := signer.SignHash(crypto.Keccak256Hash(message))
+	require.NoError(t, err)
+	assert.NotEqual(t, unexpected, signature)
+
+	// the signer signs messages the same way
+	signerSignature, err := signer.SignMessage(message)
+	require.NoError(t, err)
+	assert.Equal(t, signature, signerSignature)
+
+	assert.Equal(t, signer.Validator.GetIdentifier(), signature[:len(signer.Validator.GetIdentifier())])
+}
+
+func TestClient_SignTypedData(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	signer := client.Signer.(*account.SmartAccountPrivateKeySigner)
+	signer.AccountMetadata = &account.AccountMetadata{
+		Name:              "Kernel",
+		Version:           "0.3.1",
+		ChainId:           client.ChainID,
+		VerifyingContract: signer.Address,
+	}
+
+	newTypedData := func(chainID *math.HexOrDecimal256) apitypes.TypedData {
+		return apitypes.TypedData{
+			Types: apitypes.Types{
+				"EIP712Domain": []apitypes.Type{
+					{Name: "name", Type: "string"},
+					{Name: "chainId", Type: "uint256"},
+				},
+				"Login": []apitypes.Type{
+					{Name: "nonce", Type: "uint256"},
+				},
+			},
+			PrimaryType: "Login",
+			Domain: apitypes.TypedDataDomain{
+				Name:    "example.com",
+				ChainId: chainID,
+			},
+			Message: apitypes.TypedDataMessage{
+				"nonce": "1",
+			},
+		}
+	}
+
+	typedData := newTypedData(math.NewHexOrDecimal256(ChainPolygon))
+	signature, err := client.SignTypedData(context.Background(), typedData)
+	require.NoError(t, err)
+
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	require.NoError(t, err)
+	expected, err := signer.SignHash(common.BytesToHash(hash))
+	require.NoError(t, err)
+	assert.Equal(t, expected, signature)
+
+	_, err = client.SignTypedData(context.Background(), newTypedData(math.NewHexOrDecimal256(1)))
+	assert.EqualError(t, err, "typed data chainId 1 does not match client chainID 137")
+}
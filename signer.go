@@ -2,6 +2,7 @@ package zerodev
 
 import (
 	"crypto/ecdsa"
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
@@ -31,8 +32,7 @@ func (s *PrivateKeySigner) GetAddress() common.Address {
 }
 
 func (s *PrivateKeySigner) SignMessage(message []byte) ([]byte, error) {
-	hash := crypto.Keccak256Hash(message)
-	return s.SignHash(hash)
+	return s.SignHash(common.BytesToHash(accounts.TextHash(message)))
 }
 
 func (s *PrivateKeySigner) SignTypedData(typedData *signer.TypedData) ([]byte, error) {
@@ -0,0 +1,71 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/account"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/friendsofgo/errors"
+)
+
+// ErrSignerNotAuthorized is returned by VerifySignerAuthorized when the client's Signer can't sign for the sender
+var ErrSignerNotAuthorized = errors.New("signer is not authorized for sender")
+
+// VerifySignerAuthorized checks the client's Signer signs for sender with the key of the owner registered in the account's
+// ECDSA validator. Accounts not deployed yet are checked against the owner the AccountFactory deploys them with.
+// Signers not implementing account.OwnerSigner with an ECDSA validator can't be verified and fail the check.
+func (c *Client) VerifySignerAuthorized(ctx context.Context, sender common.Address) error {
+	if c.Signer.GetAddress() != sender {
+		return errors.Wrapf(ErrSignerNotAuthorized, "signer is for account %s, not sender %s", c.Signer.GetAddress().Hex(), sender.Hex())
+	}
+
+	ownerSigner, ok := c.Signer.(account.OwnerSigner)
+	if !ok {
+		return errors.Errorf("signer of type %T can't be verified, it doesn't expose its owner", c.Signer)
+	}
+	validator, ok := ownerSigner.GetValidator().(*account.EcdsaValidator)
+	if !ok {
+		return errors.Errorf("signer validator of type %T can't be verified, only ECDSA validators are supported", ownerSigner.GetValidator())
+	}
+
+	owner, err := account.GetEcdsaValidatorOwner(ctx, c.AccountClient.Client, validator.GetAddress(), sender)
+	if err != nil {
+		return err
+	}
+
+	// the validator is installed with the account, which the factory deploys with AccountOwner
+	if owner == (common.Address{}) && c.AccountFactory != nil {
+		deployed, err := c.AccountClient.IsDeployed(ctx, sender)
+		if err != nil {
+			return err
+		}
+		if !deployed {
+			owner = c.AccountOwner
+		}
+	}
+
+	if owner != ownerSigner.OwnerAddress() {
+		return errors.Wrapf(ErrSignerNotAuthorized, "owner of %s is %s, signer key is %s", sender.Hex(), owner.Hex(), ownerSigner.OwnerAddress().Hex())
+	}
+
+	c.verifiedSenders.Store(sender, true)
+	return nil
+}
+
+// checkSignerAccount checks the client's Signer signs for AccountAddress, the sender of the client's own operations, if it's set
+func (c *Client) checkSignerAccount() error {
+	if c.AccountAddress == (common.Address{}) || c.Signer.GetAddress() == c.AccountAddress {
+		return nil
+	}
+	return errors.Wrapf(ErrSignerNotAuthorized, "signer is for account %s, not the client's account %s", c.Signer.GetAddress().Hex(), c.AccountAddress.Hex())
+}
+
+// verifySigner runs VerifySignerAuthorized before the client's Signer signs for sender if VerifySigner is set, once per sender
+func (c *Client) verifySigner(ctx context.Context, sender common.Address) error {
+	if !c.VerifySigner {
+		return nil
+	}
+	if _, ok := c.verifiedSenders.Load(sender); ok {
+		return nil
+	}
+	return c.VerifySignerAuthorized(ctx, sender)
+}
@@ -0,0 +1,151 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/account"
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_VerifySignerAuthorized(t *testing.T) {
+	other := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	tests := []struct {
+		name          string
+		sender        *common.Address
+		owner         func(signerOwner common.Address) common.Address
+		code          string
+		factory       bool
+		signer        func(t *testing.T) interface{}
+		expectedError string
+	}{
+		{
+			name:  "owner",
+			owner: func(signerOwner common.Address) common.Address { return signerOwner },
+		},
+		{
+			name:          "other_owner",
+			owner:         func(common.Address) common.Address { return other },
+			expectedError: "signer key is",
+		},
+		{
+			name:          "other_sender",
+			sender:        &other,
+			owner:         func(signerOwner common.Address) common.Address { return signerOwner },
+			expectedError: "not sender",
+		},
+		{
+			// the validator is installed when the factory deploys the account with the client's AccountOwner
+			name:    "not_deployed",
+			owner:   func(common.Address) common.Address { return common.Address{} },
+			code:    "0x",
+			factory: true,
+		},
+		{
+			name:          "deployed_without_owner",
+			owner:         func(common.Address) common.Address { return common.Address{} },
+			code:          "0x6080",
+			factory:       true,
+			expectedError: "is 0x0000000000000000000000000000000000000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, _ := newTestClient(t, 0)
+			signerOwner := client.Signer.(account.OwnerSigner).OwnerAddress()
+
+			network := zerodevtest.NewMockRPCClient().
+				On("eth_call", hexutil.Encode(common.LeftPadBytes(tt.owner(signerOwner).Bytes(), 32)), nil).
+				On("eth_getCode", tt.code, nil)
+			client.AccountClient = &AccountClient{Client: network}
+			if tt.factory {
+				client.AccountFactory = &KernelFactory{}
+				client.AccountOwner = signerOwner
+			}
+
+			sender := client.Signer.GetAddress()
+			if tt.sender != nil {
+				sender = *tt.sender
+			}
+
+			err := client.VerifySignerAuthorized(context.Background(), sender)
+			if tt.expectedError != "" {
+				require.ErrorIs(t, err, ErrSignerNotAuthorized)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			call := network.Calls()[0]
+			assert.Equal(t, "eth_call", call.Method)
+			expectedData := append(crypto.Keccak256([]byte("ecdsaValidatorStorage(address)"))[:4], common.LeftPadBytes(sender.Bytes(), 32)...)
+			msg, err := json.Marshal(call.Args[0])
+			require.NoError(t, err)
+			assert.Contains(t, string(msg), hexutil.Encode(expectedData))
+		})
+	}
+}
+
+func TestClient_VerifySignerAuthorized_UnsupportedSigner(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	client.Signer, err = NewPrivateKeySigner(privateKey)
+	require.NoError(t, err)
+
+	err = client.VerifySignerAuthorized(context.Background(), client.Signer.GetAddress())
+	assert.EqualError(t, err, "signer of type *zerodev.PrivateKeySigner can't be verified, it doesn't expose its owner")
+}
+
+func TestClient_SendUserOperation_VerifySigner(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.VerifySigner = true
+	signerOwner := client.Signer.(account.OwnerSigner).OwnerAddress()
+
+	network := zerodevtest.NewMockRPCClient().
+		On("eth_call", hexutil.Encode(common.LeftPadBytes(common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3").Bytes(), 32)), nil).
+		On("eth_call", hexutil.Encode(common.LeftPadBytes(signerOwner.Bytes(), 32)), nil)
+	client.AccountClient = &AccountClient{Client: network}
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil)
+	callData := common.FromHex("0xdeadbeef")
+
+	_, err := client.SendUserOperation(context.Background(), &callData, false)
+	assert.ErrorIs(t, err, ErrSignerNotAuthorized)
+	assert.Equal(t, 0, bundler.CallCount("eth_sendUserOperation"))
+
+	// verified senders are not checked again
+	for i := 0; i < 2; i++ {
+		_, err = client.SendUserOperation(context.Background(), &callData, false)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, network.CallCount("eth_call"))
+	assert.Equal(t, 2, bundler.CallCount("eth_sendUserOperation"))
+}
+
+func TestClient_SendUserOperation_SignerAccountMismatch(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil)
+	callData := common.FromHex("0xdeadbeef")
+
+	client.AccountAddress = common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	_, err := client.SendUserOperation(context.Background(), &callData, false)
+	assert.ErrorIs(t, err, ErrSignerNotAuthorized)
+	assert.Equal(t, 0, bundler.CallCount("eth_sendUserOperation"))
+
+	client.AccountAddress = client.Signer.GetAddress()
+	_, err = client.SendUserOperation(context.Background(), &callData, false)
+	require.NoError(t, err)
+	sent := bundler.Calls()[len(bundler.Calls())-1].Args[0].(*UserOperation)
+	assert.Equal(t, client.AccountAddress, sent.Sender)
+}
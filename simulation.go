@@ -0,0 +1,95 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
+)
+
+// bundlerErrorCodeExecutionReverted is the ERC-7769 error code of operations reverting in the execution phase,
+// other codes are returned for operations rejected during validation
+const bundlerErrorCodeExecutionReverted = -32521
+
+// SimulationResult is the outcome of a UserOperation simulated by the bundler, nothing is submitted on-chain.
+// A successful simulation doesn't guarantee on-chain success as the state might change before inclusion,
+// the on-chain outcome is reported by UserOperationReceipt.Success.
+type SimulationResult struct {
+	// Success reports whether the operation would pass validation and execute without reverting
+	Success bool
+	// GasEstimate holds the gas limits of a successful operation
+	GasEstimate *GasEstimate
+	// ValidationFailed is set when the operation would be rejected before execution, e.g. AA2x errors, otherwise its execution reverted
+	ValidationFailed bool
+	// Error is the bundler error of a failed simulation
+	Error *BundlerError
+	// RevertData is the raw revert data of the execution, if returned by the bundler
+	RevertData hexutil.Bytes
+	// RevertReason is the decoded revert data, or the bundler error message without revert data
+	RevertReason string
+}
+
+// SimulateUserOperation simulates the validation and execution of op with eth_estimateUserOperationGas without submitting it.
+// Signed operations are simulated with their signature, unsigned ones with a dummy signature. op is not modified.
+// Failures of the operation are reported in the result, the error is returned only when the simulation couldn't be performed.
+func (b *BundlerClient) SimulateUserOperation(ctx context.Context, op *UserOperation) (*SimulationResult, error) {
+	simulatedOp := *op
+	if len(simulatedOp.Signature) == 0 {
+		simulatedOp.Signature = common.FromHex(SignatureDummy)
+	}
+
+	var estimate GasEstimate
+
+	err := b.Client.CallContext(ctx, &estimate, "eth_estimateUserOperationGas", toRPCUserOperation(&simulatedOp, b.EntryPoint.GetVersion()), b.EntryPoint.GetAddress())
+	if err == nil {
+		return &SimulationResult{
+			Success:     true,
+			GasEstimate: &estimate,
+		}, nil
+	}
+
+	var bundlerErr *BundlerError
+	if !errors.As(newBundlerError(err), &bundlerErr) {
+		return nil, errors.Wrap(err, "failed to simulate user operation")
+	}
+
+	result := &SimulationResult{
+		ValidationFailed: bundlerErr.Code != bundlerErrorCodeExecutionReverted,
+		Error:            bundlerErr,
+		RevertData:       simulationRevertData(bundlerErr.Data),
+		RevertReason:     bundlerErr.Message,
+	}
+
+	if len(result.RevertData) > 0 {
+		reason, err := DecodeRevertReason(result.RevertData)
+		if err == nil {
+			result.RevertReason = reason.Message
+		}
+	}
+
+	return result, nil
+}
+
+// simulationRevertData extracts the revert data from the error data, either the hex data itself or its revertData field
+func simulationRevertData(data interface{}) hexutil.Bytes {
+	if fields, ok := data.(map[string]interface{}); ok {
+		data = fields["revertData"]
+	}
+
+	hex, ok := data.(string)
+	if !ok {
+		return nil
+	}
+
+	revertData, err := hexutil.Decode(hex)
+	if err != nil {
+		return nil
+	}
+
+	return revertData
+}
+
+// SimulateUserOperation simulates op with the bundler without submitting it, see BundlerClient.SimulateUserOperation
+func (c *Client) SimulateUserOperation(ctx context.Context, op *UserOperation) (*SimulationResult, error) {
+	return c.BundlerClient.SimulateUserOperation(ctx, op)
+}
@@ -0,0 +1,105 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockJSONRPCDataError struct {
+	mockJSONRPCError
+	data interface{}
+}
+
+func (e *mockJSONRPCDataError) ErrorData() interface{} {
+	return e.data
+}
+
+func TestBundlerClient_SimulateUserOperation(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                     string
+		result                   interface{}
+		rpcError                 error
+		expectedSuccess          bool
+		expectedValidationFailed bool
+		expectedRevertReason     string
+		expectedReason           string
+		expectedError            bool
+	}{
+		{
+			name:            "success",
+			result:          json.RawMessage(`{"preVerificationGas":"0xc350","verificationGasLimit":"0x30d40","callGasLimit":"0x186a0"}`),
+			expectedSuccess: true,
+		},
+		{
+			name: "execution_reverted",
+			rpcError: &mockJSONRPCDataError{
+				mockJSONRPCError: mockJSONRPCError{code: -32521, message: "UserOperation reverted during execution phase"},
+				data:             testErrorRevertData,
+			},
+			expectedRevertReason: "nope",
+		},
+		{
+			name: "execution_reverted_revert_data_field",
+			rpcError: &mockJSONRPCDataError{
+				mockJSONRPCError: mockJSONRPCError{code: -32521, message: "UserOperation reverted during execution phase"},
+				data:             map[string]interface{}{"revertData": testErrorRevertData},
+			},
+			expectedRevertReason: "nope",
+		},
+		{
+			name:                     "validation_failed",
+			rpcError:                 &mockJSONRPCError{code: -32500, message: "AA21 didn't pay prefund"},
+			expectedValidationFailed: true,
+			expectedRevertReason:     "AA21 didn't pay prefund",
+			expectedReason:           "AA21",
+		},
+		{
+			name:          "network_error",
+			rpcError:      io.ErrUnexpectedEOF,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient().On("eth_estimateUserOperationGas", tt.result, tt.rpcError)
+			bundler := &BundlerClient{Client: mock, EntryPoint: entrypoint}
+
+			op := newTestUserOperation()
+			op.Signature = common.FromHex("0x01")
+
+			result, err := bundler.SimulateUserOperation(context.Background(), op)
+			assert.Equal(t, common.FromHex("0x01"), op.Signature)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSuccess, result.Success)
+			assert.Equal(t, tt.expectedValidationFailed, result.ValidationFailed)
+			assert.Equal(t, tt.expectedRevertReason, result.RevertReason)
+
+			if tt.expectedSuccess {
+				assert.Equal(t, big.NewInt(100_000), result.GasEstimate.CallGasLimit)
+				assert.Nil(t, result.Error)
+				return
+			}
+
+			require.NotNil(t, result.Error)
+			assert.Equal(t, tt.expectedReason, result.Error.Reason)
+		})
+	}
+}
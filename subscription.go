@@ -0,0 +1,75 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/friendsofgo/errors"
+	"net/url"
+	"time"
+)
+
+// isWebSocketURL reports whether rpcURL is a ws or wss endpoint
+func isWebSocketURL(rpcURL *url.URL) bool {
+	return rpcURL.Scheme == "ws" || rpcURL.Scheme == "wss"
+}
+
+// subscriptionRPCClient is the types.SubscriptionRPCClient of a WebSocket rpc.Client
+type subscriptionRPCClient struct {
+	*rpc.Client
+}
+
+func (c *subscriptionRPCClient) EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (types.Subscription, error) {
+	return c.Client.EthSubscribe(ctx, channel, args...)
+}
+
+// subscribeUserOperationReceipt waits for the UserOperationEvent of hash with a Subscriber subscription, then gets the receipt.
+// It reports whether the subscription was used, the receipt is polled for instead if subscribing fails or the subscription breaks.
+func (b *BundlerClient) subscribeUserOperationReceipt(ctx context.Context, hash []byte, maxAttempts int, delay func(attempt int) time.Duration, clock Clock) (*UserOperationReceipt, bool, error) {
+	events := make(chan ethtypes.Log, 1)
+	sub, err := b.Subscriber.EthSubscribe(ctx, events, "logs", map[string]interface{}{
+		"address": b.EntryPoint.GetAddress(),
+		"topics":  [][]common.Hash{{userOperationEventTopic}, {common.BytesToHash(hash)}},
+	})
+	if err != nil {
+		return nil, false, nil
+	}
+	defer sub.Unsubscribe()
+
+	// the operation may have been included before the subscription started
+	var response GetUserOperationReceiptResponse
+	if err := b.callBundlers(ctx, &response, func() bool { return response.UserOpHash != nil }, "eth_getUserOperationReceipt", hexutil.Encode(hash)); err != nil {
+		return nil, true, errors.Wrap(err, "failed to call eth_getUserOperationReceipt")
+	}
+	if response.UserOpHash != nil {
+		receipt, err := newUserOperationReceipt(b.EntryPoint.GetAddress(), &response)
+		return receipt, true, err
+	}
+
+	// maxAttempts polls would have waited for the sum of their delays
+	var timeout <-chan time.Time
+	if maxAttempts > 0 {
+		var wait time.Duration
+		for attempt := 0; attempt < maxAttempts-1; attempt++ {
+			wait += delay(attempt)
+		}
+		timeout = clock.After(wait)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, true, errors.Wrap(ctx.Err(), "timed out waiting for receipt of user operation "+hexutil.Encode(hash))
+	case <-timeout:
+		return nil, true, errors.New("failed to get receipt for user operation: " + hexutil.Encode(hash))
+	case <-sub.Err():
+		return nil, false, nil
+	case <-events:
+	}
+
+	// the bundler may index the receipt shortly after the event
+	receipt, err := b.pollUserOperationReceipt(ctx, hash, maxAttempts, delay, clock)
+	return receipt, true, err
+}
@@ -0,0 +1,172 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSubscription struct {
+	err          chan error
+	unsubscribed bool
+}
+
+func (s *mockSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *mockSubscription) Unsubscribe() {
+	s.unsubscribed = true
+}
+
+// mockSubscriber sends event to the channel of its subscription if set, or fails the subscription with subscriptionErr
+type mockSubscriber struct {
+	*zerodevtest.MockRPCClient
+	subscribeErr    error
+	event           *ethtypes.Log
+	subscriptionErr error
+
+	args         []interface{}
+	subscription *mockSubscription
+}
+
+func (m *mockSubscriber) EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (types.Subscription, error) {
+	if m.subscribeErr != nil {
+		return nil, m.subscribeErr
+	}
+
+	m.args = args
+	m.subscription = &mockSubscription{err: make(chan error, 1)}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		if m.event != nil {
+			channel.(chan ethtypes.Log) <- *m.event
+		}
+		if m.subscriptionErr != nil {
+			m.subscription.err <- m.subscriptionErr
+		}
+	}()
+	return m.subscription, nil
+}
+
+func TestIsWebSocketURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{url: "ws://localhost:8546", expected: true},
+		{url: "wss://rpc.zerodev.app/api/v3/project/chain/137", expected: true},
+		{url: "https://rpc.zerodev.app/api/v3/project/chain/137"},
+		{url: "http://localhost:8545"},
+	}
+
+	for _, tt := range tests {
+		rpcURL, err := url.Parse(tt.url)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, isWebSocketURL(rpcURL), tt.url)
+	}
+}
+
+func TestBundlerClient_WaitForUserOperationReceipt_Subscription(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	hash := common.FromHex("0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77")
+	receiptJSON := json.RawMessage(`{"userOpHash":"0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77","success":true,"receipt":{"transactionHash":"0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"}}`)
+
+	tests := []struct {
+		name               string
+		subscriber         *mockSubscriber
+		pollingDelay       time.Duration
+		responses          []interface{}
+		expectedCalls      int
+		expectedSubscribed bool
+	}{
+		{
+			// the delay would time out the test if the receipt was polled for
+			name:               "event",
+			subscriber:         &mockSubscriber{event: &ethtypes.Log{}},
+			pollingDelay:       time.Hour,
+			responses:          []interface{}{nil, receiptJSON},
+			expectedCalls:      2,
+			expectedSubscribed: true,
+		},
+		{
+			name:               "included_before_subscription",
+			subscriber:         &mockSubscriber{},
+			pollingDelay:       time.Hour,
+			responses:          []interface{}{receiptJSON},
+			expectedCalls:      1,
+			expectedSubscribed: true,
+		},
+		{
+			name:          "subscribe_error",
+			subscriber:    &mockSubscriber{subscribeErr: errors.New("notifications not supported")},
+			pollingDelay:  time.Millisecond,
+			responses:     []interface{}{nil, nil, receiptJSON},
+			expectedCalls: 3,
+		},
+		{
+			name:               "subscription_error",
+			subscriber:         &mockSubscriber{subscriptionErr: errors.New("connection reset")},
+			pollingDelay:       time.Millisecond,
+			responses:          []interface{}{nil, nil, receiptJSON},
+			expectedCalls:      3,
+			expectedSubscribed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient()
+			for _, response := range tt.responses {
+				mock.On("eth_getUserOperationReceipt", response, nil)
+			}
+			bundler := &BundlerClient{Client: mock, EntryPoint: entrypoint, Subscriber: tt.subscriber}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			receipt, err := bundler.WaitForUserOperationReceipt(ctx, hash, tt.pollingDelay)
+			require.NoError(t, err)
+			assert.Equal(t, common.HexToHash("0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"), receipt.TransactionHash)
+			assert.Equal(t, tt.expectedCalls, mock.CallCount("eth_getUserOperationReceipt"))
+
+			if !tt.expectedSubscribed {
+				assert.Nil(t, tt.subscriber.subscription)
+				return
+			}
+			assert.True(t, tt.subscriber.subscription.unsubscribed)
+			assert.Equal(t, []interface{}{"logs", map[string]interface{}{
+				"address": entrypoint.GetAddress(),
+				"topics":  [][]common.Hash{{userOperationEventTopic}, {common.BytesToHash(hash)}},
+			}}, tt.subscriber.args)
+		})
+	}
+}
+
+func TestBundlerClient_GetUserOperationReceipt_SubscriptionRetries(t *testing.T) {
+	entrypoint, err := NewEntrypoint07(nil, big.NewInt(ChainPolygon))
+	require.NoError(t, err)
+
+	mock := zerodevtest.NewMockRPCClient().On("eth_getUserOperationReceipt", nil, nil)
+	bundler := &BundlerClient{Client: mock, EntryPoint: entrypoint, Subscriber: &mockSubscriber{}}
+
+	// without an event, the wait is bounded by the delays of the retries
+	start := time.Now()
+	_, err = bundler.GetUserOperationReceiptWithBackoff(context.Background(), []byte{0x01}, &ReceiptPollingBackoff{BaseDelay: 20 * time.Millisecond}, 3)
+	assert.EqualError(t, err, "failed to get receipt for user operation: 0x01")
+	assert.Equal(t, 1, mock.CallCount("eth_getUserOperationReceipt"))
+	assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+}
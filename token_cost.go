@@ -0,0 +1,120 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// TokenCost is an amount of an ERC-20 token in raw token units, Decimals of the token scale it to whole tokens
+type TokenCost struct {
+	Amount   *big.Int
+	Decimals uint8
+}
+
+// String formats the cost in whole tokens, e.g. 0.42 for 420000 units of a token with 6 decimals
+func (c *TokenCost) String() string {
+	if c.Amount == nil {
+		return "0"
+	}
+
+	amount := new(big.Int).Abs(c.Amount).String()
+	sign := ""
+	if c.Amount.Sign() < 0 {
+		sign = "-"
+	}
+
+	decimals := int(c.Decimals)
+	if decimals == 0 {
+		return sign + amount
+	}
+	if len(amount) <= decimals {
+		amount = strings.Repeat("0", decimals-len(amount)+1) + amount
+	}
+
+	fraction := strings.TrimRight(amount[len(amount)-decimals:], "0")
+	if fraction == "" {
+		return sign + amount[:len(amount)-decimals]
+	}
+	return sign + amount[:len(amount)-decimals] + "." + fraction
+}
+
+type tokenQuoteRequest struct {
+	ChainID           *big.Int       `json:"chainId"`
+	Operation         interface{}    `json:"userOp"`
+	TokenAddress      common.Address `json:"tokenAddress"`
+	EntryPointAddress common.Address `json:"entryPointAddress"`
+}
+
+type tokenQuoteResponse struct {
+	MaxGasCostToken *hexutil.Big    `json:"maxGasCostToken"`
+	TokenDecimals   json.RawMessage `json:"tokenDecimals"`
+}
+
+// EstimateUserOperationCostInToken quotes the maximum cost of op in token with the ERC-20 paymaster, the paymaster's markup included.
+// op needs its gas limits and fees set, e.g. by GetUserOperationAndHashToSign in ERC-20 paymaster mode. The charged amount is lower
+// when the operation uses less gas than its limits or pays less than its max fee.
+func (p *PaymasterClient) EstimateUserOperationCostInToken(ctx context.Context, op *UserOperation, token common.Address) (*TokenCost, error) {
+	quoted := *op
+	if len(quoted.Signature) == 0 {
+		quoted.Signature = common.FromHex(SignatureDummy)
+	}
+
+	request := tokenQuoteRequest{
+		ChainID:           p.ChainID,
+		Operation:         toRPCUserOperation(&quoted, p.EntryPoint.GetVersion()),
+		TokenAddress:      token,
+		EntryPointAddress: p.EntryPoint.GetAddress(),
+	}
+
+	var response tokenQuoteResponse
+	err := p.Client.CallContext(ctx, &response, "stackup_getERC20TokenQuotes", &request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call stackup_getERC20TokenQuotes")
+	}
+	if response.MaxGasCostToken == nil {
+		return nil, errors.New("paymaster returned no token quote")
+	}
+
+	decimals, err := decodeTokenDecimals(response.TokenDecimals)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenCost{Amount: response.MaxGasCostToken.ToInt(), Decimals: decimals}, nil
+}
+
+// EstimateUserOperationCostInToken quotes the maximum cost of op in token with the client's paymaster, see PaymasterClient.EstimateUserOperationCostInToken
+func (c *Client) EstimateUserOperationCostInToken(ctx context.Context, op *UserOperation, token common.Address) (*TokenCost, error) {
+	if c.PaymasterClient == nil {
+		return nil, errors.New("estimating the cost in a token requires a paymaster")
+	}
+
+	return c.PaymasterClient.EstimateUserOperationCostInToken(ctx, op, token)
+}
+
+// decodeTokenDecimals decodes the token decimals of a quote, paymasters return them as a JSON number or a hex string
+func decodeTokenDecimals(raw json.RawMessage) (uint8, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, errors.New("paymaster returned no token decimals")
+	}
+
+	var decimals hexutil.Uint64
+	if err := json.Unmarshal(raw, &decimals); err != nil {
+		var number uint64
+		if err := json.Unmarshal(raw, &number); err != nil {
+			return 0, errors.Wrapf(err, "failed to decode token decimals %s", raw)
+		}
+		decimals = hexutil.Uint64(number)
+	}
+	if decimals > math.MaxUint8 {
+		return 0, errors.Errorf("invalid token decimals %d", uint64(decimals))
+	}
+
+	return uint8(decimals), nil
+}
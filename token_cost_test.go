@@ -0,0 +1,97 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCost_String(t *testing.T) {
+	tests := []struct {
+		cost     TokenCost
+		expected string
+	}{
+		{cost: TokenCost{}, expected: "0"},
+		{cost: TokenCost{Amount: big.NewInt(420_000), Decimals: 6}, expected: "0.42"},
+		{cost: TokenCost{Amount: big.NewInt(1_500_000), Decimals: 6}, expected: "1.5"},
+		{cost: TokenCost{Amount: big.NewInt(2_000_000), Decimals: 6}, expected: "2"},
+		{cost: TokenCost{Amount: big.NewInt(5), Decimals: 6}, expected: "0.000005"},
+		{cost: TokenCost{Amount: big.NewInt(12), Decimals: 0}, expected: "12"},
+		{cost: TokenCost{Amount: big.NewInt(-420_000), Decimals: 6}, expected: "-0.42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.cost.String())
+		})
+	}
+}
+
+func TestPaymasterClient_EstimateUserOperationCostInToken(t *testing.T) {
+	token := common.HexToAddress("0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359")
+
+	tests := []struct {
+		name          string
+		response      json.RawMessage
+		expected      *TokenCost
+		expectedError string
+	}{
+		{
+			name:     "hex_decimals",
+			response: json.RawMessage(`{"maxGasCostToken": "0x668a0", "tokenDecimals": "0x6"}`),
+			expected: &TokenCost{Amount: big.NewInt(420_000), Decimals: 6},
+		},
+		{
+			name:     "number_decimals",
+			response: json.RawMessage(`{"maxGasCostToken": "0x668a0", "tokenDecimals": 6}`),
+			expected: &TokenCost{Amount: big.NewInt(420_000), Decimals: 6},
+		},
+		{
+			name:          "no_quote",
+			response:      json.RawMessage(`{"tokenDecimals": 6}`),
+			expectedError: "paymaster returned no token quote",
+		},
+		{
+			name:          "no_decimals",
+			response:      json.RawMessage(`{"maxGasCostToken": "0x668a0"}`),
+			expectedError: "paymaster returned no token decimals",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, paymaster := newTestClient(t, 0)
+			paymaster.On("stackup_getERC20TokenQuotes", tt.response, nil)
+
+			op := newTestUserOperation()
+			cost, err := client.EstimateUserOperationCostInToken(context.Background(), op, token)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cost)
+			assert.Equal(t, "0.42", cost.String())
+
+			// the quote is requested with a dummy signature, op is not modified
+			assert.Empty(t, op.Signature)
+			request := paymaster.Calls()[0].Args[0].(*tokenQuoteRequest)
+			assert.Equal(t, token, request.TokenAddress)
+			assert.Equal(t, client.EntryPoint.GetAddress(), request.EntryPointAddress)
+			assert.Equal(t, common.FromHex(SignatureDummy), request.Operation.(*UserOperation).Signature)
+		})
+	}
+}
+
+func TestClient_EstimateUserOperationCostInToken_NoPaymaster(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	client.PaymasterClient = nil
+
+	_, err := client.EstimateUserOperationCostInToken(context.Background(), newTestUserOperation(), common.Address{})
+	assert.EqualError(t, err, "estimating the cost in a token requires a paymaster")
+}
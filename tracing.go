@@ -0,0 +1,118 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"math/big"
+)
+
+// Span names of the UserOperation lifecycle, the SendUserOperation span is the parent of the others
+const (
+	SpanSendUserOperation           = "zerodev.SendUserOperation"
+	SpanEstimateUserOperationGas    = "zerodev.EstimateUserOperationGas"
+	SpanSponsorUserOperation        = "zerodev.SponsorUserOperation"
+	SpanSubmitUserOperation         = "zerodev.SubmitUserOperation"
+	SpanWaitForUserOperationReceipt = "zerodev.WaitForUserOperationReceipt"
+	// SpanCapMaxFeePerGas is recorded when the maxFeePerGas suggested by the bundler exceeds the base fee cap and is lowered
+	SpanCapMaxFeePerGas = "zerodev.CapMaxFeePerGas"
+)
+
+// Span attribute keys
+const (
+	AttributeUserOperationHash             = "userOpHash"
+	AttributeSender                        = "sender"
+	AttributeChainID                       = "chainID"
+	AttributePreVerificationGas            = "preVerificationGas"
+	AttributeVerificationGasLimit          = "verificationGasLimit"
+	AttributeCallGasLimit                  = "callGasLimit"
+	AttributePaymasterVerificationGasLimit = "paymasterVerificationGasLimit"
+	AttributePaymasterPostOpGasLimit       = "paymasterPostOpGasLimit"
+	AttributeGasLimitMultiplier            = "gasLimitMultiplier"
+	// AttributeCallGasLimitWarning is set when the estimated call gas limit is implausibly large for the call data
+	AttributeCallGasLimitWarning = "callGasLimitWarning"
+	// AttributeMaxFeePerGasCapped and AttributeBaseFee describe a maxFeePerGas lowered to the base fee cap
+	AttributeMaxFeePerGasCapped = "maxFeePerGasCapped"
+	AttributeBaseFee            = "baseFee"
+	// AttributeGasEstimateCached is set when the gas limits are a cached estimate of a similar user operation
+	AttributeGasEstimateCached = "gasEstimateCached"
+)
+
+// Attribute is a key-value pair tagging a span, values are strings as gas values don't fit in int64
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Tracer starts spans around client operations. The client doesn't trace unless ClientConfig.Tracer is set,
+// the otelzerodev module provides an OpenTelemetry implementation without adding the dependency to this module.
+type Tracer interface {
+	Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span)
+}
+
+// Span is a traced operation started by a Tracer
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// startSpan starts a span with the client's tracer, if any
+func (c *Client) startSpan(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span) {
+	if c.Tracer == nil {
+		return noopTracer{}.Start(ctx, name, attributes...)
+	}
+	return c.Tracer.Start(ctx, name, attributes...)
+}
+
+// endSpan records err, if any, and ends the span
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// gasLimitAttributes tags the gas limits returned by the bundler or paymaster
+func gasLimitAttributes(op *UserOperation) []Attribute {
+	attributes := []Attribute{
+		bigIntAttribute(AttributePreVerificationGas, op.PreVerificationGas),
+		bigIntAttribute(AttributeVerificationGasLimit, op.VerificationGasLimit),
+		bigIntAttribute(AttributeCallGasLimit, op.CallGasLimit),
+	}
+
+	if op.hasPaymaster() {
+		attributes = append(attributes,
+			bigIntAttribute(AttributePaymasterVerificationGasLimit, op.PaymasterVerificationGasLimit),
+			bigIntAttribute(AttributePaymasterPostOpGasLimit, op.PaymasterPostOpGasLimit),
+		)
+	}
+
+	if warning := callGasLimitWarning(op); warning != "" {
+		attributes = append(attributes, Attribute{Key: AttributeCallGasLimitWarning, Value: warning})
+	}
+
+	return attributes
+}
+
+func bigIntAttribute(key string, value *big.Int) Attribute {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	return Attribute{Key: key, Value: value.String()}
+}
+
+func userOperationHashAttribute(hash []byte) Attribute {
+	return Attribute{Key: AttributeUserOperationHash, Value: hexutil.Encode(hash)}
+}
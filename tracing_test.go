@@ -0,0 +1,128 @@
+package zerodev
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes map[string]string
+	err        error
+	ended      bool
+}
+
+type spanContextKey struct{}
+
+// recordingTracer records spans, parents are tracked through the context like in OpenTelemetry
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, attributes ...Attribute) (context.Context, Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	span := &recordedSpan{name: name, attributes: map[string]string{}}
+	if parent, ok := ctx.Value(spanContextKey{}).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+	span.SetAttributes(attributes...)
+
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+func (r *recordingTracer) span(name string) *recordedSpan {
+	for _, span := range r.spans {
+		if span.name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func (s *recordedSpan) SetAttributes(attributes ...Attribute) {
+	for _, attribute := range attributes {
+		s.attributes[attribute.Key] = attribute.Value
+	}
+}
+
+func (s *recordedSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *recordedSpan) End() {
+	s.ended = true
+}
+
+func TestClient_SendUserOperation_Tracing(t *testing.T) {
+	const opHash = "0xe361e4b3c1b1e3d82bb8e55a1a0b8f8b35b28b5d0c6dd0bfa11e9c2b1b6b8876"
+
+	tests := []struct {
+		name          string
+		sendError     error
+		expectedSpans []string
+	}{
+		{
+			name:          "sent",
+			expectedSpans: []string{SpanSendUserOperation, SpanSponsorUserOperation, SpanSubmitUserOperation},
+		},
+		{
+			name:          "rejected",
+			sendError:     &mockJSONRPCError{code: -32507, message: "AA24 signature error"},
+			expectedSpans: []string{SpanSendUserOperation, SpanSponsorUserOperation, SpanSubmitUserOperation},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, _ := newTestClient(t, 0)
+			tracer := &recordingTracer{}
+			client.Tracer = tracer
+			client.BundlerClient.Client.(*zerodevtest.MockRPCClient).On("eth_sendUserOperation", opHash, tt.sendError)
+
+			callData := []byte{0xde, 0xad, 0xbe, 0xef}
+			_, err := client.SendUserOperation(context.Background(), &callData, false)
+
+			names := make([]string, len(tracer.spans))
+			for i, span := range tracer.spans {
+				names[i] = span.name
+				assert.True(t, span.ended, span.name)
+			}
+			assert.Equal(t, tt.expectedSpans, names)
+
+			send := tracer.span(SpanSendUserOperation)
+			assert.Empty(t, send.parent)
+			assert.Equal(t, client.Signer.GetAddress().Hex(), send.attributes[AttributeSender])
+			assert.Equal(t, "137", send.attributes[AttributeChainID])
+
+			sponsor := tracer.span(SpanSponsorUserOperation)
+			assert.Equal(t, SpanSendUserOperation, sponsor.parent)
+			assert.Equal(t, "100000", sponsor.attributes[AttributeCallGasLimit])
+			assert.Equal(t, "45000", sponsor.attributes[AttributePaymasterVerificationGasLimit])
+
+			submit := tracer.span(SpanSubmitUserOperation)
+			assert.Equal(t, SpanSendUserOperation, submit.parent)
+
+			if tt.sendError != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(submit.err, ErrInvalidSignature))
+				assert.True(t, errors.Is(send.err, ErrInvalidSignature))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, opHash, submit.attributes[AttributeUserOperationHash])
+			assert.Equal(t, opHash, send.attributes[AttributeUserOperationHash])
+		})
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"context"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
 	signer "github.com/ethereum/go-ethereum/signer/core/apitypes"
 )
 
@@ -11,10 +12,43 @@ type RPCClient interface {
 	Close()
 }
 
+// BatchRPCClient is an RPCClient sending several calls in one JSON-RPC batch request, *rpc.Client implements it.
+// The error of each call is set on its element, the returned error is the error of the batch request itself
+type BatchRPCClient interface {
+	RPCClient
+	BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error
+}
+
+// Subscription is an eth_subscribe subscription, *rpc.ClientSubscription implements it
+type Subscription interface {
+	Err() <-chan error
+	Unsubscribe()
+}
+
+// SubscriptionRPCClient is an RPCClient of a WebSocket endpoint, notifications of eth_subscribe are sent to channel
+type SubscriptionRPCClient interface {
+	RPCClient
+	EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (Subscription, error)
+}
+
 type AccountSigner interface {
+	// GetAddress returns the address of the smart account signed for, the sender of its user operations, not the address of the owner key
 	GetAddress() common.Address
 	SignMessage(message []byte) ([]byte, error)
 	SignTypedData(typedData *signer.TypedData) ([]byte, error)
 	SignHash(hash common.Hash) ([]byte, error)
 	SignUserOperationHash(hash common.Hash) ([]byte, error)
 }
+
+// ContextHashSigner signs hashes for ERC-1271 validation within ctx, e.g. reading the account metadata bound to its deadline
+type ContextHashSigner interface {
+	SignHashContext(ctx context.Context, hash common.Hash) ([]byte, error)
+}
+
+// ContextAccountSigner is an AccountSigner signing through a remote service, e.g. a KMS,
+// whose signing calls respect cancellation and timeouts of ctx.
+type ContextAccountSigner interface {
+	AccountSigner
+	SignHashContext(ctx context.Context, hash common.Hash) ([]byte, error)
+	SignUserOperationHashContext(ctx context.Context, hash common.Hash) ([]byte, error)
+}
@@ -1,22 +1,29 @@
 package zerodev
 
 import (
+	"context"
 	"encoding/json"
+	"github.com/DIMO-Network/go-zerodev/types"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
 	"math/big"
 )
 
 var (
-	address, _ = abi.NewType("address", "", nil)
-	uint256, _ = abi.NewType("uint256", "", nil)
-	bytes32, _ = abi.NewType("bytes32", "", nil)
+	address, _   = abi.NewType("address", "", nil)
+	uint256, _   = abi.NewType("uint256", "", nil)
+	bytes32, _   = abi.NewType("bytes32", "", nil)
+	bytesType, _ = abi.NewType("bytes", "", nil)
+	boolType, _  = abi.NewType("bool", "", nil)
 )
 
 type UserOperation struct {
 	Sender                        common.Address `json:"sender"`
 	Nonce                         *big.Int       `json:"nonce"`
+	Factory                       common.Address `json:"factory,omitempty"`
+	FactoryData                   []byte         `json:"factoryData,omitempty"`
 	CallData                      []byte         `json:"callData"`
 	CallGasLimit                  *big.Int       `json:"callGasLimit,omitempty"`
 	VerificationGasLimit          *big.Int       `json:"verificationGasLimit,omitempty"`
@@ -28,11 +35,74 @@ type UserOperation struct {
 	PaymasterVerificationGasLimit *big.Int       `json:"paymasterVerificationGasLimit,omitempty"`
 	PaymasterPostOpGasLimit       *big.Int       `json:"paymasterPostOpGasLimit,omitempty"`
 	Signature                     []byte         `json:"signature,omitempty"`
+	// EIP7702Auth is the authorization delegating the sender EOA, see SetEIP7702Authorization. Entrypoint 0.8 only
+	EIP7702Auth *EIP7702Authorization `json:"eip7702Auth,omitempty"`
+}
+
+// Validate checks the fields bundlers require are set: the sender, non-zero gas limits, fees with
+// a priority fee up to the max fee and the signature. It doesn't check the signature is valid.
+func (op *UserOperation) Validate() error {
+	if op.Sender == (common.Address{}) {
+		return errors.New("sender is required")
+	}
+	if op.Nonce == nil {
+		return errors.New("nonce is required")
+	}
+	if op.CallGasLimit == nil || op.CallGasLimit.Sign() <= 0 {
+		return errors.New("callGasLimit must be greater than zero")
+	}
+	if op.VerificationGasLimit == nil || op.VerificationGasLimit.Sign() <= 0 {
+		return errors.New("verificationGasLimit must be greater than zero")
+	}
+	if op.PreVerificationGas == nil || op.PreVerificationGas.Sign() <= 0 {
+		return errors.New("preVerificationGas must be greater than zero")
+	}
+	if op.MaxFeePerGas == nil || op.MaxFeePerGas.Sign() < 0 {
+		return errors.New("maxFeePerGas is required")
+	}
+	if op.MaxPriorityFeePerGas == nil || op.MaxPriorityFeePerGas.Sign() < 0 {
+		return errors.New("maxPriorityFeePerGas is required")
+	}
+	if op.MaxPriorityFeePerGas.Cmp(op.MaxFeePerGas) > 0 {
+		return errors.Errorf("maxPriorityFeePerGas %s is greater than maxFeePerGas %s", op.MaxPriorityFeePerGas, op.MaxFeePerGas)
+	}
+	if len(op.Signature) == 0 {
+		return errors.New("signature is required")
+	}
+	return nil
+}
+
+// SignUserOperation signs the hash of op, as returned by GetUserOperationAndHashToSign, with signer and sets the signature of op
+func SignUserOperation(op *UserOperation, hash common.Hash, signer types.AccountSigner) error {
+	return SignUserOperationContext(context.Background(), op, hash, signer)
+}
+
+// SignUserOperationContext works like SignUserOperation, remote signers implementing types.ContextAccountSigner sign within ctx.
+func SignUserOperationContext(ctx context.Context, op *UserOperation, hash common.Hash, signer types.AccountSigner) error {
+	if signer == nil {
+		return errors.New("signer is required")
+	}
+
+	var signature []byte
+	var err error
+	if contextSigner, ok := signer.(types.ContextAccountSigner); ok {
+		signature, err = contextSigner.SignUserOperationHashContext(ctx, hash)
+	} else {
+		signature, err = signer.SignUserOperationHash(hash)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to sign user operation")
+	}
+
+	op.Signature = signature
+	return nil
 }
 
 type UserOperationHex struct {
 	Sender                        string `json:"sender"`
 	Nonce                         string `json:"nonce"`
+	Factory                       string `json:"factory,omitempty"`
+	FactoryData                   string `json:"factoryData,omitempty"`
 	CallData                      string `json:"callData"`
 	CallGasLimit                  string `json:"callGasLimit,omitempty"`
 	VerificationGasLimit          string `json:"verificationGasLimit,omitempty"`
@@ -43,28 +113,132 @@ type UserOperationHex struct {
 	PaymasterData                 string `json:"paymasterData,omitempty"`
 	PaymasterVerificationGasLimit string `json:"paymasterVerificationGasLimit,omitempty"`
 	PaymasterPostOpGasLimit       string `json:"paymasterPostOpGasLimit,omitempty"`
-	Signature                     string `json:"signature,omitempty"`
+	Signature                     string `json:"signature"`
+
+	EIP7702Auth *EIP7702Authorization `json:"eip7702Auth,omitempty"`
+}
+
+// UserOperationHex06 is the wire format of a UserOperation for Entrypoint 0.6
+type UserOperationHex06 struct {
+	Sender               string `json:"sender"`
+	Nonce                string `json:"nonce"`
+	InitCode             string `json:"initCode"`
+	CallData             string `json:"callData"`
+	CallGasLimit         string `json:"callGasLimit,omitempty"`
+	VerificationGasLimit string `json:"verificationGasLimit,omitempty"`
+	PreVerificationGas   string `json:"preVerificationGas,omitempty"`
+	MaxFeePerGas         string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+	PaymasterAndData     string `json:"paymasterAndData"`
+	Signature            string `json:"signature"`
+}
+
+// toRPCUserOperation returns the representation of the UserOperation expected by bundler and paymaster for the entrypoint version
+func toRPCUserOperation(op *UserOperation, entryPointVersion string) interface{} {
+	if entryPointVersion != EntryPointVersion06 {
+		return op
+	}
+
+	return &UserOperationHex06{
+		Sender:               op.Sender.String(),
+		Nonce:                encodeBigInt(op.Nonce),
+		InitCode:             hexutil.Encode(op.initCode()),
+		CallData:             hexutil.Encode(op.CallData),
+		CallGasLimit:         encodeBigInt(op.CallGasLimit),
+		VerificationGasLimit: encodeBigInt(op.VerificationGasLimit),
+		PreVerificationGas:   encodeBigInt(op.PreVerificationGas),
+		MaxFeePerGas:         encodeBigInt(op.MaxFeePerGas),
+		MaxPriorityFeePerGas: encodeBigInt(op.MaxPriorityFeePerGas),
+		PaymasterAndData:     hexutil.Encode(op.paymasterAndData06()),
+		Signature:            hexutil.Encode(op.Signature),
+	}
+}
+
+// fromRPCUserOperation decodes a UserOperation returned by the bundler in the representation of the entrypoint version
+func fromRPCUserOperation(data []byte, entryPointVersion string) (*UserOperation, error) {
+	if entryPointVersion != EntryPointVersion06 {
+		var op UserOperation
+		if err := json.Unmarshal(data, &op); err != nil {
+			return nil, err
+		}
+		return &op, nil
+	}
+
+	var hexOp UserOperationHex06
+	if err := json.Unmarshal(data, &hexOp); err != nil {
+		return nil, err
+	}
+
+	// the 0.6 fields are converted back to the hex form of 0.7, splitting initCode and paymasterAndData
+	opHex := UserOperationHex{
+		Sender:               hexOp.Sender,
+		Nonce:                hexOp.Nonce,
+		CallData:             hexOp.CallData,
+		CallGasLimit:         hexOp.CallGasLimit,
+		VerificationGasLimit: hexOp.VerificationGasLimit,
+		PreVerificationGas:   hexOp.PreVerificationGas,
+		MaxFeePerGas:         hexOp.MaxFeePerGas,
+		MaxPriorityFeePerGas: hexOp.MaxPriorityFeePerGas,
+		Signature:            hexOp.Signature,
+	}
+
+	initCode, err := decodeBytes(hexOp.InitCode)
+	if err != nil {
+		return nil, err
+	}
+	if len(initCode) >= common.AddressLength {
+		opHex.Factory = common.BytesToAddress(initCode[:common.AddressLength]).Hex()
+		opHex.FactoryData = encodeBytes(initCode[common.AddressLength:])
+	}
+
+	paymasterAndData, err := decodeBytes(hexOp.PaymasterAndData)
+	if err != nil {
+		return nil, err
+	}
+	if len(paymasterAndData) >= common.AddressLength {
+		opHex.Paymaster = encodeBytes(paymasterAndData[:common.AddressLength])
+		opHex.PaymasterData = encodeBytes(paymasterAndData[common.AddressLength:])
+	}
+
+	opJSON, err := json.Marshal(&opHex)
+	if err != nil {
+		return nil, err
+	}
+
+	var op UserOperation
+	if err := json.Unmarshal(opJSON, &op); err != nil {
+		return nil, err
+	}
+	return &op, nil
 }
 
+// MarshalJSON encodes the UserOperation in the wire format of bundlers for Entrypoint 0.7 and later:
+// quantities as 0x-prefixed hex, bytes as hex and addresses checksummed. Unset optional fields are omitted.
 func (op *UserOperation) MarshalJSON() ([]byte, error) {
 	hexOp := UserOperationHex{
 		Sender:                        op.Sender.String(),
 		Nonce:                         encodeBigInt(op.Nonce),
-		CallData:                      encodeBytes(op.CallData),
+		CallData:                      hexutil.Encode(op.CallData),
+		FactoryData:                   encodeBytes(op.FactoryData),
 		MaxFeePerGas:                  encodeBigInt(op.MaxFeePerGas),
 		MaxPriorityFeePerGas:          encodeBigInt(op.MaxPriorityFeePerGas),
 		CallGasLimit:                  encodeBigInt(op.CallGasLimit),
 		VerificationGasLimit:          encodeBigInt(op.VerificationGasLimit),
 		PreVerificationGas:            encodeBigInt(op.PreVerificationGas),
-		Paymaster:                     encodeBytes(op.Paymaster),
+		Paymaster:                     encodePaymaster(op.Paymaster),
 		PaymasterData:                 encodeBytes(op.PaymasterData),
-		Signature:                     encodeBytes(op.Signature),
+		Signature:                     hexutil.Encode(op.Signature),
 		PaymasterPostOpGasLimit:       encodeBigInt(op.PaymasterPostOpGasLimit),
 		PaymasterVerificationGasLimit: encodeBigInt(op.PaymasterVerificationGasLimit),
+		EIP7702Auth:                   op.EIP7702Auth,
+	}
+	if op.hasFactory() {
+		hexOp.Factory = op.Factory.String()
 	}
 	return json.Marshal(&hexOp)
 }
 
+// UnmarshalJSON decodes a UserOperation encoded by MarshalJSON, e.g. persisted to be submitted later
 func (op *UserOperation) UnmarshalJSON(b []byte) error {
 	var hexOp UserOperationHex
 	err := json.Unmarshal(b, &hexOp)
@@ -79,6 +253,15 @@ func (op *UserOperation) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
+	if hexOp.Factory != "" {
+		op.Factory = common.HexToAddress(hexOp.Factory)
+	}
+
+	op.FactoryData, err = decodeBytes(hexOp.FactoryData)
+	if err != nil {
+		return err
+	}
+
 	op.CallData, err = decodeBytes(hexOp.CallData)
 	if err != nil {
 		return err
@@ -134,9 +317,30 @@ func (op *UserOperation) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
+	op.EIP7702Auth = hexOp.EIP7702Auth
+
 	return nil
 }
 
+// hasPaymaster reports whether the operation is sponsored by a paymaster, an empty or zero paymaster address means it's not.
+func (op *UserOperation) hasPaymaster() bool {
+	return len(op.Paymaster) > 0 && common.BytesToAddress(op.Paymaster) != common.Address{}
+}
+
+// hasFactory reports whether the operation deploys the sender account.
+func (op *UserOperation) hasFactory() bool {
+	return op.Factory != common.Address{}
+}
+
+// initCode returns factory || factoryData, the initCode representation used by Entrypoint 0.6 and in the 0.7 hash.
+func (op *UserOperation) initCode() []byte {
+	if !op.hasFactory() {
+		return nil
+	}
+
+	return append(op.Factory.Bytes(), op.FactoryData...)
+}
+
 func encodeBigInt(value *big.Int) string {
 	if value != nil {
 		return hexutil.EncodeBig(value)
@@ -151,6 +355,7 @@ func decodeBigInt(value string) (*big.Int, error) {
 	return nil, nil
 }
 
+// encodeBytes encodes the optional bytes fields, empty ones are omitted. Required fields are encoded with hexutil.Encode, empty as "0x"
 func encodeBytes(value []byte) string {
 	if len(value) > 0 {
 		return hexutil.Encode(value)
@@ -158,9 +363,23 @@ func encodeBytes(value []byte) string {
 	return ""
 }
 
+// encodePaymaster checksums the paymaster address, paymasters which are not 20-byte addresses are encoded as is
+func encodePaymaster(value []byte) string {
+	if len(value) == common.AddressLength {
+		return common.BytesToAddress(value).Hex()
+	}
+	return encodeBytes(value)
+}
+
+// decodeBytes decodes bytes fields, empty ones ("" or "0x") are nil
 func decodeBytes(value string) ([]byte, error) {
-	if value != "" {
-		return hexutil.Decode(value)
+	if value == "" {
+		return nil, nil
 	}
-	return nil, nil
+
+	decoded, err := hexutil.Decode(value)
+	if err != nil || len(decoded) == 0 {
+		return nil, err
+	}
+	return decoded, nil
 }
@@ -0,0 +1,61 @@
+package zerodev
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/friendsofgo/errors"
+	"math/big"
+)
+
+// userOperationEventTopic is emitted by the entrypoint (0.6, 0.7 and 0.8) for every included UserOperation, its hash is the first indexed topic
+var userOperationEventTopic = crypto.Keccak256Hash([]byte("UserOperationEvent(bytes32,address,address,uint256,bool,uint256,uint256)"))
+
+// UserOperationEvent is the event the entrypoint emits for each UserOperation it executes,
+// the source of truth for whether the UserOperation succeeded, a bundle transaction succeeds even if its operations revert.
+type UserOperationEvent struct {
+	UserOperationHash common.Hash    `json:"userOpHash"`
+	Sender            common.Address `json:"sender"`
+	Paymaster         common.Address `json:"paymaster"`
+	Nonce             *big.Int       `json:"nonce"`
+	Success           bool           `json:"success"`
+	ActualGasCost     *big.Int       `json:"actualGasCost"`
+	ActualGasUsed     *big.Int       `json:"actualGasUsed"`
+}
+
+// FindUserOperationEvent looks for the UserOperationEvent the entrypoint emitted for the UserOperation in the logs and decodes it, returns nil if it's missing.
+// Logs from other contracts are ignored, so a contract emitting a lookalike event can't fake the result.
+func FindUserOperationEvent(entrypoint common.Address, userOpHash []byte, logGroups ...[]ethtypes.Log) (*UserOperationEvent, error) {
+	args := abi.Arguments{
+		{Name: "nonce", Type: uint256},
+		{Name: "success", Type: boolType},
+		{Name: "actualGasCost", Type: uint256},
+		{Name: "actualGasUsed", Type: uint256},
+	}
+
+	for _, logs := range logGroups {
+		for _, log := range logs {
+			if log.Address != entrypoint || len(log.Topics) != 4 || log.Topics[0] != userOperationEventTopic || log.Topics[1] != common.BytesToHash(userOpHash) {
+				continue
+			}
+
+			unpacked, err := args.Unpack(log.Data)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to decode UserOperationEvent event")
+			}
+
+			return &UserOperationEvent{
+				UserOperationHash: log.Topics[1],
+				Sender:            common.BytesToAddress(log.Topics[2].Bytes()),
+				Paymaster:         common.BytesToAddress(log.Topics[3].Bytes()),
+				Nonce:             unpacked[0].(*big.Int),
+				Success:           unpacked[1].(bool),
+				ActualGasCost:     unpacked[2].(*big.Int),
+				ActualGasUsed:     unpacked[3].(*big.Int),
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,185 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserOperation_JSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		modify       func(op *UserOperation)
+		expectedJSON string
+	}{
+		{
+			name:   "minimal",
+			modify: func(op *UserOperation) {},
+			expectedJSON: `{
+				"sender": "0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A",
+				"nonce": "0x5",
+				"callData": "0xdeadbeef",
+				"callGasLimit": "0x186a0",
+				"verificationGasLimit": "0x30d40",
+				"preVerificationGas": "0xc350",
+				"maxFeePerGas": "0x6fc23ac00",
+				"maxPriorityFeePerGas": "0x59682f00",
+				"signature": "0x"
+			}`,
+		},
+		{
+			// required bytes fields are "0x" when empty, as expected by bundlers
+			name:   "empty_call_data",
+			modify: func(op *UserOperation) { op.CallData = nil },
+			expectedJSON: `{
+				"sender": "0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A",
+				"nonce": "0x5",
+				"callData": "0x",
+				"callGasLimit": "0x186a0",
+				"verificationGasLimit": "0x30d40",
+				"preVerificationGas": "0xc350",
+				"maxFeePerGas": "0x6fc23ac00",
+				"maxPriorityFeePerGas": "0x59682f00",
+				"signature": "0x"
+			}`,
+		},
+		{
+			name: "factory_paymaster_signature",
+			modify: func(op *UserOperation) {
+				op.Factory = common.HexToAddress(KernelMetaFactoryAddress)
+				op.FactoryData = common.FromHex("0xc5265d5d")
+				op.Paymaster = common.FromHex("0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633")
+				op.PaymasterData = common.FromHex("0xabab")
+				op.PaymasterVerificationGasLimit = big.NewInt(45_000)
+				op.PaymasterPostOpGasLimit = big.NewInt(1)
+				op.Signature = common.FromHex("0x01")
+			},
+			expectedJSON: `{
+				"sender": "0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A",
+				"nonce": "0x5",
+				"factory": "` + common.HexToAddress(KernelMetaFactoryAddress).Hex() + `",
+				"factoryData": "0xc5265d5d",
+				"callData": "0xdeadbeef",
+				"callGasLimit": "0x186a0",
+				"verificationGasLimit": "0x30d40",
+				"preVerificationGas": "0xc350",
+				"maxFeePerGas": "0x6fc23ac00",
+				"maxPriorityFeePerGas": "0x59682f00",
+				"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633",
+				"paymasterData": "0xabab",
+				"paymasterVerificationGasLimit": "0xafc8",
+				"paymasterPostOpGasLimit": "0x1",
+				"signature": "0x01"
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := newTestUserOperation()
+			tt.modify(op)
+
+			marshaled, err := json.Marshal(op)
+			require.NoError(t, err)
+			// addresses are checksummed, compared case-insensitively as hex quantities are lowercase
+			assert.JSONEq(t, strings.ToLower(tt.expectedJSON), strings.ToLower(string(marshaled)))
+			assert.Contains(t, string(marshaled), `"sender":"`+op.Sender.Hex()+`"`)
+			if op.hasPaymaster() {
+				assert.Contains(t, string(marshaled), `"paymaster":"`+common.BytesToAddress(op.Paymaster).Hex()+`"`)
+			}
+
+			var unmarshaled UserOperation
+			require.NoError(t, json.Unmarshal(marshaled, &unmarshaled))
+			assert.Equal(t, op, &unmarshaled)
+		})
+	}
+}
+
+func TestUserOperation_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(op *UserOperation)
+		expectedError string
+	}{
+		{
+			name:   "valid",
+			modify: func(op *UserOperation) {},
+		},
+		{
+			name:   "zero_fees",
+			modify: func(op *UserOperation) { op.MaxFeePerGas, op.MaxPriorityFeePerGas = big.NewInt(0), big.NewInt(0) },
+		},
+		{
+			name:          "zero_sender",
+			modify:        func(op *UserOperation) { op.Sender = common.Address{} },
+			expectedError: "sender is required",
+		},
+		{
+			name:          "no_nonce",
+			modify:        func(op *UserOperation) { op.Nonce = nil },
+			expectedError: "nonce is required",
+		},
+		{
+			name:          "zero_call_gas_limit",
+			modify:        func(op *UserOperation) { op.CallGasLimit = big.NewInt(0) },
+			expectedError: "callGasLimit must be greater than zero",
+		},
+		{
+			name:          "no_verification_gas_limit",
+			modify:        func(op *UserOperation) { op.VerificationGasLimit = nil },
+			expectedError: "verificationGasLimit must be greater than zero",
+		},
+		{
+			name:          "zero_pre_verification_gas",
+			modify:        func(op *UserOperation) { op.PreVerificationGas = big.NewInt(0) },
+			expectedError: "preVerificationGas must be greater than zero",
+		},
+		{
+			name:          "no_max_fee",
+			modify:        func(op *UserOperation) { op.MaxFeePerGas = nil },
+			expectedError: "maxFeePerGas is required",
+		},
+		{
+			name:          "priority_fee_above_max_fee",
+			modify:        func(op *UserOperation) { op.MaxPriorityFeePerGas = big.NewInt(31_000_000_000) },
+			expectedError: "maxPriorityFeePerGas 31000000000 is greater than maxFeePerGas 30000000000",
+		},
+		{
+			name:          "no_signature",
+			modify:        func(op *UserOperation) { op.Signature = nil },
+			expectedError: "signature is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := newTestUserOperation()
+			op.Signature = common.FromHex("0xdeadbeef")
+			tt.modify(op)
+
+			err := op.Validate()
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_SendSignedUserOperation_Invalid(t *testing.T) {
+	client, _, _ := newTestClient(t, 0)
+	bundler := client.BundlerClient.Client.(*zerodevtest.MockRPCClient)
+
+	op := newTestUserOperation()
+	_, err := client.SendSignedUserOperation(context.Background(), op, false)
+	require.EqualError(t, err, "invalid user operation: signature is required")
+	assert.Zero(t, bundler.CallCount("eth_sendUserOperation"))
+}
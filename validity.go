@@ -0,0 +1,49 @@
+package zerodev
+
+import (
+	"github.com/friendsofgo/errors"
+	"time"
+)
+
+// validateValidity checks the validity window of opts, the window is enforced by the paymaster signing it into the paymaster data
+func (c *Client) validateValidity(opts *UserOperationOptions, now time.Time) error {
+	if opts.ValidAfter.IsZero() && opts.ValidUntil.IsZero() {
+		return nil
+	}
+
+	if opts.SelfFunded || c.PaymasterClient == nil {
+		return errors.New("validAfter and validUntil require a sponsored UserOperation")
+	}
+	if !opts.ValidAfter.IsZero() && !opts.ValidUntil.IsZero() && !opts.ValidAfter.Before(opts.ValidUntil) {
+		return errors.Errorf("validAfter %s must be before validUntil %s", opts.ValidAfter.UTC().Format(time.RFC3339), opts.ValidUntil.UTC().Format(time.RFC3339))
+	}
+	if !opts.ValidUntil.IsZero() && !opts.ValidUntil.After(now) {
+		return errors.Errorf("validUntil %s is in the past", opts.ValidUntil.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// paymasterContext returns the configured paymaster context with the validity window of opts, in unix seconds
+func (c *Client) paymasterContext(opts *UserOperationOptions) map[string]interface{} {
+	var configContext map[string]interface{}
+	if c.PaymasterConfig != nil {
+		configContext = c.PaymasterConfig.Context
+	}
+	if opts == nil || (opts.ValidAfter.IsZero() && opts.ValidUntil.IsZero()) {
+		return configContext
+	}
+
+	paymasterContext := make(map[string]interface{}, len(configContext)+2)
+	for key, value := range configContext {
+		paymasterContext[key] = value
+	}
+	if !opts.ValidAfter.IsZero() {
+		paymasterContext["validAfter"] = opts.ValidAfter.Unix()
+	}
+	if !opts.ValidUntil.IsZero() {
+		paymasterContext["validUntil"] = opts.ValidUntil.Unix()
+	}
+
+	return paymasterContext
+}
@@ -0,0 +1,111 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ValidityWindow(t *testing.T) {
+	validAfter := time.Now().Add(-time.Minute).Truncate(time.Second)
+	validUntil := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	client, _, paymaster := newTestClient(t, 0)
+	client.PaymasterConfig = &PaymasterConfig{Mode: PaymasterModeSponsored, Context: map[string]interface{}{"sponsorshipPolicyId": "sp_1"}}
+
+	callData := common.FromHex("0xdeadbeef")
+	_, _, err := client.GetUserOperationAndHashToSignWithOptions(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData, &UserOperationOptions{
+		ValidAfter: validAfter,
+		ValidUntil: validUntil,
+	})
+	require.NoError(t, err)
+
+	request := paymaster.Calls()[0].Args[0].(*SponsorUserOperationRequest)
+	assert.Equal(t, map[string]interface{}{
+		"sponsorshipPolicyId": "sp_1",
+		"validAfter":          validAfter.Unix(),
+		"validUntil":          validUntil.Unix(),
+	}, request.Context)
+	// the window of one operation is not kept in the configured context
+	assert.Equal(t, map[string]interface{}{"sponsorshipPolicyId": "sp_1"}, client.PaymasterConfig.Context)
+}
+
+func TestClient_ValidityWindowEIP7677(t *testing.T) {
+	validUntil := time.Now().Add(time.Hour).Truncate(time.Second)
+	token := common.HexToAddress("0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174")
+
+	client, _, paymaster := newTestClient(t, 0)
+	client.PaymasterConfig = &PaymasterConfig{Mode: PaymasterModeERC20, Token: token, EIP7677: true}
+	paymaster.On("pm_getPaymasterStubData", json.RawMessage(`{"paymaster": "0x2cc0c7981D846b9F2a16276556f6e8cb52BfB633", "paymasterData": "0xcdcd", "isFinal": true}`), nil)
+	client.BundlerClient.Client.(*zerodevtest.MockRPCClient).
+		On("eth_estimateUserOperationGas", json.RawMessage(`{"preVerificationGas": "0xc350", "verificationGasLimit": "0x30d40", "callGasLimit": "0x186a0"}`), nil)
+
+	callData := common.FromHex("0xdeadbeef")
+	_, _, err := client.GetUserOperationAndHashToSignWithOptions(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData, &UserOperationOptions{
+		ValidUntil: validUntil,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{"token": token, "validUntil": validUntil.Unix()}, paymaster.Calls()[0].Args[3])
+}
+
+func TestClient_ValidityWindowInvalid(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		opts          UserOperationOptions
+		noPaymaster   bool
+		expectedError string
+	}{
+		{
+			name:          "after_not_before_until",
+			opts:          UserOperationOptions{ValidAfter: now.Add(time.Hour), ValidUntil: now.Add(time.Minute)},
+			expectedError: "must be before validUntil",
+		},
+		{
+			name:          "equal",
+			opts:          UserOperationOptions{ValidAfter: now.Add(time.Hour), ValidUntil: now.Add(time.Hour)},
+			expectedError: "must be before validUntil",
+		},
+		{
+			name:          "expired",
+			opts:          UserOperationOptions{ValidUntil: now.Add(-time.Minute)},
+			expectedError: "is in the past",
+		},
+		{
+			name:          "self_funded",
+			opts:          UserOperationOptions{SelfFunded: true, ValidUntil: now.Add(time.Hour)},
+			expectedError: "validAfter and validUntil require a sponsored UserOperation",
+		},
+		{
+			name:          "no_paymaster",
+			opts:          UserOperationOptions{ValidAfter: now},
+			noPaymaster:   true,
+			expectedError: "validAfter and validUntil require a sponsored UserOperation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, network, paymaster := newTestClient(t, 0)
+			if tt.noPaymaster {
+				client.PaymasterClient = nil
+			}
+
+			callData := common.FromHex("0xdeadbeef")
+			_, _, err := client.GetUserOperationAndHashToSignWithOptions(context.Background(), common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"), &callData, &tt.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+
+			assert.Empty(t, network.Calls())
+			assert.Empty(t, paymaster.Calls())
+		})
+	}
+}
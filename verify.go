@@ -0,0 +1,106 @@
+package zerodev
+
+import (
+	"context"
+	"github.com/DIMO-Network/go-zerodev/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/friendsofgo/errors"
+	"math/big"
+	"strings"
+)
+
+// ErrChainIDMismatch is returned when the network RPC or the bundler serve a different chain than ClientConfig.ChainID,
+// user operations would be hashed with the wrong chainID and fail with invalid signatures
+var ErrChainIDMismatch = errors.New("chainID mismatch")
+
+// ErrEntryPointNotSupported is returned when the bundler doesn't support the configured entrypoint
+var ErrEntryPointNotSupported = errors.New("entrypoint not supported by bundler")
+
+// ErrUserOperationHashMismatch is returned by VerifyUserOperationHash when op doesn't hash to the expected hash
+var ErrUserOperationHashMismatch = errors.New("user operation hash mismatch")
+
+// VerifyChainID checks that the network RPC and the bundler serve the configured ChainID.
+// NewClient calls it unless ClientConfig.SkipChainIDVerification is set.
+func (c *Client) VerifyChainID(ctx context.Context) error {
+	endpoints := []struct {
+		name   string
+		client types.RPCClient
+	}{
+		{"network RPC", c.AccountClient.Client},
+		{"bundler", c.BundlerClient.Client},
+	}
+
+	for _, endpoint := range endpoints {
+		chainID, err := getChainID(ctx, endpoint.client)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get %s chainID", endpoint.name)
+		}
+
+		if chainID.Cmp(c.ChainID) != 0 {
+			return errors.Wrapf(ErrChainIDMismatch, "%s serves chainID %s, configured %s", endpoint.name, chainID, c.ChainID)
+		}
+	}
+
+	return nil
+}
+
+func getChainID(ctx context.Context, rpcClient types.RPCClient) (*big.Int, error) {
+	var chainID hexutil.Big
+	if err := rpcClient.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return nil, errors.Wrap(err, "failed to call eth_chainId")
+	}
+
+	return chainID.ToInt(), nil
+}
+
+// VerifyEntryPoint checks that the bundler supports the client's entrypoint, the error lists the entrypoints it does support.
+// NewClient calls it unless ClientConfig.SkipEntryPointVerification is set.
+func (c *Client) VerifyEntryPoint(ctx context.Context) error {
+	supported, err := c.BundlerClient.SupportedEntryPoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	addresses := make([]string, len(supported))
+	for i, entrypoint := range supported {
+		if entrypoint == c.EntryPoint.GetAddress() {
+			return nil
+		}
+		addresses[i] = entrypoint.Hex()
+	}
+
+	return errors.Wrapf(ErrEntryPointNotSupported, "entrypoint %s %s, bundler supports [%s]",
+		c.EntryPoint.GetVersion(), c.EntryPoint.GetAddress().Hex(), strings.Join(addresses, ", "))
+}
+
+// VerifyUserOperationHash checks that op hashes to expected with the client's entrypoint and chainID,
+// e.g. to assert against known vectors that the packing of operations matches the on-chain entrypoint.
+func (c *Client) VerifyUserOperationHash(op *UserOperation, expected common.Hash) error {
+	hash, err := c.EntryPoint.GetUserOperationHash(op)
+	if err != nil {
+		return errors.Wrap(err, "failed to get user operation hash")
+	}
+
+	if *hash != expected {
+		return errors.Wrapf(ErrUserOperationHashMismatch, "entrypoint %s hash %s, expected %s", c.EntryPoint.GetVersion(), hash.Hex(), expected.Hex())
+	}
+
+	return nil
+}
+
+// VerifyUserOperationHashOnChain checks that the hash of op computed by the client matches the one getUserOpHash of the
+// entrypoint returns, e.g. to check the client's hashing against any chain's deployed entrypoint.
+func (c *Client) VerifyUserOperationHashOnChain(ctx context.Context, op *UserOperation) error {
+	hasher, ok := c.EntryPoint.(EntrypointOnChainHasher)
+	if !ok {
+		return errors.Errorf("entrypoint %s doesn't implement EntrypointOnChainHasher", c.EntryPoint.GetVersion())
+	}
+
+	onChainHash, err := hasher.GetUserOperationHashOnChain(ctx, op)
+	if err != nil {
+		return errors.Wrap(err, "failed to get user operation hash on chain")
+	}
+
+	return c.VerifyUserOperationHash(op, *onChainHash)
+}
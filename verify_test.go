@@ -0,0 +1,214 @@
+package zerodev
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/friendsofgo/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_VerifyChainID(t *testing.T) {
+	tests := []struct {
+		name           string
+		networkChainID string
+		networkErr     error
+		bundlerChainID string
+		wantMismatch   bool
+		wantErr        string
+	}{
+		{
+			name:           "matching",
+			networkChainID: "0x89",
+			bundlerChainID: "0x89",
+		},
+		{
+			name:           "network mismatch",
+			networkChainID: "0x1",
+			bundlerChainID: "0x89",
+			wantMismatch:   true,
+			wantErr:        "network RPC serves chainID 1, configured 137: chainID mismatch",
+		},
+		{
+			name:           "bundler mismatch",
+			networkChainID: "0x89",
+			bundlerChainID: "0x14a34",
+			wantMismatch:   true,
+			wantErr:        "bundler serves chainID 84532, configured 137: chainID mismatch",
+		},
+		{
+			name:       "network error",
+			networkErr: errors.New("connection refused"),
+			wantErr:    "failed to get network RPC chainID: failed to call eth_chainId: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, network, _ := newTestClient(t, 0)
+			network.On("eth_chainId", tt.networkChainID, tt.networkErr)
+			client.BundlerClient.Client.(*zerodevtest.MockRPCClient).On("eth_chainId", tt.bundlerChainID, nil)
+
+			err := client.VerifyChainID(context.Background())
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.EqualError(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantMismatch, errors.Is(err, ErrChainIDMismatch))
+		})
+	}
+}
+
+func TestClient_VerifyEntryPoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		supported   []string
+		expectedErr string
+	}{
+		{
+			name:      "supported",
+			supported: []string{"0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789", "0x0000000071727De22E5E9d8BAf0edAc6f37da032"},
+		},
+		{
+			name:        "only_06",
+			supported:   []string{"0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789"},
+			expectedErr: "entrypoint 0.7 0x0000000071727De22E5E9d8BAf0edAc6f37da032, bundler supports [0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789]: entrypoint not supported by bundler",
+		},
+		{
+			name:        "none",
+			supported:   []string{},
+			expectedErr: "entrypoint 0.7 0x0000000071727De22E5E9d8BAf0edAc6f37da032, bundler supports []: entrypoint not supported by bundler",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _, _ := newTestClient(t, 0)
+			client.BundlerClient.Client.(*zerodevtest.MockRPCClient).On("eth_supportedEntryPoints", tt.supported, nil)
+
+			err := client.VerifyEntryPoint(context.Background())
+			if tt.expectedErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			// addresses are checksummed
+			assert.True(t, strings.EqualFold(tt.expectedErr, err.Error()), err.Error())
+			assert.ErrorIs(t, err, ErrEntryPointNotSupported)
+		})
+	}
+}
+
+// userOperationHashVector is a UserOperation in the wire format and its hash computed like the on-chain entrypoint
+type userOperationHashVector struct {
+	Name          string         `json:"name"`
+	EntryPoint    common.Address `json:"entryPoint"`
+	ChainID       int64          `json:"chainId"`
+	UserOperation *UserOperation `json:"userOperation"`
+	Hash          common.Hash    `json:"hash"`
+}
+
+func TestClient_VerifyUserOperationHash(t *testing.T) {
+	data, err := os.ReadFile("testdata/userop_hash_vectors_07.json")
+	require.NoError(t, err)
+
+	var vectors []userOperationHashVector
+	require.NoError(t, json.Unmarshal(data, &vectors))
+	require.NotEmpty(t, vectors)
+
+	for _, vector := range vectors {
+		t.Run(vector.Name, func(t *testing.T) {
+			entrypoint, err := NewEntrypoint07WithAddress(nil, big.NewInt(vector.ChainID), vector.EntryPoint)
+			require.NoError(t, err)
+			client := &Client{EntryPoint: entrypoint, ChainID: big.NewInt(vector.ChainID)}
+
+			require.NoError(t, client.VerifyUserOperationHash(vector.UserOperation, vector.Hash))
+
+			vector.UserOperation.CallGasLimit = new(big.Int).Add(vector.UserOperation.CallGasLimit, big.NewInt(1))
+			err = client.VerifyUserOperationHash(vector.UserOperation, vector.Hash)
+			assert.True(t, errors.Is(err, ErrUserOperationHashMismatch))
+		})
+	}
+}
+
+func TestClient_VerifyUserOperationHashOnChain(t *testing.T) {
+	op := newTestUserOperation()
+
+	tests := []struct {
+		name          string
+		onChainHash   func(local common.Hash) common.Hash
+		expectedError error
+	}{
+		{
+			name:        "match",
+			onChainHash: func(local common.Hash) common.Hash { return local },
+		},
+		{
+			name:          "mismatch",
+			onChainHash:   func(common.Hash) common.Hash { return common.HexToHash("0x01") },
+			expectedError: ErrUserOperationHashMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := zerodevtest.NewMockRPCClient()
+			entrypoint, err := NewEntrypoint07(mock, big.NewInt(ChainPolygon))
+			require.NoError(t, err)
+			client := &Client{EntryPoint: entrypoint, ChainID: big.NewInt(ChainPolygon)}
+
+			local, err := entrypoint.GetUserOperationHash(op)
+			require.NoError(t, err)
+			mock.On("eth_call", tt.onChainHash(*local).Hex(), nil)
+
+			err = client.VerifyUserOperationHashOnChain(context.Background(), op)
+			if tt.expectedError != nil {
+				assert.True(t, errors.Is(err, tt.expectedError))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestClient_VerifyUserOperationHashOnChain_RPC checks the hashing of every entrypoint version against the entrypoints
+// deployed on the chain of ZERODEV_TEST_RPC_URL, skipped if it's not set.
+func TestClient_VerifyUserOperationHashOnChain_RPC(t *testing.T) {
+	rpcURL := os.Getenv("ZERODEV_TEST_RPC_URL")
+	if rpcURL == "" {
+		t.Skip("ZERODEV_TEST_RPC_URL is not set")
+	}
+
+	ctx := context.Background()
+	rpcClient, err := rpc.DialContext(ctx, rpcURL)
+	require.NoError(t, err)
+	defer rpcClient.Close()
+
+	chainID, err := getChainID(ctx, rpcClient)
+	require.NoError(t, err)
+
+	for _, version := range []string{EntryPointVersion06, EntryPointVersion07, EntryPointVersion08} {
+		t.Run(version, func(t *testing.T) {
+			entrypoint, err := newEntrypoint(version, rpcClient, chainID, common.Address{})
+			require.NoError(t, err)
+			client := &Client{EntryPoint: entrypoint, ChainID: chainID}
+
+			err = client.VerifyUserOperationHashOnChain(ctx, newTestUserOperation())
+			if err != nil && strings.Contains(err.Error(), "no entrypoint deployed") {
+				t.Skipf("entrypoint %s is not deployed on chain %s", version, chainID)
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package zerodevtest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/DIMO-Network/go-zerodev"
+	"github.com/DIMO-Network/go-zerodev/zerodevtest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func ExampleMockRPCClient() {
+	entrypoint, _ := zerodev.NewEntrypoint07(nil, big.NewInt(zerodev.ChainPolygon))
+
+	mock := zerodevtest.NewMockRPCClient().
+		On("eth_sendUserOperation", "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77", nil).
+		On("eth_getUserOperationReceipt", nil, nil).
+		On("eth_getUserOperationReceipt", json.RawMessage(`{
+			"userOpHash": "0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77",
+			"success": true,
+			"receipt": {"transactionHash": "0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"}
+		}`), nil)
+
+	bundler, _ := zerodev.NewBundlerClient(mock, entrypoint, big.NewInt(zerodev.ChainPolygon))
+
+	op := &zerodev.UserOperation{
+		Sender:   common.HexToAddress("0xC81d8Fa063A7C73795C8455F6b766dd245D8F47A"),
+		Nonce:    big.NewInt(0),
+		CallData: common.FromHex("0xdeadbeef"),
+	}
+
+	opHash, _ := bundler.SendUserOperation(context.Background(), op)
+	receipt, _ := bundler.GetUserOperationReceipt(context.Background(), opHash, 0, 3)
+
+	fmt.Println(hexutil.Encode(opHash))
+	fmt.Println(receipt.TransactionHash)
+	fmt.Println(mock.CallCount("eth_getUserOperationReceipt"))
+	// Output:
+	// 0x8e67c077d2e68c8a19acd882cdeda944e1abe4e3084dd38e56dc354427727f77
+	// 0x1d7c3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d
+	// 2
+}
@@ -0,0 +1,164 @@
+package zerodevtest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/friendsofgo/errors"
+	"sync"
+	"time"
+)
+
+// Response is a canned response of MockRPCClient, Result is marshaled to JSON and decoded into the caller's result.
+type Response struct {
+	Result interface{}
+	Error  error
+}
+
+// Call is a call recorded by MockRPCClient
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// MockRPCClient implements types.RPCClient and types.BatchRPCClient with canned responses keyed by method name.
+// Responses registered for a method are returned in order, the last one is repeated for any further calls.
+type MockRPCClient struct {
+	// Latency delays every call and batch, to simulate a remote endpoint
+	Latency time.Duration
+
+	mu        sync.Mutex
+	responses map[string][]Response
+	calls     []Call
+	batches   int
+	closed    bool
+}
+
+func NewMockRPCClient() *MockRPCClient {
+	return &MockRPCClient{
+		responses: make(map[string][]Response),
+	}
+}
+
+// On registers a response for the method, use json.RawMessage to return raw JSON.
+func (m *MockRPCClient) On(method string, result interface{}, err error) *MockRPCClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.responses[method] = append(m.responses[method], Response{Result: result, Error: err})
+	return m
+}
+
+func (m *MockRPCClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := m.wait(ctx); err != nil {
+		return err
+	}
+
+	return m.call(result, method, args)
+}
+
+// BatchCallContext answers every element of batch with the responses of its method, the batch is delayed by Latency once
+func (m *MockRPCClient) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	if err := m.wait(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.batches++
+	m.mu.Unlock()
+
+	for i := range batch {
+		batch[i].Error = m.call(batch[i].Result, batch[i].Method, batch[i].Args)
+	}
+	return nil
+}
+
+func (m *MockRPCClient) wait(ctx context.Context) error {
+	if m.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(m.Latency):
+		}
+	}
+
+	return ctx.Err()
+}
+
+func (m *MockRPCClient) call(result interface{}, method string, args []interface{}) error {
+	m.mu.Lock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+
+	responses, ok := m.responses[method]
+	if !ok || len(responses) == 0 {
+		m.mu.Unlock()
+		return errors.New("no response registered for method " + method)
+	}
+
+	response := responses[0]
+	if len(responses) > 1 {
+		m.responses[method] = responses[1:]
+	}
+	m.mu.Unlock()
+
+	if response.Error != nil {
+		return response.Error
+	}
+
+	// go through JSON like the real transport, so custom unmarshalers are exercised
+	encoded, err := json.Marshal(response.Result)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal mock response")
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(encoded, result)
+}
+
+func (m *MockRPCClient) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closed = true
+}
+
+// Calls returns the calls made so far
+func (m *MockRPCClient) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Call(nil), m.calls...)
+}
+
+// CallCount returns the number of calls of the method
+func (m *MockRPCClient) CallCount(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, call := range m.calls {
+		if call.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// Batches returns the number of batch calls made so far, their elements are counted by Calls and CallCount
+func (m *MockRPCClient) Batches() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.batches
+}
+
+// Closed reports whether Close was called
+func (m *MockRPCClient) Closed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.closed
+}